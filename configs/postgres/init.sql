@@ -1,11 +1,13 @@
 -- Bluesky Automation Platform Database Schema
 -- Created: 2025-01-02
+-- Services also apply the equivalent migrations in shared/migrations at startup;
+-- schema changes go in a new numbered migration there as well as here.
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 
 -- Create enum types
-CREATE TYPE account_status AS ENUM ('active', 'inactive', 'suspended', 'error');
+CREATE TYPE account_status AS ENUM ('active', 'inactive', 'suspended', 'error', 'needs_reauth');
 CREATE TYPE proxy_type AS ENUM ('http', 'socks5');
 CREATE TYPE proxy_status AS ENUM ('active', 'inactive', 'error');
 CREATE TYPE strategy_type AS ENUM ('post', 'follow', 'like', 'repost', 'monitor', 'growth');
@@ -27,10 +29,21 @@ CREATE TABLE proxies (
     last_health_check TIMESTAMP,
     health_check_success BOOLEAN DEFAULT true,
     response_time_ms INTEGER DEFAULT 0,
+    bsky_reachable BOOLEAN, -- NULL until the Bluesky-specific probe has run
+    health_check_enabled BOOLEAN DEFAULT true, -- false skips scheduled checks (e.g. upstream maintenance)
+    max_accounts INTEGER CHECK (max_accounts > 0), -- NULL means no cap on assigned accounts
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Proxy tags table (provider, region, etc.)
+CREATE TABLE proxy_tags (
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE CASCADE,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (proxy_id, tag)
+);
+
 -- Accounts table
 CREATE TABLE accounts (
     id SERIAL PRIMARY KEY,
@@ -108,6 +121,7 @@ CREATE TABLE tasks (
     started_at TIMESTAMP,
     completed_at TIMESTAMP,
     worker_id VARCHAR(255),
+    cancel_requested BOOLEAN DEFAULT FALSE,
     error_message TEXT,
     result JSONB DEFAULT '{}',
     execution_time_ms INTEGER,
@@ -138,6 +152,44 @@ CREATE TABLE metrics (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Account errors table (failure history per account)
+CREATE TABLE account_errors (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    operation VARCHAR(100) NOT NULL, -- authenticate, refresh_auth, etc.
+    kind VARCHAR(50) NOT NULL, -- auth, proxy, rate_limit, network, other
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Proxy assignment history (one row per period an account spent on a proxy)
+CREATE TABLE proxy_assignments (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE SET NULL,
+    strategy VARCHAR(50) NOT NULL, -- manual, least_used, round_robin, etc.
+    assigned_at TIMESTAMP DEFAULT NOW(),
+    released_at TIMESTAMP -- NULL while the assignment is active
+);
+
+-- Proxy health check history (one row per check, feeds response time trends)
+CREATE TABLE proxy_health_checks (
+    id SERIAL PRIMARY KEY,
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE CASCADE,
+    success BOOLEAN NOT NULL,
+    response_time_ms INTEGER,
+    checked_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Authentication history (one row per login attempt, successful or not)
+CREATE TABLE auth_attempts (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    operation VARCHAR(100) NOT NULL, -- refresh_auth, test_auth, etc.
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
 -- Audit logs table
 CREATE TABLE audit_logs (
     id SERIAL PRIMARY KEY,
@@ -172,6 +224,7 @@ CREATE INDEX idx_accounts_last_activity ON accounts(last_activity);
 CREATE INDEX idx_proxies_status ON proxies(status);
 CREATE INDEX idx_proxies_type ON proxies(type);
 CREATE INDEX idx_proxies_health ON proxies(health_check_success);
+CREATE INDEX idx_proxy_tags_tag ON proxy_tags(tag);
 
 CREATE INDEX idx_strategies_type ON strategies(type);
 CREATE INDEX idx_strategies_status ON strategies(status);
@@ -194,6 +247,13 @@ CREATE INDEX idx_metrics_strategy_id ON metrics(strategy_id);
 CREATE INDEX idx_metrics_type ON metrics(metric_type);
 CREATE INDEX idx_metrics_timestamp ON metrics(timestamp);
 
+CREATE INDEX idx_account_errors_account_id ON account_errors(account_id, created_at DESC);
+
+CREATE INDEX idx_proxy_assignments_account_id ON proxy_assignments(account_id, assigned_at DESC);
+CREATE INDEX idx_proxy_assignments_proxy_id ON proxy_assignments(proxy_id, assigned_at DESC);
+CREATE INDEX idx_proxy_health_checks_proxy_id ON proxy_health_checks(proxy_id, checked_at);
+CREATE INDEX idx_auth_attempts_account_id ON auth_attempts(account_id, created_at);
+
 CREATE INDEX idx_audit_logs_entity ON audit_logs(entity_type, entity_id);
 CREATE INDEX idx_audit_logs_action ON audit_logs(action);
 CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
@@ -231,11 +291,17 @@ INSERT INTO system_settings (key, value, description) VALUES
 ('max_concurrent_tasks_per_account', '5', 'Maximum number of concurrent tasks per account'),
 ('default_task_timeout', '300', 'Default task timeout in seconds'),
 ('proxy_health_check_interval', '300', 'Proxy health check interval in seconds'),
+('proxy_health_check_url', 'https://httpbin.org/ip', 'Default proxy health check URL; add proxy_health_check_url:<tag> to override it for proxies with that tag'),
 ('rate_limit_requests_per_minute', '60', 'Default rate limit per account per minute'),
 ('strategy_execution_interval', '60', 'Strategy execution check interval in seconds'),
 ('max_retry_attempts', '3', 'Maximum retry attempts for failed tasks'),
 ('cleanup_completed_tasks_days', '7', 'Days to keep completed tasks before cleanup'),
-('cleanup_metrics_days', '30', 'Days to keep metrics data before cleanup');
+('cleanup_metrics_days', '30', 'Days to keep metrics data before cleanup'),
+('default_page_size', '10', 'Page size of list endpoints when the request names none'),
+('max_page_size', '100', 'Largest page size list endpoints return'),
+('default_proxy_strategy', '', 'Proxy assignment strategy when the request names none; empty uses DEFAULT_PROXY_STRATEGY'),
+('consistent_hash_vnodes', '100', 'Virtual nodes per proxy on the consistent_hash assignment ring'),
+('automation_paused', 'false', 'Refuses new and retried tasks, stops task claims and skips scheduled health checks while true');
 
 -- Create views for common queries
 CREATE VIEW active_accounts AS
@@ -252,6 +318,20 @@ JOIN strategies s ON t.strategy_id = s.id
 WHERE t.status = 'pending'
 ORDER BY t.priority DESC, t.scheduled_at ASC;
 
+-- Account strategies new tasks may be created for; paused or inactive
+-- associations, strategies and accounts are excluded so no new tasks are
+-- created for them
+CREATE VIEW schedulable_account_strategies AS
+SELECT as_.*, a.handle as account_handle, s.name as strategy_name, s.type as strategy_type,
+       s.schedule as strategy_schedule, s.priority as strategy_priority,
+       s.retry_count as strategy_retry_count, s.timeout_seconds as strategy_timeout_seconds
+FROM account_strategies as_
+JOIN accounts a ON as_.account_id = a.id
+JOIN strategies s ON as_.strategy_id = s.id
+WHERE as_.status = 'active'
+  AND s.status = 'active'
+  AND a.status = 'active';
+
 CREATE VIEW strategy_performance AS
 SELECT 
     s.id,
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// recordAccountError logs a failed operation to the account's error history.
+// Logging failures are not returned since the caller is already handling err.
+func (s *AccountService) recordAccountError(ctx context.Context, accountID int, operation string, err error) {
+	query := `
+		INSERT INTO account_errors (account_id, operation, kind, message)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, dbErr := s.db.ExecContext(ctx, query, accountID, operation, bluesky.ClassifyError(err), err.Error())
+	if dbErr != nil {
+		log.Printf("Failed to record error for account %d: %v", accountID, dbErr)
+	}
+
+	// Back a rate-limited account off so its tasks wait for the limit to
+	// reset: new ones through the cooldown, queued ones by deferring them
+	if reset, ok := bluesky.RateLimitReset(err); ok && s.rdb != nil {
+		until := utils.RateLimitCooldownUntil(reset, time.Now())
+		if cdErr := utils.SetAccountCooldown(ctx, s.rdb, accountID, until); cdErr != nil {
+			log.Printf("Failed to set cooldown for account %d: %v", accountID, cdErr)
+		}
+		if dErr := s.deferPendingTasks(ctx, accountID, until); dErr != nil {
+			log.Printf("Failed to defer pending tasks for account %d: %v", accountID, dErr)
+		}
+	}
+}
+
+// deferPendingTasks pushes the account's pending tasks back to until, leaving
+// tasks already scheduled later alone
+func (s *AccountService) deferPendingTasks(ctx context.Context, accountID int, until time.Time) error {
+	query := `
+		UPDATE tasks
+		SET scheduled_at = GREATEST(scheduled_at, $2), updated_at = NOW()
+		WHERE account_id = $1 AND status = 'pending'
+	`
+	if _, err := s.db.ExecContext(ctx, query, accountID, until); err != nil {
+		return fmt.Errorf("failed to defer pending tasks: %w", err)
+	}
+	return nil
+}
+
+// recordAuthAttempt logs the outcome of a login attempt, feeding the
+// assignment effectiveness stats. Like recordAccountError it only logs when
+// the insert fails.
+func (s *AccountService) recordAuthAttempt(ctx context.Context, accountID int, operation string, success bool) {
+	query := "INSERT INTO auth_attempts (account_id, operation, success) VALUES ($1, $2, $3)"
+	if _, err := s.db.ExecContext(ctx, query, accountID, operation, success); err != nil {
+		log.Printf("Failed to record auth attempt for account %d: %v", accountID, err)
+	}
+}
+
+// ListAccountErrors returns the account's most recent errors, newest first
+func (s *AccountService) ListAccountErrors(ctx context.Context, accountID, limit int) ([]models.AccountError, error) {
+	if err := s.ensureAccountExists(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, account_id, operation, kind, message, created_at
+		FROM account_errors
+		WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, accountID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account errors: %w", err)
+	}
+	defer rows.Close()
+
+	accountErrors := []models.AccountError{}
+	for rows.Next() {
+		var accountErr models.AccountError
+		err := rows.Scan(
+			&accountErr.ID, &accountErr.AccountID, &accountErr.Operation,
+			&accountErr.Kind, &accountErr.Message, &accountErr.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account error: %w", err)
+		}
+		accountErrors = append(accountErrors, accountErr)
+	}
+
+	return accountErrors, nil
+}
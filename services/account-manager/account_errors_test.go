@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestAccountErrorsLoggedAndRetrievable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "AuthenticationRequired", "message": "Invalid identifier or password"})
+	}))
+	defer pds.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "bad.bsky.social", "wrong", pds.URL, "https://bsky.network", "active",
+				nil, nil, nil, nil, nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec(`INSERT INTO account_errors \(account_id, operation, kind, message\)`).
+		WithArgs(1, "test_auth", models.ErrorKindAuth, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "test_auth", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := &AccountService{db: db}
+	_, err = service.TestAuthentication(context.Background(), 1)
+	assert.Error(t, err)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM account_errors\s+WHERE account_id = \$1\s+ORDER BY created_at DESC, id DESC\s+LIMIT \$2`).
+		WithArgs(1, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "operation", "kind", "message", "created_at"}).
+			AddRow(1, 1, "test_auth", "auth", "failed to create session: invalid credentials", now))
+
+	handler := &AccountHandler{accountService: service}
+	router := gin.New()
+	router.GET("/accounts/:id/errors", handler.GetAccountErrors)
+
+	req, _ := http.NewRequest("GET", "/accounts/1/errors", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var accountErrors []models.AccountError
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &accountErrors))
+	assert.Len(t, accountErrors, 1)
+	assert.Equal(t, "test_auth", accountErrors[0].Operation)
+	assert.Equal(t, models.ErrorKindAuth, accountErrors[0].Kind)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitedAccountErrorSetsCooldown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	reset := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+	limited := &xrpc.Error{StatusCode: http.StatusTooManyRequests, Ratelimit: &xrpc.RatelimitInfo{Reset: reset}}
+
+	mock.ExpectExec(`INSERT INTO account_errors`).
+		WithArgs(1, "refresh_auth", models.ErrorKindRateLimit, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	// Tasks already queued for the account are deferred to the same time
+	mock.ExpectExec(`UPDATE tasks\s+SET scheduled_at = GREATEST\(scheduled_at, \$2\).*WHERE account_id = \$1 AND status = 'pending'`).
+		WithArgs(1, reset).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	service := &AccountService{db: db, rdb: rdb}
+	service.recordAccountError(context.Background(), 1, "refresh_auth", limited)
+
+	remaining, err := utils.AccountCooldownRemaining(context.Background(), rdb, 1, time.Now())
+	assert.NoError(t, err)
+	assert.InDelta(t, 5*time.Minute, remaining, float64(2*time.Second))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSnapshotLoginRecordedAsAuthAttempt(t *testing.T) {
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "AuthenticationRequired", "message": "Invalid identifier or password"})
+	}))
+	defer pds.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "bad.bsky.social", "wrong", pds.URL, "https://bsky.network", "active",
+				nil, nil, nil, nil, nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectExec(`INSERT INTO account_errors \(account_id, operation, kind, message\)`).
+		WithArgs(1, "snapshot", models.ErrorKindAuth, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "snapshot", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := &AccountService{db: db}
+	_, err = service.GetAccountSnapshot(context.Background(), 1)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
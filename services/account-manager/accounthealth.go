@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+)
+
+// CheckAccountStatus looks for signs the account is deactivated or
+// shadow-banned. It reads the account's repo status and profile without
+// logging in, so checking a taken-down account does not fail a login,
+// record auth errors or rotate its tokens. Findings are recorded as a
+// status_check account error, so restricted accounts show up in the error
+// history and can be flagged.
+func (s *AccountService) CheckAccountStatus(ctx context.Context, id int) (*bluesky.AccountHealth, error) {
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var did string
+	if account.DID != nil {
+		did = *account.DID
+	} else if did, err = s.resolveHandle(ctx, account.Handle); err != nil {
+		return nil, err
+	}
+
+	client, err := s.newAccountClient(account)
+	if err != nil {
+		return nil, err
+	}
+
+	health, err := client.CheckAccountStatus(ctx, did, s.appViewHost)
+	if err != nil {
+		s.recordAccountError(ctx, id, "status_check", err)
+		return nil, err
+	}
+
+	if !health.Healthy() {
+		details := make([]string, 0, len(health.Findings))
+		for _, finding := range health.Findings {
+			details = append(details, fmt.Sprintf("%s: %s", finding.Code, finding.Detail))
+		}
+		s.recordAccountError(ctx, id, "status_check", fmt.Errorf("account appears restricted: %s", strings.Join(details, "; ")))
+	}
+	return health, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccountStatusDoesNotLogIn(t *testing.T) {
+	// Serves the PLC directory, PDS and AppView; the account is taken down,
+	// so a login would fail and record an auth error
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"), "%s must not be authenticated", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/did:plc:searcher":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": "did:plc:searcher",
+				"service": []map[string]string{
+					{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "http://" + r.Host},
+				},
+			})
+		case "/did:plc:searcher/data":
+			json.NewEncoder(w).Encode(map[string]interface{}{"did": "did:plc:searcher", "rotationKeys": []string{}})
+		case "/xrpc/com.atproto.sync.getRepoStatus":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "RepoTakendown", "message": "Repo has been takendown"})
+		case "/xrpc/app.bsky.actor.getProfile":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "AccountTakedown", "message": "Account has been suspended"})
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer pds.Close()
+	t.Setenv("PLC_DIRECTORY_URL", pds.URL)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The account is loaded and the finding recorded; no tokens are saved
+	expectSearchAccount(mock, pds.URL, "active", "stored-access")
+	mock.ExpectExec(`INSERT INTO account_errors`).
+		WithArgs(1, "status_check", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := &AccountService{db: db, appViewHost: pds.URL}
+	health, err := service.CheckAccountStatus(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "did:plc:searcher", health.DID)
+	assert.Equal(t, "takendown", health.Status)
+	assert.False(t, health.Healthy())
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
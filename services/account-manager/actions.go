@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// errActionFailed wraps step failures so they can be told apart from the
+// transaction itself failing to begin or commit
+var errActionFailed = errors.New("action failed")
+
+// RunAccountActions executes a sequence of operations against one account.
+// Consecutive database steps (set_status, reset_errors) share a transaction,
+// so a failing step rolls back the others in its run. refresh_auth talks to
+// Bluesky and assign_proxy to proxy-manager, which checks the proxy's health
+// and capacity; both commit whatever came before them. After a failure the
+// remaining steps are skipped when StopOnError is set, otherwise execution
+// continues with the next step in a fresh transaction.
+func (s *AccountService) RunAccountActions(ctx context.Context, id int, req *AccountActionsRequest) (*AccountActionsResponse, error) {
+	if err := s.ensureAccountExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	response := &AccountActionsResponse{
+		AccountID: id,
+		Results:   make([]AccountActionResult, len(req.Actions)),
+	}
+	for i, action := range req.Actions {
+		response.Results[i] = AccountActionResult{Type: action.Type, Status: AccountActionSkipped}
+	}
+
+	for i := 0; i < len(req.Actions); {
+		if isExternalAction(req.Actions[i].Type) {
+			err := s.runExternalAction(ctx, id, req.Actions[i])
+			response.Results[i].finish(err)
+			i++
+			if err != nil && req.StopOnError {
+				break
+			}
+			continue
+		}
+
+		next, err := s.runAccountActionBatch(ctx, id, req.Actions, i, response.Results)
+		if err != nil && !errors.Is(err, errActionFailed) {
+			return nil, err
+		}
+		i = next
+		if err != nil && req.StopOnError {
+			break
+		}
+	}
+
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	response.Account = account
+
+	return response, nil
+}
+
+// runAccountActionBatch applies the database steps starting at start in one
+// transaction, stopping at the first external step or failure. It returns the
+// index of the next step to run.
+func (s *AccountService) runAccountActionBatch(ctx context.Context, id int, actions []AccountAction, start int, results []AccountActionResult) (int, error) {
+	end := start
+	err := utils.Transaction(s.db, func(tx *sql.Tx) error {
+		for ; end < len(actions) && !isExternalAction(actions[end].Type); end++ {
+			if err := applyAccountAction(ctx, tx, id, actions[end]); err != nil {
+				results[end].finish(err)
+				end++
+				return fmt.Errorf("%w: %w", errActionFailed, err)
+			}
+			results[end].Status = AccountActionSucceeded
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing in the batch was applied
+		for i := start; i < end; i++ {
+			if results[i].Status == AccountActionSucceeded {
+				results[i].Status = AccountActionRolledBack
+			}
+		}
+	}
+	return end, err
+}
+
+// isExternalAction reports whether a step runs outside the database
+// transaction
+func isExternalAction(actionType AccountActionType) bool {
+	return actionType == AccountActionRefreshAuth || actionType == AccountActionAssignProxy
+}
+
+// runExternalAction performs a step that goes through another service.
+// Proxies are assigned by proxy-manager, so a full or unhealthy proxy is
+// rejected the same way as from its own assign endpoint.
+func (s *AccountService) runExternalAction(ctx context.Context, id int, action AccountAction) error {
+	switch action.Type {
+	case AccountActionRefreshAuth:
+		_, err := s.RefreshAuthentication(ctx, id)
+		return err
+
+	case AccountActionAssignProxy:
+		if action.ProxyID == nil {
+			return fmt.Errorf("proxy_id is required")
+		}
+		_, err := s.assignProxy(ctx, ProxyAssignmentRequest{AccountID: id, ProxyID: action.ProxyID})
+		return err
+
+	default:
+		return fmt.Errorf("unknown action: %s", action.Type)
+	}
+}
+
+// applyAccountAction performs a single database step
+func applyAccountAction(ctx context.Context, tx *sql.Tx, id int, action AccountAction) error {
+	switch action.Type {
+	case AccountActionSetStatus:
+		if action.Status == nil {
+			return fmt.Errorf("status is required")
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE accounts SET status = $1, updated_at = NOW() WHERE id = $2", *action.Status, id)
+		if err != nil {
+			return fmt.Errorf("failed to update status: %w", err)
+		}
+
+	case AccountActionResetErrors:
+		_, err := tx.ExecContext(ctx, "UPDATE accounts SET error_count = 0, error_message = NULL, updated_at = NOW() WHERE id = $1", id)
+		if err != nil {
+			return fmt.Errorf("failed to reset errors: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown action: %s", action.Type)
+	}
+
+	return nil
+}
+
+func (r *AccountActionResult) finish(err error) {
+	if err != nil {
+		r.Status = AccountActionFailed
+		r.Error = err.Error()
+		return
+	}
+	r.Status = AccountActionSucceeded
+}
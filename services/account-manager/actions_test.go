@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func runAccountActionsRequest(t *testing.T, service *AccountService, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := &AccountHandler{accountService: service, validator: validator.New()}
+	router := gin.New()
+	router.POST("/accounts/:id/actions", handler.RunAccountActions)
+
+	req, _ := http.NewRequest("POST", "/accounts/1/actions", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func expectAccountRow(mock sqlmock.Sqlmock, status string, proxyID interface{}) {
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "alice.bsky.social", "pw", "https://bsky.social", "https://bsky.network", status,
+				proxyID, nil, nil, nil, nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				proxyID, nil, nil, nil, nil, nil, nil))
+}
+
+func TestRunAccountActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE accounts SET error_count = 0, error_message = NULL`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accounts SET status = \$1`).
+		WithArgs("active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectAccountRow(mock, "active", 5)
+
+	var assigned []ProxyAssignmentRequest
+	service := &AccountService{
+		db: db,
+		assignProxy: func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+			assigned = append(assigned, req)
+			return &ProxyAssignment{ProxyID: *req.ProxyID}, nil
+		},
+	}
+
+	w := runAccountActionsRequest(t, service, `{
+		"actions": [
+			{"type": "assign_proxy", "proxy_id": 5},
+			{"type": "reset_errors"},
+			{"type": "set_status", "status": "active"}
+		]
+	}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AccountActionsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 3)
+	for _, result := range response.Results {
+		assert.Equal(t, AccountActionSucceeded, result.Status, result.Type)
+	}
+	assert.Equal(t, 5, *response.Account.ProxyID)
+	if assert.Len(t, assigned, 1) {
+		assert.Equal(t, 1, assigned[0].AccountID)
+		assert.Equal(t, 5, *assigned[0].ProxyID)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunAccountActionsStopOnError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE accounts SET error_count = 0, error_message = NULL`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectAccountRow(mock, "error", nil)
+
+	service := &AccountService{
+		db: db,
+		assignProxy: func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+			return nil, &ProxyManagerError{StatusCode: http.StatusNotFound, Message: "proxy not found"}
+		},
+	}
+
+	w := runAccountActionsRequest(t, service, `{
+		"stop_on_error": true,
+		"actions": [
+			{"type": "reset_errors"},
+			{"type": "assign_proxy", "proxy_id": 99},
+			{"type": "refresh_auth"},
+			{"type": "set_status", "status": "active"}
+		]
+	}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AccountActionsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []AccountActionResult{
+		{Type: AccountActionResetErrors, Status: AccountActionSucceeded},
+		{Type: AccountActionAssignProxy, Status: AccountActionFailed, Error: "proxy-manager returned 404: proxy not found"},
+		{Type: AccountActionRefreshAuth, Status: AccountActionSkipped},
+		{Type: AccountActionSetStatus, Status: AccountActionSkipped},
+	}, response.Results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// A proxy named in assign_proxy goes through proxy-manager, which refuses
+// proxies that are full or unhealthy; the account row is never touched.
+func TestRunAccountActionsRejectsUnusableProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	proxyManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/assignment/assign", r.URL.Path)
+		var req ProxyAssignmentRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		switch *req.ProxyID {
+		case 3:
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No proxy capacity", Message: "proxy is at capacity", Code: http.StatusConflict})
+		default:
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Proxy not healthy", Message: "proxy is not healthy", Code: http.StatusConflict})
+		}
+	}))
+	defer proxyManager.Close()
+
+	for proxyID, message := range map[int]string{3: "proxy is at capacity", 4: "proxy is not healthy"} {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		expectAccountRow(mock, "active", nil)
+
+		service := &AccountService{db: db, assignProxy: newProxyManagerAssigner(proxyManager.URL)}
+		w := runAccountActionsRequest(t, service, fmt.Sprintf(`{"actions": [{"type": "assign_proxy", "proxy_id": %d}]}`, proxyID))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response AccountActionsResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, AccountActionFailed, response.Results[0].Status)
+		assert.Contains(t, response.Results[0].Error, message)
+		assert.Nil(t, response.Account.ProxyID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	}
+}
+
+func TestRunAccountActionsValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, body := range []string{
+		`{"actions": []}`,
+		`{"actions": [{"type": "delete_everything"}]}`,
+		`{"actions": [{"type": "assign_proxy"}]}`,
+		`{"actions": [{"type": "set_status", "status": "bogus"}]}`,
+	} {
+		w := runAccountActionsRequest(t, &AccountService{}, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
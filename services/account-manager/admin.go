@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// adminRole is the JWT role allowed to use the admin routes
+const adminRole = "admin"
+
+// requireAdmin rejects requests that do not carry a valid, unrevoked bearer
+// token issued to an admin
+func requireAdmin(auth *AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "A bearer token is required",
+				Code:    http.StatusUnauthorized,
+			})
+			return
+		}
+
+		claims, err := auth.ValidateToken(token)
+		if err != nil || auth.isTokenBlacklisted(c.Request.Context(), token) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:   "Unauthorized",
+				Message: "The bearer token is invalid or has been revoked",
+				Code:    http.StatusUnauthorized,
+			})
+			return
+		}
+		if claims.Role != adminRole {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: "Admin role required",
+				Code:    http.StatusForbidden,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// SetAutomationPaused turns the global kill switch on or off. CreateTask,
+// RetryTask, worker claims and the proxy health scheduler read it before each
+// task, claim or cycle, so nothing new starts. Work already running finishes.
+func (s *AccountService) SetAutomationPaused(ctx context.Context, paused bool) error {
+	if err := utils.SetAutomationPaused(ctx, s.db, paused); err != nil {
+		return err
+	}
+	if paused {
+		log.Println("Automation paused")
+	} else {
+		log.Println("Automation resumed")
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+func newAdminTestRouter(t *testing.T) (*gin.Engine, *AuthService, sqlmock.Sqlmock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	auth := &AuthService{db: db, rdb: rdb, jwtSecret: []byte("test-secret")}
+	handler := NewAccountHandler(&AccountService{db: db, rdb: rdb}, auth)
+
+	router := gin.New()
+	admin := router.Group("/api/v1/admin", requireAdmin(auth))
+	admin.POST("/pause", handler.PauseAutomation)
+	admin.POST("/resume", handler.ResumeAutomation)
+	return router, auth, mock
+}
+
+func postAdmin(router *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPauseAndResumeAutomation(t *testing.T) {
+	router, auth, mock := newAdminTestRouter(t)
+	token, _, _, err := auth.generateTokens(1, "admin", adminRole)
+	require.NoError(t, err)
+
+	mock.ExpectExec(`INSERT INTO system_settings`).
+		WithArgs(utils.AutomationPausedSetting, "true").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w := postAdmin(router, "/api/v1/admin/pause", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var status AutomationStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.True(t, status.Paused)
+
+	mock.ExpectExec(`INSERT INTO system_settings`).
+		WithArgs(utils.AutomationPausedSetting, "false").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w = postAdmin(router, "/api/v1/admin/resume", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.False(t, status.Paused)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	router, auth, mock := newAdminTestRouter(t)
+
+	w := postAdmin(router, "/api/v1/admin/pause", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postAdmin(router, "/api/v1/admin/pause", "not-a-jwt")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	viewer, _, _, err := auth.generateTokens(2, "viewer", "viewer")
+	require.NoError(t, err)
+	w = postAdmin(router, "/api/v1/admin/pause", viewer)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// A logged-out admin token is refused
+	admin, _, expiresAt, err := auth.generateTokens(1, "admin", adminRole)
+	require.NoError(t, err)
+	require.NoError(t, auth.blacklistToken(context.Background(), admin, expiresAt.Add(time.Minute)))
+	w = postAdmin(router, "/api/v1/admin/pause", admin)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// No request reached the kill switch
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -94,6 +94,7 @@ type AccountMetricsResponse struct {
 	LastActivity     *time.Time             `json:"last_activity"`
 	DailyMetrics     []DailyMetric          `json:"daily_metrics"`
 	StrategyMetrics  []StrategyMetric       `json:"strategy_metrics"`
+	ActionMetrics    []ActionMetric         `json:"action_metrics"`
 }
 
 // DailyMetric represents daily performance metrics
@@ -113,6 +114,15 @@ type StrategyMetric struct {
 	SuccessRate    float64 `json:"success_rate"`
 }
 
+// ActionMetric breaks task outcomes down by task type (post, follow, like...)
+type ActionMetric struct {
+	Type           string  `json:"type"`
+	TotalTasks     int     `json:"total_tasks"`
+	TasksCompleted int     `json:"tasks_completed"`
+	TasksFailed    int     `json:"tasks_failed"`
+	SuccessRate    float64 `json:"success_rate"`
+}
+
 // JWTClaims represents JWT token claims
 type JWTClaims struct {
 	UserID   int    `json:"user_id"`
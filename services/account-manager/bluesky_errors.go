@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/gin-gonic/gin"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+	"github.com/bsky-automation/shared/models"
+)
+
+// httpStatusForBlueskyError maps an error from the Bluesky client onto the
+// status a handler should answer with: rate limits stay 429, rejected
+// credentials or sessions become 401 and missing records or repos 404.
+// Anything else is a 500.
+func httpStatusForBlueskyError(err error) int {
+	switch {
+	case errors.Is(err, bluesky.ErrInvalidCredentials),
+		errors.Is(err, bluesky.ErrReauthRequired),
+		errors.Is(err, bluesky.ErrMissingCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, bluesky.ErrTargetGone):
+		return http.StatusNotFound
+	}
+
+	var xe *xrpc.Error
+	if !errors.As(err, &xe) {
+		return http.StatusInternalServerError
+	}
+
+	var name string
+	var inner *xrpc.XRPCError
+	if errors.As(xe.Wrapped, &inner) {
+		name = inner.ErrStr
+	}
+
+	switch {
+	case xe.StatusCode == http.StatusTooManyRequests || name == "RateLimitExceeded":
+		return http.StatusTooManyRequests
+	case xe.StatusCode == http.StatusUnauthorized || name == "AuthenticationRequired" ||
+		name == "ExpiredToken" || name == "InvalidToken":
+		return http.StatusUnauthorized
+	case xe.StatusCode == http.StatusNotFound || name == "RecordNotFound" ||
+		name == "RepoNotFound" || name == "ProfileNotFound":
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// respondBlueskyError writes the ErrorResponse for a failed Bluesky call,
+// with a Retry-After header when the PDS reported when its rate limit resets
+func respondBlueskyError(c *gin.Context, title string, err error) {
+	status := httpStatusForBlueskyError(err)
+
+	var xe *xrpc.Error
+	if status == http.StatusTooManyRequests && errors.As(err, &xe) && xe.Ratelimit != nil {
+		if wait := time.Until(xe.Ratelimit.Reset); wait > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		}
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   title,
+		Message: err.Error(),
+		Code:    status,
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+)
+
+// xrpcErr synthesizes the error the indigo client returns for a failed call
+func xrpcErr(status int, name string) error {
+	err := &xrpc.Error{StatusCode: status}
+	if name != "" {
+		err.Wrapped = &xrpc.XRPCError{ErrStr: name, Message: "synthesized"}
+	}
+	return fmt.Errorf("failed to like post: %w", err)
+}
+
+func TestHTTPStatusForBlueskyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"rate limited", xrpcErr(http.StatusTooManyRequests, ""), http.StatusTooManyRequests},
+		{"rate limit by name", xrpcErr(http.StatusBadRequest, "RateLimitExceeded"), http.StatusTooManyRequests},
+		{"unauthorized", xrpcErr(http.StatusUnauthorized, ""), http.StatusUnauthorized},
+		{"expired token", xrpcErr(http.StatusBadRequest, "ExpiredToken"), http.StatusUnauthorized},
+		{"invalid token", xrpcErr(http.StatusBadRequest, "InvalidToken"), http.StatusUnauthorized},
+		{"invalid credentials", fmt.Errorf("failed to create session: %w", bluesky.ErrInvalidCredentials), http.StatusUnauthorized},
+		{"reauth required", fmt.Errorf("authentication failed: %w", bluesky.ErrReauthRequired), http.StatusUnauthorized},
+		{"not found", xrpcErr(http.StatusNotFound, ""), http.StatusNotFound},
+		{"record not found", xrpcErr(http.StatusBadRequest, "RecordNotFound"), http.StatusNotFound},
+		{"target gone", fmt.Errorf("failed to get post: %w", bluesky.ErrTargetGone), http.StatusNotFound},
+		{"upstream error", xrpcErr(http.StatusBadGateway, "UpstreamFailure"), http.StatusInternalServerError},
+		{"plain error", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.want, httpStatusForBlueskyError(test.err), test.name)
+	}
+}
+
+func TestRespondBlueskyErrorSetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	err := &xrpc.Error{
+		StatusCode: http.StatusTooManyRequests,
+		Ratelimit:  &xrpc.RatelimitInfo{Reset: time.Now().Add(30 * time.Second)},
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondBlueskyError(c, "Failed to refresh authentication", fmt.Errorf("authentication failed: %w", err))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, []string{"30", "31"}, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), `"code":429`)
+}
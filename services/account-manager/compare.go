@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// CompareAccounts loads two accounts and lists the fields relevant to
+// debugging side by side, flagging the ones that differ
+func (s *AccountService) CompareAccounts(ctx context.Context, idA, idB int) (*AccountComparisonResponse, error) {
+	a, err := s.GetAccount(ctx, idA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetAccount(ctx, idB)
+	if err != nil {
+		return nil, err
+	}
+
+	fieldsA := comparableAccountFields(a)
+	fieldsB := comparableAccountFields(b)
+
+	response := &AccountComparisonResponse{
+		A:           AccountComparisonSide{ID: a.ID, Handle: a.Handle},
+		B:           AccountComparisonSide{ID: b.ID, Handle: b.Handle},
+		Fields:      make([]AccountFieldComparison, 0, len(comparedAccountFields)),
+		Differences: []string{},
+	}
+
+	for _, name := range comparedAccountFields {
+		field := AccountFieldComparison{
+			Field: name,
+			A:     fieldsA[name],
+			B:     fieldsB[name],
+		}
+		field.Differs = !reflect.DeepEqual(field.A, field.B)
+		if field.Differs {
+			response.Differences = append(response.Differences, name)
+		}
+		response.Fields = append(response.Fields, field)
+	}
+
+	return response, nil
+}
+
+// comparedAccountFields is the order fields appear in a comparison
+var comparedAccountFields = []string{
+	"status", "proxy_id", "proxy_status", "error_count", "error_message",
+	"last_login", "has_session", "host",
+}
+
+// comparableAccountFields flattens the debugging-relevant parts of an account
+// into comparable values. Nil pointers become nil so two unset fields match.
+func comparableAccountFields(account *models.Account) map[string]interface{} {
+	fields := map[string]interface{}{
+		"status":        account.Status,
+		"proxy_id":      nil,
+		"proxy_status":  nil,
+		"error_count":   account.ErrorCount,
+		"error_message": nil,
+		"last_login":    nil,
+		"has_session":   account.AccessJWT != nil && account.RefreshJWT != nil,
+		"host":          account.Host,
+	}
+
+	if account.ProxyID != nil {
+		fields["proxy_id"] = *account.ProxyID
+	}
+	if account.Proxy != nil {
+		fields["proxy_status"] = account.Proxy.Status
+	}
+	if account.ErrorMessage != nil {
+		fields["error_message"] = *account.ErrorMessage
+	}
+	if account.LastLogin != nil {
+		fields["last_login"] = account.LastLogin.UTC().Format(time.RFC3339)
+	}
+
+	return fields
+}
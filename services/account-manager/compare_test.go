@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+var accountColumns = []string{
+	"id", "uuid", "handle", "password", "host", "bgs", "status",
+	"proxy_id", "did", "access_jwt", "refresh_jwt", "last_login",
+	"last_activity", "error_count", "error_message", "metadata",
+	"created_at", "updated_at",
+	"id", "uuid", "name", "type", "host", "port", "status",
+}
+
+func TestCompareAccounts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	lastLogin := now.Add(-time.Hour)
+
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "works.bsky.social", "pw", "https://bsky.social", "https://bsky.network", "active",
+				5, "did:plc:works", "access", "refresh", lastLogin,
+				now, 0, nil, []byte(`{}`), now, now,
+				5, utils.GenerateUUID().String(), "proxy-5", "http", "10.0.0.5", 8080, "active"))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(2, utils.GenerateUUID().String(), "broken.bsky.social", "pw", "https://bsky.social", "https://bsky.network", "error",
+				5, "did:plc:broken", nil, nil, lastLogin,
+				now, 4, "session expired", []byte(`{}`), now, now,
+				5, utils.GenerateUUID().String(), "proxy-5", "http", "10.0.0.5", 8080, "active"))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/compare", handler.CompareAccounts)
+
+	req, _ := http.NewRequest("GET", "/accounts/compare?a=1&b=2", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var comparison AccountComparisonResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &comparison))
+	assert.Equal(t, "works.bsky.social", comparison.A.Handle)
+	assert.Equal(t, "broken.bsky.social", comparison.B.Handle)
+	assert.ElementsMatch(t, []string{"status", "error_count", "error_message", "has_session"}, comparison.Differences)
+
+	differs := make(map[string]bool)
+	for _, field := range comparison.Fields {
+		differs[field.Field] = field.Differs
+	}
+	assert.False(t, differs["proxy_id"])
+	assert.False(t, differs["proxy_status"])
+	assert.False(t, differs["last_login"])
+	assert.True(t, differs["status"])
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompareAccountsInvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AccountHandler{accountService: &AccountService{}}
+	router := gin.New()
+	router.GET("/accounts/compare", handler.CompareAccounts)
+
+	req, _ := http.NewRequest("GET", "/accounts/compare?a=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// wireEvents connects the account service to bus and registers the
+// subscribers that react to its events: an audit log entry, and event counts
+// in Redis
+func wireEvents(bus *utils.EventBus, rdb *redis.Client, accountService *AccountService) {
+	accountService.events = bus
+
+	bus.SubscribeAsync(utils.EventAccountAuthFailed, "auth failure audit", accountService.auditAuthFailure)
+	bus.SubscribeAsync(utils.EventAccountAuthFailed, "event counts", utils.CountEvents(rdb))
+}
+
+// publishAuthFailure announces that logging in as account failed
+func (s *AccountService) publishAuthFailure(ctx context.Context, account *models.Account, operation string, err error) {
+	s.events.Publish(ctx, utils.AccountAuthFailed{
+		AccountID: account.ID,
+		Handle:    account.Handle,
+		Operation: operation,
+		Error:     err.Error(),
+		At:        time.Now(),
+	})
+}
+
+// auditAuthFailure records an AccountAuthFailed event in the audit log
+func (s *AccountService) auditAuthFailure(ctx context.Context, event utils.Event) {
+	failed := event.(utils.AccountAuthFailed)
+
+	query := `
+		INSERT INTO audit_logs (entity_type, entity_id, action, new_values)
+		VALUES ('accounts', $1, 'auth_failed', $2)
+	`
+	_, err := s.db.ExecContext(ctx, query, failed.AccountID, models.JSONB{
+		"operation": failed.Operation,
+		"error":     failed.Error,
+	})
+	if err != nil {
+		log.Printf("Failed to audit auth failure for account %d: %v", failed.AccountID, err)
+	}
+}
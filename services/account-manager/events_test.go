@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestAuthFailureIsAuditedAndCounted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	service := &AccountService{db: db, rdb: rdb}
+	bus := utils.NewEventBus()
+	wireEvents(bus, rdb, service)
+
+	mock.ExpectExec(`INSERT INTO audit_logs \(entity_type, entity_id, action, new_values\)\s+VALUES \('accounts', \$1, 'auth_failed', \$2\)`).
+		WithArgs(7, models.JSONB{"operation": "refresh_auth", "error": "invalid credentials"}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	account := &models.Account{ID: 7, Handle: "tester.bsky.social"}
+	service.publishAuthFailure(context.Background(), account, "refresh_auth", errors.New("invalid credentials"))
+	require.NoError(t, bus.Wait(context.Background()))
+
+	count, err := rdb.HGet(context.Background(), utils.EventCountsKey, string(utils.EventAccountAuthFailed)).Int()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
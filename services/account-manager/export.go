@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// ExportAccounts streams every account, optionally filtered by status, to
+// emit one row at a time. Credentials and session tokens are not exported.
+func (s *AccountService) ExportAccounts(ctx context.Context, status *models.AccountStatus, emit func(models.Account) error) error {
+	query := `
+		SELECT id, uuid, handle, host, bgs, status, proxy_id, did,
+		       last_login, last_activity, error_count, created_at, updated_at
+		FROM accounts
+	`
+
+	var args []interface{}
+	if status != nil {
+		query += " WHERE status = $1"
+		args = append(args, *status)
+	}
+	query += " ORDER BY id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export accounts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account models.Account
+		err := rows.Scan(
+			&account.ID, &account.UUID, &account.Handle, &account.Host, &account.BGS,
+			&account.Status, &account.ProxyID, &account.DID, &account.LastLogin,
+			&account.LastActivity, &account.ErrorCount, &account.CreatedAt, &account.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan account: %w", err)
+		}
+		if err := emit(account); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
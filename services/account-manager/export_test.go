@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestExportAccountsStreamsNDJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	columns := []string{
+		"id", "uuid", "handle", "host", "bgs", "status", "proxy_id", "did",
+		"last_login", "last_activity", "error_count", "created_at", "updated_at",
+	}
+	rows := sqlmock.NewRows(columns)
+	for i := 1; i <= 3; i++ {
+		rows.AddRow(i, utils.GenerateUUID().String(), "user.bsky.social", "https://bsky.social", "https://bsky.network",
+			"active", nil, nil, nil, nil, 0, now, now)
+	}
+	mock.ExpectQuery(`FROM accounts\s+WHERE status = \$1 ORDER BY id`).
+		WithArgs(models.AccountStatusActive).
+		WillReturnRows(rows)
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/export", handler.ExportAccounts)
+
+	req, _ := http.NewRequest("GET", "/accounts/export?status=active", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+
+	lines := 0
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var account map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &account))
+		assert.Empty(t, account["password"])
+		assert.Nil(t, account["access_jwt"])
+		lines++
+	}
+	assert.Equal(t, 3, lines)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// cancellingRecorder cancels the request context on its first flush, as if
+// the client disconnected after receiving the first batch
+type cancellingRecorder struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (r *cancellingRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.cancel()
+}
+
+func TestExportAccountsStopsWhenClientDisconnects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("EXPORT_FLUSH_ROWS", "2")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	columns := []string{
+		"id", "uuid", "handle", "host", "bgs", "status", "proxy_id", "did",
+		"last_login", "last_activity", "error_count", "created_at", "updated_at",
+	}
+	rows := sqlmock.NewRows(columns)
+	for i := 1; i <= 10; i++ {
+		rows.AddRow(i, utils.GenerateUUID().String(), "user.bsky.social", "https://bsky.social", "https://bsky.network",
+			"active", nil, nil, nil, nil, 0, now, now)
+	}
+	mock.ExpectQuery(`FROM accounts\s+ORDER BY id`).
+		WillReturnRows(rows).
+		RowsWillBeClosed()
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/export", handler.ExportAccounts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/accounts/export", nil)
+	w := &cancellingRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+	router.ServeHTTP(w, req)
+
+	// Only the first batch is written and the cursor is released
+	assert.Equal(t, 2, strings.Count(w.Body.String(), "\n"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// ErrUnknownAccountFlag is returned when a flag update names a flag that is
+// not in models.AccountFlagDefaults
+var ErrUnknownAccountFlag = errors.New("unknown account flag")
+
+// SetAccountFlags sets or clears the account's feature flags in one update.
+// A nil value removes the flag so the default applies again; other flags in
+// the metadata are kept. It returns the effective value of every flag.
+func (s *AccountService) SetAccountFlags(ctx context.Context, id int, flags map[string]*bool) (*AccountFlagsResponse, error) {
+	for name := range flags {
+		if !models.IsAccountFlag(name) {
+			return nil, fmt.Errorf("%w %q; recognized flags are %s", ErrUnknownAccountFlag, name,
+				strings.Join(models.AccountFlagNames(), ", "))
+		}
+	}
+
+	if len(flags) > 0 {
+		// Removed flags are written as null and then stripped
+		patch, err := json.Marshal(flags)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode account flags: %w", err)
+		}
+
+		query := `
+			UPDATE accounts
+			SET metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), '{flags}', jsonb_strip_nulls(
+			        CASE WHEN jsonb_typeof(metadata->'flags') = 'object' THEN metadata->'flags' ELSE '{}'::jsonb END
+			        || $1::jsonb)),
+			    updated_at = NOW()
+			WHERE id = $2
+		`
+		result, err := s.db.ExecContext(ctx, query, string(patch), id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update account flags: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err == nil && updated == 0 {
+			return nil, fmt.Errorf("account not found")
+		}
+	}
+
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountFlagsResponse{AccountID: account.ID, Flags: account.Flags()}, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// flagAccountRow returns an account row with the given metadata
+func flagAccountRow(host, metadata string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(accountColumns).
+		AddRow(1, utils.GenerateUUID().String(), "flagged.bsky.social", "pw", host, "https://bsky.network", "active",
+			nil, "did:plc:flagged", "old-access", "old-refresh", nil,
+			nil, 0, nil, []byte(metadata), now, now,
+			nil, nil, nil, nil, nil, nil, nil)
+}
+
+func TestSetAccountFlags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE accounts\s+SET metadata = jsonb_set`).
+		WithArgs(`{"allow_self_actions":null,"follow_pds_migration":false}`, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(flagAccountRow("https://pds.example", `{"source": "import", "flags": {"follow_pds_migration": false}}`))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.PATCH("/accounts/:id/flags", handler.SetAccountFlags)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("PATCH", "/accounts/1/flags",
+		strings.NewReader(`{"follow_pds_migration": false, "allow_self_actions": null}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response AccountFlagsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.AccountID)
+	assert.Equal(t, map[string]bool{
+		models.AccountFlagFollowPDSMigration: false,
+		models.AccountFlagAllowSelfActions:   false,
+	}, response.Flags)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetAccountFlagsErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE accounts\s+SET metadata = jsonb_set`).
+		WithArgs(`{"allow_self_actions":true}`, 9).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.PATCH("/accounts/:id/flags", handler.SetAccountFlags)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		error  string
+	}{
+		{"unknown flag", "/accounts/1/flags", `{"auto_rotate_proxy": false}`, http.StatusBadRequest, "Unknown account flag"},
+		{"not a boolean", "/accounts/1/flags", `{"follow_pds_migration": "off"}`, http.StatusBadRequest, "Invalid request body"},
+		{"missing account", "/accounts/9/flags", `{"allow_self_actions": true}`, http.StatusNotFound, "Account not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("PATCH", tt.path, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.error, response.Error)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshAuthenticationHonorsFollowPDSMigrationFlag(t *testing.T) {
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.server.refreshSession" {
+			t.Errorf("unexpected request %s; the DID document must not be fetched", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":        "did:plc:flagged",
+			"handle":     "flagged.bsky.social",
+			"accessJwt":  "new-access",
+			"refreshJwt": "new-refresh",
+		})
+	}))
+	defer pds.Close()
+	t.Setenv("PLC_DIRECTORY_URL", pds.URL)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	metadata := `{"flags": {"follow_pds_migration": false}}`
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(flagAccountRow(pds.URL, metadata))
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:flagged", "new-access", "new-refresh", sqlmock.AnyArg(), "active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(flagAccountRow(pds.URL, metadata))
+
+	service := &AccountService{db: db}
+	_, err = service.RefreshAuthentication(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
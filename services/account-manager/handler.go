@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
 	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
 )
 
 // AccountHandler handles HTTP requests for account management
@@ -39,7 +46,7 @@ func NewAccountHandler(accountService *AccountService, authService *AuthService)
 // @Router /api/v1/accounts [post]
 func (h *AccountHandler) CreateAccount(c *gin.Context) {
 	var req models.CreateAccountRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -70,6 +77,88 @@ func (h *AccountHandler) CreateAccount(c *gin.Context) {
 	c.JSON(http.StatusCreated, account)
 }
 
+// OnboardAccount creates, assigns a proxy to and authenticates an account
+// @Summary Onboard account
+// @Description Create an account, have proxy-manager assign it a proxy by strategy and log in through it. A failure names the step that failed; with rollback_on_auth_failure a failed login deletes the account again.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param request body OnboardAccountRequest true "Account and proxy selection"
+// @Success 201 {object} models.Account
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} OnboardErrorResponse
+// @Failure 404 {object} OnboardErrorResponse
+// @Failure 409 {object} OnboardErrorResponse
+// @Failure 502 {object} OnboardErrorResponse
+// @Failure 500 {object} OnboardErrorResponse
+// @Router /api/v1/accounts/onboard [post]
+func (h *AccountHandler) OnboardAccount(c *gin.Context) {
+	var req OnboardAccountRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	account, err := h.accountService.OnboardAccount(c.Request.Context(), &req)
+	if err != nil {
+		var onboardErr *OnboardError
+		if !errors.As(err, &onboardErr) {
+			onboardErr = &OnboardError{Err: err}
+		}
+
+		status := onboardErrorStatus(onboardErr)
+		response := OnboardErrorResponse{
+			Error:      "Failed to onboard account",
+			Message:    onboardErr.Err.Error(),
+			Code:       status,
+			Step:       onboardErr.Step,
+			RolledBack: onboardErr.RolledBack,
+		}
+		if onboardErr.AccountID != 0 && !onboardErr.RolledBack {
+			response.AccountID = &onboardErr.AccountID
+		}
+		c.JSON(status, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+// onboardErrorStatus picks the response status for a failed onboarding step
+func onboardErrorStatus(err *OnboardError) int {
+	switch err.Step {
+	case OnboardStepCreate:
+		if strings.Contains(err.Err.Error(), "already exists") {
+			return http.StatusConflict
+		}
+		if err.Err.Error() == "invalid handle format" {
+			return http.StatusBadRequest
+		}
+	case OnboardStepAssignProxy:
+		var pmErr *ProxyManagerError
+		if errors.As(err.Err, &pmErr) && pmErr.StatusCode < http.StatusInternalServerError {
+			return pmErr.StatusCode
+		}
+		return http.StatusBadGateway
+	case OnboardStepAuthenticate:
+		return httpStatusForBlueskyError(err.Err)
+	}
+	return http.StatusInternalServerError
+}
+
 // GetAccount retrieves an account by ID
 // @Summary Get account by ID
 // @Description Get a specific account by its ID
@@ -121,15 +210,16 @@ func (h *AccountHandler) GetAccount(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(10)
+// @Param page_size query int false "Page size; defaults to the default_page_size setting (10) and is capped by max_page_size (100)"
 // @Param status query string false "Filter by status" Enums(active,inactive,suspended,error)
+// @Param skip_malformed query bool false "Skip and count rows that fail to scan instead of failing the request"
 // @Success 200 {object} models.ListResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/accounts [get]
 func (h *AccountHandler) ListAccounts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size")) // 0 uses the default_page_size setting
 	
 	var status *models.AccountStatus
 	if statusStr := c.Query("status"); statusStr != "" {
@@ -137,7 +227,8 @@ func (h *AccountHandler) ListAccounts(c *gin.Context) {
 		status = &s
 	}
 
-	result, err := h.accountService.ListAccounts(c.Request.Context(), page, pageSize, status)
+	skipMalformed := c.Query("skip_malformed") == "true"
+	result, err := h.accountService.ListAccounts(c.Request.Context(), page, pageSize, status, skipMalformed)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to list accounts",
@@ -147,6 +238,9 @@ func (h *AccountHandler) ListAccounts(c *gin.Context) {
 		return
 	}
 
+	if result.SkippedRows > 0 {
+		c.Header("X-Skipped-Rows", strconv.Itoa(result.SkippedRows))
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -176,7 +270,7 @@ func (h *AccountHandler) UpdateAccount(c *gin.Context) {
 	}
 
 	var req models.UpdateAccountRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -258,9 +352,11 @@ func (h *AccountHandler) DeleteAccount(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Account ID"
-// @Success 200 {object} map[string]string
+// @Success 200 {object} AuthTestResult
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/accounts/{id}/test-auth [post]
 func (h *AccountHandler) TestAuthentication(c *gin.Context) {
@@ -275,20 +371,22 @@ func (h *AccountHandler) TestAuthentication(c *gin.Context) {
 		return
 	}
 
-	err = h.accountService.TestAuthentication(c.Request.Context(), id)
+	result, err := h.accountService.TestAuthentication(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Authentication test failed",
-			Message: err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+
+		respondBlueskyError(c, "Authentication test failed", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, map[string]string{
-		"status":  "success",
-		"message": "Authentication test passed",
-	})
+	c.JSON(http.StatusOK, result)
 }
 
 // RefreshAuthentication refreshes account authentication
@@ -300,7 +398,9 @@ func (h *AccountHandler) TestAuthentication(c *gin.Context) {
 // @Param id path int true "Account ID"
 // @Success 200 {object} models.Account
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/accounts/{id}/refresh-auth [post]
 func (h *AccountHandler) RefreshAuthentication(c *gin.Context) {
@@ -317,15 +417,83 @@ func (h *AccountHandler) RefreshAuthentication(c *gin.Context) {
 
 	account, err := h.accountService.RefreshAuthentication(c.Request.Context(), id)
 	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		respondBlueskyError(c, "Failed to refresh authentication", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+// RunAccountActions runs a batch of operations on an account
+// @Summary Run account actions
+// @Description Execute an ordered list of operations (assign_proxy, refresh_auth, set_status, reset_errors) on an account, returning per-step results. Consecutive database steps share a transaction; with stop_on_error the remaining steps are skipped after a failure.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param request body AccountActionsRequest true "Actions to run"
+// @Success 200 {object} AccountActionsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/actions [post]
+func (h *AccountHandler) RunAccountActions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req AccountActionsRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	response, err := h.accountService.RunAccountActions(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "Failed to refresh authentication",
+			Error:   "Failed to run account actions",
 			Message: err.Error(),
 			Code:    http.StatusInternalServerError,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, account)
+	c.JSON(http.StatusOK, response)
 }
 
 // Login handles user login
@@ -341,7 +509,7 @@ func (h *AccountHandler) RefreshAuthentication(c *gin.Context) {
 // @Router /api/v1/auth/login [post]
 func (h *AccountHandler) Login(c *gin.Context) {
 	var req LoginRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -350,6 +518,15 @@ func (h *AccountHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
 	response, err := h.authService.Login(c.Request.Context(), &req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
@@ -376,7 +553,7 @@ func (h *AccountHandler) Login(c *gin.Context) {
 // @Router /api/v1/auth/refresh [post]
 func (h *AccountHandler) RefreshToken(c *gin.Context) {
 	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -410,7 +587,7 @@ func (h *AccountHandler) RefreshToken(c *gin.Context) {
 // @Router /api/v1/auth/logout [post]
 func (h *AccountHandler) Logout(c *gin.Context) {
 	var req LogoutRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -505,3 +682,930 @@ func (h *AccountHandler) GetAccountMetrics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, metrics)
 }
+
+// maxTimeseriesDays bounds the window of the task timeseries
+const maxTimeseriesDays = 365
+
+// GetTaskTimeseries returns daily task counts for charting
+// @Summary Get task timeseries
+// @Description Get per-day completed, failed and pending task counts, with days without tasks filled in as zeros
+// @Tags stats
+// @Produce json
+// @Param days query int false "Number of days to include, up to 365" default(7)
+// @Param account_id query int false "Only count tasks for this account"
+// @Success 200 {object} TaskTimeseriesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/stats/tasks/timeseries [get]
+func (h *AccountHandler) GetTaskTimeseries(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > maxTimeseriesDays {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid days",
+			Message: "days must be an integer between 1 and 365",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var accountID *int
+	if idStr := c.Query("account_id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid account ID",
+				Message: "Account ID must be a valid integer",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		accountID = &id
+	}
+
+	timeseries, err := h.accountService.GetTaskTimeseries(c.Request.Context(), days, accountID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get task timeseries",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, timeseries)
+}
+
+// GetUpcomingSchedule previews scheduled strategy runs
+// @Summary Get upcoming schedule
+// @Description List active account strategies whose cron schedule fires within the next N minutes, ordered by next run. Schedules are evaluated in UTC unless prefixed with CRON_TZ=<zone>.
+// @Tags schedule
+// @Produce json
+// @Param minutes query int false "Window length in minutes, up to 1440" default(60)
+// @Success 200 {object} UpcomingScheduleResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/schedule/upcoming [get]
+func (h *AccountHandler) GetUpcomingSchedule(c *gin.Context) {
+	minutes, err := strconv.Atoi(c.DefaultQuery("minutes", "60"))
+	if err != nil || minutes < 1 || minutes > maxUpcomingMinutes {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid minutes",
+			Message: "minutes must be an integer between 1 and 1440",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	schedule, err := h.accountService.UpcomingSchedule(c.Request.Context(), time.Now().UTC(), time.Duration(minutes)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get upcoming schedule",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ListAccountStrategies lists the strategies attached to an account
+// @Summary List account strategies
+// @Description Get the strategies attached to an account with per-account config and execution counts
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param status query string false "Filter by status" Enums(active,inactive,paused)
+// @Success 200 {array} models.AccountStrategy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/strategies [get]
+func (h *AccountHandler) ListAccountStrategies(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var status *models.StrategyStatus
+	if statusStr := c.Query("status"); statusStr != "" {
+		s := models.StrategyStatus(statusStr)
+		status = &s
+	}
+
+	strategies, err := h.accountService.ListAccountStrategies(c.Request.Context(), id, status)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to list account strategies",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, strategies)
+}
+
+// PauseAccountStrategy pauses a strategy on a single account
+// @Summary Pause account strategy
+// @Description Pause one strategy on one account without detaching it. Inactive associations cannot be paused.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param strategyId path int true "Strategy ID"
+// @Success 200 {object} models.AccountStrategy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/strategies/{strategyId}/pause [post]
+func (h *AccountHandler) PauseAccountStrategy(c *gin.Context) {
+	h.setAccountStrategyStatus(c, models.StrategyStatusPaused)
+}
+
+// ResumeAccountStrategy resumes a paused strategy on a single account
+// @Summary Resume account strategy
+// @Description Resume a paused strategy on one account. Inactive associations cannot be resumed.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param strategyId path int true "Strategy ID"
+// @Success 200 {object} models.AccountStrategy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/strategies/{strategyId}/resume [post]
+func (h *AccountHandler) ResumeAccountStrategy(c *gin.Context) {
+	h.setAccountStrategyStatus(c, models.StrategyStatusActive)
+}
+
+func (h *AccountHandler) setAccountStrategyStatus(c *gin.Context, status models.StrategyStatus) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	strategyID, err := strconv.Atoi(c.Param("strategyId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid strategy ID",
+			Message: "Strategy ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	audit := AuditContext{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+
+	accountStrategy, err := h.accountService.SetAccountStrategyStatus(c.Request.Context(), id, strategyID, status, audit)
+	if err != nil {
+		if err.Error() == "account strategy not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account strategy not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrAccountStrategyInactive) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Account strategy inactive",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update account strategy",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, accountStrategy)
+}
+
+// ExportAccounts streams all accounts as newline-delimited JSON
+// @Summary Export accounts
+// @Description Stream accounts as NDJSON without credentials, flushing periodically for large datasets
+// @Tags accounts
+// @Produce application/x-ndjson
+// @Param status query string false "Filter by status"
+// @Success 200 {array} models.Account
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/export [get]
+func (h *AccountHandler) ExportAccounts(c *gin.Context) {
+	var status *models.AccountStatus
+	if statusStr := c.Query("status"); statusStr != "" {
+		s := models.AccountStatus(statusStr)
+		status = &s
+	}
+
+	flushEvery := utils.GetEnvAsInt("EXPORT_FLUSH_ROWS", 100)
+	rows, err := utils.StreamNDJSON(c.Request.Context(), c.Writer, flushEvery, func(emit func(v interface{}) error) error {
+		return h.accountService.ExportAccounts(c.Request.Context(), status, func(account models.Account) error {
+			return emit(account)
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		// The client went away; there is no one left to tell
+		log.Printf("Account export cancelled by the client after %d rows", rows)
+		return
+	}
+	if err != nil {
+		if rows == 0 {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to export accounts",
+				Message: err.Error(),
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+		// Headers are already sent; the truncated stream signals the failure
+		log.Printf("Account export aborted after %d rows: %v", rows, err)
+	}
+}
+
+// CompareAccounts compares two accounts side by side
+// @Summary Compare two accounts
+// @Description Compare status, proxy, errors and session state of two accounts, flagging differing fields
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param a query int true "First account ID"
+// @Param b query int true "Second account ID"
+// @Success 200 {object} AccountComparisonResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/compare [get]
+func (h *AccountHandler) CompareAccounts(c *gin.Context) {
+	idA, errA := strconv.Atoi(c.Query("a"))
+	idB, errB := strconv.Atoi(c.Query("b"))
+	if errA != nil || errB != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Query parameters a and b must be valid integers",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	comparison, err := h.accountService.CompareAccounts(c.Request.Context(), idA, idB)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to compare accounts",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// GetAccountErrors returns an account's recent error history
+// @Summary Get account errors
+// @Description Get the most recent failures of an account, classified as auth, proxy, rate_limit, network or other
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param limit query int false "Maximum number of errors" default(50)
+// @Success 200 {array} models.AccountError
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/errors [get]
+func (h *AccountHandler) GetAccountErrors(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	accountErrors, err := h.accountService.ListAccountErrors(c.Request.Context(), id, limit)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get account errors",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, accountErrors)
+}
+
+// GetAccountLimits returns the quota status of an account
+// @Summary Get account limits
+// @Description Get per-action limits, usage in the current window and time until reset
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} AccountLimitsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/limits [get]
+func (h *AccountHandler) GetAccountLimits(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limits, err := h.accountService.GetAccountLimits(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get account limits",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, limits)
+}
+
+// SetAccountFlags sets or clears an account's feature flags
+// @Summary Set account flags
+// @Description Set feature flags stored in the account's metadata. Send flag names mapped to true or false; null restores the default. Flags not named are left unchanged.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param flags body map[string]bool true "Flags to set"
+// @Success 200 {object} AccountFlagsResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/flags [patch]
+func (h *AccountHandler) SetAccountFlags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var flags map[string]*bool
+	if err := utils.BindJSON(c, &flags); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.accountService.SetAccountFlags(c.Request.Context(), id, flags)
+	if err != nil {
+		if errors.Is(err, ErrUnknownAccountFlag) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Unknown account flag",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update account flags",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SearchPosts searches Bluesky posts as an account
+// @Summary Search posts as an account
+// @Description Search Bluesky posts using the account's stored session, which is only refreshed once its access token has expired or is rejected. Pass the returned cursor to get the next page.
+// @Tags accounts
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param q query string true "Search query"
+// @Param author query string false "Only posts by this handle or DID"
+// @Param since query string false "Only posts at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param until query string false "Only posts before this time (RFC 3339 or YYYY-MM-DD)"
+// @Param lang query string false "Only posts in this language, e.g. en"
+// @Param cursor query string false "Cursor from the previous page"
+// @Param limit query int false "Posts per page (1-100)" default(25)
+// @Success 200 {object} bluesky.SearchResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/search [get]
+func (h *AccountHandler) SearchPosts(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid query",
+			Message: "q is required",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "25"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid limit",
+			Message: "limit must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+	limit = max(1, min(limit, maxSearchLimit))
+
+	options := &bluesky.SearchOptions{
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+		Author: strings.TrimPrefix(strings.TrimSpace(c.Query("author")), "@"),
+		Lang:   c.Query("lang"),
+	}
+	bounds := []struct {
+		name   string
+		target **time.Time
+	}{{"since", &options.Since}, {"until", &options.Until}}
+	for _, bound := range bounds {
+		t, err := parseSearchTime(c.Query(bound.name))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid " + bound.name,
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		*bound.target = t
+	}
+	if options.Since != nil && options.Until != nil && !options.Until.After(*options.Since) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid time window",
+			Message: "until must be after since",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.accountService.SearchPosts(c.Request.Context(), id, query, options)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		respondBlueskyError(c, "Failed to search posts", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetAccountSnapshot exports an account's Bluesky-side state
+// @Summary Get account snapshot
+// @Description Log in as the account and export its profile, follower and follow counts, recent posts, blocks and mutes. Sections that could not be fetched are listed under failed.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} bluesky.ProfileSnapshot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/snapshot [get]
+func (h *AccountHandler) GetAccountSnapshot(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	snapshot, err := h.accountService.GetAccountSnapshot(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		respondBlueskyError(c, "Failed to export account snapshot", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// CheckAccountStatus checks whether an account is restricted
+// @Summary Check account status
+// @Description Without logging in, check whether the PDS reports the account active, the AppView serves its profile without hiding labels, and its latest post is indexed. Findings are also recorded as a status_check account error. Checks that could not run are listed under failed.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} bluesky.AccountHealth
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/accounts/{id}/status-check [get]
+func (h *AccountHandler) CheckAccountStatus(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid account ID",
+			Message: "Account ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	health, err := h.accountService.CheckAccountStatus(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: "The requested account does not exist",
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		respondBlueskyError(c, "Failed to check account status", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, health)
+}
+
+// ResolveHandles resolves handles to DIDs
+// @Summary Resolve handles to DIDs
+// @Description Resolve a batch of Bluesky handles to DIDs, reporting per-handle errors
+// @Tags utilities
+// @Accept json
+// @Produce json
+// @Param request body ResolveHandlesRequest true "Handles to resolve"
+// @Success 200 {object} ResolveHandlesResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Router /api/v1/resolve-handles [post]
+func (h *AccountHandler) ResolveHandles(c *gin.Context) {
+	var req ResolveHandlesRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.accountService.ResolveHandles(c.Request.Context(), req.Handles))
+}
+
+// CreateTask queues a task for an account strategy
+// @Summary Create task
+// @Description Queue a task for an account under one of its strategies. Priority and timeout default to the strategy's. Paused or inactive associations, strategies and accounts get no new tasks. Tasks for an account in a rate-limit cooldown are scheduled for when it ends. Nothing is created while automation is paused. The task type must be one the strategy's type allows, with the payload fields it needs.
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param task body models.CreateTaskRequest true "Task data"
+// @Success 201 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tasks [post]
+func (h *AccountHandler) CreateTask(c *gin.Context) {
+	var req models.CreateTaskRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	task, err := h.accountService.CreateTask(c.Request.Context(), &req)
+	if err != nil {
+		if err.Error() == "account strategy not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account strategy not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrAccountStrategyNotSchedulable) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Account strategy is not active",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		if errors.Is(err, ErrAutomationPaused) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Automation paused",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		if errors.Is(err, models.ErrInvalidTaskType) || errors.Is(err, models.ErrInvalidTaskPayload) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid task",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to create task",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, task)
+}
+
+// RetryTask re-queues a failed task
+// @Summary Retry task
+// @Description Reset a failed task to pending, clear its error and increment its retry count. Capped by TASK_RETRY_CEILING. Nothing is retried while automation is paused.
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tasks/{id}/retry [post]
+func (h *AccountHandler) RetryTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid task ID",
+			Message: "Task ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	task, err := h.accountService.RetryTask(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Task not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrTaskNotRetryable) || errors.Is(err, ErrTaskRetryLimit) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Task cannot be retried",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		if errors.Is(err, ErrAutomationPaused) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Automation paused",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to retry task",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// CancelTask cancels a pending or running task
+// @Summary Cancel task
+// @Description Cancel a pending task immediately, or flag a running task so its worker stops between steps and cancels in-flight work
+// @Tags tasks
+// @Produce json
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/tasks/{id}/cancel [post]
+func (h *AccountHandler) CancelTask(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid task ID",
+			Message: "Task ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	task, err := h.accountService.CancelTask(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "task not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Task not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrTaskNotCancellable) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Task cannot be cancelled",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to cancel task",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// PauseAutomation turns on the global kill switch
+// @Summary Pause all automation
+// @Description Stop new tasks from being created and the proxy health scheduler from running cycles. Queued and running tasks are not touched; the other APIs stay up. Requires an admin token.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} AutomationStatusResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/pause [post]
+func (h *AccountHandler) PauseAutomation(c *gin.Context) {
+	h.setAutomationPaused(c, true)
+}
+
+// ResumeAutomation turns off the global kill switch
+// @Summary Resume automation
+// @Description Allow task creation and let the proxy health scheduler run again after a pause. Requires an admin token.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} AutomationStatusResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/admin/resume [post]
+func (h *AccountHandler) ResumeAutomation(c *gin.Context) {
+	h.setAutomationPaused(c, false)
+}
+
+func (h *AccountHandler) setAutomationPaused(c *gin.Context, paused bool) {
+	if err := h.accountService.SetAutomationPaused(c.Request.Context(), paused); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update automation status",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, AutomationStatusResponse{Paused: paused})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// limitedActions are the action types tracked against per-account quotas
+var limitedActions = []models.StrategyType{
+	models.StrategyTypePost,
+	models.StrategyTypeFollow,
+	models.StrategyTypeLike,
+	models.StrategyTypeRepost,
+}
+
+// defaultActionLimits are the per-window quotas used when no override is set
+var defaultActionLimits = map[models.StrategyType]int{
+	models.StrategyTypePost:   50,
+	models.StrategyTypeFollow: 100,
+	models.StrategyTypeLike:   300,
+	models.StrategyTypeRepost: 100,
+}
+
+// actionLimit returns the configured quota for an action type, overridable
+// through ACCOUNT_LIMIT_<ACTION> (e.g. ACCOUNT_LIMIT_FOLLOW)
+func actionLimit(action models.StrategyType) int {
+	key := "ACCOUNT_LIMIT_" + strings.ToUpper(string(action))
+	return utils.GetEnvAsInt(key, defaultActionLimits[action])
+}
+
+// GetAccountLimits returns the quota status of each action type for an
+// account, counted by workers through utils.RecordActionUsage, together with
+// any rate-limit cooldown still in place
+func (s *AccountService) GetAccountLimits(ctx context.Context, id int) (*AccountLimitsResponse, error) {
+	if err := s.ensureAccountExists(ctx, id); err != nil {
+		return nil, err
+	}
+
+	response := &AccountLimitsResponse{
+		AccountID:     id,
+		WindowSeconds: int(utils.ActionLimitWindow().Seconds()),
+		Limits:        make(map[models.StrategyType]ActionLimitStatus, len(limitedActions)),
+	}
+
+	for _, action := range limitedActions {
+		key := utils.ActionUsageKey(id, string(action))
+
+		used, err := s.rdb.Get(ctx, key).Int()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get %s usage: %w", action, err)
+		}
+
+		var resetIn time.Duration
+		if used > 0 {
+			resetIn, err = s.rdb.TTL(ctx, key).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s usage window: %w", action, err)
+			}
+			if resetIn < 0 {
+				resetIn = 0
+			}
+		}
+
+		limit := actionLimit(action)
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		response.Limits[action] = ActionLimitStatus{
+			Limit:          limit,
+			Used:           used,
+			Remaining:      remaining,
+			ResetInSeconds: int(resetIn.Seconds()),
+		}
+	}
+
+	cooldown, err := utils.AccountCooldownRemaining(ctx, s.rdb, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	response.CooldownSeconds = int(cooldown.Seconds())
+
+	return response, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestGetAccountLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ACCOUNT_LIMIT_FOLLOW", "40")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	service := &AccountService{db: db, rdb: rdb}
+
+	// Seed usage: 30 follows and 2 posts recorded in the current window
+	for i := 0; i < 30; i++ {
+		_, err := utils.RecordActionUsage(context.Background(), rdb, 7, string(models.StrategyTypeFollow))
+		assert.NoError(t, err)
+	}
+	mr.Set(utils.ActionUsageKey(7, string(models.StrategyTypePost)), "2")
+	mr.SetTTL(utils.ActionUsageKey(7, string(models.StrategyTypePost)), time.Hour)
+
+	// A rate limit has the account cooling down for ten more minutes
+	assert.NoError(t, utils.SetAccountCooldown(context.Background(), rdb, 7, time.Now().Add(10*time.Minute)))
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	handler := &AccountHandler{accountService: service}
+	router := gin.New()
+	router.GET("/accounts/:id/limits", handler.GetAccountLimits)
+
+	req, _ := http.NewRequest("GET", "/accounts/7/limits", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AccountLimitsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 7, response.AccountID)
+
+	follow := response.Limits[models.StrategyTypeFollow]
+	assert.Equal(t, 40, follow.Limit)
+	assert.Equal(t, 30, follow.Used)
+	assert.Equal(t, 10, follow.Remaining)
+	assert.Equal(t, 86400, follow.ResetInSeconds)
+
+	post := response.Limits[models.StrategyTypePost]
+	assert.Equal(t, 2, post.Used)
+	assert.Equal(t, 48, post.Remaining)
+	assert.Equal(t, 3600, post.ResetInSeconds)
+
+	// No recorded usage reports the full quota
+	like := response.Limits[models.StrategyTypeLike]
+	assert.Equal(t, 0, like.Used)
+	assert.Equal(t, like.Limit, like.Remaining)
+	assert.Equal(t, 0, like.ResetInSeconds)
+
+	assert.InDelta(t, 600, response.CooldownSeconds, 2)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAccountLimitsNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/:id/limits", handler.GetAccountLimits)
+
+	req, _ := http.NewRequest("GET", "/accounts/99/limits", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
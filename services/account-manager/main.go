@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/bsky-automation/shared/migrations"
 	"github.com/bsky-automation/shared/models"
 	"github.com/bsky-automation/shared/utils"
 )
@@ -39,6 +42,9 @@ import (
 // @name Authorization
 
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	flag.Parse()
+
 	// Load configuration
 	config := loadConfig()
 
@@ -49,6 +55,16 @@ func main() {
 	}
 	defer db.Close()
 
+	// Apply schema migrations
+	if *migrateOnly || utils.GetEnvAsBool("DB_AUTO_MIGRATE", true) {
+		if err := runMigrations(db); err != nil {
+			log.Fatalf("Failed to apply database migrations: %v", err)
+		}
+	}
+	if *migrateOnly {
+		return
+	}
+
 	// Initialize Redis
 	rdb := initRedis(config.RedisURL)
 	defer rdb.Close()
@@ -57,6 +73,10 @@ func main() {
 	accountService := NewAccountService(db, rdb)
 	authService := NewAuthService(db, rdb)
 
+	// Route service events to audit and metrics subscribers
+	events := utils.NewEventBus()
+	wireEvents(events, rdb, accountService)
+
 	// Initialize handlers
 	accountHandler := NewAccountHandler(accountService, authService)
 
@@ -69,6 +89,14 @@ func main() {
 		Handler: router,
 	}
 
+	shutdown := utils.NewShutdownCoordinator()
+	shutdown.Register("http server", srv.Shutdown)
+	shutdown.Register("event deliveries", events.Wait)
+	shutdown.Register("bluesky transports", func(ctx context.Context) error {
+		accountService.transports.Close()
+		return nil
+	})
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Account Manager starting on port %s", config.Port)
@@ -87,7 +115,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := shutdown.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
@@ -123,6 +151,9 @@ func initDatabase(databaseURL string) (*sql.DB, error) {
 		Password: utils.GetEnvOrDefault("DB_PASSWORD", "bsky_test_password"),
 		DBName:   utils.GetEnvOrDefault("DB_NAME", "bsky_automation"),
 		SSLMode:  "disable",
+
+		StatementTimeout:         time.Duration(utils.GetEnvAsInt("DB_STATEMENT_TIMEOUT_MS", 30000)) * time.Millisecond,
+		IdleInTransactionTimeout: time.Duration(utils.GetEnvAsInt("DB_IDLE_IN_TX_TIMEOUT_MS", 60000)) * time.Millisecond,
 	}
 
 	// In production, parse the databaseURL properly
@@ -140,6 +171,21 @@ func initDatabase(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+// runMigrations applies any embedded schema migrations the database is missing
+func runMigrations(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	applied, err := migrations.Run(ctx, db)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+	return err
+}
+
 // initRedis initializes the Redis connection
 func initRedis(redisURL string) *redis.Client {
 	config := utils.RedisConfig{
@@ -172,8 +218,9 @@ func setupRouter(accountHandler *AccountHandler) *gin.Engine {
 
 	// Middleware
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(recoveryMiddleware())
 	router.Use(corsMiddleware())
+	router.Use(utils.GzipMiddleware(utils.GetEnvAsInt("GZIP_MIN_BYTES", 1024)))
 
 	// Health check endpoint
 	router.GET("/health", healthCheckHandler)
@@ -181,19 +228,38 @@ func setupRouter(accountHandler *AccountHandler) *gin.Engine {
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Profiling, off unless ENABLE_PPROF is set and limited to admins
+	registerPprofRoutes(router, accountHandler.authService)
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
+		// Model schemas
+		v1.GET("/schema", schemaHandler)
+
 		// Account routes
 		accounts := v1.Group("/accounts")
 		{
 			accounts.GET("", accountHandler.ListAccounts)
-			accounts.POST("", accountHandler.CreateAccount)
+			accounts.POST("", utils.StrictJSON(), accountHandler.CreateAccount)
+			accounts.GET("/compare", accountHandler.CompareAccounts)
+			accounts.GET("/export", accountHandler.ExportAccounts)
+			accounts.POST("/onboard", accountHandler.OnboardAccount)
 			accounts.GET("/:id", accountHandler.GetAccount)
-			accounts.PUT("/:id", accountHandler.UpdateAccount)
+			accounts.PUT("/:id", utils.StrictJSON(), accountHandler.UpdateAccount)
 			accounts.DELETE("/:id", accountHandler.DeleteAccount)
 			accounts.POST("/:id/test-auth", accountHandler.TestAuthentication)
 			accounts.POST("/:id/refresh-auth", accountHandler.RefreshAuthentication)
+			accounts.POST("/:id/actions", accountHandler.RunAccountActions)
+			accounts.GET("/:id/limits", accountHandler.GetAccountLimits)
+			accounts.PATCH("/:id/flags", accountHandler.SetAccountFlags)
+			accounts.GET("/:id/snapshot", accountHandler.GetAccountSnapshot)
+			accounts.GET("/:id/status-check", accountHandler.CheckAccountStatus)
+			accounts.GET("/:id/search", accountHandler.SearchPosts)
+			accounts.GET("/:id/errors", accountHandler.GetAccountErrors)
+			accounts.GET("/:id/strategies", accountHandler.ListAccountStrategies)
+			accounts.POST("/:id/strategies/:strategyId/pause", accountHandler.PauseAccountStrategy)
+			accounts.POST("/:id/strategies/:strategyId/resume", accountHandler.ResumeAccountStrategy)
 		}
 
 		// Authentication routes
@@ -204,11 +270,33 @@ func setupRouter(accountHandler *AccountHandler) *gin.Engine {
 			auth.POST("/logout", accountHandler.Logout)
 		}
 
+		// Admin routes
+		admin := v1.Group("/admin", requireAdmin(accountHandler.authService))
+		{
+			admin.POST("/pause", accountHandler.PauseAutomation)
+			admin.POST("/resume", accountHandler.ResumeAutomation)
+		}
+
+		// Schedule routes
+		v1.GET("/schedule/upcoming", accountHandler.GetUpcomingSchedule)
+
+		// Task routes
+		tasks := v1.Group("/tasks")
+		{
+			tasks.POST("", accountHandler.CreateTask)
+			tasks.POST("/:id/retry", accountHandler.RetryTask)
+			tasks.POST("/:id/cancel", accountHandler.CancelTask)
+		}
+
+		// Utilities
+		v1.POST("/resolve-handles", accountHandler.ResolveHandles)
+
 		// Account statistics
 		stats := v1.Group("/stats")
 		{
 			stats.GET("/accounts", accountHandler.GetAccountStats)
 			stats.GET("/accounts/:id/metrics", accountHandler.GetAccountMetrics)
+			stats.GET("/tasks/timeseries", accountHandler.GetTaskTimeseries)
 		}
 	}
 
@@ -221,7 +309,7 @@ func corsMiddleware() gin.HandlerFunc {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -232,6 +320,32 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// recoveryMiddleware converts panics into a structured ErrorResponse
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				requestID := c.GetHeader("X-Request-ID")
+				if requestID == "" {
+					requestID = utils.GenerateUUID().String()
+				}
+
+				log.Printf("Panic recovered [request_id=%s] %s %s: %v\n%s",
+					requestID, c.Request.Method, c.Request.URL.Path, r, debug.Stack())
+
+				c.Header("X-Request-ID", requestID)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "Internal server error",
+					Message: "An unexpected error occurred",
+					Code:    http.StatusInternalServerError,
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
+
 // healthCheckHandler handles health check requests
 // @Summary Health check
 // @Description Check if the service is healthy
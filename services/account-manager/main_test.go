@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -63,6 +65,33 @@ func TestCreateAccountValidation(t *testing.T) {
 	assert.Equal(t, "Validation failed", response.Error)
 }
 
+func TestCreateAccountStrictJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AccountHandler{
+		validator: validator.New(),
+	}
+
+	router := gin.New()
+	router.POST("/accounts", utils.StrictJSON(), handler.CreateAccount)
+
+	// "pasword" is a typo for "password"
+	body := `{"handle": "test.bsky.social", "pasword": "secret"}`
+	req, _ := http.NewRequest("POST", "/accounts", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Invalid request body", response.Error)
+	assert.Contains(t, response.Message, `unknown field "pasword"`)
+}
+
 func TestLoginValidation(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -123,3 +152,28 @@ func TestOPTIONSRequest(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
+
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(recoveryMiddleware())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("database exploded: secret connection string")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set("X-Request-ID", "test-request-id")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "test-request-id", w.Header().Get("X-Request-ID"))
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Internal server error", response.Error)
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.NotContains(t, w.Body.String(), "secret connection string")
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// Onboarding steps, reported in OnboardError
+const (
+	OnboardStepCreate       = "create"
+	OnboardStepAssignProxy  = "assign_proxy"
+	OnboardStepAuthenticate = "authenticate"
+)
+
+// OnboardError reports which onboarding step failed. AccountID is 0 when the
+// account was never created.
+type OnboardError struct {
+	Step       string
+	AccountID  int
+	RolledBack bool
+	Err        error
+}
+
+func (e *OnboardError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.Step, e.Err)
+}
+
+func (e *OnboardError) Unwrap() error {
+	return e.Err
+}
+
+// OnboardAccount creates an account, has proxy-manager assign it a proxy and
+// logs in through that proxy. Failures are returned as *OnboardError. An
+// account whose login fails is deleted again when RollbackOnAuthFailure is
+// set; otherwise it is kept with its error status so it can be fixed later.
+func (s *AccountService) OnboardAccount(ctx context.Context, req *OnboardAccountRequest) (*models.Account, error) {
+	account, err := s.insertAccount(ctx, &models.CreateAccountRequest{
+		Handle:   req.Handle,
+		Password: req.Password,
+		Host:     req.Host,
+		BGS:      req.BGS,
+	})
+	if err != nil {
+		return nil, &OnboardError{Step: OnboardStepCreate, Err: err}
+	}
+
+	_, err = s.assignProxy(ctx, ProxyAssignmentRequest{
+		AccountID: account.ID,
+		ProxyID:   req.ProxyID,
+		ProxyType: req.ProxyType,
+		Strategy:  req.ProxyStrategy,
+	})
+	if err != nil {
+		return nil, &OnboardError{Step: OnboardStepAssignProxy, AccountID: account.ID, Err: err}
+	}
+
+	authenticated, err := s.RefreshAuthentication(ctx, account.ID)
+	if err != nil {
+		onboardErr := &OnboardError{Step: OnboardStepAuthenticate, AccountID: account.ID, Err: err}
+		if req.RollbackOnAuthFailure {
+			if delErr := s.DeleteAccount(ctx, account.ID); delErr != nil {
+				log.Printf("Failed to roll back onboarding of account %d: %v", account.ID, delErr)
+			} else {
+				onboardErr.RolledBack = true
+			}
+		}
+		return nil, onboardErr
+	}
+
+	return authenticated, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// newOnboardTestPDS serves createSession, rejecting the login when reject is set
+func newOnboardTestPDS(t *testing.T, reject bool) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/com.atproto.server.createSession", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if reject {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "AuthenticationRequired", "message": "Invalid identifier or password"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"did": "did:plc:newbie", "handle": "newbie.bsky.social",
+			"accessJwt": "access", "refreshJwt": "refresh",
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	// No DID documents, so the PDS migration check is skipped
+	plc := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(plc.Close)
+	t.Setenv("PLC_DIRECTORY_URL", plc.URL)
+
+	return server
+}
+
+// onboardAccountRow is the stored account; the stubbed assignment leaves it
+// without a proxy so the test PDS is reached directly
+func onboardAccountRow(host string) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows(accountColumns).
+		AddRow(7, utils.GenerateUUID().String(), "newbie.bsky.social", "pw", host, "https://bsky.network", "active",
+			nil, nil, nil, nil, nil,
+			nil, 0, nil, []byte(`{}`), now, now,
+			nil, nil, nil, nil, nil, nil, nil)
+}
+
+func expectOnboardInsert(mock sqlmock.Sqlmock, host string) {
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE handle = \$1\)`).
+		WithArgs("newbie.bsky.social").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WithArgs(sqlmock.AnyArg(), "newbie.bsky.social", "pw", host, "https://bsky.network", models.AccountStatusActive, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(7, time.Now(), time.Now()))
+}
+
+func serveOnboard(t *testing.T, service *AccountService, req OnboardAccountRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := &AccountHandler{accountService: service, validator: validator.New()}
+	router := gin.New()
+	router.POST("/accounts/onboard", handler.OnboardAccount)
+
+	body, _ := json.Marshal(req)
+	httpReq, _ := http.NewRequest("POST", "/accounts/onboard", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httpReq)
+	return w
+}
+
+func TestOnboardAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pds := newOnboardTestPDS(t, false)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectOnboardInsert(mock, pds.URL)
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(7).
+		WillReturnRows(onboardAccountRow(pds.URL))
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:newbie", "access", "refresh", sqlmock.AnyArg(), "active", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(7, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(7).
+		WillReturnRows(onboardAccountRow(pds.URL))
+
+	var assigned ProxyAssignmentRequest
+	service := &AccountService{
+		db: db,
+		assignProxy: func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+			assigned = req
+			return &ProxyAssignment{ProxyID: 3, ProxyName: "proxy-3"}, nil
+		},
+	}
+
+	w := serveOnboard(t, service, OnboardAccountRequest{
+		Handle: "newbie.bsky.social", Password: "pw", Host: pds.URL, ProxyStrategy: "least_used",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, ProxyAssignmentRequest{AccountID: 7, Strategy: "least_used"}, assigned)
+
+	var account models.Account
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &account))
+	assert.Equal(t, 7, account.ID)
+	assert.Equal(t, models.AccountStatusActive, account.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOnboardAccountRollsBackOnAuthFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	pds := newOnboardTestPDS(t, true)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectOnboardInsert(mock, pds.URL)
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(7).
+		WillReturnRows(onboardAccountRow(pds.URL))
+	mock.ExpectExec(`UPDATE accounts SET status = \$1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO account_errors`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(7, "refresh_auth", false).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(7).
+		WillReturnRows(onboardAccountRow(pds.URL))
+	mock.ExpectExec(`DELETE FROM accounts WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := &AccountService{
+		db: db,
+		assignProxy: func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+			return &ProxyAssignment{ProxyID: 3}, nil
+		},
+	}
+
+	w := serveOnboard(t, service, OnboardAccountRequest{
+		Handle: "newbie.bsky.social", Password: "pw", Host: pds.URL, RollbackOnAuthFailure: true,
+	})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	var response OnboardErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, OnboardStepAuthenticate, response.Step)
+	assert.True(t, response.RolledBack)
+	assert.Nil(t, response.AccountID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOnboardAccountProxyFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectOnboardInsert(mock, "https://bsky.social")
+
+	proxyManager := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/assignment/assign", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No proxy available", Message: "all proxies are at capacity", Code: http.StatusConflict})
+	}))
+	defer proxyManager.Close()
+
+	service := &AccountService{db: db, assignProxy: newProxyManagerAssigner(proxyManager.URL)}
+
+	w := serveOnboard(t, service, OnboardAccountRequest{Handle: "newbie.bsky.social", Password: "pw"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response OnboardErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, OnboardStepAssignProxy, response.Step)
+	assert.Equal(t, 7, *response.AccountID)
+	assert.Contains(t, response.Message, "all proxies are at capacity")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// newAccountClient creates a Bluesky client for an account through its proxy
+func (s *AccountService) newAccountClient(account *models.Account) (*bluesky.Client, error) {
+	client, err := bluesky.NewClient(bluesky.ClientConfig{
+		Account:      account,
+		Proxy:        account.Proxy,
+		Timeout:      30 * time.Second,
+		PLCDirectory: utils.GetEnvOrDefault("PLC_DIRECTORY_URL", bluesky.DefaultPLCDirectory),
+		Transports:   s.transports,
+
+		AllowSelfActions: account.Flag(models.AccountFlagAllowSelfActions),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Bluesky client: %w", err)
+	}
+	return client, nil
+}
+
+// followPDSMigration resolves the account's DID document and, when it names a
+// different PDS than the stored host, moves the account there: the host is
+// updated and audited, and cached tokens from the old PDS are dropped so the
+// next login uses the same credentials against the new one. Resolution
+// failures are logged and treated as no migration.
+func (s *AccountService) followPDSMigration(ctx context.Context, client *bluesky.Client, account *models.Account, did string) (bool, error) {
+	doc, err := client.ResolveDIDDocument(ctx, did)
+	if err != nil {
+		log.Printf("Skipping PDS migration check for account %d: %v", account.ID, err)
+		return false, nil
+	}
+	if doc.PDSEndpoint == "" || servesPDS(account.Host, doc.PDSEndpoint) {
+		return false, nil
+	}
+
+	oldHost := account.Host
+	err = utils.Transaction(s.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE accounts SET host = $1, updated_at = NOW() WHERE id = $2", doc.PDSEndpoint, account.ID)
+		if err != nil {
+			return fmt.Errorf("failed to update account host: %w", err)
+		}
+
+		auditQuery := `
+			INSERT INTO audit_logs (entity_type, entity_id, action, old_values, new_values)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.ExecContext(ctx, auditQuery,
+			"accounts", account.ID, "pds_migration",
+			models.JSONB{"host": oldHost}, models.JSONB{"host": doc.PDSEndpoint},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("Account %d migrated PDS from %s to %s", account.ID, oldHost, doc.PDSEndpoint)
+
+	account.Host = doc.PDSEndpoint
+	account.AccessJWT = nil
+	account.RefreshJWT = nil
+	return true, nil
+}
+
+// pdsEntryways maps entryway hosts to the host suffix of the PDS shards
+// behind them. Accounts created at an entryway log in there while their DID
+// document names the shard that actually holds the repo.
+var pdsEntryways = map[string]string{
+	"bsky.social": ".host.bsky.network",
+}
+
+// servesPDS reports whether the stored account host reaches the PDS endpoint
+// from a DID document, either directly or as the entryway in front of it
+func servesPDS(host, endpoint string) bool {
+	if sameHost(host, endpoint) {
+		return true
+	}
+
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return false
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+
+	suffix, ok := pdsEntryways[strings.ToLower(hostURL.Hostname())]
+	return ok && strings.HasSuffix(strings.ToLower(endpointURL.Hostname()), suffix)
+}
+
+// sameHost compares two PDS URLs ignoring case and trailing slashes
+func sameHost(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "/"), strings.TrimSuffix(b, "/"))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestRefreshAuthenticationFollowsPDSMigration(t *testing.T) {
+	oldPDS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to old PDS: %s", r.URL.Path)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer oldPDS.Close()
+
+	var loginIdentifier string
+	newPDS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/com.atproto.server.createSession", r.URL.Path)
+		var input struct {
+			Identifier string `json:"identifier"`
+			Password   string `json:"password"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		loginIdentifier = input.Identifier
+		assert.Equal(t, "pw", input.Password)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":        "did:plc:mover",
+			"handle":     "mover.bsky.social",
+			"accessJwt":  "new-access",
+			"refreshJwt": "new-refresh",
+		})
+	}))
+	defer newPDS.Close()
+
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/did:plc:mover":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":          "did:plc:mover",
+				"alsoKnownAs": []string{"at://mover.bsky.social"},
+				"service": []map[string]string{
+					{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": newPDS.URL},
+				},
+			})
+		case "/did:plc:mover/data":
+			json.NewEncoder(w).Encode(map[string]interface{}{"rotationKeys": []string{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer plc.Close()
+	t.Setenv("PLC_DIRECTORY_URL", plc.URL)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	accountRow := func(host string) *sqlmock.Rows {
+		return sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "mover.bsky.social", "pw", host, "https://bsky.network", "active",
+				nil, "did:plc:mover", "old-access", "old-refresh", nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				nil, nil, nil, nil, nil, nil, nil)
+	}
+
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(accountRow(oldPDS.URL))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE accounts SET host = \$1`).
+		WithArgs(newPDS.URL, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WithArgs("accounts", 1, "pds_migration", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:mover", "new-access", "new-refresh", sqlmock.AnyArg(), "active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(accountRow(newPDS.URL))
+
+	service := &AccountService{db: db}
+	account, err := service.RefreshAuthentication(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, newPDS.URL, account.Host)
+	assert.Equal(t, "mover.bsky.social", loginIdentifier)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFollowPDSMigrationIgnoresEntrywayShard(t *testing.T) {
+	plc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/did:plc:settled":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id":          "did:plc:settled",
+				"alsoKnownAs": []string{"at://settled.bsky.social"},
+				"service": []map[string]string{
+					{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "https://morel.us-east.host.bsky.network"},
+				},
+			})
+		case "/did:plc:settled/data":
+			json.NewEncoder(w).Encode(map[string]interface{}{"rotationKeys": []string{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer plc.Close()
+	t.Setenv("PLC_DIRECTORY_URL", plc.URL)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	accessJWT, refreshJWT := "access", "refresh"
+	account := &models.Account{
+		ID:         1,
+		Handle:     "settled.bsky.social",
+		Password:   "pw",
+		Host:       "https://bsky.social",
+		AccessJWT:  &accessJWT,
+		RefreshJWT: &refreshJWT,
+	}
+
+	service := &AccountService{db: db}
+	client, err := service.newAccountClient(account)
+	assert.NoError(t, err)
+
+	migrated, err := service.followPDSMigration(context.Background(), client, account, "did:plc:settled")
+	assert.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Equal(t, "https://bsky.social", account.Host)
+	assert.NotNil(t, account.AccessJWT)
+	assert.NotNil(t, account.RefreshJWT)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
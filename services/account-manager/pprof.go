@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// registerPprofRoutes mounts the profiling routes when ENABLE_PPROF is set,
+// limited to admins
+func registerPprofRoutes(router *gin.Engine, auth *AuthService) {
+	if utils.PprofEnabled() {
+		utils.RegisterPprofRoutes(router, requireAdmin(auth))
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPprofRoutesFollowFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	auth := &AuthService{rdb: redis.NewClient(&redis.Options{Addr: mr.Addr()}), jwtSecret: []byte("test-secret")}
+	handler := &AccountHandler{authService: auth}
+
+	admin, _, _, err := auth.generateTokens(1, "admin", adminRole)
+	require.NoError(t, err)
+	get := func(router *gin.Engine, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Setenv("ENABLE_PPROF", "")
+	router := setupRouter(handler)
+	assert.Equal(t, http.StatusNotFound, get(router, "/debug/pprof/", admin))
+	assert.Equal(t, http.StatusNotFound, get(router, "/debug/pprof/heap", admin))
+
+	t.Setenv("ENABLE_PPROF", "true")
+	router = setupRouter(handler)
+	assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/", admin))
+	assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/heap", admin))
+
+	// Enabled routes still need an admin token
+	assert.Equal(t, http.StatusUnauthorized, get(router, "/debug/pprof/", ""))
+	viewer, _, _, err := auth.generateTokens(2, "viewer", "viewer")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, get(router, "/debug/pprof/heap", viewer))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// ProxyAssignmentRequest is the body of proxy-manager's assign endpoint
+type ProxyAssignmentRequest struct {
+	AccountID int               `json:"account_id"`
+	ProxyID   *int              `json:"proxy_id,omitempty"`
+	ProxyType *models.ProxyType `json:"proxy_type,omitempty"`
+	Strategy  string            `json:"strategy,omitempty"`
+}
+
+// ProxyAssignment is the part of proxy-manager's assignment response used here
+type ProxyAssignment struct {
+	ProxyID   int    `json:"proxy_id"`
+	ProxyName string `json:"proxy_name"`
+}
+
+// ProxyManagerError is a non-2xx answer from proxy-manager
+type ProxyManagerError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ProxyManagerError) Error() string {
+	return fmt.Sprintf("proxy-manager returned %d: %s", e.StatusCode, e.Message)
+}
+
+// newProxyManagerAssigner returns an assignProxy func that calls proxy-manager
+// at baseURL, so selection strategies live in one place
+func newProxyManagerAssigner(baseURL string) func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/v1/assignment/assign"
+
+	return func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error) {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode assignment request: %w", err)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create assignment request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach proxy-manager: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			var errResp models.ErrorResponse
+			json.NewDecoder(resp.Body).Decode(&errResp)
+			message := errResp.Message
+			if message == "" {
+				message = errResp.Error
+			}
+			return nil, &ProxyManagerError{StatusCode: resp.StatusCode, Message: message}
+		}
+
+		var assignment ProxyAssignment
+		if err := json.NewDecoder(resp.Body).Decode(&assignment); err != nil {
+			return nil, fmt.Errorf("failed to decode assignment response: %w", err)
+		}
+		return &assignment, nil
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// maxUpcomingMinutes caps the schedule preview window at one day
+const maxUpcomingMinutes = 24 * 60
+
+// UpcomingSchedule returns the schedulable account-strategy associations
+// whose cron schedule fires within window of from, ordered by their next run.
+// Schedules are evaluated in UTC unless they carry a CRON_TZ= prefix.
+// Associations whose schedule does not parse are logged and left out.
+func (s *AccountService) UpcomingSchedule(ctx context.Context, from time.Time, window time.Duration) (*UpcomingScheduleResponse, error) {
+	query := `
+		SELECT id, account_id, account_handle, strategy_id, strategy_name, strategy_type, strategy_schedule
+		FROM schedulable_account_strategies
+		WHERE strategy_schedule IS NOT NULL AND strategy_schedule <> ''
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled strategies: %w", err)
+	}
+	defer rows.Close()
+
+	until := from.Add(window)
+	executions := []UpcomingExecution{}
+	for rows.Next() {
+		var e UpcomingExecution
+		if err := rows.Scan(&e.AccountStrategyID, &e.AccountID, &e.AccountHandle,
+			&e.StrategyID, &e.StrategyName, &e.StrategyType, &e.Schedule); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled strategy: %w", err)
+		}
+
+		next, err := utils.NextCronTime(e.Schedule, from)
+		if err != nil {
+			log.Printf("Skipping account strategy %d with invalid schedule %q: %v", e.AccountStrategyID, e.Schedule, err)
+			continue
+		}
+		if next.After(until) {
+			continue
+		}
+		e.NextExecution = next
+
+		// Count every run in the window, so frequent schedules show their load
+		for run := next; err == nil && !run.After(until); run, err = utils.NextCronTime(e.Schedule, run) {
+			e.RunsInWindow++
+		}
+		executions = append(executions, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list scheduled strategies: %w", err)
+	}
+
+	sort.SliceStable(executions, func(i, j int) bool {
+		if !executions[i].NextExecution.Equal(executions[j].NextExecution) {
+			return executions[i].NextExecution.Before(executions[j].NextExecution)
+		}
+		return executions[i].AccountStrategyID < executions[j].AccountStrategyID
+	})
+
+	return &UpcomingScheduleResponse{
+		From:       from,
+		Until:      until,
+		Executions: executions,
+	}, nil
+}
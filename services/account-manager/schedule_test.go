@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var upcomingColumns = []string{"id", "account_id", "account_handle", "strategy_id", "strategy_name", "strategy_type", "strategy_schedule"}
+
+func TestUpcomingSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM schedulable_account_strategies`).
+		WillReturnRows(sqlmock.NewRows(upcomingColumns).
+			AddRow(1, 1, "alice.bsky.social", 1, "Hourly post", "post", "0 * * * *").
+			AddRow(2, 2, "bob.bsky.social", 2, "Frequent likes", "like", "*/15 * * * *").
+			AddRow(3, 1, "alice.bsky.social", 3, "Daily growth", "growth", "0 9 * * *").
+			AddRow(4, 3, "carol.bsky.social", 1, "Tokyo morning", "post", "CRON_TZ=Asia/Tokyo 0 19 * * *").
+			AddRow(5, 3, "carol.bsky.social", 4, "Broken", "follow", "not a cron"))
+
+	service := &AccountService{db: db}
+	from := time.Date(2025, 1, 15, 9, 50, 0, 0, time.UTC)
+
+	schedule, err := service.UpcomingSchedule(context.Background(), from, 70*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, from.Add(70*time.Minute), schedule.Until)
+
+	// 19:00 in Tokyo is 10:00 UTC; the daily 09:00 UTC run is tomorrow.
+	// Runs at the same time are ordered by association ID.
+	ids := make([]int, 0, len(schedule.Executions))
+	for _, e := range schedule.Executions {
+		ids = append(ids, e.AccountStrategyID)
+		assert.Equal(t, time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC), e.NextExecution)
+	}
+	assert.Equal(t, []int{1, 2, 4}, ids)
+
+	assert.Equal(t, 2, schedule.Executions[0].RunsInWindow, "10:00 and 11:00")
+	assert.Equal(t, 5, schedule.Executions[1].RunsInWindow, "10:00 through 11:00")
+	assert.Equal(t, "bob.bsky.social", schedule.Executions[1].AccountHandle)
+	assert.Equal(t, 1, schedule.Executions[2].RunsInWindow)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUpcomingScheduleRejectsBadWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AccountHandler{accountService: &AccountService{}}
+	router := gin.New()
+	router.GET("/schedule/upcoming", handler.GetUpcomingSchedule)
+
+	for _, minutes := range []string{"0", "1441", "soon"} {
+		req, _ := http.NewRequest("GET", "/schedule/upcoming?minutes="+minutes, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, minutes)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// schemaModels are the request and response bodies described by /schema,
+// keyed by the definition name clients see
+var schemaModels = map[string]interface{}{
+	"Account":                   models.Account{},
+	"CreateAccountRequest":      models.CreateAccountRequest{},
+	"UpdateAccountRequest":      models.UpdateAccountRequest{},
+	"ResolveHandlesRequest":     ResolveHandlesRequest{},
+	"ResolveHandlesResponse":    ResolveHandlesResponse{},
+	"AccountLimitsResponse":     AccountLimitsResponse{},
+	"AccountComparisonResponse": AccountComparisonResponse{},
+	"AuthTestResult":            AuthTestResult{},
+	"ErrorResponse":             models.ErrorResponse{},
+}
+
+// schemaHandler serves JSON Schema definitions for the API models
+// @Summary API model schemas
+// @Description JSON Schema (draft 2020-12) for request and response bodies, derived from the models' json and validate tags
+// @Tags schema
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/schema [get]
+func schemaHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, utils.JSONSchemaDocument(schemaModels))
+}
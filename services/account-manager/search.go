@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+)
+
+// maxSearchLimit is the most posts one search page returns
+const maxSearchLimit = 100
+
+// SearchPosts searches Bluesky posts using the account's stored session. The
+// session is only refreshed when its access token has expired, or the PDS
+// rejects it, so accounts with expired tokens can still search.
+func (s *AccountService) SearchPosts(ctx context.Context, id int, query string, options *bluesky.SearchOptions) (*bluesky.SearchResult, error) {
+	client, resumed, err := s.sessionClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := client.Search(ctx, query, options)
+	if err != nil && resumed && bluesky.IsSessionExpired(err) {
+		if client, err = s.authenticatedClient(ctx, id); err != nil {
+			return nil, err
+		}
+		result, err = client.Search(ctx, query, options)
+	}
+	if err != nil {
+		s.recordAccountError(ctx, id, "search", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseSearchTime accepts an RFC 3339 timestamp or a plain YYYY-MM-DD date,
+// which is taken as midnight UTC
+func parseSearchTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not an RFC 3339 timestamp or YYYY-MM-DD date", value)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestSearchPostsRefreshesAndForwardsFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var query map[string][]string
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			json.NewEncoder(w).Encode(map[string]string{
+				"did":        "did:plc:searcher",
+				"handle":     "searcher.bsky.social",
+				"accessJwt":  "new-access",
+				"refreshJwt": "new-refresh",
+			})
+		case "/xrpc/app.bsky.feed.searchPosts":
+			assert.Equal(t, "Bearer new-access", r.Header.Get("Authorization"))
+			query = r.URL.Query()
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"posts": []map[string]interface{}{{
+					"uri":       "at://did:plc:alice/app.bsky.feed.post/one",
+					"cid":       "bafyone",
+					"author":    map[string]string{"did": "did:plc:alice", "handle": "alice.bsky.social"},
+					"record":    map[string]string{"$type": "app.bsky.feed.post", "text": "hello golang", "createdAt": "2024-01-15T00:00:00.000Z"},
+					"indexedAt": "2024-01-15T00:00:00.000Z",
+				}},
+				"cursor": "next-page",
+			})
+		default:
+			// PLC lookups land here; a 404 means the account has not moved
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer pds.Close()
+	t.Setenv("PLC_DIRECTORY_URL", pds.URL)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The stored token is unusable, so the account is loaded again to log in
+	expectSearchAccount(mock, pds.URL, "auth_expired", "old-access")
+	expectSearchAccount(mock, pds.URL, "auth_expired", "old-access")
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:searcher", "new-access", "new-refresh", sqlmock.AnyArg(), "active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/:id/search", handler.SearchPosts)
+
+	req, _ := http.NewRequest("GET", "/accounts/1/search?q=golang&author=@alice.bsky.social&since=2024-01-01&until=2024-02-01T00:00:00Z&lang=en&cursor=page2&limit=500", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, []string{"golang"}, query["q"])
+	assert.Equal(t, []string{"alice.bsky.social"}, query["author"])
+	assert.Equal(t, []string{"2024-01-01T00:00:00.000Z"}, query["since"])
+	assert.Equal(t, []string{"2024-02-01T00:00:00.000Z"}, query["until"])
+	assert.Equal(t, []string{"en"}, query["lang"])
+	assert.Equal(t, []string{"page2"}, query["cursor"])
+	assert.Equal(t, []string{"100"}, query["limit"])
+
+	var result bluesky.SearchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Len(t, result.Posts, 1)
+	assert.Equal(t, "at://did:plc:alice/app.bsky.feed.post/one", result.Posts[0].Uri)
+	assert.Equal(t, "next-page", result.Cursor)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectSearchAccount expects searcher.bsky.social to be loaded with the given
+// stored access token
+func expectSearchAccount(mock sqlmock.Sqlmock, host, status, accessJWT string) {
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "searcher.bsky.social", "pw", host, "https://bsky.network", status,
+				nil, "did:plc:searcher", accessJWT, "old-refresh", nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				nil, nil, nil, nil, nil, nil, nil))
+}
+
+// unsignedJWT returns a JWT the PDS would issue, minus the signature, that
+// expires at expiry
+func unsignedJWT(expiry time.Time) string {
+	claims, _ := json.Marshal(map[string]int64{"exp": expiry.Unix()})
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+}
+
+func TestSearchPostsReusesStoredSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	stored := unsignedJWT(time.Now().Add(time.Hour))
+	var logins atomic.Int32
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession", "/xrpc/com.atproto.server.refreshSession":
+			logins.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/xrpc/app.bsky.feed.searchPosts":
+			assert.Equal(t, "Bearer "+stored, r.Header.Get("Authorization"))
+			json.NewEncoder(w).Encode(map[string]interface{}{"posts": []interface{}{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer pds.Close()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Two searches, each loading the account and nothing else
+	expectSearchAccount(mock, pds.URL, "active", stored)
+	expectSearchAccount(mock, pds.URL, "active", stored)
+
+	service := &AccountService{db: db}
+	for i := 0; i < 2; i++ {
+		_, err := service.SearchPosts(context.Background(), 1, "golang", nil)
+		require.NoError(t, err)
+	}
+
+	assert.Zero(t, logins.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchPostsLogsInWhenStoredSessionRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The token has not expired yet but the PDS has revoked it
+	stored := unsignedJWT(time.Now().Add(time.Hour))
+	var searches atomic.Int32
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.refreshSession":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "ExpiredToken", "message": "Token has been revoked"})
+		case "/xrpc/com.atproto.server.createSession":
+			json.NewEncoder(w).Encode(map[string]string{
+				"did":        "did:plc:searcher",
+				"handle":     "searcher.bsky.social",
+				"accessJwt":  "new-access",
+				"refreshJwt": "new-refresh",
+			})
+		case "/xrpc/app.bsky.feed.searchPosts":
+			searches.Add(1)
+			if r.Header.Get("Authorization") != "Bearer new-access" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "ExpiredToken", "message": "Token has been revoked"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"posts": []interface{}{}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer pds.Close()
+	t.Setenv("PLC_DIRECTORY_URL", pds.URL)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectSearchAccount(mock, pds.URL, "active", stored)
+	expectSearchAccount(mock, pds.URL, "active", stored)
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:searcher", "new-access", "new-refresh", sqlmock.AnyArg(), "active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	service := &AccountService{db: db}
+	_, err = service.SearchPosts(context.Background(), 1, "golang", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), searches.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSearchPostsValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AccountHandler{}
+	router := gin.New()
+	router.GET("/accounts/:id/search", handler.SearchPosts)
+
+	tests := []struct {
+		name  string
+		path  string
+		error string
+	}{
+		{"missing query", "/accounts/1/search", "Invalid query"},
+		{"bad account id", "/accounts/abc/search?q=go", "Invalid account ID"},
+		{"bad since", "/accounts/1/search?q=go&since=yesterday", "Invalid since"},
+		{"until before since", "/accounts/1/search?q=go&since=2024-02-01&until=2024-01-01", "Invalid time window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.error, response.Error)
+		})
+	}
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/bsky-automation/shared/models"
 	"github.com/bsky-automation/shared/utils"
@@ -17,18 +22,77 @@ import (
 type AccountService struct {
 	db  *sql.DB
 	rdb *redis.Client
+
+	// resolveHandle resolves a handle to a DID without an account session
+	resolveHandle func(ctx context.Context, handle string) (string, error)
+
+	// appViewHost serves the unauthenticated reads of the account status check
+	appViewHost string
+
+	// transports shares proxy connections between Bluesky clients; nil gives
+	// each client its own
+	transports *bluesky.TransportPool
+
+	// assignProxy asks proxy-manager to pick and assign a proxy
+	assignProxy func(ctx context.Context, req ProxyAssignmentRequest) (*ProxyAssignment, error)
+
+	// pages reads the page size limits from system settings; nil uses the
+	// built-in defaults
+	pages *utils.PageSettings
+
+	// events receives AccountAuthFailed events; nil drops them
+	events *utils.EventBus
+
+	// logins collapses concurrent logins as the same account, keyed by ID
+	logins singleflight.Group
 }
 
 // NewAccountService creates a new account service
 func NewAccountService(db *sql.DB, rdb *redis.Client) *AccountService {
+	resolverHost := utils.GetEnvOrDefault("BLUESKY_RESOLVER_HOST", "https://public.api.bsky.app")
 	return &AccountService{
 		db:  db,
 		rdb: rdb,
+		resolveHandle: func(ctx context.Context, handle string) (string, error) {
+			return bluesky.ResolveHandle(ctx, resolverHost, handle)
+		},
+		appViewHost: resolverHost,
+		transports: bluesky.NewTransportPool(
+			utils.GetEnvAsInt("BLUESKY_MAX_IDLE_CONNS_PER_HOST", bluesky.DefaultMaxIdleConnsPerHost),
+			time.Duration(utils.GetEnvAsInt("BLUESKY_IDLE_CONN_TIMEOUT", 90))*time.Second,
+			time.Duration(utils.GetEnvAsInt("BLUESKY_DIAL_TIMEOUT", 10))*time.Second,
+		),
+		assignProxy: newProxyManagerAssigner(utils.GetEnvOrDefault("PROXY_MANAGER_URL", "http://localhost:8002")),
+		pages:       utils.NewPageSettings(db),
 	}
 }
 
 // CreateAccount creates a new account
 func (s *AccountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
+	account, err := s.insertAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test authentication if requested. The probe logs in on a copy, so
+	// take the DID it resolved back from the result.
+	result, err := s.testAccountAuthentication(ctx, account)
+	if err != nil {
+		// Log the error but don't fail the creation
+		// Update account status to reflect the failure
+		account.Status = authFailureStatus(err)
+		errMsg := err.Error()
+		account.ErrorMessage = &errMsg
+		s.updateAccountStatus(ctx, account.ID, account.Status, account.ErrorMessage)
+	} else {
+		account.DID = result.DID
+	}
+
+	return account, nil
+}
+
+// insertAccount validates and stores a new active account
+func (s *AccountService) insertAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
 	// Validate input
 	if !utils.ValidateHandle(req.Handle) {
 		return nil, fmt.Errorf("invalid handle format")
@@ -79,16 +143,6 @@ func (s *AccountService) CreateAccount(ctx context.Context, req *models.CreateAc
 		return nil, fmt.Errorf("failed to create account: %w", err)
 	}
 
-	// Test authentication if requested
-	if err := s.testAccountAuthentication(ctx, account); err != nil {
-		// Log the error but don't fail the creation
-		// Update account status to error
-		account.Status = models.AccountStatusError
-		errMsg := err.Error()
-		account.ErrorMessage = &errMsg
-		s.updateAccountStatus(ctx, account.ID, account.Status, account.ErrorMessage)
-	}
-
 	return account, nil
 }
 
@@ -107,7 +161,9 @@ func (s *AccountService) GetAccount(ctx context.Context, id int) (*models.Accoun
 
 	account := &models.Account{}
 	var proxy models.Proxy
-	var proxyID sql.NullInt64
+	var proxyID, proxyPort sql.NullInt64
+	// The proxy columns are NULL when the account has no proxy
+	var proxyName, proxyType, proxyHost, proxyStatus sql.NullString
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&account.ID, &account.UUID, &account.Handle, &account.Password,
@@ -116,8 +172,8 @@ func (s *AccountService) GetAccount(ctx context.Context, id int) (*models.Accoun
 		&account.LastLogin, &account.LastActivity, &account.ErrorCount,
 		&account.ErrorMessage, &account.Metadata, &account.CreatedAt,
 		&account.UpdatedAt,
-		&proxyID, &proxy.UUID, &proxy.Name, &proxy.Type,
-		&proxy.Host, &proxy.Port, &proxy.Status,
+		&proxyID, &proxy.UUID, &proxyName, &proxyType,
+		&proxyHost, &proxyPort, &proxyStatus,
 	)
 
 	if err != nil {
@@ -130,6 +186,11 @@ func (s *AccountService) GetAccount(ctx context.Context, id int) (*models.Accoun
 	// Set proxy if exists
 	if proxyID.Valid {
 		proxy.ID = int(proxyID.Int64)
+		proxy.Name = proxyName.String
+		proxy.Type = models.ProxyType(proxyType.String)
+		proxy.Host = proxyHost.String
+		proxy.Port = int(proxyPort.Int64)
+		proxy.Status = models.ProxyStatus(proxyStatus.String)
 		account.Proxy = &proxy
 	}
 
@@ -137,39 +198,27 @@ func (s *AccountService) GetAccount(ctx context.Context, id int) (*models.Accoun
 }
 
 // ListAccounts retrieves a paginated list of accounts
-func (s *AccountService) ListAccounts(ctx context.Context, page, pageSize int, status *models.AccountStatus) (*models.ListResponse, error) {
-	// Calculate pagination
-	offset, limit, _ := utils.Paginate(page, pageSize, 0)
-
-	// Build query
-	baseQuery := `
-		SELECT a.id, a.uuid, a.handle, a.host, a.status, a.proxy_id,
-		       a.last_login, a.last_activity, a.error_count, a.created_at,
-		       p.name as proxy_name
-		FROM accounts a
-		LEFT JOIN proxies p ON a.proxy_id = p.id
-	`
-
-	var args []interface{}
-	whereClause := ""
-
-	if status != nil {
-		whereClause = "WHERE a.status = $1"
-		args = append(args, *status)
+func (s *AccountService) ListAccounts(ctx context.Context, page, pageSize int, status *models.AccountStatus, skipMalformed bool) (*models.ListResponse, error) {
+	query := utils.PaginatedQuery{
+		Select: `
+			SELECT a.id, a.uuid, a.handle, a.host, a.status, a.proxy_id,
+			       a.last_login, a.last_activity, a.error_count, a.created_at,
+			       p.name as proxy_name`,
+		From: `
+			FROM accounts a
+			LEFT JOIN proxies p ON a.proxy_id = p.id`,
+		OrderBy:  "a.created_at DESC",
+		Page:     page,
+		PageSize: pageSize,
+		Limits:   s.pages.Limits(ctx),
 	}
-
-	query := fmt.Sprintf("%s %s ORDER BY a.created_at DESC LIMIT $%d OFFSET $%d",
-		baseQuery, whereClause, len(args)+1, len(args)+2)
-	args = append(args, limit, offset)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	if status != nil {
+		query.Where("a.status = $%d", *status)
 	}
-	defer rows.Close()
 
-	var accounts []models.Account
-	for rows.Next() {
+	accounts := []models.Account{}
+	skipped := 0
+	pagination, err := utils.RunPaginatedQuery(ctx, s.db, query, utils.SkipMalformedRows(skipMalformed, &skipped, func(rows *sql.Rows) error {
 		var account models.Account
 		var proxyName sql.NullString
 
@@ -180,47 +229,20 @@ func (s *AccountService) ListAccounts(ctx context.Context, page, pageSize int, s
 			&proxyName,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan account: %w", err)
+			return fmt.Errorf("failed to scan account: %w", err)
 		}
 
 		accounts = append(accounts, account)
-	}
-
-	// Get total count
-	countQuery := "SELECT COUNT(*) FROM accounts"
-	if whereClause != "" {
-		countQuery += " " + whereClause
-		// Use only the status parameter for count
-		if status != nil {
-			var totalItems int64
-			err = s.db.QueryRowContext(ctx, countQuery, *status).Scan(&totalItems)
-		}
-	} else {
-		var totalItems int64
-		err = s.db.QueryRowContext(ctx, countQuery).Scan(&totalItems)
-	}
-
+		return nil
+	}))
 	if err != nil {
-		return nil, fmt.Errorf("failed to count accounts: %w", err)
-	}
-
-	var totalItems int64
-	if status != nil {
-		err = s.db.QueryRowContext(ctx, countQuery, *status).Scan(&totalItems)
-	} else {
-		err = s.db.QueryRowContext(ctx, countQuery).Scan(&totalItems)
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
 	}
 
-	_, _, totalPages := utils.Paginate(page, pageSize, totalItems)
-
 	return &models.ListResponse{
-		Data: accounts,
-		Pagination: models.PaginationResponse{
-			Page:       page,
-			PageSize:   pageSize,
-			TotalItems: totalItems,
-			TotalPages: totalPages,
-		},
+		Data:        accounts,
+		Pagination:  *pagination,
+		SkippedRows: skipped,
 	}, nil
 }
 
@@ -287,41 +309,136 @@ func (s *AccountService) DeleteAccount(ctx context.Context, id int) error {
 	return nil
 }
 
-// TestAuthentication tests account authentication with Bluesky
-func (s *AccountService) TestAuthentication(ctx context.Context, id int) error {
+// TestAuthentication tests account authentication with Bluesky. The stored
+// account and its tokens are left untouched.
+func (s *AccountService) TestAuthentication(ctx context.Context, id int) (*AuthTestResult, error) {
 	account, err := s.GetAccount(ctx, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return s.testAccountAuthentication(ctx, account)
+	result, err := s.testAccountAuthentication(ctx, account)
+	if err != nil {
+		s.recordAccountError(ctx, account.ID, "test_auth", err)
+		s.recordAuthAttempt(ctx, account.ID, "test_auth", false)
+		s.publishAuthFailure(ctx, account, "test_auth", err)
+		return result, err
+	}
+	s.recordAuthAttempt(ctx, account.ID, "test_auth", true)
+
+	return result, nil
 }
 
 // RefreshAuthentication refreshes account authentication tokens
 func (s *AccountService) RefreshAuthentication(ctx context.Context, id int) (*models.Account, error) {
+	if _, err := s.authenticatedClient(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.GetAccount(ctx, id)
+}
+
+// authenticatedClient logs in as the account, refreshing its stored session
+// or creating a new one, and saves the resulting tokens. The returned client
+// carries the live session, so callers can act as the account right away.
+//
+// Concurrent calls for one account share a single login and its client. A
+// refresh token is rotated on use, so parallel refreshes would otherwise race
+// to overwrite the stored tokens, and all but one would fail. The shared login
+// is not cancelled with any one caller's ctx; a caller whose ctx ends stops
+// waiting for it.
+func (s *AccountService) authenticatedClient(ctx context.Context, id int) (*bluesky.Client, error) {
+	result := s.logins.DoChan(strconv.Itoa(id), func() (interface{}, error) {
+		return s.login(context.WithoutCancel(ctx), id)
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*bluesky.Client), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// sessionClient returns a client on the account's stored session when its
+// access token is still valid, and logs in through authenticatedClient
+// otherwise. resumed reports which one happened, so a caller whose request is
+// rejected with a stale token can log in and retry.
+func (s *AccountService) sessionClient(ctx context.Context, id int) (client *bluesky.Client, resumed bool, err error) {
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+
+	client, err = s.newAccountClient(account)
+	if err != nil {
+		return nil, false, err
+	}
+	if client.ResumeSession() {
+		return client, true, nil
+	}
+
+	client, err = s.authenticatedClient(ctx, id)
+	return client, false, err
+}
+
+// login authenticates as the account and saves its new tokens
+func (s *AccountService) login(ctx context.Context, id int) (*bluesky.Client, error) {
 	account, err := s.GetAccount(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create Bluesky client
-	client, err := bluesky.NewClient(bluesky.ClientConfig{
-		Account: account,
-		Proxy:   account.Proxy,
-		Timeout: 30 * time.Second,
-	})
+	client, err := s.newAccountClient(account)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Bluesky client: %w", err)
+		return nil, err
+	}
+
+	// With a known DID, follow a PDS migration before logging in so a moved
+	// account authenticates against its new host rather than the old one.
+	// Accounts pinned to their host opt out with the follow_pds_migration flag.
+	knownDID := account.DID != nil
+	followMigration := account.Flag(models.AccountFlagFollowPDSMigration)
+	migrated := false
+	if knownDID && followMigration {
+		if migrated, err = s.followPDSMigration(ctx, client, account, *account.DID); err != nil {
+			return nil, err
+		}
+		if migrated {
+			if client, err = s.newAccountClient(account); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	// Authenticate
-	if err := client.Authenticate(ctx); err != nil {
-		// Update account status to error
-		account.Status = models.AccountStatusError
+	err = client.Authenticate(ctx)
+	if err == nil && !knownDID && followMigration {
+		// First login: the DID is only known now, so check it after the fact
+		// and log in again at the new PDS with the same credentials if moved
+		if migrated, err = s.followPDSMigration(ctx, client, account, *account.DID); err != nil {
+			return nil, err
+		}
+		if migrated {
+			if client, err = s.newAccountClient(account); err != nil {
+				return nil, err
+			}
+			err = client.Authenticate(ctx)
+		}
+	}
+	if err != nil {
+		// Update account status to reflect the failure
+		account.Status = authFailureStatus(err)
 		errMsg := err.Error()
 		account.ErrorMessage = &errMsg
 		account.ErrorCount++
 		s.updateAccountStatus(ctx, account.ID, account.Status, account.ErrorMessage)
+		s.recordAccountError(ctx, account.ID, "refresh_auth", err)
+		s.recordAuthAttempt(ctx, account.ID, "refresh_auth", false)
+		s.publishAuthFailure(ctx, account, "refresh_auth", err)
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	}
 
@@ -341,8 +458,72 @@ func (s *AccountService) RefreshAuthentication(ctx context.Context, id int) (*mo
 	if err != nil {
 		return nil, fmt.Errorf("failed to update account tokens: %w", err)
 	}
+	s.recordAuthAttempt(ctx, account.ID, "refresh_auth", true)
 
-	return s.GetAccount(ctx, id)
+	return client, nil
+}
+
+// ResolveHandles resolves handles to DIDs with bounded concurrency, using the
+// Redis DID cache where possible. Duplicate handles are resolved once.
+func (s *AccountService) ResolveHandles(ctx context.Context, handles []string) *ResolveHandlesResponse {
+	normalized := make([]string, 0, len(handles))
+	for _, handle := range handles {
+		normalized = append(normalized, strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@")))
+	}
+	normalized = utils.RemoveDuplicates(normalized)
+
+	response := &ResolveHandlesResponse{
+		Results: make(map[string]HandleResolution, len(normalized)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	concurrency := utils.GetEnvAsInt("RESOLVE_HANDLES_CONCURRENCY", 5)
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	for _, handle := range normalized {
+		wg.Add(1)
+		go func(handle string) {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := s.resolveHandleCached(ctx, handle)
+
+			mu.Lock()
+			response.Results[handle] = result
+			mu.Unlock()
+		}(handle)
+	}
+
+	wg.Wait()
+	return response
+}
+
+// resolveHandleCached resolves a single handle, consulting the DID cache first
+func (s *AccountService) resolveHandleCached(ctx context.Context, handle string) HandleResolution {
+	if !utils.ValidateHandle(handle) {
+		return HandleResolution{Error: "invalid handle format"}
+	}
+
+	cacheKey := fmt.Sprintf("did_cache:%s", handle)
+	if did, err := s.rdb.Get(ctx, cacheKey).Result(); err == nil {
+		return HandleResolution{DID: did}
+	}
+
+	did, err := s.resolveHandle(ctx, handle)
+	if err != nil {
+		return HandleResolution{Error: err.Error()}
+	}
+
+	ttl := time.Duration(utils.GetEnvAsInt("DID_CACHE_TTL", 3600)) * time.Second
+	s.rdb.Set(ctx, cacheKey, did, ttl)
+
+	return HandleResolution{DID: did}
 }
 
 // Helper methods
@@ -354,17 +535,62 @@ func (s *AccountService) accountExists(ctx context.Context, handle string) (bool
 	return exists, err
 }
 
-func (s *AccountService) testAccountAuthentication(ctx context.Context, account *models.Account) error {
+// ensureAccountExists returns "account not found" when no account has the ID
+func (s *AccountService) ensureAccountExists(ctx context.Context, id int) error {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check account existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("account not found")
+	}
+	return nil
+}
+
+func (s *AccountService) testAccountAuthentication(ctx context.Context, account *models.Account) (*AuthTestResult, error) {
+	// Authenticate a throwaway copy so new tokens never leak into the caller's
+	// account. Cached tokens are dropped as well: refreshing would rotate the
+	// stored refresh token, while a fresh session leaves it valid.
+	probe := *account
+	probe.DID = nil
+	probe.AccessJWT = nil
+	probe.RefreshJWT = nil
+	probe.LastLogin = nil
+
+	result := &AuthTestResult{AccountID: account.ID, Handle: account.Handle}
+
 	client, err := bluesky.NewClient(bluesky.ClientConfig{
-		Account: account,
-		Proxy:   account.Proxy,
-		Timeout: 30 * time.Second,
+		Account:    &probe,
+		Proxy:      account.Proxy,
+		Timeout:    30 * time.Second,
+		Transports: s.transports,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to create Bluesky client: %w", err)
+		return result, fmt.Errorf("failed to create Bluesky client: %w", err)
 	}
 
-	return client.Authenticate(ctx)
+	start := time.Now()
+	err = client.Authenticate(ctx)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return result, err
+	}
+
+	result.SessionCreated = probe.AccessJWT != nil
+	result.DID = probe.DID
+	result.DIDResolved = probe.DID != nil && *probe.DID != ""
+
+	return result, nil
+}
+
+// authFailureStatus picks the account status for an authentication error.
+// Expired sessions that need a fresh login are kept apart from bad credentials.
+func authFailureStatus(err error) models.AccountStatus {
+	if errors.Is(err, bluesky.ErrReauthRequired) {
+		return models.AccountStatusNeedsReauth
+	}
+	return models.AccountStatusError
 }
 
 func (s *AccountService) updateAccountStatus(ctx context.Context, id int, status models.AccountStatus, errorMessage *string) error {
@@ -562,5 +788,37 @@ func (s *AccountService) GetAccountMetrics(ctx context.Context, accountID int, d
 		metrics.StrategyMetrics = append(metrics.StrategyMetrics, strategy)
 	}
 
+	// Get per-action metrics, so e.g. failing follows stand out next to
+	// succeeding posts
+	actionQuery := `
+		SELECT
+			type,
+			COUNT(*) as total,
+			COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
+			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed
+		FROM tasks
+		WHERE account_id = $1 AND created_at >= NOW() - INTERVAL '%d days'
+		GROUP BY type
+		ORDER BY type
+	`
+	query = fmt.Sprintf(actionQuery, days)
+	rows, err = s.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get action metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var action ActionMetric
+		if err := rows.Scan(&action.Type, &action.TotalTasks, &action.TasksCompleted, &action.TasksFailed); err != nil {
+			return nil, fmt.Errorf("failed to scan action metric: %w", err)
+		}
+		finished := action.TasksCompleted + action.TasksFailed
+		if finished > 0 {
+			action.SuccessRate = utils.CalculateSuccessRate(action.TasksCompleted, finished)
+		}
+		metrics.ActionMetrics = append(metrics.ActionMetrics, action)
+	}
+
 	return metrics, nil
 }
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestAuthFailureStatus(t *testing.T) {
+	reauth := fmt.Errorf("failed to create session: %w", bluesky.ErrReauthRequired)
+	assert.Equal(t, models.AccountStatusNeedsReauth, authFailureStatus(reauth))
+
+	invalid := fmt.Errorf("failed to create session: %w", bluesky.ErrInvalidCredentials)
+	assert.Equal(t, models.AccountStatusError, authFailureStatus(invalid))
+
+	assert.Equal(t, models.AccountStatusError, authFailureStatus(fmt.Errorf("connection refused")))
+}
+
+func TestResolveHandlesEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	service := &AccountService{
+		rdb: rdb,
+		resolveHandle: func(ctx context.Context, handle string) (string, error) {
+			mu.Lock()
+			calls[handle]++
+			mu.Unlock()
+			if handle == "ghost.bsky.social" {
+				return "", fmt.Errorf("failed to resolve handle %s: Unable to resolve handle", handle)
+			}
+			return "did:plc:" + strings.Split(handle, ".")[0], nil
+		},
+	}
+	handler := &AccountHandler{accountService: service, validator: validator.New()}
+
+	router := gin.New()
+	router.POST("/resolve-handles", handler.ResolveHandles)
+
+	body, _ := json.Marshal(ResolveHandlesRequest{
+		Handles: []string{"alice.bsky.social", "@Alice.bsky.social", "ghost.bsky.social", "not a handle"},
+	})
+	req, _ := http.NewRequest("POST", "/resolve-handles", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response ResolveHandlesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 3)
+	assert.Equal(t, "did:plc:alice", response.Results["alice.bsky.social"].DID)
+	assert.NotEmpty(t, response.Results["ghost.bsky.social"].Error)
+	assert.Equal(t, "invalid handle format", response.Results["not a handle"].Error)
+
+	// Duplicates are resolved once and successful lookups are cached
+	assert.Equal(t, 1, calls["alice.bsky.social"])
+	cached, err := mr.Get("did_cache:alice.bsky.social")
+	assert.NoError(t, err)
+	assert.Equal(t, "did:plc:alice", cached)
+	assert.False(t, mr.Exists("did_cache:ghost.bsky.social"))
+}
+
+func TestResolveHandlesClampsConcurrency(t *testing.T) {
+	mr := miniredis.RunT(t)
+	service := &AccountService{
+		rdb: redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+		resolveHandle: func(ctx context.Context, handle string) (string, error) {
+			return "did:plc:" + strings.Split(handle, ".")[0], nil
+		},
+	}
+
+	for _, concurrency := range []string{"0", "-3"} {
+		t.Setenv("RESOLVE_HANDLES_CONCURRENCY", concurrency)
+		response := service.ResolveHandles(context.Background(), []string{"alice.bsky.social", "bob.bsky.social"})
+		assert.Equal(t, "did:plc:bob", response.Results["bob.bsky.social"].DID, concurrency)
+	}
+}
+
+func TestTestAuthenticationLeavesAccountTokensUnchanged(t *testing.T) {
+	var refreshCalls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":        "did:plc:tester",
+			"handle":     "tester.bsky.social",
+			"accessJwt":  "new-access",
+			"refreshJwt": "new-refresh",
+		})
+	})
+	pds := httptest.NewServer(mux)
+	defer pds.Close()
+
+	did, access, refresh := "did:plc:tester", "old-access", "old-refresh"
+	account := &models.Account{
+		ID:         7,
+		Handle:     "tester.bsky.social",
+		Password:   "password",
+		Host:       pds.URL,
+		DID:        &did,
+		AccessJWT:  &access,
+		RefreshJWT: &refresh,
+	}
+
+	service := &AccountService{}
+	result, err := service.testAccountAuthentication(context.Background(), account)
+	assert.NoError(t, err)
+	assert.True(t, result.SessionCreated)
+	assert.True(t, result.DIDResolved)
+	assert.Equal(t, "did:plc:tester", *result.DID)
+	assert.Zero(t, refreshCalls)
+
+	assert.Equal(t, "old-access", *account.AccessJWT)
+	assert.Equal(t, "old-refresh", *account.RefreshJWT)
+	assert.Same(t, &access, account.AccessJWT)
+	assert.Nil(t, account.LastLogin)
+}
+
+func TestCreateAccountKeepsResolvedDID(t *testing.T) {
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":        "did:plc:tester",
+			"handle":     "tester.bsky.social",
+			"accessJwt":  "new-access",
+			"refreshJwt": "new-refresh",
+		})
+	}))
+	defer pds.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE handle = \$1\)`).
+		WithArgs("tester.bsky.social").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(7, time.Now(), time.Now()))
+
+	service := &AccountService{db: db}
+	account, err := service.CreateAccount(context.Background(), &models.CreateAccountRequest{
+		Handle:   "tester.bsky.social",
+		Password: "password",
+		Host:     pds.URL,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.AccountStatusActive, account.Status)
+	if assert.NotNil(t, account.DID) {
+		assert.Equal(t, "did:plc:tester", *account.DID)
+	}
+	// The probe's session stays with the probe
+	assert.Nil(t, account.AccessJWT)
+	assert.Nil(t, account.RefreshJWT)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefreshAuthenticationSharesConcurrentRefresh(t *testing.T) {
+	var refreshes, logins atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		if refreshes.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did":        "did:plc:refresher",
+			"handle":     "refresher.bsky.social",
+			"accessJwt":  "new-access",
+			"refreshJwt": "new-refresh",
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		logins.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	// PLC lookups get a 404, so the account has not moved
+	pds := httptest.NewServer(mux)
+	defer pds.Close()
+	t.Setenv("PLC_DIRECTORY_URL", pds.URL)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	const callers = 5
+	now := time.Now()
+	expectAccount := func(status, access, refresh string) {
+		mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(accountColumns).
+				AddRow(1, utils.GenerateUUID().String(), "refresher.bsky.social", "pw", pds.URL, "https://bsky.network", status,
+					nil, "did:plc:refresher", access, refresh, nil,
+					nil, 0, nil, []byte(`{}`), now, now,
+					nil, nil, nil, nil, nil, nil, nil))
+	}
+
+	// One login: load the account, save the new tokens, record the attempt
+	expectAccount("auth_expired", "old-access", "old-refresh")
+	mock.ExpectExec(`UPDATE accounts\s+SET did = \$1, access_jwt = \$2`).
+		WithArgs("did:plc:refresher", "new-access", "new-refresh", sqlmock.AnyArg(), "active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO auth_attempts`).
+		WithArgs(1, "refresh_auth", true).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	// Then every caller reads back the updated account
+	for i := 0; i < callers; i++ {
+		expectAccount("active", "new-access", "new-refresh")
+	}
+
+	service := &AccountService{db: db}
+	accounts := make(chan *models.Account, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			account, err := service.RefreshAuthentication(context.Background(), 1)
+			assert.NoError(t, err)
+			accounts <- account
+		}()
+	}
+
+	<-started
+	// Let the other callers join the refresh in flight before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(accounts)
+
+	for account := range accounts {
+		if assert.NotNil(t, account) {
+			assert.Equal(t, "new-refresh", *account.RefreshJWT)
+		}
+	}
+	assert.Equal(t, int32(1), refreshes.Load())
+	assert.Zero(t, logins.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAccountsCountsFilteredRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id WHERE a.status = \$1 ORDER BY a.created_at DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(models.AccountStatusError, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "uuid", "handle", "host", "status", "proxy_id",
+			"last_login", "last_activity", "error_count", "created_at", "proxy_name",
+		}).AddRow(1, "0b6e2b1c-6a4c-4e0e-9a7e-0d7f1c2b3a4d", "broken.bsky.social", "https://bsky.social", "error", nil,
+			nil, nil, 3, now, nil))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id WHERE a.status = \$1$`).
+		WithArgs(models.AccountStatusError).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	status := models.AccountStatusError
+	service := &AccountService{db: db}
+	result, err := service.ListAccounts(context.Background(), 1, 10, &status, false)
+	assert.NoError(t, err)
+	assert.Len(t, result.Data, 1)
+	assert.Equal(t, int64(1), result.Pagination.TotalItems)
+	assert.Equal(t, 1, result.Pagination.TotalPages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAccountsSkipsMalformedRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	columns := []string{
+		"id", "uuid", "handle", "host", "status", "proxy_id",
+		"last_login", "last_activity", "error_count", "created_at", "proxy_name",
+	}
+	page := func() *sqlmock.Rows {
+		return sqlmock.NewRows(columns).
+			AddRow(1, utils.GenerateUUID().String(), "alice.bsky.social", "https://bsky.social", "active", nil, nil, nil, 0, now, nil).
+			AddRow(2, utils.GenerateUUID().String(), nil, "https://bsky.social", "active", nil, nil, nil, 0, now, nil).
+			AddRow(3, utils.GenerateUUID().String(), "carol.bsky.social", "https://bsky.social", "active", nil, nil, nil, 0, now, nil)
+	}
+
+	service := &AccountService{db: db}
+
+	// Fail-fast by default
+	mock.ExpectQuery(`FROM accounts a`).WillReturnRows(page())
+	_, err = service.ListAccounts(context.Background(), 1, 10, nil, false)
+	assert.ErrorContains(t, err, "failed to scan account")
+
+	mock.ExpectQuery(`FROM accounts a`).WillReturnRows(page())
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	result, err := service.ListAccounts(context.Background(), 1, 10, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.SkippedRows)
+	accounts := result.Data.([]models.Account)
+	if assert.Len(t, accounts, 2) {
+		assert.Equal(t, "alice.bsky.social", accounts[0].Handle)
+		assert.Equal(t, "carol.bsky.social", accounts[1].Handle)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAccountMetricsByActionType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM accounts a\s+LEFT JOIN proxies p ON a.proxy_id = p.id\s+WHERE a.id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountColumns).
+			AddRow(1, utils.GenerateUUID().String(), "alice.bsky.social", "pw", "https://bsky.social", "https://bsky.network", "active",
+				nil, nil, nil, nil, nil,
+				nil, 0, nil, []byte(`{}`), now, now,
+				nil, nil, nil, nil, nil, nil, nil))
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\) as total_tasks`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "completed", "failed"}).AddRow(10, 6, 3))
+	mock.ExpectQuery(`GROUP BY DATE\(created_at\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"date", "completed", "failed"}))
+	mock.ExpectQuery(`JOIN strategies s ON t.strategy_id = s.id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type", "completed", "failed"}))
+	mock.ExpectQuery(`FROM tasks\s+WHERE account_id = \$1 AND created_at >= NOW\(\) - INTERVAL '7 days'\s+GROUP BY type`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "total", "completed", "failed"}).
+			AddRow("follow", 4, 1, 3).
+			AddRow("like", 1, 0, 0).
+			AddRow("post", 5, 5, 0))
+
+	service := &AccountService{db: db}
+	metrics, err := service.GetAccountMetrics(context.Background(), 1, 7)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []ActionMetric{
+		{Type: "follow", TotalTasks: 4, TasksCompleted: 1, TasksFailed: 3, SuccessRate: 25},
+		{Type: "like", TotalTasks: 1},
+		{Type: "post", TotalTasks: 5, TasksCompleted: 5, SuccessRate: 100},
+	}, metrics.ActionMetrics)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
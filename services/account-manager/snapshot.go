@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	bluesky "github.com/bsky-automation/shared/bluesky-client"
+)
+
+// GetAccountSnapshot logs in as the account and exports its current
+// Bluesky-side state. Like testAccountAuthentication it uses a fresh session on
+// a copy of the account, so the stored refresh token is not rotated.
+func (s *AccountService) GetAccountSnapshot(ctx context.Context, id int) (*bluesky.ProfileSnapshot, error) {
+	account, err := s.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	probe := *account
+	probe.DID = nil
+	probe.AccessJWT = nil
+	probe.RefreshJWT = nil
+	probe.LastLogin = nil
+
+	client, err := s.newAccountClient(&probe)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Authenticate(ctx); err != nil {
+		s.recordAccountError(ctx, account.ID, "snapshot", err)
+		s.recordAuthAttempt(ctx, account.ID, "snapshot", false)
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	s.recordAuthAttempt(ctx, account.ID, "snapshot", true)
+
+	snapshot, err := client.ExportProfile(ctx)
+	if err != nil {
+		s.recordAccountError(ctx, account.ID, "snapshot", err)
+		return nil, err
+	}
+	return snapshot, nil
+}
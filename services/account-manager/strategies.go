@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// ListAccountStrategies returns the strategies attached to an account along
+// with their per-account configuration and execution counters
+func (s *AccountService) ListAccountStrategies(ctx context.Context, accountID int, status *models.StrategyStatus) ([]models.AccountStrategy, error) {
+	if err := s.ensureAccountExists(ctx, accountID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT as_.id, as_.uuid, as_.account_id, as_.strategy_id, as_.config, as_.status,
+		       as_.last_executed, as_.next_execution, as_.execution_count,
+		       as_.success_count, as_.error_count, as_.created_at, as_.updated_at,
+		       s.id, s.uuid, s.name, s.description, s.type, s.config, s.schedule,
+		       s.status, s.priority
+		FROM account_strategies as_
+		JOIN strategies s ON as_.strategy_id = s.id
+		WHERE as_.account_id = $1
+	`
+
+	args := []interface{}{accountID}
+	if status != nil {
+		query += " AND as_.status = $2"
+		args = append(args, *status)
+	}
+
+	query += " ORDER BY s.priority DESC, as_.id ASC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account strategies: %w", err)
+	}
+	defer rows.Close()
+
+	strategies := []models.AccountStrategy{}
+	for rows.Next() {
+		var as models.AccountStrategy
+		var strategy models.Strategy
+		err := rows.Scan(
+			&as.ID, &as.UUID, &as.AccountID, &as.StrategyID, &as.Config, &as.Status,
+			&as.LastExecuted, &as.NextExecution, &as.ExecutionCount,
+			&as.SuccessCount, &as.ErrorCount, &as.CreatedAt, &as.UpdatedAt,
+			&strategy.ID, &strategy.UUID, &strategy.Name, &strategy.Description,
+			&strategy.Type, &strategy.Config, &strategy.Schedule,
+			&strategy.Status, &strategy.Priority,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account strategy: %w", err)
+		}
+		as.Strategy = &strategy
+		strategies = append(strategies, as)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list account strategies: %w", err)
+	}
+
+	return strategies, nil
+}
+
+// AuditContext carries request metadata recorded alongside audit log entries
+type AuditContext struct {
+	UserID    *string
+	IPAddress string
+	UserAgent string
+}
+
+// ErrAccountStrategyInactive is returned when pausing or resuming an
+// association that was explicitly set inactive
+var ErrAccountStrategyInactive = errors.New("account strategy is inactive")
+
+// SetAccountStrategyStatus pauses or resumes a single account-strategy
+// association and records the change in the audit log. Only active and
+// paused associations move between the two; an inactive one stays inactive.
+func (s *AccountService) SetAccountStrategyStatus(ctx context.Context, accountID, strategyID int, status models.StrategyStatus, audit AuditContext) (*models.AccountStrategy, error) {
+	as := &models.AccountStrategy{}
+
+	err := utils.Transaction(s.db, func(tx *sql.Tx) error {
+		query := `
+			SELECT id, uuid, account_id, strategy_id, config, status,
+			       last_executed, next_execution, execution_count,
+			       success_count, error_count, created_at, updated_at
+			FROM account_strategies
+			WHERE account_id = $1 AND strategy_id = $2
+			FOR UPDATE
+		`
+		err := tx.QueryRowContext(ctx, query, accountID, strategyID).Scan(
+			&as.ID, &as.UUID, &as.AccountID, &as.StrategyID, &as.Config, &as.Status,
+			&as.LastExecuted, &as.NextExecution, &as.ExecutionCount,
+			&as.SuccessCount, &as.ErrorCount, &as.CreatedAt, &as.UpdatedAt,
+		)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("account strategy not found")
+			}
+			return fmt.Errorf("failed to get account strategy: %w", err)
+		}
+
+		oldStatus := as.Status
+		if oldStatus == status {
+			return nil
+		}
+		if oldStatus == models.StrategyStatusInactive {
+			return ErrAccountStrategyInactive
+		}
+
+		updateQuery := `
+			UPDATE account_strategies SET status = $1, updated_at = NOW()
+			WHERE id = $2
+			RETURNING updated_at
+		`
+		if err := tx.QueryRowContext(ctx, updateQuery, status, as.ID).Scan(&as.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to update account strategy status: %w", err)
+		}
+		as.Status = status
+
+		action := "resume"
+		if status == models.StrategyStatusPaused {
+			action = "pause"
+		}
+
+		var ipAddress *string
+		if audit.IPAddress != "" {
+			ipAddress = &audit.IPAddress
+		}
+
+		auditQuery := `
+			INSERT INTO audit_logs (entity_type, entity_id, action, old_values, new_values, user_id, ip_address, user_agent)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`
+		_, err = tx.ExecContext(ctx, auditQuery,
+			"account_strategies", as.ID, action,
+			models.JSONB{"status": oldStatus}, models.JSONB{"status": status},
+			audit.UserID, ipAddress, audit.UserAgent,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
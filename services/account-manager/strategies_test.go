@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+var accountStrategyColumns = []string{
+	"id", "uuid", "account_id", "strategy_id", "config", "status",
+	"last_executed", "next_execution", "execution_count",
+	"success_count", "error_count", "created_at", "updated_at",
+	"id", "uuid", "name", "description", "type", "config", "schedule",
+	"status", "priority",
+}
+
+func TestListAccountStrategies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	next := now.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE id = \$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM account_strategies as_\s+JOIN strategies s ON as_.strategy_id = s.id\s+WHERE as_.account_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountStrategyColumns).
+			AddRow(10, utils.GenerateUUID().String(), 1, 1, []byte(`{"daily_limit": 30}`), "active",
+				now, next, 12, 10, 2, now, now,
+				1, utils.GenerateUUID().String(), "Follow tech users", nil, "follow", []byte(`{}`), "0 */2 * * *",
+				"active", 5).
+			AddRow(11, utils.GenerateUUID().String(), 1, 3, []byte(`{"daily_limit": 80}`), "paused",
+				nil, nil, 0, 0, 0, now, now,
+				3, utils.GenerateUUID().String(), "Smart likes", nil, "like", []byte(`{}`), nil,
+				"active", 3))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/:id/strategies", handler.ListAccountStrategies)
+
+	req, _ := http.NewRequest("GET", "/accounts/1/strategies", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var strategies []models.AccountStrategy
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &strategies))
+	assert.Len(t, strategies, 2)
+
+	assert.Equal(t, 1, strategies[0].StrategyID)
+	assert.Equal(t, models.StrategyStatusActive, strategies[0].Status)
+	assert.Equal(t, float64(30), strategies[0].Config["daily_limit"])
+	assert.Equal(t, 12, strategies[0].ExecutionCount)
+	assert.Equal(t, 10, strategies[0].SuccessCount)
+	assert.Equal(t, 2, strategies[0].ErrorCount)
+	assert.NotNil(t, strategies[0].NextExecution)
+	assert.Equal(t, "Follow tech users", strategies[0].Strategy.Name)
+
+	assert.Equal(t, models.StrategyStatusPaused, strategies[1].Status)
+	assert.Nil(t, strategies[1].NextExecution)
+	assert.Equal(t, models.StrategyTypeLike, strategies[1].Strategy.Type)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAccountStrategiesStatusFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`WHERE as_.account_id = \$1 AND as_.status = \$2`).
+		WithArgs(1, models.StrategyStatusPaused).
+		WillReturnRows(sqlmock.NewRows(accountStrategyColumns))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/accounts/:id/strategies", handler.ListAccountStrategies)
+
+	req, _ := http.NewRequest("GET", "/accounts/1/strategies?status=paused", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAccountStrategiesRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM account_strategies as_`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(accountStrategyColumns).
+			AddRow(10, utils.GenerateUUID().String(), 1, 1, []byte(`{}`), "active",
+				nil, nil, 0, 0, 0, now, now,
+				1, utils.GenerateUUID().String(), "Follow tech users", nil, "follow", []byte(`{}`), nil,
+				"active", 5).
+			RowError(0, errors.New("connection reset")))
+
+	// A list cut short by a failed read is an error, not a shorter list
+	service := &AccountService{db: db}
+	_, err = service.ListAccountStrategies(context.Background(), 1, nil)
+	assert.ErrorContains(t, err, "connection reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPauseAndResumeAccountStrategy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.POST("/accounts/:id/strategies/:strategyId/pause", handler.PauseAccountStrategy)
+	router.POST("/accounts/:id/strategies/:strategyId/resume", handler.ResumeAccountStrategy)
+
+	associationColumns := accountStrategyColumns[:13]
+	now := time.Now()
+
+	expectToggle := func(from, to models.StrategyStatus, action string) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_strategies\s+WHERE account_id = \$1 AND strategy_id = \$2\s+FOR UPDATE`).
+			WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows(associationColumns).
+				AddRow(11, utils.GenerateUUID().String(), 1, 3, []byte(`{}`), string(from),
+					nil, nil, 0, 0, 0, now, now))
+		mock.ExpectQuery(`UPDATE account_strategies SET status = \$1`).
+			WithArgs(to, 11).
+			WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(now))
+		mock.ExpectExec(`INSERT INTO audit_logs`).
+			WithArgs("account_strategies", 11, action,
+				models.JSONB{"status": from}, models.JSONB{"status": to},
+				nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	expectToggle(models.StrategyStatusActive, models.StrategyStatusPaused, "pause")
+	req, _ := http.NewRequest("POST", "/accounts/1/strategies/3/pause", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var paused models.AccountStrategy
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &paused))
+	assert.Equal(t, models.StrategyStatusPaused, paused.Status)
+
+	expectToggle(models.StrategyStatusPaused, models.StrategyStatusActive, "resume")
+	req, _ = http.NewRequest("POST", "/accounts/1/strategies/3/resume", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resumed models.AccountStrategy
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resumed))
+	assert.Equal(t, models.StrategyStatusActive, resumed.Status)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResumeAccountStrategyLeavesInactive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.POST("/accounts/:id/strategies/:strategyId/pause", handler.PauseAccountStrategy)
+	router.POST("/accounts/:id/strategies/:strategyId/resume", handler.ResumeAccountStrategy)
+
+	// Neither action touches an association that was set inactive
+	for _, action := range []string{"resume", "pause"} {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`FROM account_strategies\s+WHERE account_id = \$1 AND strategy_id = \$2\s+FOR UPDATE`).
+			WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows(accountStrategyColumns[:13]).
+				AddRow(11, utils.GenerateUUID().String(), 1, 3, []byte(`{}`), "inactive",
+					nil, nil, 0, 0, 0, time.Now(), time.Now()))
+		mock.ExpectRollback()
+
+		req, _ := http.NewRequest("POST", "/accounts/1/strategies/3/"+action, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code, action)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPauseAccountStrategyNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM account_strategies`).
+		WithArgs(1, 99).
+		WillReturnRows(sqlmock.NewRows(accountStrategyColumns[:13]))
+	mock.ExpectRollback()
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.POST("/accounts/:id/strategies/:strategyId/pause", handler.PauseAccountStrategy)
+
+	req, _ := http.NewRequest("POST", "/accounts/1/strategies/99/pause", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
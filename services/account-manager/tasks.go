@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// ErrAutomationPaused is returned when creating or retrying a task while the
+// global kill switch is on
+var ErrAutomationPaused = errors.New("automation is paused")
+
+// ErrTaskNotRetryable is returned when retrying a task that has not failed
+var ErrTaskNotRetryable = errors.New("only failed tasks can be retried")
+
+// ErrTaskRetryLimit is returned when a task has used up its retry ceiling
+var ErrTaskRetryLimit = errors.New("task has reached the retry ceiling")
+
+// ErrTaskNotCancellable is returned when cancelling a task that already finished
+var ErrTaskNotCancellable = errors.New("only pending or running tasks can be cancelled")
+
+// ErrAccountStrategyNotSchedulable is returned when creating a task for an
+// association whose account, strategy or association itself is not active
+var ErrAccountStrategyNotSchedulable = errors.New("account strategy is paused or inactive")
+
+// defaultTaskRetryCeiling caps how many times a task may be retried in total,
+// whatever the task's own max_retries says
+const defaultTaskRetryCeiling = 10
+
+// taskColumns is the column list scanned by scanTask. Association ids are
+// nullable in the table, so they read back as 0 when unset.
+const taskColumns = `
+	id, uuid, COALESCE(account_id, 0), COALESCE(strategy_id, 0), COALESCE(account_strategy_id, 0),
+	type, payload, status, priority, retry_count, max_retries, timeout_seconds,
+	scheduled_at, started_at, completed_at, worker_id, cancel_requested, error_message, result,
+	execution_time_ms, created_at, updated_at
+`
+
+func scanTask(row *sql.Row, task *models.Task) error {
+	return row.Scan(
+		&task.ID, &task.UUID, &task.AccountID, &task.StrategyID, &task.AccountStrategyID,
+		&task.Type, &task.Payload, &task.Status, &task.Priority, &task.RetryCount, &task.MaxRetries, &task.TimeoutSeconds,
+		&task.ScheduledAt, &task.StartedAt, &task.CompletedAt, &task.WorkerID, &task.CancelRequested, &task.ErrorMessage, &task.Result,
+		&task.ExecutionTimeMs, &task.CreatedAt, &task.UpdatedAt,
+	)
+}
+
+// CreateTask queues a task for an account under one of its strategies. The
+// insert selects from schedulable_account_strategies, so nothing is written
+// unless the association, its account and its strategy are all active:
+// pausing any of them stops new tasks. Priority and timeout default to the
+// strategy's, and the strategy's retry_count becomes the task's max_retries.
+// A task for an account in a rate-limit cooldown is scheduled no earlier than
+// the end of the cooldown. No tasks are created while automation is paused.
+// The task type and payload are checked against the strategy's type, failing
+// with models.ErrInvalidTaskType or models.ErrInvalidTaskPayload.
+func (s *AccountService) CreateTask(ctx context.Context, req *models.CreateTaskRequest) (*models.Task, error) {
+	paused, err := utils.AutomationPaused(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		return nil, ErrAutomationPaused
+	}
+
+	var strategyType models.StrategyType
+	err = s.db.QueryRowContext(ctx,
+		"SELECT strategy_type FROM schedulable_account_strategies WHERE account_id = $1 AND strategy_id = $2",
+		req.AccountID, req.StrategyID,
+	).Scan(&strategyType)
+	if err == sql.ErrNoRows {
+		return nil, s.unschedulableTaskError(ctx, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get strategy type: %w", err)
+	}
+	if err := req.Validate(strategyType); err != nil {
+		return nil, err
+	}
+
+	scheduledAt, err := s.cooldownScheduledAt(ctx, req.AccountID, req.ScheduledAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO tasks (account_id, strategy_id, account_strategy_id, type, payload,
+		                   priority, max_retries, timeout_seconds, scheduled_at)
+		SELECT account_id, strategy_id, id, $3, $4,
+		       COALESCE($5, strategy_priority), strategy_retry_count,
+		       COALESCE($6, strategy_timeout_seconds), COALESCE($7, NOW())
+		FROM schedulable_account_strategies
+		WHERE account_id = $1 AND strategy_id = $2
+		RETURNING ` + taskColumns
+
+	task := &models.Task{}
+	err = scanTask(s.db.QueryRowContext(ctx, query,
+		req.AccountID, req.StrategyID, req.Type, req.Payload,
+		req.Priority, req.TimeoutSeconds, scheduledAt,
+	), task)
+	if err == nil {
+		return task, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to create task: %w", err)
+	}
+	// Paused since the type lookup
+	return nil, s.unschedulableTaskError(ctx, req)
+}
+
+// unschedulableTaskError explains why the request's association is not in
+// schedulable_account_strategies, telling a missing association from an
+// inactive one
+func (s *AccountService) unschedulableTaskError(ctx context.Context, req *models.CreateTaskRequest) error {
+	var status string
+	err := s.db.QueryRowContext(ctx,
+		"SELECT status FROM account_strategies WHERE account_id = $1 AND strategy_id = $2",
+		req.AccountID, req.StrategyID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("account strategy not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get account strategy: %w", err)
+	}
+	return fmt.Errorf("%w: account %d, strategy %d (association is %s)",
+		ErrAccountStrategyNotSchedulable, req.AccountID, req.StrategyID, status)
+}
+
+// cooldownScheduledAt returns when a new task for the account may first run:
+// requested, pushed back to the end of the account's rate-limit cooldown if
+// that is later. Nil means now.
+func (s *AccountService) cooldownScheduledAt(ctx context.Context, accountID int, requested *time.Time, now time.Time) (*time.Time, error) {
+	if s.rdb == nil {
+		return requested, nil
+	}
+
+	remaining, err := utils.AccountCooldownRemaining(ctx, s.rdb, accountID, now)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		return requested, nil
+	}
+
+	until := now.Add(remaining)
+	if requested != nil && requested.After(until) {
+		return requested, nil
+	}
+	return &until, nil
+}
+
+// RetryTask puts a failed task back in the queue: it becomes pending again,
+// scheduled now or at the end of the account's rate-limit cooldown, with its
+// error cleared and retry_count incremented. Workers pick up pending tasks,
+// so no separate enqueue is needed. Nothing is retried while automation is
+// paused.
+func (s *AccountService) RetryTask(ctx context.Context, id int) (*models.Task, error) {
+	paused, err := utils.AutomationPaused(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		return nil, ErrAutomationPaused
+	}
+
+	ceiling := utils.GetEnvAsInt("TASK_RETRY_CEILING", defaultTaskRetryCeiling)
+	task := &models.Task{}
+
+	err = utils.Transaction(s.db, func(tx *sql.Tx) error {
+		var status models.TaskStatus
+		var retryCount, accountID int
+		err := tx.QueryRowContext(ctx, "SELECT status, retry_count, account_id FROM tasks WHERE id = $1 FOR UPDATE", id).
+			Scan(&status, &retryCount, &accountID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("task not found")
+			}
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+
+		if status != models.TaskStatusFailed {
+			return fmt.Errorf("%w: task is %s", ErrTaskNotRetryable, status)
+		}
+		if retryCount >= ceiling {
+			return fmt.Errorf("%w: retried %d of %d times", ErrTaskRetryLimit, retryCount, ceiling)
+		}
+
+		scheduledAt, err := s.cooldownScheduledAt(ctx, accountID, nil, time.Now())
+		if err != nil {
+			return err
+		}
+
+		updateQuery := `
+			UPDATE tasks
+			SET status = 'pending', error_message = NULL, retry_count = retry_count + 1,
+			    worker_id = NULL, cancel_requested = FALSE, started_at = NULL, completed_at = NULL,
+			    scheduled_at = COALESCE($2, NOW()), updated_at = NOW()
+			WHERE id = $1
+			RETURNING ` + taskColumns
+		if err := scanTask(tx.QueryRowContext(ctx, updateQuery, id, scheduledAt), task); err != nil {
+			return fmt.Errorf("failed to retry task: %w", err)
+		}
+
+		auditQuery := `
+			INSERT INTO audit_logs (entity_type, entity_id, action, old_values, new_values)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.ExecContext(ctx, auditQuery,
+			"tasks", id, "retry",
+			models.JSONB{"status": status, "retry_count": retryCount},
+			models.JSONB{"status": task.Status, "retry_count": task.RetryCount},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// CancelTask stops a task. A pending task is cancelled outright. A running
+// task only gets cancel_requested set: its worker notices the flag, cancels
+// the task's context and records the cancellation itself.
+func (s *AccountService) CancelTask(ctx context.Context, id int) (*models.Task, error) {
+	task := &models.Task{}
+
+	err := utils.Transaction(s.db, func(tx *sql.Tx) error {
+		var status models.TaskStatus
+		err := tx.QueryRowContext(ctx, "SELECT status FROM tasks WHERE id = $1 FOR UPDATE", id).Scan(&status)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("task not found")
+			}
+			return fmt.Errorf("failed to get task: %w", err)
+		}
+
+		var updateQuery string
+		switch status {
+		case models.TaskStatusPending:
+			updateQuery = `
+				UPDATE tasks
+				SET status = 'cancelled', completed_at = NOW(), updated_at = NOW()
+				WHERE id = $1
+				RETURNING ` + taskColumns
+		case models.TaskStatusRunning:
+			updateQuery = `
+				UPDATE tasks
+				SET cancel_requested = TRUE, updated_at = NOW()
+				WHERE id = $1
+				RETURNING ` + taskColumns
+		default:
+			return fmt.Errorf("%w: task is %s", ErrTaskNotCancellable, status)
+		}
+
+		if err := scanTask(tx.QueryRowContext(ctx, updateQuery, id), task); err != nil {
+			return fmt.Errorf("failed to cancel task: %w", err)
+		}
+
+		auditQuery := `
+			INSERT INTO audit_logs (entity_type, entity_id, action, old_values, new_values)
+			VALUES ($1, $2, $3, $4, $5)
+		`
+		_, err = tx.ExecContext(ctx, auditQuery,
+			"tasks", id, "cancel",
+			models.JSONB{"status": status},
+			models.JSONB{"status": task.Status, "cancel_requested": task.CancelRequested},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// GetTaskTimeseries counts tasks per creation day over the last days days,
+// optionally for one account. Days without tasks are filled in with zeros so
+// charts get a point for every day.
+func (s *AccountService) GetTaskTimeseries(ctx context.Context, days int, accountID *int) (*TaskTimeseriesResponse, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	query := `
+		SELECT
+			DATE(created_at) as date,
+			COUNT(CASE WHEN status = 'completed' THEN 1 END) as completed,
+			COUNT(CASE WHEN status = 'failed' THEN 1 END) as failed,
+			COUNT(CASE WHEN status = 'pending' THEN 1 END) as pending
+		FROM tasks
+		WHERE created_at >= $1
+	`
+	args := []interface{}{start}
+	if accountID != nil {
+		query += " AND account_id = $2"
+		args = append(args, *accountID)
+	}
+	query += " GROUP BY DATE(created_at) ORDER BY date"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task timeseries: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]TaskTimeseriesPoint)
+	for rows.Next() {
+		var date time.Time
+		var point TaskTimeseriesPoint
+		if err := rows.Scan(&date, &point.Completed, &point.Failed, &point.Pending); err != nil {
+			return nil, fmt.Errorf("failed to scan task timeseries: %w", err)
+		}
+		point.Date = date.Format("2006-01-02")
+		counts[point.Date] = point
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read task timeseries: %w", err)
+	}
+
+	response := &TaskTimeseriesResponse{
+		AccountID: accountID,
+		Days:      days,
+		Points:    make([]TaskTimeseriesPoint, 0, days),
+	}
+	for day := start; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		point, ok := counts[date]
+		if !ok {
+			point = TaskTimeseriesPoint{Date: date}
+		}
+		response.Points = append(response.Points, point)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+var taskColumnNames = []string{
+	"id", "uuid", "account_id", "strategy_id", "account_strategy_id",
+	"type", "payload", "status", "priority", "retry_count", "max_retries", "timeout_seconds",
+	"scheduled_at", "started_at", "completed_at", "worker_id", "cancel_requested", "error_message", "result",
+	"execution_time_ms", "created_at", "updated_at",
+}
+
+func newTaskRouter(service *AccountService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewAccountHandler(service, nil)
+	router := gin.New()
+	router.POST("/tasks", handler.CreateTask)
+	router.POST("/tasks/:id/retry", handler.RetryTask)
+	router.POST("/tasks/:id/cancel", handler.CancelTask)
+	return router
+}
+
+// expectAutomationRunning expects CreateTask or RetryTask to read the kill
+// switch
+func expectAutomationRunning(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WithArgs(utils.AutomationPausedSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("false"))
+}
+
+// expectStrategyType expects the association's strategy type to be looked up;
+// an empty strategyType means the association is not schedulable
+func expectStrategyType(mock sqlmock.Sqlmock, strategyType string) {
+	rows := sqlmock.NewRows([]string{"strategy_type"})
+	if strategyType != "" {
+		rows.AddRow(strategyType)
+	}
+	mock.ExpectQuery(`SELECT strategy_type FROM schedulable_account_strategies WHERE account_id = \$1 AND strategy_id = \$2`).
+		WithArgs(1, 2).
+		WillReturnRows(rows)
+}
+
+const createTaskBody = `{"account_id": 1, "strategy_id": 2, "type": "follow_user", "payload": {"target_handle": "bob.bsky.social"}}`
+
+func TestCreateTaskUsesStrategyDefaults(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+
+	expectAutomationRunning(mock)
+	expectStrategyType(mock, "follow")
+	mock.ExpectQuery(`INSERT INTO tasks .*SELECT .*FROM schedulable_account_strategies\s+WHERE account_id = \$1 AND strategy_id = \$2`).
+		WithArgs(1, 2, "follow_user", sqlmock.AnyArg(), nil, nil, nil).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(9, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow_user", []byte(`{"target_handle":"bob.bsky.social"}`), "pending", 7, 0, 4, 120,
+				now, nil, nil, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(createTaskBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var task models.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, 3, task.AccountStrategyID)
+	assert.Equal(t, models.TaskStatusPending, task.Status)
+	assert.Equal(t, 7, task.Priority)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// timeNear matches a scheduled_at argument within a second of want
+type timeNear struct{ want time.Time }
+
+func (m timeNear) Match(v driver.Value) bool {
+	got, ok := v.(time.Time)
+	return ok && got.Sub(m.want).Abs() < time.Second
+}
+
+func TestCreateTaskDefersAccountCoolingDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	// Account 1 is rate-limited for another ten minutes
+	until := time.Now().Add(10 * time.Minute)
+	assert.NoError(t, utils.SetAccountCooldown(context.Background(), rdb, 1, until))
+
+	now := time.Now()
+	expectAutomationRunning(mock)
+	expectStrategyType(mock, "follow")
+	mock.ExpectQuery(`INSERT INTO tasks`).
+		WithArgs(1, 2, "follow_user", sqlmock.AnyArg(), nil, nil, timeNear{until}).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(9, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow_user", []byte(`{}`), "pending", 7, 0, 4, 120,
+				until, nil, nil, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+
+	// A time after the cooldown is kept as requested
+	later := now.Add(time.Hour).UTC().Truncate(time.Second)
+	expectAutomationRunning(mock)
+	expectStrategyType(mock, "follow")
+	mock.ExpectQuery(`INSERT INTO tasks`).
+		WithArgs(1, 2, "follow_user", sqlmock.AnyArg(), nil, nil, timeNear{later}).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(10, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow_user", []byte(`{}`), "pending", 7, 0, 4, 120,
+				later, nil, nil, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+
+	router := newTaskRouter(&AccountService{db: db, rdb: rdb})
+	for _, body := range []string{
+		createTaskBody,
+		`{"account_id": 1, "strategy_id": 2, "type": "follow_user", "payload": {"target_handle": "bob.bsky.social"}, "scheduled_at": "` + later.Format(time.RFC3339) + `"}`,
+	} {
+		req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTaskSkipsPausedAssociation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The view leaves paused associations out, so nothing is inserted
+	expectAutomationRunning(mock)
+	expectStrategyType(mock, "")
+	mock.ExpectQuery(`SELECT status FROM account_strategies WHERE account_id = \$1 AND strategy_id = \$2`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("paused"))
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(createTaskBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Message, "association is paused")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTaskUnknownAssociation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectAutomationRunning(mock)
+	expectStrategyType(mock, "")
+	mock.ExpectQuery(`SELECT status FROM account_strategies`).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}))
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(createTaskBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTaskValidatesAgainstStrategyType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	router := newTaskRouter(&AccountService{db: db})
+	for _, tc := range []struct {
+		strategyType string
+		body         string
+		message      string
+	}{
+		// A post strategy cannot follow
+		{"post", createTaskBody, "not a post task"},
+		// A follow needs a target
+		{"follow", `{"account_id": 1, "strategy_id": 2, "type": "follow_user", "payload": {"reason": "x"}}`, "requires one of"},
+	} {
+		// No insert is expected
+		expectAutomationRunning(mock)
+		expectStrategyType(mock, tc.strategyType)
+
+		req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response models.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Invalid task", response.Error)
+		assert.Contains(t, response.Message, tc.message)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTaskRefusedWhileAutomationPaused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// No insert is expected, so any attempt to create the task fails the test
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WithArgs(utils.AutomationPausedSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("true"))
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(createTaskBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Automation paused", response.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryTaskRequeuesFailedTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+
+	expectAutomationRunning(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, retry_count, account_id FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "retry_count", "account_id"}).AddRow("failed", 2, 1))
+	mock.ExpectQuery(`UPDATE tasks\s+SET status = 'pending', error_message = NULL, retry_count = retry_count \+ 1`).
+		WithArgs(7, nil).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(7, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow", []byte(`{}`), "pending", 5, 3, 3, 300,
+				now, nil, nil, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WithArgs("tasks", 7, "retry", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var task models.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, models.TaskStatusPending, task.Status)
+	assert.Equal(t, 3, task.RetryCount)
+	assert.Nil(t, task.ErrorMessage)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryTaskDefersAccountCoolingDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	// Account 1 is rate-limited for another ten minutes, so the retry waits
+	until := time.Now().Add(10 * time.Minute)
+	assert.NoError(t, utils.SetAccountCooldown(context.Background(), rdb, 1, until))
+
+	now := time.Now()
+	expectAutomationRunning(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, retry_count, account_id FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "retry_count", "account_id"}).AddRow("failed", 0, 1))
+	mock.ExpectQuery(`UPDATE tasks\s+SET status = 'pending'.*scheduled_at = COALESCE\(\$2, NOW\(\)\)`).
+		WithArgs(7, timeNear{until}).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(7, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow", []byte(`{}`), "pending", 5, 1, 3, 300,
+				until, nil, nil, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WithArgs("tasks", 7, "retry", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := newTaskRouter(&AccountService{db: db, rdb: rdb})
+	req, _ := http.NewRequest("POST", "/tasks/7/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryTaskRefusedWhileAutomationPaused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The task is not even read, let alone requeued
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WithArgs(utils.AutomationPausedSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("true"))
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Automation paused", response.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryTaskRejectsNonFailedTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectAutomationRunning(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, retry_count, account_id FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "retry_count", "account_id"}).AddRow("running", 0, 1))
+	mock.ExpectRollback()
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRetryTaskEnforcesCeiling(t *testing.T) {
+	t.Setenv("TASK_RETRY_CEILING", "3")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectAutomationRunning(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status, retry_count, account_id FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "retry_count", "account_id"}).AddRow("failed", 3, 1))
+	mock.ExpectRollback()
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/retry", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Contains(t, response.Message, "retry ceiling")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelPendingTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(`UPDATE tasks\s+SET status = 'cancelled', completed_at = NOW\(\)`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(7, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow", []byte(`{}`), "cancelled", 5, 0, 3, 300,
+				now, nil, now, nil, false, nil, []byte(`{}`),
+				nil, now, now))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WithArgs("tasks", 7, "cancel", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/cancel", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var task models.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, models.TaskStatusCancelled, task.Status)
+	assert.NotNil(t, task.CompletedAt)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCancelRunningTaskSetsFlag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	worker := "worker-1"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT status FROM tasks WHERE id = \$1 FOR UPDATE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("running"))
+	mock.ExpectQuery(`UPDATE tasks\s+SET cancel_requested = TRUE`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(taskColumnNames).
+			AddRow(7, utils.GenerateUUID().String(), 1, 2, 3,
+				"follow", []byte(`{}`), "running", 5, 0, 3, 300,
+				now, now, nil, worker, true, nil, []byte(`{}`),
+				nil, now, now))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WithArgs("tasks", 7, "cancel", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	router := newTaskRouter(&AccountService{db: db})
+	req, _ := http.NewRequest("POST", "/tasks/7/cancel", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var task models.Task
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &task))
+	assert.Equal(t, models.TaskStatusRunning, task.Status)
+	assert.True(t, task.CancelRequested)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaskTimeseriesZeroFillsMissingDays(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start := today.AddDate(0, 0, -4)
+
+	// Only the first and the last day of the window had tasks
+	mock.ExpectQuery(`GROUP BY DATE\(created_at\) ORDER BY date`).
+		WithArgs(start, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"date", "completed", "failed", "pending"}).
+			AddRow(start, 4, 1, 0).
+			AddRow(today, 2, 0, 3))
+
+	handler := &AccountHandler{accountService: &AccountService{db: db}}
+	router := gin.New()
+	router.GET("/stats/tasks/timeseries", handler.GetTaskTimeseries)
+
+	req, _ := http.NewRequest("GET", "/stats/tasks/timeseries?days=5&account_id=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var timeseries TaskTimeseriesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &timeseries))
+	assert.Equal(t, 5, timeseries.Days)
+	if assert.Len(t, timeseries.Points, 5) {
+		assert.Equal(t, TaskTimeseriesPoint{Date: start.Format("2006-01-02"), Completed: 4, Failed: 1}, timeseries.Points[0])
+		for i := 1; i <= 3; i++ {
+			assert.Equal(t, TaskTimeseriesPoint{Date: start.AddDate(0, 0, i).Format("2006-01-02")}, timeseries.Points[i])
+		}
+		assert.Equal(t, TaskTimeseriesPoint{Date: today.Format("2006-01-02"), Completed: 2, Pending: 3}, timeseries.Points[4])
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaskTimeseriesRejectsInvalidDays(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &AccountHandler{accountService: &AccountService{}}
+	router := gin.New()
+	router.GET("/stats/tasks/timeseries", handler.GetTaskTimeseries)
+
+	for _, days := range []string{"0", "400", "week"} {
+		req, _ := http.NewRequest("GET", "/stats/tasks/timeseries?days="+days, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, days)
+	}
+}
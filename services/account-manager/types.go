@@ -0,0 +1,194 @@
+package main
+
+import (
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// ResolveHandlesRequest represents a request to resolve handles to DIDs
+type ResolveHandlesRequest struct {
+	Handles []string `json:"handles" validate:"required,min=1,max=100,dive,required"`
+}
+
+// HandleResolution represents the outcome of resolving a single handle
+type HandleResolution struct {
+	DID   string `json:"did,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ResolveHandlesResponse maps each requested handle to its resolution
+type ResolveHandlesResponse struct {
+	Results map[string]HandleResolution `json:"results"`
+}
+
+// ActionLimitStatus represents quota usage for one action type
+type ActionLimitStatus struct {
+	Limit          int `json:"limit"`
+	Used           int `json:"used"`
+	Remaining      int `json:"remaining"`
+	ResetInSeconds int `json:"reset_in_seconds"`
+}
+
+// AccountLimitsResponse represents an account's current quota status
+type AccountLimitsResponse struct {
+	AccountID       int                                       `json:"account_id"`
+	WindowSeconds   int                                       `json:"window_seconds"`
+	Limits          map[models.StrategyType]ActionLimitStatus `json:"limits"`
+	CooldownSeconds int                                       `json:"cooldown_seconds"`
+}
+
+// AccountFlagsResponse lists the effective value of every account flag
+type AccountFlagsResponse struct {
+	AccountID int             `json:"account_id"`
+	Flags     map[string]bool `json:"flags"`
+}
+
+// AutomationStatusResponse reports the global kill switch after a pause or
+// resume
+type AutomationStatusResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// AccountComparisonSide identifies one of the compared accounts
+type AccountComparisonSide struct {
+	ID     int    `json:"id"`
+	Handle string `json:"handle"`
+}
+
+// AccountFieldComparison holds one field's value for both accounts
+type AccountFieldComparison struct {
+	Field   string      `json:"field"`
+	A       interface{} `json:"a"`
+	B       interface{} `json:"b"`
+	Differs bool        `json:"differs"`
+}
+
+// AccountComparisonResponse represents two accounts compared side by side
+type AccountComparisonResponse struct {
+	A           AccountComparisonSide    `json:"a"`
+	B           AccountComparisonSide    `json:"b"`
+	Fields      []AccountFieldComparison `json:"fields"`
+	Differences []string                 `json:"differences"`
+}
+
+// AuthTestResult describes the outcome of a test-auth call
+type AuthTestResult struct {
+	AccountID      int     `json:"account_id"`
+	Handle         string  `json:"handle"`
+	DID            *string `json:"did,omitempty"`
+	DIDResolved    bool    `json:"did_resolved"`
+	SessionCreated bool    `json:"session_created"`
+	DurationMs     int64   `json:"duration_ms"`
+}
+
+// TaskTimeseriesPoint counts the tasks created on one day by status
+type TaskTimeseriesPoint struct {
+	Date      string `json:"date"` // YYYY-MM-DD, UTC
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	Pending   int    `json:"pending"`
+}
+
+// TaskTimeseriesResponse is a continuous daily series of task outcomes,
+// oldest day first, with days that had no tasks reported as zeros
+type TaskTimeseriesResponse struct {
+	AccountID *int                  `json:"account_id,omitempty"`
+	Days      int                   `json:"days"`
+	Points    []TaskTimeseriesPoint `json:"points"`
+}
+
+// AccountActionType identifies one step of a batch action request
+type AccountActionType string
+
+const (
+	AccountActionAssignProxy AccountActionType = "assign_proxy"
+	AccountActionRefreshAuth AccountActionType = "refresh_auth"
+	AccountActionSetStatus   AccountActionType = "set_status"
+	AccountActionResetErrors AccountActionType = "reset_errors"
+)
+
+// AccountAction is one operation in a batch; ProxyID is used by assign_proxy
+// and Status by set_status
+type AccountAction struct {
+	Type    AccountActionType     `json:"type" validate:"required,oneof=assign_proxy refresh_auth set_status reset_errors"`
+	ProxyID *int                  `json:"proxy_id,omitempty" validate:"required_if=Type assign_proxy"`
+	Status  *models.AccountStatus `json:"status,omitempty" validate:"required_if=Type set_status,omitempty,oneof=active inactive suspended error needs_reauth"`
+}
+
+// AccountActionsRequest is an ordered list of operations to run on an account
+type AccountActionsRequest struct {
+	Actions     []AccountAction `json:"actions" validate:"required,min=1,max=20,dive"`
+	StopOnError bool            `json:"stop_on_error"`
+}
+
+// AccountActionStatus is the outcome of one batch step
+type AccountActionStatus string
+
+const (
+	AccountActionSucceeded AccountActionStatus = "succeeded"
+	AccountActionFailed    AccountActionStatus = "failed"
+	// AccountActionRolledBack marks a step undone because another step in
+	// its transaction failed
+	AccountActionRolledBack AccountActionStatus = "rolled_back"
+	AccountActionSkipped    AccountActionStatus = "skipped"
+)
+
+// AccountActionResult reports what happened to one step
+type AccountActionResult struct {
+	Type   AccountActionType   `json:"type"`
+	Status AccountActionStatus `json:"status"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// AccountActionsResponse holds per-step results and the account afterwards
+type AccountActionsResponse struct {
+	AccountID int                   `json:"account_id"`
+	Results   []AccountActionResult `json:"results"`
+	Account   *models.Account       `json:"account"`
+}
+
+// OnboardAccountRequest creates an account, assigns it a proxy and logs in.
+// ProxyID picks a specific proxy; otherwise ProxyStrategy and ProxyType are
+// passed to proxy-manager's selection.
+type OnboardAccountRequest struct {
+	Handle                string            `json:"handle" validate:"required"`
+	Password              string            `json:"password" validate:"required"`
+	Host                  string            `json:"host,omitempty"`
+	BGS                   string            `json:"bgs,omitempty"`
+	ProxyID               *int              `json:"proxy_id,omitempty"`
+	ProxyStrategy         string            `json:"proxy_strategy,omitempty"`
+	ProxyType             *models.ProxyType `json:"proxy_type,omitempty"`
+	RollbackOnAuthFailure bool              `json:"rollback_on_auth_failure"`
+}
+
+// OnboardErrorResponse is returned when onboarding fails, naming the step
+type OnboardErrorResponse struct {
+	Error      string `json:"error"`
+	Message    string `json:"message"`
+	Code       int    `json:"code"`
+	Step       string `json:"step"`
+	AccountID  *int   `json:"account_id,omitempty"`
+	RolledBack bool   `json:"rolled_back"`
+}
+
+// UpcomingExecution is an account-strategy association due to run within the
+// schedule preview window
+type UpcomingExecution struct {
+	AccountStrategyID int                 `json:"account_strategy_id"`
+	AccountID         int                 `json:"account_id"`
+	AccountHandle     string              `json:"account_handle"`
+	StrategyID        int                 `json:"strategy_id"`
+	StrategyName      string              `json:"strategy_name"`
+	StrategyType      models.StrategyType `json:"strategy_type"`
+	Schedule          string              `json:"schedule"`
+	NextExecution     time.Time           `json:"next_execution"`
+	RunsInWindow      int                 `json:"runs_in_window"`
+}
+
+// UpcomingScheduleResponse lists scheduled work between From and Until
+type UpcomingScheduleResponse struct {
+	From       time.Time           `json:"from"`
+	Until      time.Time           `json:"until"`
+	Executions []UpcomingExecution `json:"executions"`
+}
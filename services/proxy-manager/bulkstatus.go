@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// ErrEmptyBulkFilter is returned when a bulk update names no filter, which
+// would otherwise match every proxy
+var ErrEmptyBulkFilter = errors.New("at least one of tag, type or host_pattern is required")
+
+// BulkUpdateStatus sets the status of every proxy matching the filter in one
+// statement and returns how many proxies changed. Proxies already in the
+// target status are not counted. Deactivating every assignable proxy needs
+// force, as for a single proxy. The guard locks the assignable proxies and
+// the update runs in the same transaction, so two concurrent bulk updates
+// cannot each leave the other's proxies as the last assignable ones.
+func (s *ProxyService) BulkUpdateStatus(ctx context.Context, req *BulkStatusRequest, force bool) (int64, error) {
+	match, args, err := bulkStatusFilter(req)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated []int
+	err = utils.Transaction(s.db, func(tx *sql.Tx) error {
+		if req.Status != "active" && !force {
+			if err := checkBulkLeavesAssignable(ctx, tx, match, args); err != nil {
+				return err
+			}
+		}
+
+		updateArgs := append(append([]interface{}{}, args...), req.Status)
+		query := fmt.Sprintf(`
+			UPDATE proxies SET status = $%[1]d, updated_at = NOW()
+			WHERE %[2]s AND status <> $%[1]d
+			RETURNING id
+		`, len(updateArgs), match)
+
+		rows, err := tx.QueryContext(ctx, query, updateArgs...)
+		if err != nil {
+			return fmt.Errorf("failed to update proxy status: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				return fmt.Errorf("failed to scan updated proxy: %w", err)
+			}
+			updated = append(updated, id)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read updated proxies: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.clearQuarantine(ctx, updated...)
+	return int64(len(updated)), nil
+}
+
+// checkBulkLeavesAssignable locks the assignable proxies and returns
+// ErrLastAssignableProxy when match covers all of them
+func checkBulkLeavesAssignable(ctx context.Context, tx *sql.Tx, match string, args []interface{}) error {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM proxies
+		WHERE status = 'active' AND health_check_success = true
+	`, match) + assignableFilter("") + " FOR UPDATE"
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to count assignable proxies: %w", err)
+	}
+	defer rows.Close()
+
+	var matched, others int
+	for rows.Next() {
+		var isMatch bool
+		if err := rows.Scan(&isMatch); err != nil {
+			return fmt.Errorf("failed to scan assignable proxy: %w", err)
+		}
+		if isMatch {
+			matched++
+		} else {
+			others++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to count assignable proxies: %w", err)
+	}
+
+	if matched > 0 && others == 0 {
+		return fmt.Errorf("%w: the filter matches every assignable proxy; retry with force=true to proceed", ErrLastAssignableProxy)
+	}
+	return nil
+}
+
+// bulkStatusFilter builds the WHERE condition for a bulk update's filter.
+// Every given field must match. The host pattern uses * as a wildcard and is
+// matched case-insensitively.
+func bulkStatusFilter(req *BulkStatusRequest) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	if tag := strings.TrimSpace(req.Tag); tag != "" {
+		args = append(args, tag)
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT proxy_id FROM proxy_tags WHERE tag = $%d)", len(args)))
+	}
+	if req.Type != nil {
+		args = append(args, *req.Type)
+		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)))
+	}
+	if pattern := strings.TrimSpace(req.HostPattern); pattern != "" {
+		args = append(args, hostPatternToLike(pattern))
+		conditions = append(conditions, fmt.Sprintf("host ILIKE $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, ErrEmptyBulkFilter
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// hostPatternToLike converts a * wildcard pattern to a LIKE pattern,
+// escaping LIKE's own wildcards
+func hostPatternToLike(pattern string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(pattern)
+	return strings.ReplaceAll(escaped, "*", "%")
+}
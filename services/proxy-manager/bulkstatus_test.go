@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func newBulkStatusRouter(service *ProxyService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	handler := &ProxyHandler{proxyService: service, validator: validator.New()}
+	router := gin.New()
+	router.POST("/proxies/bulk-status", utils.StrictJSON(), handler.BulkUpdateStatus)
+	return router
+}
+
+func TestBulkUpdateStatusByTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Other providers' proxies stay assignable, so no force is needed. The
+	// check locks the assignable proxies and the update shares its transaction.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id IN \(SELECT proxy_id FROM proxy_tags WHERE tag = \$1\)\s+FROM proxies\s+WHERE status = 'active'.* FOR UPDATE$`).
+		WithArgs("provider-a").
+		WillReturnRows(sqlmock.NewRows([]string{"match"}).AddRow(true).AddRow(false).AddRow(true))
+	mock.ExpectQuery(`UPDATE proxies SET status = \$2, updated_at = NOW\(\)\s+WHERE id IN \(SELECT proxy_id FROM proxy_tags WHERE tag = \$1\) AND status <> \$2\s+RETURNING id`).
+		WithArgs("provider-a", models.ProxyStatusInactive).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4))
+	mock.ExpectCommit()
+
+	// The operator's status replaces the health checks' quarantine
+	mr := miniredis.RunT(t)
+	mr.Set(quarantineKey(2), "1")
+	mr.Set(quarantineKey(5), "1")
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	router := newBulkStatusRouter(&ProxyService{db: db, rdb: rdb})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/proxies/bulk-status",
+		strings.NewReader(`{"tag": "provider-a", "status": "inactive"}`)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response BulkStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.EqualValues(t, 4, response.Updated)
+	assert.False(t, mr.Exists(quarantineKey(2)))
+	assert.True(t, mr.Exists(quarantineKey(5)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBulkUpdateStatusGuards(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// The filter matches every assignable proxy, so nothing is updated
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM proxies\s+WHERE status = 'active'`).
+		WithArgs("%.provider.net").
+		WillReturnRows(sqlmock.NewRows([]string{"match"}).AddRow(true).AddRow(true))
+	mock.ExpectRollback()
+
+	router := newBulkStatusRouter(&ProxyService{db: db})
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		error  string
+	}{
+		{"no filter", `{"status": "inactive"}`, http.StatusBadRequest, "Filter required"},
+		{"blank filter", `{"tag": "  ", "status": "inactive"}`, http.StatusBadRequest, "Filter required"},
+		{"invalid status", `{"tag": "provider-a", "status": "paused"}`, http.StatusBadRequest, "Validation failed"},
+		{"missing status", `{"tag": "provider-a"}`, http.StatusBadRequest, "Validation failed"},
+		{"unknown field", `{"tags": "provider-a", "status": "inactive"}`, http.StatusBadRequest, "Invalid request body"},
+		{"everything assignable", `{"host_pattern": "*.provider.net", "status": "inactive"}`, http.StatusConflict, "Last assignable proxy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/proxies/bulk-status", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.status, w.Code)
+
+			var response models.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.error, response.Error)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHostPatternToLike(t *testing.T) {
+	assert.Equal(t, "%.provider.net", hostPatternToLike("*.provider.net"))
+	assert.Equal(t, `10.0.\_1%`, hostPatternToLike("10.0._1*"))
+	assert.Equal(t, `100\%`, hostPatternToLike("100%"))
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// StartRedisCleanupScheduler prunes stale health and alert keys, and health
+// check history past its retention, every REDIS_CLEANUP_INTERVAL seconds
+// until the health check scheduler is stopped or ctx ends. An interval of 0
+// disables the cleanup.
+func (h *HealthService) StartRedisCleanupScheduler(ctx context.Context) {
+	interval := utils.GetEnvAsInt("REDIS_CLEANUP_INTERVAL", 3600)
+	if interval <= 0 {
+		log.Println("Redis key cleanup disabled")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := h.CleanupRedisKeys(ctx)
+			if err != nil {
+				log.Printf("Redis key cleanup failed: %v", err)
+			} else if removed > 0 {
+				log.Printf("Redis key cleanup removed %d keys", removed)
+			}
+
+			pruned, err := h.PruneHealthCheckHistory(ctx)
+			if err != nil {
+				log.Printf("Health check history cleanup failed: %v", err)
+			} else if pruned > 0 {
+				log.Printf("Health check history cleanup removed %d rows", pruned)
+			}
+		case <-h.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CleanupRedisKeys deletes proxy_failures:*, proxy_health:* and proxy_alert:*
+// keys for proxies that no longer exist, plus alerts older than
+// ALERT_RETENTION_HOURS, which would otherwise pile up if they lost their TTL.
+// Keys are walked with SCAN so Redis is never blocked. It returns how many
+// keys were removed.
+func (h *HealthService) CleanupRedisKeys(ctx context.Context) (int, error) {
+	existing, err := h.existingProxyIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	retention := time.Duration(utils.GetEnvAsInt("ALERT_RETENTION_HOURS", 7*24)) * time.Hour
+	cutoff := time.Now().Add(-retention).Unix()
+
+	removed := 0
+	for _, pattern := range []string{"proxy_failures:*", "proxy_health:*", "proxy_alert:*"} {
+		var cursor uint64
+		for {
+			keys, next, err := h.rdb.Scan(ctx, cursor, pattern, 100).Result()
+			if err != nil {
+				return removed, fmt.Errorf("failed to scan %s: %w", pattern, err)
+			}
+
+			var stale []string
+			for _, key := range keys {
+				if staleProxyKey(key, existing, cutoff) {
+					stale = append(stale, key)
+				}
+			}
+			if len(stale) > 0 {
+				n, err := h.rdb.Del(ctx, stale...).Result()
+				if err != nil {
+					return removed, fmt.Errorf("failed to delete stale keys: %w", err)
+				}
+				removed += int(n)
+			}
+
+			cursor = next
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// PruneHealthCheckHistory deletes proxy_health_checks rows older than
+// HEALTH_CHECK_RETENTION_DAYS (default 90, the longest trend window). A
+// retention of 0 keeps the history forever. It returns how many rows were
+// removed.
+func (h *HealthService) PruneHealthCheckHistory(ctx context.Context) (int64, error) {
+	days := utils.GetEnvAsInt("HEALTH_CHECK_RETENTION_DAYS", 90)
+	if days <= 0 {
+		return 0, nil
+	}
+
+	result, err := h.db.ExecContext(ctx,
+		"DELETE FROM proxy_health_checks WHERE checked_at < NOW() - $1 * INTERVAL '1 day'", days)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune health check history: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// staleProxyKey reports whether a proxy_*:<id>[:<unix time>] key belongs to a
+// deleted proxy or is an alert older than cutoff. Keys that don't parse are
+// left alone.
+func staleProxyKey(key string, existing map[int]bool, cutoff int64) bool {
+	parts := strings.Split(key, ":")
+	if len(parts) < 2 {
+		return false
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	if !existing[id] {
+		return true
+	}
+
+	if parts[0] == "proxy_alert" && len(parts) == 3 {
+		timestamp, err := strconv.ParseInt(parts[2], 10, 64)
+		return err == nil && timestamp < cutoff
+	}
+	return false
+}
+
+func (h *HealthService) existingProxyIDs(ctx context.Context) (map[int]bool, error) {
+	rows, err := h.db.QueryContext(ctx, "SELECT id FROM proxies")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query proxy ids: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan proxy id: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
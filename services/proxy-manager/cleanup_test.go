@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanupRedisKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	now := time.Now()
+	recentAlert := fmt.Sprintf("proxy_alert:1:%d", now.Add(-time.Hour).Unix())
+	oldAlert := fmt.Sprintf("proxy_alert:1:%d", now.Add(-30*24*time.Hour).Unix())
+	orphanAlert := fmt.Sprintf("proxy_alert:9:%d", now.Unix())
+
+	// Proxy 1 still exists; proxy 9 was deleted
+	mr.Set("proxy_failures:1", "2")
+	mr.Set("proxy_failures:9", "3")
+	mr.HSet("proxy_health:1", "success", "1")
+	mr.HSet("proxy_health:9", "success", "0")
+	mr.HSet(recentAlert, "type", "proxy_failure")
+	mr.HSet(oldAlert, "type", "proxy_failure")
+	mr.HSet(orphanAlert, "type", "proxy_failure")
+	mr.Set("unrelated", "kept")
+
+	mock.ExpectQuery(`SELECT id FROM proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	service := &HealthService{db: db, rdb: rdb}
+	removed, err := service.CleanupRedisKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, removed)
+
+	assert.True(t, mr.Exists("proxy_failures:1"))
+	assert.True(t, mr.Exists("proxy_health:1"))
+	assert.True(t, mr.Exists(recentAlert))
+	assert.True(t, mr.Exists("unrelated"))
+
+	assert.False(t, mr.Exists("proxy_failures:9"))
+	assert.False(t, mr.Exists("proxy_health:9"))
+	assert.False(t, mr.Exists(orphanAlert))
+	assert.False(t, mr.Exists(oldAlert))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPruneHealthCheckHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := &HealthService{db: db}
+
+	mock.ExpectExec(`DELETE FROM proxy_health_checks WHERE checked_at < NOW\(\) - \$1 \* INTERVAL '1 day'`).
+		WithArgs(90).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+	removed, err := service.PruneHealthCheckHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), removed)
+
+	t.Setenv("HEALTH_CHECK_RETENTION_DAYS", "14")
+	mock.ExpectExec(`DELETE FROM proxy_health_checks`).
+		WithArgs(14).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = service.PruneHealthCheckHistory(context.Background())
+	assert.NoError(t, err)
+
+	// 0 keeps the history and issues no query
+	t.Setenv("HEALTH_CHECK_RETENTION_DAYS", "0")
+	removed, err = service.PruneHealthCheckHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, removed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// assignmentAttempt is one assignment joined with one login attempt by its
+// account; attemptedAt and success are nil for an assignment with none
+type assignmentAttempt struct {
+	assignmentID int
+	strategy     string
+	assignedAt   time.Time
+	releasedAt   *time.Time
+	attemptedAt  *time.Time
+	success      *bool
+}
+
+// assignmentOutcome is the login record of one assignment
+type assignmentOutcome struct {
+	strategy  string
+	successes int
+	failures  int
+}
+
+// GetAssignmentEffectiveness compares strategies by what happened to the
+// accounts they placed. For every assignment made in the last days, the
+// account's login attempts in the first windowHours after assignment are
+// counted, stopping early if the assignment was released.
+func (s *ProxyService) GetAssignmentEffectiveness(ctx context.Context, days, windowHours int) (*AssignmentEffectivenessResponse, error) {
+	// The join only narrows the rows fetched; assignmentOutcomes decides
+	// which attempts count
+	query := `
+		SELECT pa.id, pa.strategy, pa.assigned_at, pa.released_at, aa.created_at, aa.success
+		FROM proxy_assignments pa
+		LEFT JOIN auth_attempts aa ON aa.account_id = pa.account_id
+		 AND aa.created_at >= pa.assigned_at
+		 AND aa.created_at < pa.assigned_at + $2 * INTERVAL '1 hour'
+		WHERE pa.assigned_at >= NOW() - $1 * INTERVAL '1 day'
+		ORDER BY pa.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, days, windowHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []assignmentAttempt
+	for rows.Next() {
+		var attempt assignmentAttempt
+		if err := rows.Scan(&attempt.assignmentID, &attempt.strategy, &attempt.assignedAt,
+			&attempt.releasedAt, &attempt.attemptedAt, &attempt.success); err != nil {
+			return nil, fmt.Errorf("failed to scan assignment outcome: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read assignment outcomes: %w", err)
+	}
+
+	window := time.Duration(windowHours) * time.Hour
+	return &AssignmentEffectivenessResponse{
+		Days:        days,
+		WindowHours: windowHours,
+		Strategies:  summarizeAssignmentOutcomes(assignmentOutcomes(attempts, window)),
+	}, nil
+}
+
+// assignmentOutcomes counts, per assignment, the login attempts made from its
+// start until window later or its release, whichever comes first. Every
+// assignment gets an outcome, even with no attempts.
+func assignmentOutcomes(attempts []assignmentAttempt, window time.Duration) []assignmentOutcome {
+	index := make(map[int]int)
+	var outcomes []assignmentOutcome
+	for _, attempt := range attempts {
+		i, ok := index[attempt.assignmentID]
+		if !ok {
+			i = len(outcomes)
+			index[attempt.assignmentID] = i
+			outcomes = append(outcomes, assignmentOutcome{strategy: attempt.strategy})
+		}
+
+		if attempt.attemptedAt == nil || attempt.success == nil {
+			continue
+		}
+		at := *attempt.attemptedAt
+		if at.Before(attempt.assignedAt) || !at.Before(attempt.assignedAt.Add(window)) {
+			continue
+		}
+		if attempt.releasedAt != nil && !at.Before(*attempt.releasedAt) {
+			continue
+		}
+
+		if *attempt.success {
+			outcomes[i].successes++
+		} else {
+			outcomes[i].failures++
+		}
+	}
+	return outcomes
+}
+
+// summarizeAssignmentOutcomes aggregates assignment outcomes per strategy,
+// ordered by strategy name
+func summarizeAssignmentOutcomes(outcomes []assignmentOutcome) []StrategyEffectiveness {
+	byStrategy := make(map[string]*StrategyEffectiveness)
+	healthy := make(map[string]int)
+	for _, outcome := range outcomes {
+		summary, ok := byStrategy[outcome.strategy]
+		if !ok {
+			summary = &StrategyEffectiveness{Strategy: outcome.strategy}
+			byStrategy[outcome.strategy] = summary
+		}
+		summary.Assignments++
+		summary.AuthSuccesses += outcome.successes
+		summary.AuthFailures += outcome.failures
+		if outcome.successes+outcome.failures > 0 {
+			summary.AssignmentsWithOutcomes++
+			if outcome.failures == 0 {
+				healthy[outcome.strategy]++
+			}
+		}
+	}
+
+	strategies := make([]StrategyEffectiveness, 0, len(byStrategy))
+	for strategy, summary := range byStrategy {
+		if attempts := summary.AuthSuccesses + summary.AuthFailures; attempts > 0 {
+			summary.SuccessRate = float64(summary.AuthSuccesses) / float64(attempts) * 100
+		}
+		if summary.AssignmentsWithOutcomes > 0 {
+			summary.HealthyAssignmentRate = float64(healthy[strategy]) / float64(summary.AssignmentsWithOutcomes) * 100
+		}
+		strategies = append(strategies, *summary)
+	}
+	sort.Slice(strategies, func(i, j int) bool { return strategies[i].Strategy < strategies[j].Strategy })
+	return strategies
+}
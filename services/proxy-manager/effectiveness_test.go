@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAssignmentEffectiveness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// One row per assignment and login attempt, as the join returns them
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(hours float64) time.Time { return base.Add(time.Duration(hours * float64(time.Hour))) }
+	released := at(3)
+	columns := []string{"id", "strategy", "assigned_at", "released_at", "created_at", "success"}
+	mock.ExpectQuery(`FROM proxy_assignments pa\s+LEFT JOIN auth_attempts aa`).
+		WithArgs(7, 12).
+		WillReturnRows(sqlmock.NewRows(columns).
+			// Two successful logins
+			AddRow(1, "least_used", base, nil, at(1), true).
+			AddRow(1, "least_used", base, nil, at(2), true).
+			// One of each; the failure after release is not counted
+			AddRow(2, "least_used", base, released, at(1), true).
+			AddRow(2, "least_used", base, released, at(2), false).
+			AddRow(2, "least_used", base, released, at(4), false).
+			// No logins at all
+			AddRow(3, "least_used", base, nil, nil, nil).
+			// Only failures
+			AddRow(4, "round_robin", base, nil, at(1), false).
+			AddRow(4, "round_robin", base, nil, at(11.5), false).
+			// An attempt exactly at the end of the window is outside it
+			AddRow(5, "manual", base, nil, at(0), true).
+			AddRow(5, "manual", base, nil, at(5), true).
+			AddRow(5, "manual", base, nil, at(6), true).
+			AddRow(5, "manual", base, nil, at(12), false))
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.GET("/stats/assignment-effectiveness", handler.GetAssignmentEffectiveness)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/stats/assignment-effectiveness?days=7&window_hours=12", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response AssignmentEffectivenessResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 7, response.Days)
+	assert.Equal(t, 12, response.WindowHours)
+	require.Len(t, response.Strategies, 3)
+
+	leastUsed, manual, roundRobin := response.Strategies[0], response.Strategies[1], response.Strategies[2]
+	assert.Equal(t, "manual", manual.Strategy)
+	assert.Equal(t, 3, manual.AuthSuccesses)
+	assert.Equal(t, 0, manual.AuthFailures)
+	assert.Equal(t, 100.0, manual.SuccessRate)
+
+	assert.Equal(t, "least_used", leastUsed.Strategy)
+	assert.Equal(t, 3, leastUsed.Assignments)
+	assert.Equal(t, 2, leastUsed.AssignmentsWithOutcomes)
+	assert.Equal(t, 3, leastUsed.AuthSuccesses)
+	assert.Equal(t, 1, leastUsed.AuthFailures)
+	assert.Equal(t, 75.0, leastUsed.SuccessRate)
+	assert.Equal(t, 50.0, leastUsed.HealthyAssignmentRate)
+
+	assert.Equal(t, "round_robin", roundRobin.Strategy)
+	assert.Equal(t, 2, roundRobin.AuthFailures)
+	assert.Equal(t, 0.0, roundRobin.SuccessRate)
+	assert.Equal(t, 0.0, roundRobin.HealthyAssignmentRate)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAssignmentEffectivenessRejectsBadWindow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &ProxyHandler{}
+	router := gin.New()
+	router.GET("/stats/assignment-effectiveness", handler.GetAssignmentEffectiveness)
+
+	for _, query := range []string{"days=0", "days=91", "window_hours=0", "window_hours=abc"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/stats/assignment-effectiveness?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
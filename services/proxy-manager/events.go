@@ -0,0 +1,19 @@
+package main
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// wireEvents connects the services to bus and registers the subscribers that
+// react to their events: failure alerts, and event counts in Redis
+func wireEvents(bus *utils.EventBus, rdb *redis.Client, proxyService *ProxyService, healthService *HealthService) {
+	proxyService.events = bus
+	healthService.events = bus
+	healthService.proxyService.events = bus
+
+	bus.Subscribe(utils.EventProxyFailed, "proxy failure alert", healthService.notifyProxyFailure)
+	bus.SubscribeAsync(utils.EventProxyFailed, "event counts", utils.CountEvents(rdb))
+	bus.SubscribeAsync(utils.EventProxyAssigned, "event counts", utils.CountEvents(rdb))
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// exportedProxy is an export row that reveals the proxy's credentials
+type exportedProxy struct {
+	models.Proxy
+	Password *string `json:"password,omitempty"`
+}
+
+// ExportProxies streams every proxy, optionally filtered by status, to emit
+// one row at a time. Credentials are only read, and decrypted into
+// proxy.Password, when includeCredentials is set.
+func (s *ProxyService) ExportProxies(ctx context.Context, status *models.ProxyStatus, includeCredentials bool, emit func(models.Proxy) error) error {
+	query := `
+		SELECT id, uuid, name, type, host, port, status, health_check_url,
+		       last_health_check, health_check_success, health_check_enabled,
+		       response_time_ms, created_at, updated_at, username, password
+		FROM proxies
+	`
+
+	var args []interface{}
+	if status != nil {
+		query += " WHERE status = $1"
+		args = append(args, *status)
+	}
+	query += " ORDER BY id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to export proxies: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var proxy models.Proxy
+		var username, password *string
+		err := rows.Scan(
+			&proxy.ID, &proxy.UUID, &proxy.Name, &proxy.Type, &proxy.Host, &proxy.Port,
+			&proxy.Status, &proxy.HealthCheckURL, &proxy.LastHealthCheck,
+			&proxy.HealthCheckSuccess, &proxy.HealthCheckEnabled, &proxy.ResponseTimeMs,
+			&proxy.CreatedAt, &proxy.UpdatedAt, &username, &password,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan proxy: %w", err)
+		}
+		if includeCredentials {
+			proxy.Username = username
+			if password != nil {
+				plaintext, err := utils.DecryptCredential(*password)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt password for proxy %d: %w", proxy.ID, err)
+				}
+				proxy.Password = &plaintext
+			}
+		}
+		if err := emit(proxy); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// credentialExportAllowed reports whether token unlocks credentials in
+// exports. Revealing credentials is off unless PROXY_EXPORT_CREDENTIALS_TOKEN
+// is configured.
+func credentialExportAllowed(token string) bool {
+	expected := utils.GetEnvOrDefault("PROXY_EXPORT_CREDENTIALS_TOKEN", "")
+	if expected == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 
 	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
 )
 
 // ProxyHandler handles HTTP requests for proxy management
@@ -37,7 +42,7 @@ func NewProxyHandler(proxyService *ProxyService) *ProxyHandler {
 // @Router /api/v1/proxies [post]
 func (h *ProxyHandler) CreateProxy(c *gin.Context) {
 	var req models.CreateProxyRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -57,6 +62,22 @@ func (h *ProxyHandler) CreateProxy(c *gin.Context) {
 
 	proxy, err := h.proxyService.CreateProxy(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidProxyHost) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid proxy host",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		if errors.Is(err, ErrHealthCheckURLNotAllowed) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid health check URL",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to create proxy",
 			Message: err.Error(),
@@ -119,16 +140,17 @@ func (h *ProxyHandler) GetProxy(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number" default(1)
-// @Param page_size query int false "Page size" default(10)
+// @Param page_size query int false "Page size; defaults to the default_page_size setting (10) and is capped by max_page_size (100)"
 // @Param status query string false "Filter by status" Enums(active,inactive,error)
 // @Param type query string false "Filter by type" Enums(http,socks5)
+// @Param skip_malformed query bool false "Skip and count rows that fail to scan instead of failing the request"
 // @Success 200 {object} models.ListResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/proxies [get]
 func (h *ProxyHandler) ListProxies(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size")) // 0 uses the default_page_size setting
 	
 	var status *models.ProxyStatus
 	if statusStr := c.Query("status"); statusStr != "" {
@@ -142,7 +164,8 @@ func (h *ProxyHandler) ListProxies(c *gin.Context) {
 		proxyType = &t
 	}
 
-	result, err := h.proxyService.ListProxies(c.Request.Context(), page, pageSize, status, proxyType)
+	skipMalformed := c.Query("skip_malformed") == "true"
+	result, err := h.proxyService.ListProxies(c.Request.Context(), page, pageSize, status, proxyType, skipMalformed)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to list proxies",
@@ -152,6 +175,9 @@ func (h *ProxyHandler) ListProxies(c *gin.Context) {
 		return
 	}
 
+	if result.SkippedRows > 0 {
+		c.Header("X-Skipped-Rows", strconv.Itoa(result.SkippedRows))
+	}
 	c.JSON(http.StatusOK, result)
 }
 
@@ -163,9 +189,11 @@ func (h *ProxyHandler) ListProxies(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Proxy ID"
 // @Param proxy body UpdateProxyRequest true "Proxy update data"
+// @Param force query bool false "Allow deactivating the last assignable proxy"
 // @Success 200 {object} models.Proxy
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/proxies/{id} [put]
 func (h *ProxyHandler) UpdateProxy(c *gin.Context) {
@@ -181,7 +209,7 @@ func (h *ProxyHandler) UpdateProxy(c *gin.Context) {
 	}
 
 	var req UpdateProxyRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -190,7 +218,8 @@ func (h *ProxyHandler) UpdateProxy(c *gin.Context) {
 		return
 	}
 
-	proxy, err := h.proxyService.UpdateProxy(c.Request.Context(), id, &req)
+	force := c.Query("force") == "true"
+	proxy, err := h.proxyService.UpdateProxy(c.Request.Context(), id, &req, force)
 	if err != nil {
 		if err.Error() == "proxy not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -200,6 +229,30 @@ func (h *ProxyHandler) UpdateProxy(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, ErrLastAssignableProxy) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Last assignable proxy",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		if errors.Is(err, utils.ErrInvalidProxyHost) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid proxy host",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		if errors.Is(err, ErrHealthCheckURLNotAllowed) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid health check URL",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to update proxy",
 			Message: err.Error(),
@@ -218,9 +271,11 @@ func (h *ProxyHandler) UpdateProxy(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path int true "Proxy ID"
+// @Param force query bool false "Allow deleting the last assignable proxy"
 // @Success 204
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/proxies/{id} [delete]
 func (h *ProxyHandler) DeleteProxy(c *gin.Context) {
@@ -235,7 +290,8 @@ func (h *ProxyHandler) DeleteProxy(c *gin.Context) {
 		return
 	}
 
-	err = h.proxyService.DeleteProxy(c.Request.Context(), id)
+	force := c.Query("force") == "true"
+	err = h.proxyService.DeleteProxy(c.Request.Context(), id, force)
 	if err != nil {
 		if err.Error() == "proxy not found" {
 			c.JSON(http.StatusNotFound, models.ErrorResponse{
@@ -245,6 +301,14 @@ func (h *ProxyHandler) DeleteProxy(c *gin.Context) {
 			})
 			return
 		}
+		if errors.Is(err, ErrLastAssignableProxy) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Last assignable proxy",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to delete proxy",
 			Message: err.Error(),
@@ -256,6 +320,69 @@ func (h *ProxyHandler) DeleteProxy(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// BulkUpdateStatus sets the status of every proxy matching a filter
+// @Summary Bulk update proxy status
+// @Description Set the status of all proxies matching the tag, type and host pattern filters in one update, e.g. to deactivate a provider during an outage. At least one filter is required.
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param request body BulkStatusRequest true "Filter and target status"
+// @Param force query bool false "Allow deactivating every assignable proxy"
+// @Success 200 {object} BulkStatusResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/bulk-status [post]
+func (h *ProxyHandler) BulkUpdateStatus(c *gin.Context) {
+	var req BulkStatusRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	force := c.Query("force") == "true"
+	updated, err := h.proxyService.BulkUpdateStatus(c.Request.Context(), &req, force)
+	if err != nil {
+		if errors.Is(err, ErrEmptyBulkFilter) {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Filter required",
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		if errors.Is(err, ErrLastAssignableProxy) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Last assignable proxy",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update proxy status",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, BulkStatusResponse{Updated: updated})
+}
+
 // TestProxy tests proxy connection
 // @Summary Test proxy connection
 // @Description Test if a proxy server is working correctly
@@ -293,6 +420,80 @@ func (h *ProxyHandler) TestProxy(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// RotateCredentials rotates a proxy's credentials
+// @Summary Rotate proxy credentials
+// @Description Test a proxy with a new username and password and store them only if the test passes. A failed test leaves the old credentials in place.
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path int true "Proxy ID"
+// @Param credentials body RotateCredentialsRequest true "New credentials"
+// @Success 200 {object} RotateCredentialsResult
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/{id}/rotate-credentials [post]
+func (h *ProxyHandler) RotateCredentials(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid proxy ID",
+			Message: "Proxy ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	var req RotateCredentialsRequest
+	if err := utils.BindJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid request body",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation failed",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.proxyService.RotateCredentials(c.Request.Context(), id, &req)
+	if err != nil {
+		if err.Error() == "proxy not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Proxy not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrCredentialsChanged) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Proxy credentials changed",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to rotate proxy credentials",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // RunHealthCheck runs health check for a proxy
 // @Summary Run health check
 // @Description Run health check for a specific proxy
@@ -331,6 +532,195 @@ func (h *ProxyHandler) RunHealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetProxyRanking returns proxies ranked by a composite score
+// @Summary Rank proxies
+// @Description Score every proxy from its latest health check, response time and assignment load, best first. score = 100 * (wh*health + ws*speed + wl*load) / (wh + ws + wl)
+// @Tags proxies
+// @Produce json
+// @Param health_weight query number false "Weight of the health component" default(0.5)
+// @Param speed_weight query number false "Weight of the response time component" default(0.3)
+// @Param load_weight query number false "Weight of the assignment load component" default(0.2)
+// @Success 200 {object} ProxyRankingResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/ranking [get]
+func (h *ProxyHandler) GetProxyRanking(c *gin.Context) {
+	weights, err := parseRankingWeights(c.Query("health_weight"), c.Query("speed_weight"), c.Query("load_weight"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid ranking weights",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	ranking, err := h.proxyService.GetProxyRanking(c.Request.Context(), weights)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to rank proxies",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, ranking)
+}
+
+// ExportProxies streams all proxies as newline-delimited JSON
+// @Summary Export proxies
+// @Description Stream proxies as NDJSON, flushing periodically for large datasets. Credentials are left out unless include_credentials is set and the X-Export-Token header matches PROXY_EXPORT_CREDENTIALS_TOKEN.
+// @Tags proxies
+// @Produce application/x-ndjson
+// @Param status query string false "Filter by status" Enums(active,inactive,error)
+// @Param include_credentials query bool false "Include decrypted usernames and passwords"
+// @Param X-Export-Token header string false "Token authorizing credential export"
+// @Success 200 {array} models.Proxy
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/export [get]
+func (h *ProxyHandler) ExportProxies(c *gin.Context) {
+	var status *models.ProxyStatus
+	if statusStr := c.Query("status"); statusStr != "" {
+		s := models.ProxyStatus(statusStr)
+		status = &s
+	}
+
+	includeCredentials := c.Query("include_credentials") == "true"
+	if includeCredentials && !credentialExportAllowed(c.GetHeader("X-Export-Token")) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:   "Credential export not allowed",
+			Message: "A valid X-Export-Token is required to export proxy credentials",
+			Code:    http.StatusForbidden,
+		})
+		return
+	}
+
+	flushEvery := utils.GetEnvAsInt("EXPORT_FLUSH_ROWS", 100)
+	rows, err := utils.StreamNDJSON(c.Request.Context(), c.Writer, flushEvery, func(emit func(v interface{}) error) error {
+		return h.proxyService.ExportProxies(c.Request.Context(), status, includeCredentials, func(proxy models.Proxy) error {
+			if includeCredentials {
+				return emit(exportedProxy{Proxy: proxy, Password: proxy.Password})
+			}
+			return emit(proxy)
+		})
+	})
+	if errors.Is(err, context.Canceled) {
+		// The client went away; there is no one left to tell
+		log.Printf("Proxy export cancelled by the client after %d rows", rows)
+		return
+	}
+	if err != nil {
+		if rows == 0 {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error:   "Failed to export proxies",
+				Message: err.Error(),
+				Code:    http.StatusInternalServerError,
+			})
+			return
+		}
+		// Headers are already sent; the truncated stream signals the failure
+		log.Printf("Proxy export aborted after %d rows: %v", rows, err)
+	}
+}
+
+// ImportProxies creates proxies from newline-delimited JSON
+// @Summary Import proxies
+// @Description Create one proxy per NDJSON line, in the format of the create request; rows from the export endpoint are accepted. Bad lines are reported and skipped, and connections are left to the health scheduler. At most PROXY_IMPORT_MAX_ENTRIES lines and PROXY_IMPORT_MAX_BYTES bytes are processed, and processing stops after PROXY_IMPORT_TIMEOUT_SECONDS, also while waiting on a slow body, or when the client disconnects, returning the partial result with a note.
+// @Tags proxies
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} ProxyImportResult
+// @Router /api/v1/proxies/import [post]
+func (h *ProxyHandler) ImportProxies(c *gin.Context) {
+	maxEntries := utils.GetEnvAsInt("PROXY_IMPORT_MAX_ENTRIES", defaultProxyImportMaxEntries)
+	timeout := time.Duration(utils.GetEnvAsInt("PROXY_IMPORT_TIMEOUT_SECONDS", defaultProxyImportTimeout)) * time.Second
+
+	maxBytes := int64(utils.GetEnvAsInt("PROXY_IMPORT_MAX_BYTES", defaultProxyImportMaxBytes))
+
+	deadline := time.Now().Add(timeout)
+	ctx, cancel := context.WithDeadline(c.Request.Context(), deadline)
+	defer cancel()
+
+	// ctx is only checked between entries, so a client trickling its body
+	// would keep the import blocked in a read; the read deadline ends that.
+	// Writers that cannot set one (e.g. in tests) are left without it.
+	_ = http.NewResponseController(c.Writer).SetReadDeadline(deadline)
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	result := h.proxyService.ImportProxies(ctx, body, maxEntries)
+	if result.Cancelled {
+		log.Printf("Proxy import %s", result.Note)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// EnableHealthCheck re-enables scheduled health checks for a proxy
+// @Summary Enable proxy health checks
+// @Description Resume scheduled health checks for a proxy
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path int true "Proxy ID"
+// @Success 200 {object} models.Proxy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/{id}/health-check/enable [post]
+func (h *ProxyHandler) EnableHealthCheck(c *gin.Context) {
+	h.setHealthCheckEnabled(c, true)
+}
+
+// DisableHealthCheck stops scheduled health checks for a proxy
+// @Summary Disable proxy health checks
+// @Description Skip a proxy in scheduled health checks so failures during maintenance do not change its status
+// @Tags proxies
+// @Accept json
+// @Produce json
+// @Param id path int true "Proxy ID"
+// @Success 200 {object} models.Proxy
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/{id}/health-check/disable [post]
+func (h *ProxyHandler) DisableHealthCheck(c *gin.Context) {
+	h.setHealthCheckEnabled(c, false)
+}
+
+func (h *ProxyHandler) setHealthCheckEnabled(c *gin.Context, enabled bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid proxy ID",
+			Message: "Proxy ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	proxy, err := h.proxyService.SetHealthCheckEnabled(c.Request.Context(), id, enabled)
+	if err != nil {
+		if err.Error() == "proxy not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Proxy not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to update health check setting",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, proxy)
+}
+
 // GetAvailableProxies returns available proxies for assignment
 // @Summary Get available proxies
 // @Description Get list of available proxies for assignment
@@ -338,7 +728,9 @@ func (h *ProxyHandler) RunHealthCheck(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param type query string false "Filter by proxy type" Enums(http,socks5)
+// @Param max_health_age query string false "Exclude proxies last checked longer ago than this duration (e.g. 10m)"
 // @Success 200 {array} models.Proxy
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/assignment/available [get]
 func (h *ProxyHandler) GetAvailableProxies(c *gin.Context) {
@@ -348,7 +740,17 @@ func (h *ProxyHandler) GetAvailableProxies(c *gin.Context) {
 		proxyType = &t
 	}
 
-	proxies, err := h.proxyService.GetAvailableProxies(c.Request.Context(), proxyType)
+	maxHealthAge, err := parseMaxHealthAge(c.Query("max_health_age"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid max_health_age",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	proxies, err := h.proxyService.GetAvailableProxies(c.Request.Context(), proxyType, maxHealthAge)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get available proxies",
@@ -363,18 +765,20 @@ func (h *ProxyHandler) GetAvailableProxies(c *gin.Context) {
 
 // AssignProxy assigns a proxy to an account
 // @Summary Assign proxy to account
-// @Description Assign a proxy to a specific account
+// @Description Assign a proxy to a specific account. A proxy named by proxy_id must be active, have passed its last health check and have spare capacity.
 // @Tags assignment
 // @Accept json
 // @Produce json
 // @Param assignment body ProxyAssignmentRequest true "Assignment data"
 // @Success 200 {object} ProxyAssignmentResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/assignment/assign [post]
 func (h *ProxyHandler) AssignProxy(c *gin.Context) {
 	var req ProxyAssignmentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -383,8 +787,41 @@ func (h *ProxyHandler) AssignProxy(c *gin.Context) {
 		return
 	}
 
+	if _, err := parseMaxHealthAge(req.MaxHealthAge); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid max_health_age",
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
 	result, err := h.proxyService.AssignProxy(c.Request.Context(), &req)
 	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		if errors.Is(err, ErrProxyAtCapacity) || errors.Is(err, ErrNoProxyCapacity) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "No proxy capacity",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
+		if errors.Is(err, ErrProxyUnhealthy) {
+			c.JSON(http.StatusConflict, models.ErrorResponse{
+				Error:   "Proxy not healthy",
+				Message: err.Error(),
+				Code:    http.StatusConflict,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to assign proxy",
 			Message: err.Error(),
@@ -405,11 +842,12 @@ func (h *ProxyHandler) AssignProxy(c *gin.Context) {
 // @Param release body ProxyReleaseRequest true "Release data"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /api/v1/assignment/release [post]
 func (h *ProxyHandler) ReleaseProxy(c *gin.Context) {
 	var req ProxyReleaseRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := utils.BindJSON(c, &req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid request body",
 			Message: err.Error(),
@@ -420,6 +858,14 @@ func (h *ProxyHandler) ReleaseProxy(c *gin.Context) {
 
 	err := h.proxyService.ReleaseProxy(c.Request.Context(), &req)
 	if err != nil {
+		if err.Error() == "account not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Account not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to release proxy",
 			Message: err.Error(),
@@ -434,6 +880,90 @@ func (h *ProxyHandler) ReleaseProxy(c *gin.Context) {
 	})
 }
 
+// GetAssignmentHistory lists past and current proxy assignments
+// @Summary Get assignment history
+// @Description List proxy assignments newest first. from/to select assignments held at any point in the window, e.g. to find which proxy an account used during an incident.
+// @Tags assignment
+// @Produce json
+// @Param account_id query int false "Filter by account"
+// @Param proxy_id query int false "Filter by proxy"
+// @Param strategy query string false "Filter by assignment strategy, e.g. manual or least_used"
+// @Param active query bool false "Only assignments that have not been released"
+// @Param from query string false "Window start (RFC 3339)"
+// @Param to query string false "Window end (RFC 3339)"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size; defaults to the default_page_size setting (10) and is capped by max_page_size (100)"
+// @Success 200 {object} models.ListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/assignment/history [get]
+func (h *ProxyHandler) GetAssignmentHistory(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size")) // 0 uses the default_page_size setting
+
+	filter := AssignmentHistoryFilter{
+		Strategy:   c.Query("strategy"),
+		ActiveOnly: c.Query("active") == "true",
+		Page:       page,
+		PageSize:   pageSize,
+	}
+
+	for name, target := range map[string]**int{"account_id": &filter.AccountID, "proxy_id": &filter.ProxyID} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid " + name,
+				Message: name + " must be a valid integer",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		*target = &id
+	}
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid " + name,
+				Message: name + " must be an RFC 3339 timestamp",
+				Code:    http.StatusBadRequest,
+			})
+			return
+		}
+		*target = &t
+	}
+
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid time window",
+			Message: "to must not be before from",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	result, err := h.proxyService.ListAssignmentHistory(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get assignment history",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
 // GetProxyUsage returns proxy usage statistics
 // @Summary Get proxy usage
 // @Description Get proxy usage statistics and assignments
@@ -503,6 +1033,128 @@ func (h *ProxyHandler) GetHealthStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// GetHealthStatsByTag returns proxy health statistics grouped by tag
+// @Summary Get proxy health statistics by tag
+// @Description Get proxy health and availability statistics grouped by tag
+// @Tags stats
+// @Accept json
+// @Produce json
+// @Success 200 {object} ProxyTagHealthStatsResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/stats/health/by-tag [get]
+func (h *ProxyHandler) GetHealthStatsByTag(c *gin.Context) {
+	stats, err := h.proxyService.GetHealthStatsByTag(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get health stats by tag",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetAssignmentEffectiveness compares assignment strategies by login outcomes
+// @Summary Get assignment strategy effectiveness
+// @Description For assignments made in the last days, count each account's login attempts within window_hours of assignment and report success rates per strategy
+// @Tags stats
+// @Produce json
+// @Param days query int false "Assignments made in the last days (1-90)" default(30)
+// @Param window_hours query int false "Hours after assignment to count login attempts (1-168)" default(24)
+// @Success 200 {object} AssignmentEffectivenessResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/stats/assignment-effectiveness [get]
+func (h *ProxyHandler) GetAssignmentEffectiveness(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 90 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid days",
+			Message: "days must be an integer between 1 and 90",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	windowHours, err := strconv.Atoi(c.DefaultQuery("window_hours", "24"))
+	if err != nil || windowHours < 1 || windowHours > 168 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid window_hours",
+			Message: "window_hours must be an integer between 1 and 168",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	stats, err := h.proxyService.GetAssignmentEffectiveness(c.Request.Context(), days, windowHours)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get assignment effectiveness",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetResponseTimeTrend reports whether a proxy is getting faster or slower
+// @Summary Get proxy response time trend
+// @Description Fit a line through the proxy's successful health check response times and classify it as improving, stable or degrading
+// @Tags proxies
+// @Produce json
+// @Param id path int true "Proxy ID"
+// @Param days query int false "Number of days to include (1-90)" default(7)
+// @Success 200 {object} ResponseTimeTrend
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /api/v1/proxies/{id}/trend [get]
+func (h *ProxyHandler) GetResponseTimeTrend(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid proxy ID",
+			Message: "Proxy ID must be a valid integer",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days < 1 || days > 90 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid days",
+			Message: "days must be an integer between 1 and 90",
+			Code:    http.StatusBadRequest,
+		})
+		return
+	}
+
+	trend, err := h.proxyService.GetResponseTimeTrend(c.Request.Context(), id, days)
+	if err != nil {
+		if err.Error() == "proxy not found" {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Error:   "Proxy not found",
+				Message: err.Error(),
+				Code:    http.StatusNotFound,
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get response time trend",
+			Message: err.Error(),
+			Code:    http.StatusInternalServerError,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
 // GetPerformanceStats returns proxy performance statistics
 // @Summary Get proxy performance statistics
 // @Description Get proxy performance and response time statistics
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// hashRing maps keys onto proxies using consistent hashing with virtual nodes,
+// so adding or removing a proxy only remaps a fraction of keys
+type hashRing struct {
+	vnodes int
+	hashes []uint32
+	owners map[uint32]int
+}
+
+// newHashRing builds a ring over the given proxy IDs
+func newHashRing(proxyIDs []int, vnodes int) *hashRing {
+	if vnodes < 1 {
+		vnodes = 1
+	}
+
+	r := &hashRing{
+		vnodes: vnodes,
+		owners: make(map[uint32]int, len(proxyIDs)*vnodes),
+	}
+
+	for _, id := range proxyIDs {
+		for i := 0; i < vnodes; i++ {
+			h := hashKey(strconv.Itoa(id) + "#" + strconv.Itoa(i))
+			owner, exists := r.owners[h]
+			if !exists {
+				r.hashes = append(r.hashes, h)
+			} else if owner < id {
+				// On the rare collision keep the lower ID so the ring is deterministic
+				continue
+			}
+			r.owners[h] = id
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Get returns the proxy ID owning the given key
+func (r *hashRing) Get(key string) (int, bool) {
+	if len(r.hashes) == 0 {
+		return 0, false
+	}
+
+	h := hashKey(key)
+	idx := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if idx == len(r.hashes) {
+		idx = 0
+	}
+
+	return r.owners[r.hashes[idx]], true
+}
+
+func hashKey(key string) uint32 {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(key))
+	return hasher.Sum32()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRingMinimalRemapping(t *testing.T) {
+	proxyIDs := []int{1, 2, 3, 4, 5}
+	before := newHashRing(proxyIDs, 100)
+	after := newHashRing([]int{1, 2, 3, 5}, 100)
+
+	const accounts = 10000
+	moved := 0
+	for i := 0; i < accounts; i++ {
+		key := strconv.Itoa(i)
+		oldID, ok := before.Get(key)
+		assert.True(t, ok)
+		newID, ok := after.Get(key)
+		assert.True(t, ok)
+
+		if oldID != newID {
+			// Only accounts on the removed proxy may move
+			assert.Equal(t, 4, oldID)
+			moved++
+		}
+	}
+
+	// Roughly 1/5 of accounts lived on the removed proxy
+	assert.Greater(t, moved, 0)
+	assert.Less(t, moved, accounts*2/5)
+}
+
+func TestHashRingStable(t *testing.T) {
+	ring := newHashRing([]int{3, 1, 2}, 50)
+	other := newHashRing([]int{1, 2, 3}, 50)
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		a, _ := ring.Get(key)
+		b, _ := other.Get(key)
+		assert.Equal(t, a, b)
+	}
+}
+
+func TestHashRingEmpty(t *testing.T) {
+	ring := newHashRing(nil, 100)
+	_, ok := ring.Get("1")
+	assert.False(t, ok)
+}
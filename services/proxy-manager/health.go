@@ -3,12 +3,15 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/bsky-automation/shared/models"
 	"github.com/bsky-automation/shared/utils"
@@ -20,19 +23,56 @@ type HealthService struct {
 	rdb *redis.Client
 	proxyService *ProxyService
 	stopChan     chan struct{}
+	stopOnce     sync.Once
 	wg           sync.WaitGroup
+
+	// cycles tracks scheduled health check cycles so shutdown can wait for
+	// the one in progress
+	cycles utils.InFlight
+
+	// jitter returns how long to wait before checking a proxy, spreading a
+	// cycle's checks over the jitter window; nil checks immediately
+	jitter func() time.Duration
+	// check runs one proxy's health check; nil uses checkProxyHealth
+	check func(ctx context.Context, proxy *models.Proxy)
+
+	// events receives ProxyFailed events; nil raises the failure alert
+	// directly instead
+	events *utils.EventBus
 }
 
+// healthCheckTimeout bounds a single proxy health check
+const healthCheckTimeout = 30 * time.Second
+
 // NewHealthService creates a new health service
 func NewHealthService(db *sql.DB, rdb *redis.Client) *HealthService {
+	interval := time.Duration(utils.GetEnvAsInt("PROXY_HEALTH_CHECK_INTERVAL", 300)) * time.Second
+	window := healthCheckJitterWindow(interval, time.Duration(utils.GetEnvAsInt("PROXY_HEALTH_CHECK_JITTER", 30))*time.Second)
+
 	return &HealthService{
 		db:  db,
 		rdb: rdb,
 		proxyService: NewProxyService(db, rdb),
 		stopChan: make(chan struct{}),
+		jitter: func() time.Duration {
+			return utils.RandomDelay(0, int(window/time.Second))
+		},
 	}
 }
 
+// healthCheckJitterWindow caps the requested jitter window so a check started
+// at the end of it still finishes before the next cycle begins
+func healthCheckJitterWindow(interval, requested time.Duration) time.Duration {
+	limit := interval - healthCheckTimeout
+	if requested > limit {
+		requested = limit
+	}
+	if requested < 0 {
+		return 0
+	}
+	return requested
+}
+
 // StartHealthCheckScheduler starts the health check scheduler
 func (h *HealthService) StartHealthCheckScheduler(ctx context.Context) {
 	log.Println("Starting proxy health check scheduler...")
@@ -42,13 +82,23 @@ func (h *HealthService) StartHealthCheckScheduler(ctx context.Context) {
 	ticker := time.NewTicker(time.Duration(interval) * time.Second)
 	defer ticker.Stop()
 
+	// Restore a consistent baseline left by a previous run
+	if err := h.reconcileOnStartup(ctx); err != nil {
+		log.Printf("Failed to reconcile proxy health state: %v", err)
+	}
+
 	// Run initial health check
-	h.runHealthCheckCycle(ctx)
+	if !h.runScheduledCycle(ctx) {
+		return
+	}
 
 	for {
 		select {
 		case <-ticker.C:
-			h.runHealthCheckCycle(ctx)
+			if !h.runScheduledCycle(ctx) {
+				log.Println("Health check scheduler stopped")
+				return
+			}
 		case <-h.stopChan:
 			log.Println("Health check scheduler stopped")
 			return
@@ -59,10 +109,35 @@ func (h *HealthService) StartHealthCheckScheduler(ctx context.Context) {
 	}
 }
 
-// StopHealthCheckScheduler stops the health check scheduler
-func (h *HealthService) StopHealthCheckScheduler() {
-	close(h.stopChan)
-	h.wg.Wait()
+// StopHealthCheckScheduler stops the health check scheduler and waits for a
+// cycle in progress to finish, or for ctx to end
+func (h *HealthService) StopHealthCheckScheduler(ctx context.Context) error {
+	h.stopOnce.Do(func() { close(h.stopChan) })
+	return h.cycles.Drain(ctx)
+}
+
+// runScheduledCycle runs one health check cycle unless the scheduler is being
+// stopped, reporting whether the scheduler should keep going. A cycle is
+// skipped while automation is paused, or when the pause setting cannot be
+// read, since a cycle writes proxy status.
+func (h *HealthService) runScheduledCycle(ctx context.Context) bool {
+	if !h.cycles.Start() {
+		return false
+	}
+	defer h.cycles.Done()
+
+	paused, err := utils.AutomationPaused(ctx, h.db)
+	if err != nil {
+		log.Printf("Skipping health check cycle: %v", err)
+		return true
+	}
+	if paused {
+		log.Println("Skipping health check cycle: automation is paused")
+		return true
+	}
+
+	h.runHealthCheckCycle(ctx)
+	return true
 }
 
 // runHealthCheckCycle runs a complete health check cycle for all active proxies
@@ -87,17 +162,34 @@ func (h *HealthService) runHealthCheckCycle(ctx context.Context) {
 	maxConcurrent := utils.GetEnvAsInt("MAX_CONCURRENT_HEALTH_CHECKS", 10)
 	semaphore := make(chan struct{}, maxConcurrent)
 
+	check := h.check
+	if check == nil {
+		check = h.checkProxyHealth
+	}
+
 	// Check each proxy concurrently
 	for _, proxy := range proxies {
 		h.wg.Add(1)
 		go func(p models.Proxy) {
 			defer h.wg.Done()
-			
+
+			// Spread checks over the jitter window so proxies are not all
+			// checked in one burst; a stop request skips the remaining ones
+			if h.jitter != nil {
+				select {
+				case <-time.After(h.jitter()):
+				case <-h.stopChan:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+
 			// Acquire semaphore
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			h.checkProxyHealth(ctx, &p)
+			check(ctx, &p)
 		}(proxy)
 	}
 
@@ -111,7 +203,7 @@ func (h *HealthService) checkProxyHealth(ctx context.Context, proxy *models.Prox
 	log.Printf("Checking health of proxy %s (%s:%d)", proxy.Name, proxy.Host, proxy.Port)
 
 	// Create a timeout context for the health check
-	checkCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
 	defer cancel()
 
 	start := time.Now()
@@ -122,6 +214,13 @@ func (h *HealthService) checkProxyHealth(ctx context.Context, proxy *models.Prox
 	err := h.proxyService.testProxyConnection(checkCtx, proxy)
 	duration := time.Since(start)
 
+	// A rejected health check URL is a configuration error, not a sign the
+	// proxy is down, so it neither counts as a failure nor changes status
+	if errors.Is(err, ErrHealthCheckURLNotAllowed) {
+		log.Printf("CONFIG ERROR: skipped health check of proxy %s: %v", proxy.Name, err)
+		return
+	}
+
 	if err != nil {
 		success = false
 		errorMsg = err.Error()
@@ -136,6 +235,18 @@ func (h *HealthService) checkProxyHealth(ctx context.Context, proxy *models.Prox
 		log.Printf("Failed to update health status for proxy %s: %v", proxy.Name, err)
 	}
 
+	// Optionally confirm Bluesky itself is reachable through the proxy
+	if success && utils.GetEnvAsBool("PROXY_BSKY_PROBE_ENABLED", false) {
+		reachable := true
+		if err := h.proxyService.testBskyReachability(checkCtx, proxy); err != nil {
+			reachable = false
+			log.Printf("Proxy %s passed health check but cannot reach Bluesky: %v", proxy.Name, err)
+		}
+		if err := h.proxyService.updateProxyBskyReachable(ctx, proxy.ID, reachable); err != nil {
+			log.Printf("Failed to update Bluesky reachability for proxy %s: %v", proxy.Name, err)
+		}
+	}
+
 	// Update proxy status based on consecutive failures
 	if !success {
 		h.handleProxyFailure(ctx, proxy)
@@ -144,14 +255,104 @@ func (h *HealthService) checkProxyHealth(ctx context.Context, proxy *models.Prox
 	}
 }
 
-// getActiveProxies retrieves all active proxies that need health checking
+// reconcileOnStartup brings proxy status in line with the last recorded
+// health result and drops failure counters a previous run left behind, e.g.
+// when the process stopped between a health update and the status change.
+// Error proxies are only restored if the health checks quarantined them.
+func (h *HealthService) reconcileOnStartup(ctx context.Context) error {
+	query := `
+		SELECT id, status, health_check_success
+		FROM proxies
+		WHERE status IN ('active', 'error') AND health_check_enabled = true
+	`
+
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query proxies for reconciliation: %w", err)
+	}
+
+	type proxyState struct {
+		id      int
+		status  models.ProxyStatus
+		healthy bool
+	}
+	var states []proxyState
+	for rows.Next() {
+		var state proxyState
+		if err := rows.Scan(&state.id, &state.status, &state.healthy); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan proxy: %w", err)
+		}
+		states = append(states, state)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read proxies for reconciliation: %w", err)
+	}
+
+	maxFailures := utils.GetEnvAsInt("MAX_PROXY_FAILURES", 3)
+	for _, state := range states {
+		failureKey := fmt.Sprintf("proxy_failures:%d", state.id)
+		failures, err := h.rdb.Get(ctx, failureKey).Int()
+		if err != nil && err != redis.Nil {
+			return fmt.Errorf("failed to get failure count for proxy %d: %w", state.id, err)
+		}
+
+		// Only proxies the health checks quarantined are restored; an
+		// operator's error status stays until the operator changes it
+		quarantined, err := h.rdb.Exists(ctx, quarantineKey(state.id)).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check quarantine of proxy %d: %w", state.id, err)
+		}
+
+		switch {
+		case state.healthy && state.status == models.ProxyStatusError && quarantined > 0:
+			log.Printf("Proxy %d passed its last health check, restoring to active", state.id)
+			if err := h.updateProxyStatus(ctx, state.id, models.ProxyStatusActive); err != nil {
+				return fmt.Errorf("failed to restore proxy %d: %w", state.id, err)
+			}
+			h.rdb.Del(ctx, failureKey, quarantineKey(state.id))
+		case state.healthy:
+			h.rdb.Del(ctx, failureKey)
+		case state.status == models.ProxyStatusActive && failures >= maxFailures:
+			log.Printf("Proxy %d reached %d failures before restart, marking as error", state.id, failures)
+			if err := h.quarantineProxy(ctx, state.id); err != nil {
+				return fmt.Errorf("failed to mark proxy %d as error: %w", state.id, err)
+			}
+			h.rdb.Del(ctx, failureKey)
+		}
+	}
+
+	// Counters are meant to expire after an hour; one without a TTL was
+	// left behind by an interrupted update and would never reset
+	iter := h.rdb.Scan(ctx, 0, "proxy_failures:*", 100).Iterator()
+	for iter.Next(ctx) {
+		ttl, err := h.rdb.TTL(ctx, iter.Val()).Result()
+		if err != nil {
+			return fmt.Errorf("failed to get TTL for %s: %w", iter.Val(), err)
+		}
+		if ttl < 0 {
+			h.rdb.Del(ctx, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan failure counters: %w", err)
+	}
+
+	return nil
+}
+
+// getActiveProxies retrieves active proxies with health checks enabled, least
+// recently checked first. Proxies with checks disabled are left exactly as
+// they are: no check, no failure count and no status change.
 func (h *HealthService) getActiveProxies(ctx context.Context) ([]models.Proxy, error) {
 	query := `
 		SELECT id, uuid, name, type, host, port, username, password, status,
-		       health_check_url, last_health_check, health_check_success,
-		       response_time_ms, created_at, updated_at
+		       health_check_url, last_health_check, health_check_success, health_check_enabled,
+		       response_time_ms, created_at, updated_at, ` + proxyTagsColumn + `
 		FROM proxies
-		WHERE status = 'active'
+		WHERE status = 'active' AND health_check_enabled = true
 		ORDER BY last_health_check ASC NULLS FIRST
 	`
 
@@ -167,8 +368,8 @@ func (h *HealthService) getActiveProxies(ctx context.Context) ([]models.Proxy, e
 		err := rows.Scan(
 			&proxy.ID, &proxy.UUID, &proxy.Name, &proxy.Type, &proxy.Host,
 			&proxy.Port, &proxy.Username, &proxy.Password, &proxy.Status,
-			&proxy.HealthCheckURL, &proxy.LastHealthCheck, &proxy.HealthCheckSuccess,
-			&proxy.ResponseTimeMs, &proxy.CreatedAt, &proxy.UpdatedAt,
+			&proxy.HealthCheckURL, &proxy.LastHealthCheck, &proxy.HealthCheckSuccess, &proxy.HealthCheckEnabled,
+			&proxy.ResponseTimeMs, &proxy.CreatedAt, &proxy.UpdatedAt, pq.Array(&proxy.Tags),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan proxy: %w", err)
@@ -195,6 +396,8 @@ func (h *HealthService) updateProxyHealthStatus(ctx context.Context, proxyID int
 		return fmt.Errorf("failed to update proxy health status: %w", err)
 	}
 
+	recordHealthCheck(ctx, h.db, proxyID, success, responseTimeMs)
+
 	// Store health check result in Redis for metrics
 	healthKey := fmt.Sprintf("proxy_health:%d", proxyID)
 	healthData := map[string]interface{}{
@@ -233,7 +436,7 @@ func (h *HealthService) handleProxyFailure(ctx context.Context, proxy *models.Pr
 		log.Printf("Proxy %s has %d consecutive failures, marking as error", proxy.Name, failures)
 		
 		// Update proxy status to error
-		err = h.updateProxyStatus(ctx, proxy.ID, models.ProxyStatusError)
+		err = h.quarantineProxy(ctx, proxy.ID)
 		if err != nil {
 			log.Printf("Failed to update proxy status to error: %v", err)
 		}
@@ -241,8 +444,22 @@ func (h *HealthService) handleProxyFailure(ctx context.Context, proxy *models.Pr
 		// Reset failure counter
 		h.rdb.Del(ctx, failureKey)
 
-		// Notify about proxy failure (could send to monitoring system)
-		h.notifyProxyFailure(ctx, proxy, int(failures))
+		failed := utils.ProxyFailed{
+			ProxyID:  proxy.ID,
+			Name:     proxy.Name,
+			Host:     proxy.Host,
+			Port:     proxy.Port,
+			Failures: int(failures),
+			At:       time.Now(),
+		}
+
+		// Subscribers alert on it and count it; without a bus the alert is
+		// still raised directly
+		if h.events == nil {
+			h.notifyProxyFailure(ctx, failed)
+		} else {
+			h.events.Publish(ctx, failed)
+		}
 	}
 }
 
@@ -259,9 +476,26 @@ func (h *HealthService) handleProxySuccess(ctx context.Context, proxy *models.Pr
 		if err != nil {
 			log.Printf("Failed to update proxy status to active: %v", err)
 		}
+		h.rdb.Del(ctx, quarantineKey(proxy.ID))
 	}
 }
 
+// quarantineKey marks a proxy the health checks moved to error, as opposed to
+// one an operator set to error
+func quarantineKey(proxyID int) string {
+	return fmt.Sprintf("proxy_quarantined:%d", proxyID)
+}
+
+// quarantineProxy marks a failing proxy as error on behalf of the health
+// checks. The marker is set first, so an interrupted update never leaves an
+// error proxy that reconciliation would not restore.
+func (h *HealthService) quarantineProxy(ctx context.Context, proxyID int) error {
+	if err := h.rdb.Set(ctx, quarantineKey(proxyID), "1", 0).Err(); err != nil {
+		return fmt.Errorf("failed to mark proxy quarantined: %w", err)
+	}
+	return h.updateProxyStatus(ctx, proxyID, models.ProxyStatusError)
+}
+
 // updateProxyStatus updates the status of a proxy
 func (h *HealthService) updateProxyStatus(ctx context.Context, proxyID int, status models.ProxyStatus) error {
 	query := "UPDATE proxies SET status = $1, updated_at = NOW() WHERE id = $2"
@@ -269,21 +503,24 @@ func (h *HealthService) updateProxyStatus(ctx context.Context, proxyID int, stat
 	return err
 }
 
-// notifyProxyFailure sends notification about proxy failure
-func (h *HealthService) notifyProxyFailure(ctx context.Context, proxy *models.Proxy, failures int) {
+// notifyProxyFailure sends notification about proxy failure. It subscribes
+// to ProxyFailed events.
+func (h *HealthService) notifyProxyFailure(ctx context.Context, event utils.Event) {
+	failed := event.(utils.ProxyFailed)
+
 	// This could send notifications to Slack, email, or monitoring systems
 	log.Printf("ALERT: Proxy %s (%s:%d) has failed %d times and is now marked as error", 
-		proxy.Name, proxy.Host, proxy.Port, failures)
+		failed.Name, failed.Host, failed.Port, failed.Failures)
 
 	// Store alert in Redis for dashboard
-	alertKey := fmt.Sprintf("proxy_alert:%d:%d", proxy.ID, time.Now().Unix())
+	alertKey := fmt.Sprintf("proxy_alert:%d:%d", failed.ProxyID, failed.At.Unix())
 	alertData := map[string]interface{}{
-		"proxy_id":     proxy.ID,
-		"proxy_name":   proxy.Name,
-		"proxy_host":   proxy.Host,
-		"proxy_port":   proxy.Port,
-		"failure_count": failures,
-		"timestamp":    time.Now().Unix(),
+		"proxy_id":     failed.ProxyID,
+		"proxy_name":   failed.Name,
+		"proxy_host":   failed.Host,
+		"proxy_port":   failed.Port,
+		"failure_count": failed.Failures,
+		"timestamp":    failed.At.Unix(),
 		"type":         "proxy_failure",
 	}
 
@@ -291,11 +528,45 @@ func (h *HealthService) notifyProxyFailure(ctx context.Context, proxy *models.Pr
 	h.rdb.Expire(ctx, alertKey, 7*24*time.Hour) // Keep alerts for 7 days
 }
 
-// GetHealthMetrics returns health metrics for monitoring
+// GetHealthMetrics returns health metrics for monitoring. The SQL aggregate
+// and the live Redis samples are read concurrently; if Redis is unavailable
+// the SQL metrics are still returned with redis_available set to false.
 func (h *HealthService) GetHealthMetrics(ctx context.Context) (map[string]interface{}, error) {
 	metrics := make(map[string]interface{})
 
-	// Get overall health statistics
+	var g errgroup.Group
+	var live *liveHealthMetrics
+	var liveErr error
+
+	g.Go(func() error {
+		return h.collectSQLHealthMetrics(ctx, metrics)
+	})
+	g.Go(func() error {
+		// Redis failures degrade the response instead of failing it
+		live, liveErr = h.collectLiveHealthMetrics(ctx)
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	metrics["redis_available"] = liveErr == nil
+	if liveErr != nil {
+		log.Printf("Failed to read live health metrics from Redis: %v", liveErr)
+		return metrics, nil
+	}
+
+	metrics["quarantined_proxies"] = live.quarantined
+	if live.mostRecentCheck > 0 {
+		metrics["most_recent_check_age_seconds"] = time.Now().Unix() - live.mostRecentCheck
+	}
+
+	return metrics, nil
+}
+
+// collectSQLHealthMetrics fills metrics with the aggregate proxy health
+func (h *HealthService) collectSQLHealthMetrics(ctx context.Context, metrics map[string]interface{}) error {
 	healthQuery := `
 		SELECT 
 			COUNT(*) as total_proxies,
@@ -312,7 +583,7 @@ func (h *HealthService) GetHealthMetrics(ctx context.Context) (map[string]interf
 	err := h.db.QueryRowContext(ctx, healthQuery).Scan(
 		&totalProxies, &activeProxies, &healthyProxies, &errorProxies, &avgResponseTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get health metrics: %w", err)
+		return fmt.Errorf("failed to get health metrics: %w", err)
 	}
 
 	metrics["total_proxies"] = totalProxies
@@ -331,5 +602,50 @@ func (h *HealthService) GetHealthMetrics(ctx context.Context) (map[string]interf
 		metrics["avg_response_time_ms"] = 0.0
 	}
 
-	return metrics, nil
+	return nil
+}
+
+// liveHealthMetrics holds the per-proxy samples kept in Redis
+type liveHealthMetrics struct {
+	// quarantined counts proxies failing consecutive checks that have not
+	// yet been marked as error
+	quarantined int
+	// mostRecentCheck is the Unix time of the newest stored check result
+	mostRecentCheck int64
+}
+
+// collectLiveHealthMetrics reads the failure counters and latest check
+// results stored by the health check cycle
+func (h *HealthService) collectLiveHealthMetrics(ctx context.Context) (*liveHealthMetrics, error) {
+	live := &liveHealthMetrics{}
+
+	iter := h.rdb.Scan(ctx, 0, "proxy_failures:*", 100).Iterator()
+	for iter.Next(ctx) {
+		failures, err := h.rdb.Get(ctx, iter.Val()).Int()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get %s: %w", iter.Val(), err)
+		}
+		if failures > 0 {
+			live.quarantined++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan failure counters: %w", err)
+	}
+
+	iter = h.rdb.Scan(ctx, 0, "proxy_health:*", 100).Iterator()
+	for iter.Next(ctx) {
+		timestamp, err := h.rdb.HGet(ctx, iter.Val(), "timestamp").Int64()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get %s: %w", iter.Val(), err)
+		}
+		if timestamp > live.mostRecentCheck {
+			live.mostRecentCheck = timestamp
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan health results: %w", err)
+	}
+
+	return live, nil
 }
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+func TestReconcileOnStartup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	// Proxy 1 recovered but is still quarantined, proxy 2 hit the failure
+	// limit without being marked, proxy 3 is healthy with a leftover counter,
+	// proxy 5 passed its last check but an operator set it to error, and
+	// proxy 9 no longer exists but its counter never got a TTL
+	mr.Set(quarantineKey(1), "1")
+	mr.Set("proxy_failures:2", "3")
+	mr.SetTTL("proxy_failures:2", time.Hour)
+	mr.Set("proxy_failures:3", "1")
+	mr.SetTTL("proxy_failures:3", time.Hour)
+	mr.Set("proxy_failures:4", "1")
+	mr.SetTTL("proxy_failures:4", time.Hour)
+	mr.Set("proxy_failures:9", "2")
+
+	mock.ExpectQuery(`SELECT id, status, health_check_success\s+FROM proxies\s+WHERE status IN \('active', 'error'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "health_check_success"}).
+			AddRow(1, "error", true).
+			AddRow(2, "active", false).
+			AddRow(3, "active", true).
+			AddRow(4, "active", false).
+			AddRow(5, "error", true))
+	mock.ExpectExec(`UPDATE proxies SET status = \$1`).
+		WithArgs("active", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE proxies SET status = \$1`).
+		WithArgs("error", 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := &HealthService{db: db, rdb: rdb}
+	assert.NoError(t, service.reconcileOnStartup(context.Background()))
+
+	assert.False(t, mr.Exists(quarantineKey(1)))
+	assert.True(t, mr.Exists(quarantineKey(2)))
+	assert.False(t, mr.Exists("proxy_failures:2"))
+	assert.False(t, mr.Exists("proxy_failures:3"))
+	assert.False(t, mr.Exists("proxy_failures:9"))
+	// Proxy 4 is below the limit, so its counter keeps counting
+	assert.True(t, mr.Exists("proxy_failures:4"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReconcileOnStartupRowError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	mr.Set(quarantineKey(1), "1")
+
+	// A read that fails partway must not reconcile the rows it did get
+	mock.ExpectQuery(`SELECT id, status, health_check_success\s+FROM proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "health_check_success"}).
+			AddRow(1, "error", true).
+			AddRow(2, "active", false).
+			RowError(1, errors.New("connection reset")))
+
+	service := &HealthService{db: db, rdb: rdb}
+	assert.ErrorContains(t, service.reconcileOnStartup(context.Background()), "connection reset")
+	assert.True(t, mr.Exists(quarantineKey(1)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var cycleProxyColumns = []string{
+	"id", "uuid", "name", "type", "host", "port", "username", "password", "status",
+	"health_check_url", "last_health_check", "health_check_success", "health_check_enabled",
+	"response_time_ms", "created_at", "updated_at", "tags",
+}
+
+func TestHealthCheckCycleSkipsDisabledProxies(t *testing.T) {
+	t.Setenv("MAX_PROXY_FAILURES", "1")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	// Nothing listens on either proxy's port, so a check of either fails
+	// and, with MAX_PROXY_FAILURES=1, marks it error
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	deadPort := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	healthURL := "http://generic.test/ip"
+	now := time.Now()
+	// The query itself excludes proxy 2, whose checks are disabled
+	mock.ExpectQuery(`FROM proxies\s+WHERE status = 'active' AND health_check_enabled = true\s+ORDER BY`).
+		WillReturnRows(sqlmock.NewRows(cycleProxyColumns).
+			AddRow(1, utils.GenerateUUID().String(), "enabled", "http", "127.0.0.1", deadPort, nil, nil, "active",
+				healthURL, nil, true, true, 0, now, now, "{}"))
+
+	// Only proxy 1 is checked and marked error; any statement for proxy 2
+	// would be unexpected
+	mock.ExpectExec(`UPDATE proxies\s+SET health_check_success = \$1`).
+		WithArgs(false, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO proxy_health_checks`).
+		WithArgs(1, false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE proxies SET status = \$1`).
+		WithArgs("error", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Proxy 2 already has a failure on record from before checks were
+	// disabled; it must stay as it is
+	mr.Set("proxy_failures:2", "1")
+
+	service := &HealthService{
+		db:           db,
+		rdb:          rdb,
+		proxyService: &ProxyService{},
+		stopChan:     make(chan struct{}),
+	}
+	service.runHealthCheckCycle(context.Background())
+
+	value, err := mr.Get("proxy_failures:2")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+	assert.False(t, mr.Exists("proxy_health:2"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestScheduledCycleSkippedWhileAutomationPaused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The proxy query would come next; it is not expected, so running the
+	// cycle fails the test
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WithArgs(utils.AutomationPausedSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("true"))
+
+	service := &HealthService{db: db}
+	assert.True(t, service.runScheduledCycle(context.Background()), "the scheduler keeps ticking while paused")
+
+	// Resuming lets the next tick run a cycle
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow("false"))
+	mock.ExpectQuery(`FROM proxies\s+WHERE status = 'active' AND health_check_enabled = true`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	assert.True(t, service.runScheduledCycle(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetHealthCheckEnabledUnknownProxy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE proxies SET health_check_enabled = \$1`).
+		WithArgs(false, 42).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := &ProxyService{db: db}
+	_, err = service.SetHealthCheckEnabled(context.Background(), 42, false)
+	assert.EqualError(t, err, "proxy not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var healthMetricsColumns = []string{"total_proxies", "active_proxies", "healthy_proxies", "error_proxies", "avg_response_time"}
+
+func TestGetHealthMetricsIncludesLiveSamples(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	mr.Set("proxy_failures:1", "2")
+	mr.Set("proxy_failures:2", "0")
+	mr.HSet("proxy_health:1", "timestamp", fmt.Sprint(time.Now().Add(-30*time.Second).Unix()))
+	mr.HSet("proxy_health:2", "timestamp", fmt.Sprint(time.Now().Add(-10*time.Minute).Unix()))
+
+	mock.ExpectQuery(`FROM proxies`).
+		WillReturnRows(sqlmock.NewRows(healthMetricsColumns).AddRow(4, 3, 2, 1, 120.0))
+
+	service := &HealthService{db: db, rdb: rdb}
+	metrics, err := service.GetHealthMetrics(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, metrics["total_proxies"])
+	assert.Equal(t, true, metrics["redis_available"])
+	assert.Equal(t, 1, metrics["quarantined_proxies"])
+	assert.InDelta(t, 30, metrics["most_recent_check_age_seconds"], 5)
+}
+
+func TestGetHealthMetricsRedisDown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	mr.Close()
+
+	mock.ExpectQuery(`FROM proxies`).
+		WillReturnRows(sqlmock.NewRows(healthMetricsColumns).AddRow(4, 3, 2, 1, 120.0))
+
+	service := &HealthService{db: db, rdb: rdb}
+	metrics, err := service.GetHealthMetrics(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, metrics["total_proxies"])
+	assert.Equal(t, 2, metrics["healthy_proxies"])
+	assert.Equal(t, false, metrics["redis_available"])
+	assert.NotContains(t, metrics, "quarantined_proxies")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckUsesTagHealthURL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The forward proxy rejects the default test site, as some providers do
+	proxy := newFakeForwardProxy(t, "httpbin.org")
+	proxy.HealthCheckURL = nil
+	proxy.ID = 6
+	proxy.Tags = []string{"zeta", "provider"}
+
+	mock.ExpectQuery(`SELECT key, value FROM system_settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value"}).
+			AddRow("proxy_health_check_url", "http://generic.test/ip").
+			AddRow("proxy_health_check_url:provider", "http://provider.test/health").
+			AddRow("proxy_health_check_url:zeta", "http://zeta.test/health"))
+	mock.ExpectExec(`UPDATE proxies`).
+		WithArgs(true, sqlmock.AnyArg(), 6).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO proxy_health_checks`).
+		WithArgs(6, true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	service := &HealthService{db: db, rdb: rdb, proxyService: &ProxyService{db: db, rdb: rdb, settings: utils.NewSettings(db)}}
+	service.checkProxyHealth(context.Background(), proxy)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckURLFallsBackToDefault(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Only a tag the proxy does not have sets a URL
+	mock.ExpectQuery(`SELECT key, value FROM system_settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value"}).
+			AddRow("proxy_health_check_url:other", "http://other.test/ip"))
+
+	service := &ProxyService{db: db, settings: utils.NewSettings(db)}
+	assert.Equal(t, defaultHealthCheckURL, service.healthCheckURL(context.Background(), &models.Proxy{ID: 3, Tags: []string{"provider"}}))
+
+	own := "http://own.test/ip"
+	assert.Equal(t, own, service.healthCheckURL(context.Background(), &models.Proxy{ID: 3, HealthCheckURL: &own}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckCycleSpreadsChecksOverJitterWindow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(cycleProxyColumns)
+	for i := 1; i <= 3; i++ {
+		rows.AddRow(i, utils.GenerateUUID().String(), fmt.Sprintf("proxy-%d", i), "http", "10.0.0.1", 8080+i, nil, nil, "active",
+			nil, nil, true, true, 0, now, now, "{provider}")
+	}
+	mock.ExpectQuery(`FROM proxies\s+WHERE status = 'active' AND health_check_enabled = true`).WillReturnRows(rows)
+
+	var mu sync.Mutex
+	delays := []time.Duration{0, 60 * time.Millisecond, 120 * time.Millisecond}
+	var started []time.Duration
+
+	start := time.Now()
+	service := &HealthService{
+		db: db,
+		jitter: func() time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			d := delays[0]
+			delays = delays[1:]
+			return d
+		},
+		check: func(ctx context.Context, proxy *models.Proxy) {
+			mu.Lock()
+			defer mu.Unlock()
+			started = append(started, time.Since(start))
+		},
+	}
+	service.runHealthCheckCycle(context.Background())
+
+	if assert.Len(t, started, 3) {
+		sort.Slice(started, func(i, j int) bool { return started[i] < started[j] })
+		assert.Less(t, started[0], 50*time.Millisecond)
+		assert.GreaterOrEqual(t, started[1], 60*time.Millisecond)
+		assert.GreaterOrEqual(t, started[2], 120*time.Millisecond, "checks are spread, not all at t=0")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckJitterWindow(t *testing.T) {
+	assert.Equal(t, 30*time.Second, healthCheckJitterWindow(5*time.Minute, 30*time.Second))
+	// Capped so the last check still finishes before the next tick
+	assert.Equal(t, 30*time.Second, healthCheckJitterWindow(time.Minute, 5*time.Minute))
+	assert.Zero(t, healthCheckJitterWindow(20*time.Second, 10*time.Second))
+	assert.Zero(t, healthCheckJitterWindow(5*time.Minute, 0))
+}
+
+func TestProxyFailurePublishesEvent(t *testing.T) {
+	t.Setenv("MAX_PROXY_FAILURES", "1")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	mock.ExpectExec(`UPDATE proxies SET status = \$1`).
+		WithArgs(models.ProxyStatusError, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := &HealthService{db: db, rdb: rdb, proxyService: &ProxyService{db: db, rdb: rdb}}
+	bus := utils.NewEventBus()
+	wireEvents(bus, rdb, service.proxyService, service)
+
+	var published []utils.ProxyFailed
+	bus.Subscribe(utils.EventProxyFailed, "test", func(ctx context.Context, event utils.Event) {
+		published = append(published, event.(utils.ProxyFailed))
+	})
+
+	proxy := &models.Proxy{ID: 4, Name: "p4", Host: "10.0.0.4", Port: 8080, Status: models.ProxyStatusActive}
+	service.handleProxyFailure(context.Background(), proxy)
+	assert.NoError(t, bus.Wait(context.Background()))
+
+	if assert.Len(t, published, 1) {
+		assert.Equal(t, 4, published[0].ProxyID)
+		assert.Equal(t, 1, published[0].Failures)
+	}
+
+	// The alert subscriber stored the dashboard alert and the failure was counted
+	alerts, err := rdb.Keys(context.Background(), "proxy_alert:4:*").Result()
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+	count, err := rdb.HGet(context.Background(), utils.EventCountsKey, string(utils.EventProxyFailed)).Int()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProxyFailureAlertsWithoutEventBus(t *testing.T) {
+	t.Setenv("MAX_PROXY_FAILURES", "1")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	mock.ExpectExec(`UPDATE proxies SET status = \$1`).
+		WithArgs(models.ProxyStatusError, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	service := &HealthService{db: db, rdb: rdb, proxyService: &ProxyService{db: db, rdb: rdb}}
+
+	proxy := &models.Proxy{ID: 5, Name: "p5", Host: "10.0.0.5", Port: 8080, Status: models.ProxyStatusActive}
+	service.handleProxyFailure(context.Background(), proxy)
+
+	alerts, err := rdb.Keys(context.Background(), "proxy_alert:5:*").Result()
+	assert.NoError(t, err)
+	assert.Len(t, alerts, 1)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
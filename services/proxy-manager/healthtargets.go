@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// ErrHealthCheckURLNotAllowed indicates a health check URL whose target is
+// an internal address or outside the configured allowlist
+var ErrHealthCheckURLNotAllowed = errors.New("health check URL not allowed")
+
+// HealthCheckTargets restricts the URLs proxies are health-checked against, so
+// a crafted health_check_url cannot be used to reach internal endpoints such
+// as cloud metadata. Loopback, link-local and unspecified addresses are always
+// rejected.
+type HealthCheckTargets struct {
+	// AllowedHosts lists the hosts that may be probed over http or https;
+	// "*.example.com" matches its subdomains. Empty allows any https host.
+	AllowedHosts []string
+	// BlockPrivate also rejects RFC 1918 and IPv6 unique local addresses
+	BlockPrivate bool
+}
+
+// loadHealthCheckTargets reads PROXY_HEALTH_CHECK_ALLOWED_HOSTS, a comma
+// separated host list, and PROXY_HEALTH_CHECK_BLOCK_PRIVATE
+func loadHealthCheckTargets() *HealthCheckTargets {
+	targets := &HealthCheckTargets{
+		BlockPrivate: utils.GetEnvAsBool("PROXY_HEALTH_CHECK_BLOCK_PRIVATE", false),
+	}
+	for _, host := range strings.Split(utils.GetEnvOrDefault("PROXY_HEALTH_CHECK_ALLOWED_HOSTS", ""), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			targets.AllowedHosts = append(targets.AllowedHosts, host)
+		}
+	}
+	return targets
+}
+
+// Check returns ErrHealthCheckURLNotAllowed if proxies must not be probed
+// against rawURL. A nil HealthCheckTargets allows every URL. Host names are
+// only matched against the allowlist, not resolved: the proxy resolves them
+// on its side, so only IP literals can be judged by address here.
+func (t *HealthCheckTargets) Check(rawURL string) error {
+	if t == nil {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: %q is not an absolute URL", ErrHealthCheckURLNotAllowed, rawURL)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if ip := net.ParseIP(host); ip != nil {
+		if kind := t.blockedAddress(ip); kind != "" {
+			return fmt.Errorf("%w: %s is a %s address", ErrHealthCheckURLNotAllowed, host, kind)
+		}
+	} else if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s is a loopback address", ErrHealthCheckURLNotAllowed, host)
+	}
+
+	if len(t.AllowedHosts) == 0 {
+		if u.Scheme != "https" {
+			return fmt.Errorf("%w: only https URLs are allowed, got %s", ErrHealthCheckURLNotAllowed, u.Scheme)
+		}
+		return nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https URLs are allowed, got %s", ErrHealthCheckURLNotAllowed, u.Scheme)
+	}
+	if !t.hostAllowed(host) {
+		return fmt.Errorf("%w: host %s is not in the allowlist", ErrHealthCheckURLNotAllowed, host)
+	}
+	return nil
+}
+
+// blockedAddress names the kind of internal address ip is, or returns "" if
+// it may be probed
+func (t *HealthCheckTargets) blockedAddress(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback"
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return "link-local"
+	case ip.IsUnspecified():
+		return "unspecified"
+	case t.BlockPrivate && ip.IsPrivate():
+		return "private"
+	}
+	return ""
+}
+
+func (t *HealthCheckTargets) hostAllowed(host string) bool {
+	for _, allowed := range t.AllowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
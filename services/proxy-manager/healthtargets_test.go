@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+const metadataURL = "http://169.254.169.254/latest/meta-data/"
+
+func TestHealthCheckTargetsCheck(t *testing.T) {
+	open := &HealthCheckTargets{}
+	private := &HealthCheckTargets{BlockPrivate: true}
+	listed := &HealthCheckTargets{AllowedHosts: []string{"health.example.com", "*.probe.example.net", "169.254.169.254"}}
+
+	tests := []struct {
+		name    string
+		targets *HealthCheckTargets
+		url     string
+		allowed bool
+	}{
+		{"any https host", open, "https://httpbin.org/ip", true},
+		{"plain http without allowlist", open, "http://httpbin.org/ip", false},
+		{"metadata endpoint", open, metadataURL, false},
+		{"metadata endpoint over https", open, "https://169.254.169.254/latest/meta-data/", false},
+		{"loopback", open, "https://127.0.0.1:8080/health", false},
+		{"ipv6 loopback", open, "https://[::1]/health", false},
+		{"localhost", open, "https://localhost/health", false},
+		{"ipv6 link-local", open, "https://[fe80::1]/health", false},
+		{"unspecified", open, "https://0.0.0.0/health", false},
+		{"private allowed by default", open, "https://10.0.0.5/health", true},
+		{"private blocked when configured", private, "https://10.0.0.5/health", false},
+		{"ipv6 unique local blocked when configured", private, "https://[fd00::1]/health", false},
+		{"not a URL", open, "httpbin.org/ip", false},
+		{"listed host over http", listed, "http://health.example.com/ip", true},
+		{"listed host case-insensitive", listed, "https://Health.Example.COM/ip", true},
+		{"wildcard subdomain", listed, "https://eu.probe.example.net/ip", true},
+		{"wildcard does not match its base", listed, "https://probe.example.net/ip", false},
+		{"unlisted host", listed, "https://httpbin.org/ip", false},
+		{"listed host with other scheme", listed, "ftp://health.example.com/ip", false},
+		{"listed metadata address", listed, metadataURL, false},
+		{"nil allows everything", nil, metadataURL, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.targets.Check(tt.url)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrHealthCheckURLNotAllowed)
+			}
+		})
+	}
+}
+
+func TestLoadHealthCheckTargets(t *testing.T) {
+	t.Setenv("PROXY_HEALTH_CHECK_ALLOWED_HOSTS", " Health.Example.com, ,*.probe.example.net")
+	t.Setenv("PROXY_HEALTH_CHECK_BLOCK_PRIVATE", "true")
+
+	targets := loadHealthCheckTargets()
+	assert.Equal(t, []string{"health.example.com", "*.probe.example.net"}, targets.AllowedHosts)
+	assert.True(t, targets.BlockPrivate)
+}
+
+func TestMetadataHealthCheckURLRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	handler := &ProxyHandler{
+		proxyService: &ProxyService{db: db, targets: &HealthCheckTargets{}},
+		validator:    validator.New(),
+	}
+	router := gin.New()
+	router.POST("/proxies", handler.CreateProxy)
+	router.PUT("/proxies/:id", handler.UpdateProxy)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Rejected before anything is stored
+	w := serve("POST", "/proxies", `{"name": "p", "type": "http", "host": "1.2.3.4", "port": 8080, "health_check_url": "`+metadataURL+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "link-local")
+
+	expectGetProxy(mock, 1)
+	w = serve("PUT", "/proxies/1", `{"health_check_url": "`+metadataURL+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTestProxyConnectionChecksTarget(t *testing.T) {
+	// The fake proxy answers every URL, so only the check can fail the probe
+	proxy := newFakeForwardProxy(t, "")
+	target := metadataURL
+	proxy.HealthCheckURL = &target
+
+	service := &ProxyService{targets: &HealthCheckTargets{}}
+	err := service.testProxyConnection(context.Background(), proxy)
+	assert.ErrorIs(t, err, ErrHealthCheckURLNotAllowed)
+	assert.Equal(t, ProxyErrorTargetNotAllowed, classifyProxyError(err))
+
+	// A nil policy leaves the probe unrestricted
+	service.targets = nil
+	assert.NoError(t, service.testProxyConnection(context.Background(), proxy))
+}
+
+func TestScheduledCheckSkipsDisallowedTarget(t *testing.T) {
+	t.Setenv("MAX_PROXY_FAILURES", "1")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	proxy := newFakeForwardProxy(t, "")
+	target := metadataURL
+	proxy.HealthCheckURL = &target
+	proxy.Status = models.ProxyStatusActive
+
+	// No health result, failure count or status change is recorded; any
+	// statement would be unexpected
+	service := &HealthService{db: db, rdb: rdb, proxyService: &ProxyService{db: db, rdb: rdb, targets: &HealthCheckTargets{}}}
+	service.checkProxyHealth(context.Background(), proxy)
+
+	assert.Empty(t, mr.Keys())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// AssignmentHistoryFilter narrows the assignment history. From and To select
+// assignments that overlapped the window, i.e. were held at any point in it.
+type AssignmentHistoryFilter struct {
+	AccountID  *int
+	ProxyID    *int
+	Strategy   string
+	ActiveOnly bool
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	PageSize   int
+}
+
+// ListAssignmentHistory returns proxy assignments, newest first
+func (s *ProxyService) ListAssignmentHistory(ctx context.Context, filter AssignmentHistoryFilter) (*models.ListResponse, error) {
+	query := utils.PaginatedQuery{
+		Select:   "SELECT id, account_id, proxy_id, strategy, assigned_at, released_at",
+		From:     "FROM proxy_assignments",
+		OrderBy:  "assigned_at DESC, id DESC",
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+		Limits:   s.pages.Limits(ctx),
+	}
+	if filter.AccountID != nil {
+		query.Where("account_id = $%d", *filter.AccountID)
+	}
+	if filter.ProxyID != nil {
+		query.Where("proxy_id = $%d", *filter.ProxyID)
+	}
+	if filter.Strategy != "" {
+		query.Where("strategy = $%d", filter.Strategy)
+	}
+	if filter.ActiveOnly {
+		query.Conditions = append(query.Conditions, "released_at IS NULL")
+	}
+	if filter.From != nil {
+		query.Where("(released_at IS NULL OR released_at >= $%d)", *filter.From)
+	}
+	if filter.To != nil {
+		query.Where("assigned_at <= $%d", *filter.To)
+	}
+
+	entries := []AssignmentHistoryEntry{}
+	pagination, err := utils.RunPaginatedQuery(ctx, s.db, query, func(rows *sql.Rows) error {
+		var entry AssignmentHistoryEntry
+		var proxyID sql.NullInt64
+		if err := rows.Scan(&entry.ID, &entry.AccountID, &proxyID, &entry.Strategy, &entry.AssignedAt, &entry.ReleasedAt); err != nil {
+			return fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		if proxyID.Valid {
+			id := int(proxyID.Int64)
+			entry.ProxyID = &id
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list assignment history: %w", err)
+	}
+
+	return &models.ListResponse{
+		Data:       entries,
+		Pagination: *pagination,
+	}, nil
+}
+
+// recordAssignment closes the account's open assignment, if any, and opens
+// one for proxyID
+func recordAssignment(ctx context.Context, tx *sql.Tx, accountID, proxyID int, strategy string) error {
+	if err := closeAssignment(ctx, tx, accountID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO proxy_assignments (account_id, proxy_id, strategy) VALUES ($1, $2, $3)"
+	if _, err := tx.ExecContext(ctx, query, accountID, proxyID, strategy); err != nil {
+		return fmt.Errorf("failed to record assignment history: %w", err)
+	}
+	return nil
+}
+
+// closeAssignment marks the account's open assignment as released
+func closeAssignment(ctx context.Context, tx *sql.Tx, accountID int) error {
+	query := "UPDATE proxy_assignments SET released_at = NOW() WHERE account_id = $1 AND released_at IS NULL"
+	if _, err := tx.ExecContext(ctx, query, accountID); err != nil {
+		return fmt.Errorf("failed to close assignment history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var historyColumns = []string{"id", "account_id", "proxy_id", "strategy", "assigned_at", "released_at"}
+
+func newHistoryRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.GET("/assignment/history", handler.GetAssignmentHistory)
+	return router, mock
+}
+
+func TestAssignmentHistoryActiveOnly(t *testing.T) {
+	router, mock := newHistoryRouter(t)
+
+	mock.ExpectQuery(`FROM proxy_assignments WHERE account_id = \$1 AND released_at IS NULL ORDER BY assigned_at DESC, id DESC LIMIT \$2 OFFSET \$3`).
+		WithArgs(7, 10, 0).
+		WillReturnRows(sqlmock.NewRows(historyColumns).AddRow(3, 7, 2, "least_used", time.Now(), nil))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxy_assignments WHERE account_id = \$1 AND released_at IS NULL`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, _ := http.NewRequest("GET", "/assignment/history?account_id=7&active=true", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"strategy":"least_used"`)
+	assert.NotContains(t, w.Body.String(), "released_at")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignmentHistoryTimeWindow(t *testing.T) {
+	router, mock := newHistoryRouter(t)
+
+	from := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Assignments that overlap the window: still open or released after it
+	// started, and made before it ended
+	mock.ExpectQuery(`WHERE proxy_id = \$1 AND \(released_at IS NULL OR released_at >= \$2\) AND assigned_at <= \$3 ORDER BY`).
+		WithArgs(2, from, to, 10, 0).
+		WillReturnRows(sqlmock.NewRows(historyColumns).
+			AddRow(5, 9, 2, "manual", from.Add(-time.Hour), from.Add(30*time.Minute)))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxy_assignments WHERE`).
+		WithArgs(2, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	req, _ := http.NewRequest("GET", "/assignment/history?proxy_id=2&from=2026-03-01T10:00:00Z&to=2026-03-01T12:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"account_id":9`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for _, query := range []string{
+		"?from=yesterday",
+		"?from=2026-03-01T12:00:00Z&to=2026-03-01T10:00:00Z",
+		"?account_id=abc",
+	} {
+		req, _ := http.NewRequest("GET", "/assignment/history"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// Defaults for PROXY_IMPORT_MAX_ENTRIES, PROXY_IMPORT_TIMEOUT_SECONDS and
+// PROXY_IMPORT_MAX_BYTES
+const (
+	defaultProxyImportMaxEntries = 1000
+	defaultProxyImportTimeout    = 120
+	defaultProxyImportMaxBytes   = 10 << 20
+)
+
+// maxProxyImportLineBytes bounds a single NDJSON line of an import
+const maxProxyImportLineBytes = 64 * 1024
+
+var importValidator = validator.New()
+
+// ImportProxies creates a proxy for each NDJSON line read from r, in the
+// format of models.CreateProxyRequest; rows from the export endpoint are
+// accepted as they are. Bad lines are reported and skipped. Connections are
+// not tested here; the health scheduler checks new proxies on its next cycle.
+//
+// At most maxEntries lines are processed; zero or less means the default.
+// ctx is checked between entries, and
+// once it ends the result so far is returned with Cancelled set. An entry
+// already started is always finished, so every entry counted as created is
+// stored with its tags.
+func (s *ProxyService) ImportProxies(ctx context.Context, r io.Reader, maxEntries int) *ProxyImportResult {
+	result := &ProxyImportResult{Failures: []ProxyImportFailure{}}
+	if maxEntries <= 0 {
+		maxEntries = defaultProxyImportMaxEntries
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxProxyImportLineBytes)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			result.Cancelled = true
+			result.Note = fmt.Sprintf("cancelled after %d entries: %v", result.Processed, err)
+			return result
+		}
+		if result.Processed >= maxEntries {
+			result.Truncated = true
+			result.Note = fmt.Sprintf("stopped after %d entries: the import limit is %d", result.Processed, maxEntries)
+			return result
+		}
+
+		result.Processed++
+		if err := s.importProxy(context.WithoutCancel(ctx), text); err != nil {
+			result.Failures = append(result.Failures, ProxyImportFailure{Line: line, Error: err.Error()})
+			continue
+		}
+		result.Created++
+	}
+	if err := scanner.Err(); err != nil {
+		// The rest of the body cannot be read, e.g. a line is too long
+		result.Failures = append(result.Failures, ProxyImportFailure{Line: line + 1, Error: err.Error()})
+		result.Note = fmt.Sprintf("stopped at line %d after %d entries: %v", line+1, result.Processed, err)
+	}
+
+	return result
+}
+
+// importProxy decodes, validates and stores one import line
+func (s *ProxyService) importProxy(ctx context.Context, text string) error {
+	var req models.CreateProxyRequest
+	if err := json.Unmarshal([]byte(text), &req); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := importValidator.Struct(&req); err != nil {
+		return err
+	}
+
+	_, err := s.insertProxy(ctx, &req)
+	return err
+}
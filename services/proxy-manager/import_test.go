@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineReader returns one line per Read and calls onLine before returning the
+// line with that index, so a test can act between entries
+type lineReader struct {
+	lines  []string
+	next   int
+	onLine func(i int)
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	if r.next >= len(r.lines) {
+		return 0, io.EOF
+	}
+	if r.onLine != nil {
+		r.onLine(r.next)
+	}
+	n := copy(p, r.lines[r.next]+"\n")
+	r.next++
+	return n, nil
+}
+
+func importLine(i int) string {
+	return fmt.Sprintf(`{"name": "proxy-%d", "type": "http", "host": "10.0.0.%d", "port": 8080}`, i, i)
+}
+
+func expectProxyInsert(mock sqlmock.Sqlmock, host string, id int) {
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM proxies WHERE host = \$1 AND port = \$2\)`).
+		WithArgs(host, 8080).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(id, time.Now(), time.Now()))
+	mock.ExpectCommit()
+}
+
+func TestImportProxiesReportsBadLines(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectProxyInsert(mock, "10.0.0.1", 1)
+	expectProxyInsert(mock, "10.0.0.3", 2)
+
+	body := strings.Join([]string{
+		importLine(1),
+		`{"name": "no port", "type": "http", "host": "10.0.0.2"}`,
+		"",
+		importLine(3),
+		`not json`,
+	}, "\n")
+
+	service := &ProxyService{db: db}
+	result := service.ImportProxies(context.Background(), strings.NewReader(body), 10)
+
+	assert.Equal(t, 4, result.Processed)
+	assert.Equal(t, 2, result.Created)
+	require.Len(t, result.Failures, 2)
+	assert.Equal(t, 2, result.Failures[0].Line)
+	assert.Equal(t, 5, result.Failures[1].Line)
+	assert.Contains(t, result.Failures[1].Error, "invalid JSON")
+	assert.False(t, result.Cancelled)
+	assert.False(t, result.Truncated)
+	assert.Empty(t, result.Note)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProxiesStopsAtEntryLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectProxyInsert(mock, "10.0.0.1", 1)
+	expectProxyInsert(mock, "10.0.0.2", 2)
+
+	body := strings.Join([]string{importLine(1), importLine(2), importLine(3)}, "\n")
+	service := &ProxyService{db: db}
+	result := service.ImportProxies(context.Background(), strings.NewReader(body), 2)
+
+	assert.Equal(t, 2, result.Created)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, "stopped after 2 entries: the import limit is 2", result.Note)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProxiesCancelledMidImport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Only the entries read before the cancellation are stored; the third
+	// would fail the test with an unexpected query
+	expectProxyInsert(mock, "10.0.0.1", 1)
+	expectProxyInsert(mock, "10.0.0.2", 2)
+
+	body := &lineReader{
+		lines: []string{importLine(1), importLine(2), importLine(3), importLine(4)},
+		onLine: func(i int) {
+			if i == 2 {
+				cancel()
+			}
+		},
+	}
+
+	service := &ProxyService{db: db}
+	result := service.ImportProxies(ctx, body, 10)
+
+	assert.True(t, result.Cancelled)
+	assert.Equal(t, 2, result.Processed)
+	assert.Equal(t, 2, result.Created)
+	assert.Empty(t, result.Failures)
+	assert.Equal(t, "cancelled after 2 entries: context canceled", result.Note)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProxiesClampsEntryLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	expectProxyInsert(mock, "10.0.0.1", 1)
+
+	service := &ProxyService{db: db}
+	result := service.ImportProxies(context.Background(), strings.NewReader(importLine(1)), 0)
+
+	assert.Equal(t, 1, result.Created)
+	assert.False(t, result.Truncated)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// newImportServer serves the import endpoint over a real connection, so read
+// deadlines apply
+func newImportServer(t *testing.T, db *sql.DB) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.POST("/proxies/import", handler.ImportProxies)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestImportProxiesStopsWaitingOnSlowBody(t *testing.T) {
+	t.Setenv("PROXY_IMPORT_TIMEOUT_SECONDS", "1")
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	expectProxyInsert(mock, "10.0.0.1", 1)
+
+	server := newImportServer(t, db)
+
+	// One line, then the client stalls without closing the body
+	body, writer := io.Pipe()
+	defer writer.Close()
+	go writer.Write([]byte(importLine(1) + "\n"))
+
+	start := time.Now()
+	resp, err := http.Post(server.URL+"/proxies/import", "application/x-ndjson", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var result ProxyImportResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 1, result.Created)
+	assert.Contains(t, result.Note, "stopped at line 2")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestImportProxiesLimitsBodySize(t *testing.T) {
+	t.Setenv("PROXY_IMPORT_MAX_BYTES", "150")
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	expectProxyInsert(mock, "10.0.0.1", 1)
+
+	server := newImportServer(t, db)
+	body := strings.Join([]string{importLine(1), importLine(2), importLine(3)}, "\n")
+	resp, err := http.Post(server.URL+"/proxies/import", "application/x-ndjson", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var result ProxyImportResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, 1, result.Created)
+	assert.Contains(t, result.Note, "request body too large")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
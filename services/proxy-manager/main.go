@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime/debug"
 	"syscall"
 	"time"
 
@@ -15,6 +17,7 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"github.com/bsky-automation/shared/migrations"
 	"github.com/bsky-automation/shared/models"
 	"github.com/bsky-automation/shared/utils"
 )
@@ -39,6 +42,9 @@ import (
 // @name Authorization
 
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending database migrations and exit")
+	flag.Parse()
+
 	// Load configuration
 	config := loadConfig()
 
@@ -49,6 +55,16 @@ func main() {
 	}
 	defer db.Close()
 
+	// Apply schema migrations
+	if *migrateOnly || utils.GetEnvAsBool("DB_AUTO_MIGRATE", true) {
+		if err := runMigrations(db); err != nil {
+			log.Fatalf("Failed to apply database migrations: %v", err)
+		}
+	}
+	if *migrateOnly {
+		return
+	}
+
 	// Initialize Redis
 	rdb := initRedis(config.RedisURL)
 	defer rdb.Close()
@@ -57,14 +73,32 @@ func main() {
 	proxyService := NewProxyService(db, rdb)
 	healthService := NewHealthService(db, rdb)
 
+	// Route service events to alerting and metrics subscribers
+	events := utils.NewEventBus()
+	wireEvents(events, rdb, proxyService, healthService)
+
+	// Encrypt proxy passwords written before encryption was configured
+	if key, err := utils.CredentialsKey(); err != nil {
+		log.Fatalf("Invalid credentials encryption key: %v", err)
+	} else if key == nil && utils.PlaintextCredentialsAllowed() {
+		log.Printf("%s is not set; proxy passwords are stored unencrypted", utils.CredentialsKeyEnv)
+	} else if key == nil {
+		log.Printf("ERROR: %s is not set; proxies with passwords cannot be created or updated until it is", utils.CredentialsKeyEnv)
+	} else if n, err := proxyService.EncryptStoredPasswords(context.Background()); err != nil {
+		log.Printf("Failed to encrypt stored proxy passwords: %v", err)
+	} else if n > 0 {
+		log.Printf("Encrypted %d stored proxy passwords", n)
+	}
+
 	// Initialize handlers
 	proxyHandler := NewProxyHandler(proxyService)
 
 	// Setup router
 	router := setupRouter(proxyHandler)
 
-	// Start health check scheduler
+	// Start health check and Redis cleanup schedulers
 	go healthService.StartHealthCheckScheduler(context.Background())
+	go healthService.StartRedisCleanupScheduler(context.Background())
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -72,6 +106,12 @@ func main() {
 		Handler: router,
 	}
 
+	// Stop taking requests first, then let the running health check finish
+	shutdown := utils.NewShutdownCoordinator()
+	shutdown.Register("http server", srv.Shutdown)
+	shutdown.Register("health check scheduler", healthService.StopHealthCheckScheduler)
+	shutdown.Register("event deliveries", events.Wait)
+
 	// Start server in a goroutine
 	go func() {
 		log.Printf("Proxy Manager starting on port %s", config.Port)
@@ -90,7 +130,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := shutdown.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
@@ -124,6 +164,9 @@ func initDatabase(databaseURL string) (*sql.DB, error) {
 		Password: utils.GetEnvOrDefault("DB_PASSWORD", "bsky_test_password"),
 		DBName:   utils.GetEnvOrDefault("DB_NAME", "bsky_automation"),
 		SSLMode:  "disable",
+
+		StatementTimeout:         time.Duration(utils.GetEnvAsInt("DB_STATEMENT_TIMEOUT_MS", 30000)) * time.Millisecond,
+		IdleInTransactionTimeout: time.Duration(utils.GetEnvAsInt("DB_IDLE_IN_TX_TIMEOUT_MS", 60000)) * time.Millisecond,
 	}
 
 	// In production, parse the databaseURL properly
@@ -141,6 +184,21 @@ func initDatabase(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
+// runMigrations applies any embedded schema migrations the database is missing
+func runMigrations(db *sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	applied, err := migrations.Run(ctx, db)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	if err == nil && len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+	return err
+}
+
 // initRedis initializes the Redis connection
 func initRedis(redisURL string) *redis.Client {
 	config := utils.RedisConfig{
@@ -173,8 +231,9 @@ func setupRouter(proxyHandler *ProxyHandler) *gin.Engine {
 
 	// Middleware
 	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
+	router.Use(recoveryMiddleware())
 	router.Use(corsMiddleware())
+	router.Use(utils.GzipMiddleware(utils.GetEnvAsInt("GZIP_MIN_BYTES", 1024)))
 
 	// Health check endpoint
 	router.GET("/health", healthCheckHandler)
@@ -182,19 +241,33 @@ func setupRouter(proxyHandler *ProxyHandler) *gin.Engine {
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Profiling, off unless ENABLE_PPROF is set
+	registerPprofRoutes(router)
+
 	// API routes
 	v1 := router.Group("/api/v1")
 	{
+		// Model schemas
+		v1.GET("/schema", schemaHandler)
+
 		// Proxy routes
 		proxies := v1.Group("/proxies")
 		{
 			proxies.GET("", proxyHandler.ListProxies)
-			proxies.POST("", proxyHandler.CreateProxy)
+			proxies.POST("", utils.StrictJSON(), proxyHandler.CreateProxy)
+			proxies.GET("/export", proxyHandler.ExportProxies)
+			proxies.POST("/import", proxyHandler.ImportProxies)
+			proxies.GET("/ranking", proxyHandler.GetProxyRanking)
+			proxies.POST("/bulk-status", utils.StrictJSON(), proxyHandler.BulkUpdateStatus)
 			proxies.GET("/:id", proxyHandler.GetProxy)
-			proxies.PUT("/:id", proxyHandler.UpdateProxy)
+			proxies.PUT("/:id", utils.StrictJSON(), proxyHandler.UpdateProxy)
 			proxies.DELETE("/:id", proxyHandler.DeleteProxy)
+			proxies.GET("/:id/trend", proxyHandler.GetResponseTimeTrend)
 			proxies.POST("/:id/test", proxyHandler.TestProxy)
+			proxies.POST("/:id/rotate-credentials", proxyHandler.RotateCredentials)
 			proxies.POST("/:id/health-check", proxyHandler.RunHealthCheck)
+			proxies.POST("/:id/health-check/enable", proxyHandler.EnableHealthCheck)
+			proxies.POST("/:id/health-check/disable", proxyHandler.DisableHealthCheck)
 		}
 
 		// Proxy assignment routes
@@ -204,6 +277,7 @@ func setupRouter(proxyHandler *ProxyHandler) *gin.Engine {
 			assignment.POST("/assign", proxyHandler.AssignProxy)
 			assignment.POST("/release", proxyHandler.ReleaseProxy)
 			assignment.GET("/usage", proxyHandler.GetProxyUsage)
+			assignment.GET("/history", proxyHandler.GetAssignmentHistory)
 		}
 
 		// Proxy statistics
@@ -211,7 +285,9 @@ func setupRouter(proxyHandler *ProxyHandler) *gin.Engine {
 		{
 			stats.GET("/proxies", proxyHandler.GetProxyStats)
 			stats.GET("/health", proxyHandler.GetHealthStats)
+			stats.GET("/health/by-tag", proxyHandler.GetHealthStatsByTag)
 			stats.GET("/performance", proxyHandler.GetPerformanceStats)
+			stats.GET("/assignment-effectiveness", proxyHandler.GetAssignmentEffectiveness)
 		}
 	}
 
@@ -235,6 +311,32 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+// recoveryMiddleware converts panics into a structured ErrorResponse
+func recoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				requestID := c.GetHeader("X-Request-ID")
+				if requestID == "" {
+					requestID = utils.GenerateUUID().String()
+				}
+
+				log.Printf("Panic recovered [request_id=%s] %s %s: %v\n%s",
+					requestID, c.Request.Method, c.Request.URL.Path, r, debug.Stack())
+
+				c.Header("X-Request-ID", requestID)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "Internal server error",
+					Message: "An unexpected error occurred",
+					Code:    http.StatusInternalServerError,
+				})
+			}
+		}()
+
+		c.Next()
+	}
+}
+
 // healthCheckHandler handles health check requests
 // @Summary Health check
 // @Description Check if the service is healthy
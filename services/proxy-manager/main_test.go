@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -122,6 +124,76 @@ func TestProxyPortValidation(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestCreateProxyStrictJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	handler := &ProxyHandler{
+		validator: validator.New(),
+	}
+
+	router := gin.New()
+	router.POST("/strict/proxies", utils.StrictJSON(), handler.CreateProxy)
+	router.POST("/proxies", handler.CreateProxy)
+
+	// "por" is a typo for "port"
+	body := `{"name": "Test Proxy", "type": "http", "host": "proxy.example.com", "por": 8080}`
+
+	req, _ := http.NewRequest("POST", "/strict/proxies", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response models.ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Invalid request body", response.Error)
+	assert.Contains(t, response.Message, `unknown field "por"`)
+
+	// Lenient routes drop the field and fail later on the missing port
+	req, _ = http.NewRequest("POST", "/proxies", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Validation failed", response.Error)
+}
+
+func TestSchemaExposesPortConstraint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/schema", schemaHandler)
+
+	req, _ := http.NewRequest("GET", "/schema", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var document struct {
+		Defs map[string]struct {
+			Required   []string `json:"required"`
+			Properties map[string]struct {
+				Minimum *int `json:"minimum"`
+				Maximum *int `json:"maximum"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+
+	create, ok := document.Defs["CreateProxyRequest"]
+	if assert.True(t, ok) {
+		assert.Contains(t, create.Required, "port")
+		port := create.Properties["port"]
+		if assert.NotNil(t, port.Minimum) && assert.NotNil(t, port.Maximum) {
+			assert.Equal(t, 1, *port.Minimum)
+			assert.Equal(t, 65535, *port.Maximum)
+		}
+	}
+}
+
 func TestCORSMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -156,12 +228,38 @@ func TestOPTIONSRequest(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
 
+func TestRecoveryMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(recoveryMiddleware())
+	router.GET("/panic", func(c *gin.Context) {
+		panic("database exploded: secret connection string")
+	})
+
+	req, _ := http.NewRequest("GET", "/panic", nil)
+	req.Header.Set("X-Request-ID", "test-request-id")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "test-request-id", w.Header().Get("X-Request-ID"))
+
+	var response models.ErrorResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "Internal server error", response.Error)
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+	assert.NotContains(t, w.Body.String(), "secret connection string")
+}
+
 func TestProxyAssignmentRequest(t *testing.T) {
 	// Test proxy assignment request structure
+	proxyType := models.ProxyTypeHTTP
 	req := ProxyAssignmentRequest{
 		AccountID: 1,
 		ProxyID:   nil,
-		ProxyType: &models.ProxyTypeHTTP,
+		ProxyType: &proxyType,
 		Strategy:  "auto",
 	}
 
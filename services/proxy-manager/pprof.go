@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// registerPprofRoutes mounts the profiling routes when ENABLE_PPROF is set.
+// This service has no authentication, so the port must not be reachable from
+// outside the deployment while they are enabled.
+func registerPprofRoutes(router *gin.Engine) {
+	if utils.PprofEnabled() {
+		utils.RegisterPprofRoutes(router)
+	}
+}
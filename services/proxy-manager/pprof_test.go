@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPprofRoutesFollowFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	get := func(router *gin.Engine, path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	t.Setenv("ENABLE_PPROF", "")
+	router := setupRouter(&ProxyHandler{})
+	assert.Equal(t, http.StatusNotFound, get(router, "/debug/pprof/"))
+	assert.Equal(t, http.StatusNotFound, get(router, "/debug/pprof/goroutine"))
+
+	t.Setenv("ENABLE_PPROF", "true")
+	router = setupRouter(&ProxyHandler{})
+	assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/"))
+	assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/goroutine?debug=1"))
+	assert.Equal(t, http.StatusOK, get(router, "/debug/pprof/cmdline"))
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// DefaultRankingWeights weigh health above speed and speed above load
+var DefaultRankingWeights = RankingWeights{Health: 0.5, Speed: 0.3, Load: 0.2}
+
+// GetProxyRanking scores every proxy and returns them best to worst.
+//
+// Each proxy gets three component scores in [0, 1]:
+//
+//	health = 1 if the latest health check passed, else 0
+//	speed  = 1 - (rt - min_rt) / (max_rt - min_rt), over proxies with a
+//	         measured response time; unmeasured proxies score 0
+//	load   = 1 - accounts / busiest_accounts
+//
+// The score is their weighted mean scaled to 0-100:
+//
+//	score = 100 * (wh*health + ws*speed + wl*load) / (wh + ws + wl)
+func (s *ProxyService) GetProxyRanking(ctx context.Context, weights RankingWeights) (*ProxyRankingResponse, error) {
+	query := `
+		SELECT p.id, p.name, p.host, p.port, p.type, p.status,
+		       p.health_check_success, p.response_time_ms, COUNT(a.id)
+		FROM proxies p
+		LEFT JOIN accounts a ON p.id = a.proxy_id
+		GROUP BY p.id
+		ORDER BY p.id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxies for ranking: %w", err)
+	}
+	defer rows.Close()
+
+	rankings := []ProxyRanking{}
+	for rows.Next() {
+		var r ProxyRanking
+		err := rows.Scan(&r.ProxyID, &r.Name, &r.Host, &r.Port, &r.Type, &r.Status,
+			&r.Healthy, &r.ResponseTimeMs, &r.AssignedAccounts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan proxy ranking: %w", err)
+		}
+		rankings = append(rankings, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get proxies for ranking: %w", err)
+	}
+
+	rankProxies(rankings, weights)
+
+	return &ProxyRankingResponse{Weights: weights, Proxies: rankings}, nil
+}
+
+// rankProxies fills in the component and total scores and sorts best first.
+// Ties keep proxy ID order.
+func rankProxies(rankings []ProxyRanking, weights RankingWeights) {
+	minRT, maxRT := math.MaxInt, 0
+	maxAccounts := 0
+	for _, r := range rankings {
+		if r.ResponseTimeMs > 0 {
+			minRT = min(minRT, r.ResponseTimeMs)
+			maxRT = max(maxRT, r.ResponseTimeMs)
+		}
+		maxAccounts = max(maxAccounts, r.AssignedAccounts)
+	}
+
+	total := weights.Health + weights.Speed + weights.Load
+	for i := range rankings {
+		r := &rankings[i]
+
+		if r.Healthy {
+			r.HealthScore = 1
+		}
+		switch {
+		case r.ResponseTimeMs <= 0:
+			r.SpeedScore = 0
+		case maxRT == minRT:
+			r.SpeedScore = 1
+		default:
+			r.SpeedScore = 1 - float64(r.ResponseTimeMs-minRT)/float64(maxRT-minRT)
+		}
+		r.LoadScore = 1
+		if maxAccounts > 0 {
+			r.LoadScore = 1 - float64(r.AssignedAccounts)/float64(maxAccounts)
+		}
+
+		score := weights.Health*r.HealthScore + weights.Speed*r.SpeedScore + weights.Load*r.LoadScore
+		r.Score = math.Round(score/total*100*100) / 100
+	}
+
+	sort.SliceStable(rankings, func(i, j int) bool {
+		return rankings[i].Score > rankings[j].Score
+	})
+}
+
+// parseRankingWeights reads weights from query values, keeping the default
+// for any that are omitted. Weights must be non-negative and not all zero.
+func parseRankingWeights(health, speed, load string) (RankingWeights, error) {
+	weights := DefaultRankingWeights
+	for _, w := range []struct {
+		name  string
+		value string
+		dest  *float64
+	}{
+		{"health_weight", health, &weights.Health},
+		{"speed_weight", speed, &weights.Speed},
+		{"load_weight", load, &weights.Load},
+	} {
+		if w.value == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(w.value, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+			return weights, fmt.Errorf("%s must be a non-negative number", w.name)
+		}
+		*w.dest = v
+	}
+
+	if weights.Health+weights.Speed+weights.Load == 0 {
+		return weights, fmt.Errorf("at least one weight must be positive")
+	}
+	return weights, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankProxies(t *testing.T) {
+	rankings := []ProxyRanking{
+		{ProxyID: 1, Healthy: true, ResponseTimeMs: 300, AssignedAccounts: 0},
+		{ProxyID: 2, Healthy: true, ResponseTimeMs: 100, AssignedAccounts: 10},
+		{ProxyID: 3, Healthy: false, ResponseTimeMs: 100, AssignedAccounts: 0},
+		{ProxyID: 4, Healthy: true, ResponseTimeMs: 200, AssignedAccounts: 5},
+		{ProxyID: 5, Healthy: false, ResponseTimeMs: 0, AssignedAccounts: 0},
+	}
+
+	rankProxies(rankings, DefaultRankingWeights)
+
+	order := make([]int, len(rankings))
+	scores := make(map[int]float64)
+	for i, r := range rankings {
+		order[i] = r.ProxyID
+		scores[r.ProxyID] = r.Score
+	}
+
+	// 2: 50 + 30 + 0, 4: 50 + 15 + 10, 1: 50 + 0 + 20, 3: 0 + 30 + 20, 5: 0 + 0 + 20
+	assert.Equal(t, []int{2, 4, 1, 3, 5}, order)
+	assert.Equal(t, 75.0, scores[4])
+	assert.Equal(t, 80.0, scores[2])
+	assert.Equal(t, 70.0, scores[1])
+	assert.Equal(t, 50.0, scores[3])
+	assert.Equal(t, 20.0, scores[5])
+}
+
+func TestGetProxyRankingWeights(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	columns := []string{"id", "name", "host", "port", "type", "status", "health_check_success", "response_time_ms", "count"}
+	mock.ExpectQuery(`FROM proxies p\s+LEFT JOIN accounts a ON p.id = a.proxy_id\s+GROUP BY p.id`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "slow-idle", "10.0.0.1", 8080, "http", "active", true, 400, 0).
+			AddRow(2, "fast-busy", "10.0.0.2", 8080, "http", "active", true, 100, 8))
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.GET("/proxies/ranking", handler.GetProxyRanking)
+
+	// Weighting load alone favours the idle proxy over the fast one
+	req, _ := http.NewRequest("GET", "/proxies/ranking?health_weight=0&speed_weight=0&load_weight=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var ranking ProxyRankingResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &ranking))
+	assert.Equal(t, RankingWeights{Health: 0, Speed: 0, Load: 1}, ranking.Weights)
+	if assert.Len(t, ranking.Proxies, 2) {
+		assert.Equal(t, 1, ranking.Proxies[0].ProxyID)
+		assert.Equal(t, 100.0, ranking.Proxies[0].Score)
+		assert.Equal(t, 0.0, ranking.Proxies[1].Score)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, _ = http.NewRequest("GET", "/proxies/ranking?health_weight=0&speed_weight=0&load_weight=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req, _ = http.NewRequest("GET", "/proxies/ranking?speed_weight=-1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
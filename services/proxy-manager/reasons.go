@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// Reason codes for a failed proxy test. They are part of the API, so
+// automation can react to them; do not rename them.
+const (
+	ProxyErrorTimeout           = "timeout"
+	ProxyErrorConnectionRefused = "connection_refused"
+	ProxyErrorAuthFailed        = "auth_failed"
+	ProxyErrorBadStatus         = "bad_status"
+	ProxyErrorDNSFailure        = "dns_failure"
+	ProxyErrorTLS               = "tls_error"
+	ProxyErrorTargetNotAllowed  = "target_not_allowed"
+)
+
+// proxyStatusError is returned when the test URL answers through the proxy
+// with a status other than 200
+type proxyStatusError struct {
+	StatusCode int
+}
+
+func (e *proxyStatusError) Error() string {
+	return fmt.Sprintf("proxy returned status code: %d", e.StatusCode)
+}
+
+// classifyProxyError maps a proxy test failure to one of the ProxyError
+// reason codes. It returns an empty string for nil and for errors it cannot
+// place, such as a proxy password that fails to decrypt.
+func classifyProxyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, ErrHealthCheckURLNotAllowed) {
+		return ProxyErrorTargetNotAllowed
+	}
+
+	var statusErr *proxyStatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode == http.StatusProxyAuthRequired {
+			return ProxyErrorAuthFailed
+		}
+		return ProxyErrorBadStatus
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ProxyErrorDNSFailure
+	}
+
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &certErr) || errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) {
+		return ProxyErrorTLS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ProxyErrorTimeout
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ProxyErrorConnectionRefused
+	}
+
+	// The transport reports a rejected CONNECT or SOCKS5 login only as text
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "Proxy Authentication Required"),
+		strings.Contains(message, "authentication failed"),
+		strings.Contains(message, "no acceptable authentication methods"):
+		return ProxyErrorAuthFailed
+	case strings.Contains(message, "tls: "):
+		return ProxyErrorTLS
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyProxyError(t *testing.T) {
+	// Errors shaped like those http.Client.Do returns
+	wrap := func(err error) error {
+		return fmt.Errorf("proxy connection failed: %w", &url.Error{Op: "Get", URL: "http://generic.test/ip", Err: err})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"nil", nil, ""},
+		{"deadline", wrap(context.DeadlineExceeded), ProxyErrorTimeout},
+		{"dial timeout", wrap(&net.OpError{Op: "dial", Err: timeoutError{}}), ProxyErrorTimeout},
+		{"refused", wrap(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), ProxyErrorConnectionRefused},
+		{"dns", wrap(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "proxy.invalid", IsNotFound: true}}), ProxyErrorDNSFailure},
+		{"unknown authority", wrap(x509.UnknownAuthorityError{}), ProxyErrorTLS},
+		{"tls alert", wrap(errors.New("remote error: tls: handshake failure")), ProxyErrorTLS},
+		{"connect rejected", wrap(errors.New("Proxy Authentication Required")), ProxyErrorAuthFailed},
+		{"socks5 login", wrap(errors.New("socks connect tcp 10.0.0.1:1080->generic.test:80: username/password authentication failed")), ProxyErrorAuthFailed},
+		{"407", &proxyStatusError{StatusCode: 407}, ProxyErrorAuthFailed},
+		{"502", &proxyStatusError{StatusCode: 502}, ProxyErrorBadStatus},
+		{"target not allowed", fmt.Errorf("%w: 169.254.169.254 is a link-local address", ErrHealthCheckURLNotAllowed), ProxyErrorTargetNotAllowed},
+		{"unclassified", errors.New("failed to decrypt proxy password: bad key"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, classifyProxyError(tt.err))
+		})
+	}
+}
+
+func TestProbeBadStatusCode(t *testing.T) {
+	proxy := newFakeForwardProxy(t, "generic.test")
+
+	err := probeThroughProxy(context.Background(), proxy, "http://generic.test/ip")
+	assert.EqualError(t, err, "proxy returned status code: 403")
+	assert.Equal(t, ProxyErrorBadStatus, classifyProxyError(err))
+}
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
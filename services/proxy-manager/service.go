@@ -3,11 +3,17 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/bsky-automation/shared/models"
@@ -18,20 +24,79 @@ import (
 type ProxyService struct {
 	db  *sql.DB
 	rdb *redis.Client
+
+	// probe performs a live connectivity check against a proxy
+	probe func(ctx context.Context, proxy *models.Proxy) error
+
+	// randSource pins the service's random values, such as the sample
+	// performance figures, for reproducible tests; nil uses crypto/rand. A
+	// math/rand source is not safe for concurrent use.
+	randSource rand.Source
+
+	// pages reads the page size limits from system settings; nil uses the
+	// built-in defaults
+	pages *utils.PageSettings
+
+	// settings reads the default strategy, health check URL and hash ring
+	// settings; nil treats them as unset
+	settings *utils.Settings
+
+	// events receives ProxyAssigned events; nil drops them
+	events *utils.EventBus
+
+	// targets restricts the URLs proxies are health-checked against; nil
+	// allows any
+	targets *HealthCheckTargets
 }
 
 // NewProxyService creates a new proxy service
 func NewProxyService(db *sql.DB, rdb *redis.Client) *ProxyService {
-	return &ProxyService{
-		db:  db,
-		rdb: rdb,
-	}
+	s := &ProxyService{
+		db:       db,
+		rdb:      rdb,
+		pages:    utils.NewPageSettings(db),
+		settings: utils.NewSettings(db),
+		targets:  loadHealthCheckTargets(),
+	}
+	s.probe = s.testProxyConnection
+	return s
 }
 
 // CreateProxy creates a new proxy
 func (s *ProxyService) CreateProxy(ctx context.Context, req *models.CreateProxyRequest) (*models.Proxy, error) {
+	proxy, err := s.insertProxy(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test proxy connection
+	if err := s.testProxyConnection(ctx, proxy); err != nil {
+		// Log the error but don't fail the creation
+		// Update proxy status to error
+		proxy.Status = models.ProxyStatusError
+		s.updateProxyStatus(ctx, proxy.ID, proxy.Status)
+	}
+
+	return proxy, nil
+}
+
+// insertProxy validates req and stores it as a new active proxy with its tags,
+// without testing the connection
+func (s *ProxyService) insertProxy(ctx context.Context, req *models.CreateProxyRequest) (*models.Proxy, error) {
+	host, err := utils.NormalizeProxyHost(req.Host)
+	if err != nil {
+		return nil, err
+	}
+	req.Host = host
+
+	if req.HealthCheckURL != nil && *req.HealthCheckURL != "" {
+		if err := s.targets.Check(*req.HealthCheckURL); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate proxy URL format
-	proxyURL := fmt.Sprintf("%s://%s:%d", req.Type, req.Host, req.Port)
+	proxyURL := fmt.Sprintf("%s://%s", req.Type, utils.ProxyHostPort(req.Host, req.Port))
 	if err := utils.ValidateProxyURL(proxyURL); err != nil {
 		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
 	}
@@ -45,6 +110,11 @@ func (s *ProxyService) CreateProxy(ctx context.Context, req *models.CreateProxyR
 		return nil, fmt.Errorf("proxy with host %s and port %d already exists", req.Host, req.Port)
 	}
 
+	password, err := encryptPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create proxy
 	proxy := &models.Proxy{
 		UUID:               utils.GenerateUUID(),
@@ -53,46 +123,53 @@ func (s *ProxyService) CreateProxy(ctx context.Context, req *models.CreateProxyR
 		Host:               req.Host,
 		Port:               req.Port,
 		Username:           req.Username,
-		Password:           req.Password,
+		Password:           password,
 		Status:             models.ProxyStatusActive,
 		HealthCheckURL:     req.HealthCheckURL,
+		MaxAccounts:        req.MaxAccounts,
 		HealthCheckSuccess: true,
 		ResponseTimeMs:     0,
+		Tags:               req.Tags,
 	}
 
 	// Insert into database
 	query := `
-		INSERT INTO proxies (uuid, name, type, host, port, username, password, status, health_check_url)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO proxies (uuid, name, type, host, port, username, password, status, health_check_url, max_accounts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING id, created_at, updated_at
 	`
 
-	err = s.db.QueryRowContext(ctx, query,
-		proxy.UUID, proxy.Name, proxy.Type, proxy.Host, proxy.Port,
-		proxy.Username, proxy.Password, proxy.Status, proxy.HealthCheckURL,
-	).Scan(&proxy.ID, &proxy.CreatedAt, &proxy.UpdatedAt)
-
+	// The proxy and its tags are stored together, so a failed tag insert
+	// leaves no untagged proxy behind
+	err = utils.Transaction(s.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, query,
+			proxy.UUID, proxy.Name, proxy.Type, proxy.Host, proxy.Port,
+			proxy.Username, proxy.Password, proxy.Status, proxy.HealthCheckURL,
+			proxy.MaxAccounts,
+		).Scan(&proxy.ID, &proxy.CreatedAt, &proxy.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create proxy: %w", err)
+		}
+		return addProxyTags(ctx, tx, proxy.ID, proxy.Tags)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create proxy: %w", err)
-	}
-
-	// Test proxy connection
-	if err := s.testProxyConnection(ctx, proxy); err != nil {
-		// Log the error but don't fail the creation
-		// Update proxy status to error
-		proxy.Status = models.ProxyStatusError
-		s.updateProxyStatus(ctx, proxy.ID, proxy.Status)
+		return nil, err
 	}
 
 	return proxy, nil
 }
 
+// proxyTagsColumn selects a proxy's tags as a sorted array, for scanning into
+// Proxy.Tags with pq.Array
+const proxyTagsColumn = "ARRAY(SELECT tag FROM proxy_tags WHERE proxy_id = proxies.id ORDER BY tag) AS tags"
+
 // GetProxy retrieves a proxy by ID
 func (s *ProxyService) GetProxy(ctx context.Context, id int) (*models.Proxy, error) {
 	query := `
 		SELECT id, uuid, name, type, host, port, username, password, status,
 		       health_check_url, last_health_check, health_check_success,
-		       response_time_ms, created_at, updated_at
+		       health_check_enabled, max_accounts, response_time_ms, created_at, updated_at,
+		       ` + proxyTagsColumn + `
 		FROM proxies
 		WHERE id = $1
 	`
@@ -102,7 +179,8 @@ func (s *ProxyService) GetProxy(ctx context.Context, id int) (*models.Proxy, err
 		&proxy.ID, &proxy.UUID, &proxy.Name, &proxy.Type, &proxy.Host,
 		&proxy.Port, &proxy.Username, &proxy.Password, &proxy.Status,
 		&proxy.HealthCheckURL, &proxy.LastHealthCheck, &proxy.HealthCheckSuccess,
-		&proxy.ResponseTimeMs, &proxy.CreatedAt, &proxy.UpdatedAt,
+		&proxy.HealthCheckEnabled, &proxy.MaxAccounts, &proxy.ResponseTimeMs,
+		&proxy.CreatedAt, &proxy.UpdatedAt, pq.Array(&proxy.Tags),
 	)
 
 	if err != nil {
@@ -116,103 +194,77 @@ func (s *ProxyService) GetProxy(ctx context.Context, id int) (*models.Proxy, err
 }
 
 // ListProxies retrieves a paginated list of proxies
-func (s *ProxyService) ListProxies(ctx context.Context, page, pageSize int, status *models.ProxyStatus, proxyType *models.ProxyType) (*models.ListResponse, error) {
-	// Calculate pagination
-	offset, limit, _ := utils.Paginate(page, pageSize, 0)
-
-	// Build query
-	baseQuery := `
-		SELECT id, uuid, name, type, host, port, status, health_check_success,
-		       response_time_ms, last_health_check, created_at
-		FROM proxies
-	`
-
-	var args []interface{}
-	var conditions []string
-
+func (s *ProxyService) ListProxies(ctx context.Context, page, pageSize int, status *models.ProxyStatus, proxyType *models.ProxyType, skipMalformed bool) (*models.ListResponse, error) {
+	query := utils.PaginatedQuery{
+		Select: `
+			SELECT id, uuid, name, type, host, port, status, health_check_success,
+			       response_time_ms, last_health_check, created_at, ` + proxyTagsColumn,
+		From:     "FROM proxies",
+		OrderBy:  "created_at DESC",
+		Page:     page,
+		PageSize: pageSize,
+		Limits:   s.pages.Limits(ctx),
+	}
 	if status != nil {
-		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)+1))
-		args = append(args, *status)
+		query.Where("status = $%d", *status)
 	}
-
 	if proxyType != nil {
-		conditions = append(conditions, fmt.Sprintf("type = $%d", len(args)+1))
-		args = append(args, *proxyType)
-	}
-
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + fmt.Sprintf("%s", conditions[0])
-		for i := 1; i < len(conditions); i++ {
-			whereClause += " AND " + conditions[i]
-		}
+		query.Where("type = $%d", *proxyType)
 	}
 
-	query := fmt.Sprintf("%s %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d",
-		baseQuery, whereClause, len(args)+1, len(args)+2)
-	args = append(args, limit, offset)
-
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list proxies: %w", err)
-	}
-	defer rows.Close()
-
-	var proxies []models.Proxy
-	for rows.Next() {
+	proxies := []models.Proxy{}
+	skipped := 0
+	pagination, err := utils.RunPaginatedQuery(ctx, s.db, query, utils.SkipMalformedRows(skipMalformed, &skipped, func(rows *sql.Rows) error {
 		var proxy models.Proxy
 		err := rows.Scan(
 			&proxy.ID, &proxy.UUID, &proxy.Name, &proxy.Type, &proxy.Host,
 			&proxy.Port, &proxy.Status, &proxy.HealthCheckSuccess,
 			&proxy.ResponseTimeMs, &proxy.LastHealthCheck, &proxy.CreatedAt,
+			pq.Array(&proxy.Tags),
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan proxy: %w", err)
+			return fmt.Errorf("failed to scan proxy: %w", err)
 		}
 		proxies = append(proxies, proxy)
-	}
-
-	// Get total count
-	countQuery := "SELECT COUNT(*) FROM proxies"
-	if whereClause != "" {
-		countQuery += " " + whereClause
-	}
-
-	var totalItems int64
-	countArgs := args[:len(args)-2] // Remove limit and offset
-	err = s.db.QueryRowContext(ctx, countQuery, countArgs...).Scan(&totalItems)
+		return nil
+	}))
 	if err != nil {
-		return nil, fmt.Errorf("failed to count proxies: %w", err)
+		return nil, fmt.Errorf("failed to list proxies: %w", err)
 	}
 
-	_, _, totalPages := utils.Paginate(page, pageSize, totalItems)
-
 	return &models.ListResponse{
-		Data: proxies,
-		Pagination: models.PaginationResponse{
-			Page:       page,
-			PageSize:   pageSize,
-			TotalItems: totalItems,
-			TotalPages: totalPages,
-		},
+		Data:        proxies,
+		Pagination:  *pagination,
+		SkippedRows: skipped,
 	}, nil
 }
 
 // UpdateProxy updates an existing proxy
-func (s *ProxyService) UpdateProxy(ctx context.Context, id int, req *UpdateProxyRequest) (*models.Proxy, error) {
+func (s *ProxyService) UpdateProxy(ctx context.Context, id int, req *UpdateProxyRequest, force bool) (*models.Proxy, error) {
 	// Get existing proxy
 	proxy, err := s.GetProxy(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
+	// Deactivating the last assignable proxy needs force
+	if req.Status != nil && *req.Status != models.ProxyStatusActive && !force {
+		if err := s.checkNotLastAssignable(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build update query dynamically
 	updates := make(map[string]interface{})
 	if req.Name != nil {
 		updates["name"] = *req.Name
 	}
 	if req.Host != nil {
-		updates["host"] = *req.Host
+		host, err := utils.NormalizeProxyHost(*req.Host)
+		if err != nil {
+			return nil, err
+		}
+		updates["host"] = host
 	}
 	if req.Port != nil {
 		updates["port"] = *req.Port
@@ -221,14 +273,31 @@ func (s *ProxyService) UpdateProxy(ctx context.Context, id int, req *UpdateProxy
 		updates["username"] = *req.Username
 	}
 	if req.Password != nil {
-		updates["password"] = *req.Password
+		password, err := encryptPassword(req.Password)
+		if err != nil {
+			return nil, err
+		}
+		updates["password"] = *password
 	}
 	if req.Status != nil {
 		updates["status"] = *req.Status
 	}
 	if req.HealthCheckURL != nil {
+		// An empty URL clears it, falling back to the settings
+		if *req.HealthCheckURL != "" {
+			if err := s.targets.Check(*req.HealthCheckURL); err != nil {
+				return nil, err
+			}
+		}
 		updates["health_check_url"] = *req.HealthCheckURL
 	}
+	if req.MaxAccounts != nil {
+		if *req.MaxAccounts == 0 {
+			updates["max_accounts"] = nil
+		} else {
+			updates["max_accounts"] = *req.MaxAccounts
+		}
+	}
 
 	if len(updates) == 0 {
 		return proxy, nil // No updates
@@ -244,19 +313,28 @@ func (s *ProxyService) UpdateProxy(ctx context.Context, id int, req *UpdateProxy
 	if err != nil {
 		return nil, fmt.Errorf("failed to update proxy: %w", err)
 	}
+	if req.Status != nil {
+		s.clearQuarantine(ctx, id)
+	}
 
 	// Return updated proxy
 	return s.GetProxy(ctx, id)
 }
 
-// DeleteProxy deletes a proxy
-func (s *ProxyService) DeleteProxy(ctx context.Context, id int) error {
+// DeleteProxy deletes a proxy. Deleting the last assignable proxy needs force.
+func (s *ProxyService) DeleteProxy(ctx context.Context, id int, force bool) error {
 	// Check if proxy exists
 	_, err := s.GetProxy(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	if !force {
+		if err := s.checkNotLastAssignable(ctx, id); err != nil {
+			return err
+		}
+	}
+
 	// Check if proxy is in use
 	inUse, err := s.isProxyInUse(ctx, id)
 	if err != nil {
@@ -276,6 +354,26 @@ func (s *ProxyService) DeleteProxy(ctx context.Context, id int) error {
 	return nil
 }
 
+// SetHealthCheckEnabled turns scheduled health checks for a proxy on or off.
+// While off, the proxy keeps its current status regardless of upstream failures.
+func (s *ProxyService) SetHealthCheckEnabled(ctx context.Context, id int, enabled bool) (*models.Proxy, error) {
+	query := "UPDATE proxies SET health_check_enabled = $1, updated_at = NOW() WHERE id = $2"
+	result, err := s.db.ExecContext(ctx, query, enabled, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update health check setting: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("proxy not found")
+	}
+
+	return s.GetProxy(ctx, id)
+}
+
 // TestProxy tests proxy connection
 func (s *ProxyService) TestProxy(ctx context.Context, id int) (*ProxyTestResult, error) {
 	proxy, err := s.GetProxy(ctx, id)
@@ -297,6 +395,7 @@ func (s *ProxyService) TestProxy(ctx context.Context, id int) (*ProxyTestResult,
 	result.ResponseTime = duration
 	if err != nil {
 		result.Error = err.Error()
+		result.Code = classifyProxyError(err)
 	} else {
 		result.Success = true
 	}
@@ -307,20 +406,87 @@ func (s *ProxyService) TestProxy(ctx context.Context, id int) (*ProxyTestResult,
 	return result, nil
 }
 
+// ErrCredentialsChanged is returned when a proxy's credentials changed while
+// a rotation was testing its new ones
+var ErrCredentialsChanged = errors.New("proxy credentials changed during rotation")
+
+// RotateCredentials tests a proxy with new credentials and, only if the test
+// passes, stores them. The stored credentials are never touched by a failed
+// test, so a bad rotation never leaves accounts on a broken proxy. The swap
+// only applies if the credentials are still the ones read before the test;
+// a concurrent rotation gets ErrCredentialsChanged instead of being
+// silently overwritten.
+func (s *ProxyService) RotateCredentials(ctx context.Context, id int, req *RotateCredentialsRequest) (*RotateCredentialsResult, error) {
+	proxy, err := s.GetProxy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	oldUsername, oldPassword := proxy.Username, proxy.Password
+
+	password, err := encryptPassword(&req.Password)
+	if err != nil {
+		return nil, err
+	}
+	candidate := *proxy
+	candidate.Username, candidate.Password = &req.Username, password
+
+	result := &RotateCredentialsResult{
+		ProxyTestResult: ProxyTestResult{
+			ProxyID:   id,
+			Timestamp: time.Now(),
+		},
+	}
+
+	start := time.Now()
+	err = s.probe(ctx, &candidate)
+	duration := time.Since(start)
+
+	result.ResponseTime = duration
+	if err != nil {
+		result.Error = err.Error()
+		result.Code = classifyProxyError(err)
+		return result, nil
+	}
+
+	query := `
+		UPDATE proxies SET username = $1, password = $2, updated_at = NOW()
+		WHERE id = $3 AND username IS NOT DISTINCT FROM $4 AND password IS NOT DISTINCT FROM $5
+	`
+	updated, err := s.db.ExecContext(ctx, query, &req.Username, password, id, oldUsername, oldPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update proxy credentials: %w", err)
+	}
+	rows, err := updated.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return nil, ErrCredentialsChanged
+	}
+
+	result.Success = true
+	result.Applied = true
+	s.updateProxyHealth(ctx, id, true, int(duration.Milliseconds()))
+
+	return result, nil
+}
+
 // GetAvailableProxies returns available proxies for assignment
-func (s *ProxyService) GetAvailableProxies(ctx context.Context, proxyType *models.ProxyType) ([]models.Proxy, error) {
+func (s *ProxyService) GetAvailableProxies(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) ([]models.Proxy, error) {
 	query := `
 		SELECT id, uuid, name, type, host, port, status, health_check_success,
 		       response_time_ms, created_at
 		FROM proxies
 		WHERE status = 'active' AND health_check_success = true
 	`
+	query += assignableFilter("")
 
 	var args []interface{}
 	if proxyType != nil {
 		query += " AND type = $1"
 		args = append(args, *proxyType)
 	}
+	query, args = appendHealthAgeFilter(query, args, "last_health_check", maxHealthAge)
 
 	query += " ORDER BY response_time_ms ASC"
 
@@ -364,14 +530,71 @@ func (s *ProxyService) isProxyInUse(ctx context.Context, proxyID int) (bool, err
 }
 
 func (s *ProxyService) testProxyConnection(ctx context.Context, proxy *models.Proxy) error {
+	// The URL may come from settings, which are not checked when saved
+	testURL := s.healthCheckURL(ctx, proxy)
+	if err := s.targets.Check(testURL); err != nil {
+		return err
+	}
+	return probeThroughProxy(ctx, proxy, testURL)
+}
+
+// defaultHealthCheckURL is probed when neither the proxy nor the settings
+// name a health check URL
+const defaultHealthCheckURL = "https://httpbin.org/ip"
+
+// healthCheckURLSetting is the system setting naming the URL proxies are
+// probed with; healthCheckURLSetting + ":" + tag overrides it per tag
+const healthCheckURLSetting = "proxy_health_check_url"
+
+// healthCheckURL picks the URL a proxy is probed with: its own
+// health_check_url, else the proxy_health_check_url:<tag> setting for one of
+// its tags (e.g. its provider), else the global proxy_health_check_url
+// setting, else defaultHealthCheckURL. Some providers block the default
+// test site, which would otherwise fail every check. Tags come from
+// proxy.Tags and settings from the cached reader, so a check costs no query.
+func (s *ProxyService) healthCheckURL(ctx context.Context, proxy *models.Proxy) string {
+	if proxy.HealthCheckURL != nil && *proxy.HealthCheckURL != "" {
+		return *proxy.HealthCheckURL
+	}
+
+	// Ties go to the first tag by name
+	tags := append([]string(nil), proxy.Tags...)
+	sort.Strings(tags)
+	for _, tag := range tags {
+		if testURL, ok := s.settings.Get(ctx, healthCheckURLSetting+":"+tag); ok {
+			return testURL
+		}
+	}
+	if testURL, ok := s.settings.Get(ctx, healthCheckURLSetting); ok {
+		return testURL
+	}
+	return defaultHealthCheckURL
+}
+
+// testBskyReachability checks that Bluesky itself answers through the proxy,
+// since a proxy can pass the generic check and still be blocked by Bluesky
+func (s *ProxyService) testBskyReachability(ctx context.Context, proxy *models.Proxy) error {
+	probeURL := utils.GetEnvOrDefault("BSKY_PROBE_URL", "https://bsky.social/xrpc/com.atproto.server.describeServer")
+	if err := probeThroughProxy(ctx, proxy, probeURL); err != nil {
+		return fmt.Errorf("bluesky unreachable: %w", err)
+	}
+	return nil
+}
+
+// probeThroughProxy issues a GET for testURL through the proxy and expects 200
+func probeThroughProxy(ctx context.Context, proxy *models.Proxy, testURL string) error {
 	// Create HTTP client with proxy
-	proxyURL, err := url.Parse(fmt.Sprintf("%s://%s:%d", proxy.Type, proxy.Host, proxy.Port))
+	proxyURL, err := url.Parse(fmt.Sprintf("%s://%s", proxy.Type, utils.ProxyHostPort(proxy.Host, proxy.Port)))
 	if err != nil {
 		return fmt.Errorf("invalid proxy URL: %w", err)
 	}
 
 	if proxy.Username != nil && proxy.Password != nil {
-		proxyURL.User = url.UserPassword(*proxy.Username, *proxy.Password)
+		password, err := utils.DecryptCredential(*proxy.Password)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt proxy password: %w", err)
+		}
+		proxyURL.User = url.UserPassword(*proxy.Username, password)
 	}
 
 	transport := &http.Transport{
@@ -383,32 +606,281 @@ func (s *ProxyService) testProxyConnection(ctx context.Context, proxy *models.Pr
 		Timeout:   30 * time.Second,
 	}
 
-	// Test URL - use health check URL if provided, otherwise use a default
-	testURL := "https://httpbin.org/ip"
-	if proxy.HealthCheckURL != nil {
-		testURL = *proxy.HealthCheckURL
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testURL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid test URL: %w", err)
 	}
 
 	// Make test request
-	resp, err := client.Get(testURL)
+	resp, err := client.Do(req)
 	if err != nil {
 		return fmt.Errorf("proxy connection failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("proxy returned status code: %d", resp.StatusCode)
+		return &proxyStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// encryptPassword returns the stored form of a proxy password
+func encryptPassword(password *string) (*string, error) {
+	if password == nil {
+		return nil, nil
+	}
+	encrypted, err := utils.EncryptCredential(*password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt proxy password: %w", err)
+	}
+	return &encrypted, nil
+}
+
+// EncryptStoredPasswords encrypts proxy passwords still stored as plaintext,
+// e.g. rows written before CREDENTIALS_ENCRYPTION_KEY was configured. It is
+// safe to run repeatedly and returns how many rows were encrypted.
+func (s *ProxyService) EncryptStoredPasswords(ctx context.Context) (int, error) {
+	key, err := utils.CredentialsKey()
+	if err != nil {
+		return 0, err
+	}
+	if key == nil {
+		return 0, utils.ErrCredentialsKeyMissing
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, password FROM proxies WHERE password IS NOT NULL AND password NOT LIKE 'enc:%'")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query proxy passwords: %w", err)
+	}
+
+	type storedPassword struct {
+		id       int
+		password string
+	}
+	var plaintext []storedPassword
+	for rows.Next() {
+		var row storedPassword
+		if err := rows.Scan(&row.id, &row.password); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan proxy password: %w", err)
+		}
+		plaintext = append(plaintext, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to query proxy passwords: %w", err)
+	}
+
+	encrypted := 0
+	for _, row := range plaintext {
+		stored, err := encryptPassword(&row.password)
+		if err != nil {
+			return encrypted, err
+		}
+		// Only replace the value that was read, in case it changed meanwhile
+		query := "UPDATE proxies SET password = $1 WHERE id = $2 AND password = $3"
+		if _, err := s.db.ExecContext(ctx, query, *stored, row.id, row.password); err != nil {
+			return encrypted, fmt.Errorf("failed to encrypt password for proxy %d: %w", row.id, err)
+		}
+		encrypted++
 	}
 
+	return encrypted, nil
+}
+
+func addProxyTags(ctx context.Context, tx *sql.Tx, proxyID int, tags []string) error {
+	query := "INSERT INTO proxy_tags (proxy_id, tag) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, query, proxyID, tag); err != nil {
+			return fmt.Errorf("failed to add proxy tag: %w", err)
+		}
+	}
 	return nil
 }
 
+// clearQuarantine drops the health checks' quarantine marker from proxies
+// whose status an operator set, so startup reconciliation leaves it alone
+func (s *ProxyService) clearQuarantine(ctx context.Context, ids ...int) {
+	if len(ids) == 0 {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = quarantineKey(id)
+	}
+	if err := s.rdb.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Failed to clear quarantine of proxies %v: %v", ids, err)
+	}
+}
+
 func (s *ProxyService) updateProxyStatus(ctx context.Context, id int, status models.ProxyStatus) error {
 	query := "UPDATE proxies SET status = $1, updated_at = NOW() WHERE id = $2"
 	_, err := s.db.ExecContext(ctx, query, status, id)
 	return err
 }
 
+func (s *ProxyService) updateProxyBskyReachable(ctx context.Context, id int, reachable bool) error {
+	query := "UPDATE proxies SET bsky_reachable = $1, updated_at = NOW() WHERE id = $2"
+	_, err := s.db.ExecContext(ctx, query, reachable, id)
+	return err
+}
+
+// bskyReachableFilter returns an extra WHERE condition restricting selection
+// to proxies that passed the Bluesky probe, when PROXY_REQUIRE_BSKY_REACHABLE
+// is enabled. column is the qualified bsky_reachable column.
+func bskyReachableFilter(column string) string {
+	if !utils.GetEnvAsBool("PROXY_REQUIRE_BSKY_REACHABLE", false) {
+		return ""
+	}
+	return " AND " + column + " = true"
+}
+
+// uncheckedProxyFilter returns an extra WHERE condition excluding proxies
+// that have never been health checked, when PROXY_REQUIRE_HEALTH_CHECK is
+// enabled. New proxies default to health_check_success = true, so without
+// this they are assignable before anything has validated them. It is off by
+// default: a fresh deployment would otherwise have nothing to assign until
+// the first scheduled health check ran.
+func uncheckedProxyFilter(column string) string {
+	if !utils.GetEnvAsBool("PROXY_REQUIRE_HEALTH_CHECK", false) {
+		return ""
+	}
+	return " AND " + column + " IS NOT NULL"
+}
+
+// assignableFilter combines the selection conditions for the proxies table,
+// qualified with prefix (e.g. "p.")
+func assignableFilter(prefix string) string {
+	return uncheckedProxyFilter(prefix+"last_health_check") + bskyReachableFilter(prefix+"bsky_reachable") +
+		capacityFilter(prefix)
+}
+
+// capacityFilter excludes proxies whose assigned accounts have reached
+// max_accounts. The proxy columns are qualified with prefix, or with the table
+// name when there is none, so they are not confused with the accounts columns.
+func capacityFilter(prefix string) string {
+	if prefix == "" {
+		prefix = "proxies."
+	}
+	return fmt.Sprintf(" AND (%[1]smax_accounts IS NULL OR "+
+		"(SELECT COUNT(*) FROM accounts cap WHERE cap.proxy_id = %[1]sid) < %[1]smax_accounts)", prefix)
+}
+
+// ErrLastAssignableProxy is returned when deleting or deactivating a proxy
+// would leave auto-assignment with no proxy to pick
+var ErrLastAssignableProxy = errors.New("this is the last assignable proxy")
+
+// checkNotLastAssignable returns ErrLastAssignableProxy when the proxy is the
+// only one that GetAvailableProxies would currently offer
+func (s *ProxyService) checkNotLastAssignable(ctx context.Context, id int) error {
+	query := `
+		SELECT COUNT(CASE WHEN id = $1 THEN 1 END), COUNT(CASE WHEN id <> $1 THEN 1 END)
+		FROM proxies
+		WHERE status = 'active' AND health_check_success = true
+	` + assignableFilter("")
+
+	var self, others int
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(&self, &others); err != nil {
+		return fmt.Errorf("failed to count assignable proxies: %w", err)
+	}
+	if self > 0 && others == 0 {
+		return fmt.Errorf("%w: removing it leaves auto-assignment with no proxies; retry with force=true to proceed", ErrLastAssignableProxy)
+	}
+	return nil
+}
+
+// ErrProxyAtCapacity is returned when a proxy already serves max_accounts
+var ErrProxyAtCapacity = errors.New("proxy is at capacity")
+
+// ErrNoProxyCapacity is returned when every otherwise eligible proxy is at
+// capacity
+var ErrNoProxyCapacity = errors.New("no proxy has spare capacity")
+
+// ErrProxyUnhealthy is returned when manually assigning a proxy that is not
+// active or failed its last health check; automatic selection never picks one
+var ErrProxyUnhealthy = errors.New("proxy is not healthy")
+
+// healthCheckOutcome describes a proxy's last health check for error messages
+func healthCheckOutcome(success bool) string {
+	if success {
+		return "passed"
+	}
+	return "failed"
+}
+
+// checkProxyCapacity locks the proxy row and rejects the assignment if the
+// proxy already serves max_accounts other accounts. Locking serializes
+// concurrent assignments to the same proxy.
+func checkProxyCapacity(ctx context.Context, tx *sql.Tx, proxyID, accountID int) error {
+	query := `
+		SELECT max_accounts,
+		       (SELECT COUNT(*) FROM accounts WHERE proxy_id = $1 AND id <> $2)
+		FROM proxies
+		WHERE id = $1
+		FOR UPDATE
+	`
+	var maxAccounts sql.NullInt64
+	var assigned int64
+	if err := tx.QueryRowContext(ctx, query, proxyID, accountID).Scan(&maxAccounts, &assigned); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("proxy not found")
+		}
+		return fmt.Errorf("failed to check proxy capacity: %w", err)
+	}
+	if maxAccounts.Valid && assigned >= maxAccounts.Int64 {
+		return fmt.Errorf("%w: proxy %d serves %d of %d accounts", ErrProxyAtCapacity, proxyID, assigned, maxAccounts.Int64)
+	}
+	return nil
+}
+
+// explainNoProxy turns a failed selection into ErrNoProxyCapacity when
+// healthy proxies exist but all of them are full
+func (s *ProxyService) explainNoProxy(ctx context.Context, proxyType *models.ProxyType, selectErr error) error {
+	query := `
+		SELECT COUNT(*)
+		FROM proxies
+		WHERE status = 'active' AND health_check_success = true
+		  AND max_accounts IS NOT NULL
+		  AND (SELECT COUNT(*) FROM accounts cap WHERE cap.proxy_id = proxies.id) >= max_accounts
+	`
+	var args []interface{}
+	if proxyType != nil {
+		query += " AND type = $1"
+		args = append(args, *proxyType)
+	}
+
+	var full int
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(&full); err != nil || full == 0 {
+		return fmt.Errorf("failed to select proxy: %w", selectErr)
+	}
+	return fmt.Errorf("failed to select proxy: %w: %d eligible proxies are full", ErrNoProxyCapacity, full)
+}
+
+// parseMaxHealthAge parses a max_health_age value such as "10m". An empty
+// value means no limit.
+func parseMaxHealthAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge < 0 {
+		return 0, fmt.Errorf("invalid max_health_age: %q", value)
+	}
+	return maxAge, nil
+}
+
+// appendHealthAgeFilter restricts query to proxies checked within maxAge.
+// A zero maxAge leaves the query unfiltered.
+func appendHealthAgeFilter(query string, args []interface{}, column string, maxAge time.Duration) (string, []interface{}) {
+	if maxAge <= 0 {
+		return query, args
+	}
+	args = append(args, int(maxAge.Seconds()))
+	query += fmt.Sprintf(" AND %s >= NOW() - $%d * INTERVAL '1 second'", column, len(args))
+	return query, args
+}
+
 func (s *ProxyService) updateProxyHealth(ctx context.Context, id int, success bool, responseTimeMs int) error {
 	query := `
 		UPDATE proxies
@@ -417,7 +889,12 @@ func (s *ProxyService) updateProxyHealth(ctx context.Context, id int, success bo
 		WHERE id = $3
 	`
 	_, err := s.db.ExecContext(ctx, query, success, responseTimeMs, id)
-	return err
+	if err != nil {
+		return err
+	}
+
+	recordHealthCheck(ctx, s.db, id, success, responseTimeMs)
+	return nil
 }
 
 // AssignProxy assigns a proxy to an account
@@ -425,6 +902,7 @@ func (s *ProxyService) AssignProxy(ctx context.Context, req *ProxyAssignmentRequ
 	var proxyID int
 	var proxy *models.Proxy
 	var err error
+	strategy := "manual"
 
 	if req.ProxyID != nil {
 		// Manual assignment
@@ -432,17 +910,26 @@ func (s *ProxyService) AssignProxy(ctx context.Context, req *ProxyAssignmentRequ
 		if err != nil {
 			return nil, fmt.Errorf("failed to get specified proxy: %w", err)
 		}
+		if proxy.Status != models.ProxyStatusActive || !proxy.HealthCheckSuccess {
+			return nil, fmt.Errorf("%w: proxy %d is %s and its last health check %s",
+				ErrProxyUnhealthy, proxy.ID, proxy.Status, healthCheckOutcome(proxy.HealthCheckSuccess))
+		}
 		proxyID = *req.ProxyID
 	} else {
 		// Auto assignment based on strategy
-		strategy := req.Strategy
+		strategy = req.Strategy
 		if strategy == "" {
-			strategy = "auto"
+			strategy = s.defaultAssignmentStrategy(ctx)
+		}
+
+		maxHealthAge, err := parseMaxHealthAge(req.MaxHealthAge)
+		if err != nil {
+			return nil, err
 		}
 
-		proxyID, err = s.selectProxyByStrategy(ctx, strategy, req.ProxyType)
+		proxyID, err = s.selectProxyByStrategy(ctx, strategy, req.AccountID, req.ProxyType, maxHealthAge)
 		if err != nil {
-			return nil, fmt.Errorf("failed to select proxy: %w", err)
+			return nil, s.explainNoProxy(ctx, req.ProxyType, err)
 		}
 
 		proxy, err = s.GetProxy(ctx, proxyID)
@@ -451,29 +938,93 @@ func (s *ProxyService) AssignProxy(ctx context.Context, req *ProxyAssignmentRequ
 		}
 	}
 
-	// Update account with proxy assignment
-	updateQuery := "UPDATE accounts SET proxy_id = $1, updated_at = NOW() WHERE id = $2"
-	_, err = s.db.ExecContext(ctx, updateQuery, proxyID, req.AccountID)
+	// Update the account and record the assignment atomically
+	err = utils.Transaction(s.db, func(tx *sql.Tx) error {
+		var previousProxyID sql.NullInt64
+		err := tx.QueryRowContext(ctx, "SELECT proxy_id FROM accounts WHERE id = $1 FOR UPDATE", req.AccountID).
+			Scan(&previousProxyID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("account not found")
+			}
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+
+		if err := checkProxyCapacity(ctx, tx, proxyID, req.AccountID); err != nil {
+			return err
+		}
+
+		updateQuery := "UPDATE accounts SET proxy_id = $1, updated_at = NOW() WHERE id = $2"
+		result, err := tx.ExecContext(ctx, updateQuery, proxyID, req.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to assign proxy to account: %w", err)
+		}
+		if err := requireRowsAffected(result); err != nil {
+			return err
+		}
+
+		oldValues := models.JSONB{"proxy_id": nil}
+		if previousProxyID.Valid {
+			oldValues["proxy_id"] = previousProxyID.Int64
+		}
+		auditQuery := `
+			INSERT INTO audit_logs (entity_type, entity_id, action, old_values, new_values)
+			VALUES ('accounts', $1, 'assign_proxy', $2, $3)
+		`
+		_, err = tx.ExecContext(ctx, auditQuery, req.AccountID, oldValues,
+			models.JSONB{"proxy_id": proxyID, "strategy": req.Strategy})
+		if err != nil {
+			return fmt.Errorf("failed to record proxy assignment: %w", err)
+		}
+
+		return recordAssignment(ctx, tx, req.AccountID, proxyID, strategy)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to assign proxy to account: %w", err)
+		return nil, err
 	}
 
+	assignedAt := time.Now()
+	s.events.Publish(ctx, utils.ProxyAssigned{
+		AccountID: req.AccountID,
+		ProxyID:   proxyID,
+		Strategy:  strategy,
+		At:        assignedAt,
+	})
+
 	return &ProxyAssignmentResponse{
 		AccountID:  req.AccountID,
 		ProxyID:    proxyID,
 		ProxyName:  proxy.Name,
 		ProxyHost:  proxy.Host,
 		ProxyPort:  proxy.Port,
-		AssignedAt: time.Now(),
+		AssignedAt: assignedAt,
 	}, nil
 }
 
 // ReleaseProxy releases a proxy from an account
 func (s *ProxyService) ReleaseProxy(ctx context.Context, req *ProxyReleaseRequest) error {
-	updateQuery := "UPDATE accounts SET proxy_id = NULL, updated_at = NOW() WHERE id = $1"
-	_, err := s.db.ExecContext(ctx, updateQuery, req.AccountID)
+	return utils.Transaction(s.db, func(tx *sql.Tx) error {
+		updateQuery := "UPDATE accounts SET proxy_id = NULL, updated_at = NOW() WHERE id = $1"
+		result, err := tx.ExecContext(ctx, updateQuery, req.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to release proxy from account: %w", err)
+		}
+		if err := requireRowsAffected(result); err != nil {
+			return err
+		}
+		return closeAssignment(ctx, tx, req.AccountID)
+	})
+}
+
+// requireRowsAffected returns "account not found" when an account UPDATE
+// matched no rows
+func requireRowsAffected(result sql.Result) error {
+	rows, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to release proxy from account: %w", err)
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("account not found")
 	}
 	return nil
 }
@@ -651,12 +1202,15 @@ func (s *ProxyService) GetHealthStats(ctx context.Context) (*ProxyHealthStatsRes
 		SELECT
 			COUNT(*) as total,
 			COUNT(CASE WHEN health_check_success = true THEN 1 END) as healthy,
-			COUNT(CASE WHEN health_check_success = false THEN 1 END) as unhealthy
+			COUNT(CASE WHEN health_check_success = false THEN 1 END) as unhealthy,
+			COUNT(CASE WHEN bsky_reachable = true THEN 1 END) as bsky_reachable,
+			COUNT(CASE WHEN bsky_reachable = false THEN 1 END) as bsky_blocked
 		FROM proxies
 		WHERE status = 'active'
 	`
 	err := s.db.QueryRowContext(ctx, overallQuery).Scan(
-		&stats.TotalProxies, &stats.HealthyProxies, &stats.UnhealthyProxies)
+		&stats.TotalProxies, &stats.HealthyProxies, &stats.UnhealthyProxies,
+		&stats.BskyReachableProxies, &stats.BskyBlockedProxies)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get overall health stats: %w", err)
 	}
@@ -668,7 +1222,7 @@ func (s *ProxyService) GetHealthStats(ctx context.Context) (*ProxyHealthStatsRes
 	// Get proxy health details
 	detailQuery := `
 		SELECT id, name, host, port, type, health_check_success,
-		       last_health_check, response_time_ms
+		       bsky_reachable, last_health_check, response_time_ms
 		FROM proxies
 		WHERE status = 'active'
 		ORDER BY health_check_success DESC, response_time_ms ASC
@@ -684,7 +1238,7 @@ func (s *ProxyService) GetHealthStats(ctx context.Context) (*ProxyHealthStatsRes
 		var lastCheck sql.NullTime
 		err := rows.Scan(&detail.ProxyID, &detail.ProxyName, &detail.ProxyHost,
 			&detail.ProxyPort, &detail.ProxyType, &detail.IsHealthy,
-			&lastCheck, &detail.ResponseTimeMs)
+			&detail.BskyReachable, &lastCheck, &detail.ResponseTimeMs)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan health detail: %w", err)
 		}
@@ -730,6 +1284,69 @@ func (s *ProxyService) GetHealthStats(ctx context.Context) (*ProxyHealthStatsRes
 	return stats, nil
 }
 
+// GetHealthStatsByTag returns proxy health statistics grouped by tag. A proxy
+// counts once towards each of its tags.
+func (s *ProxyService) GetHealthStatsByTag(ctx context.Context) (*ProxyTagHealthStatsResponse, error) {
+	query := `
+		SELECT t.tag, p.health_check_success, p.response_time_ms
+		FROM proxy_tags t
+		JOIN proxies p ON p.id = t.proxy_id
+		WHERE p.status = 'active'
+		ORDER BY t.tag
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health by tag: %w", err)
+	}
+	defer rows.Close()
+
+	var tagged []taggedProxyHealth
+	for rows.Next() {
+		var row taggedProxyHealth
+		if err := rows.Scan(&row.Tag, &row.Healthy, &row.ResponseTimeMs); err != nil {
+			return nil, fmt.Errorf("failed to scan tag health: %w", err)
+		}
+		tagged = append(tagged, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tag health: %w", err)
+	}
+
+	return &ProxyTagHealthStatsResponse{HealthByTag: aggregateTagHealth(tagged)}, nil
+}
+
+// taggedProxyHealth is one proxy's health under one of its tags
+type taggedProxyHealth struct {
+	Tag            string
+	Healthy        bool
+	ResponseTimeMs int
+}
+
+// aggregateTagHealth rolls tagged proxy health up per tag, the same way
+// HealthByType is computed
+func aggregateTagHealth(rows []taggedProxyHealth) map[string]ProxyTypeHealth {
+	totals := make(map[string]int)
+	health := make(map[string]ProxyTypeHealth)
+	for _, row := range rows {
+		tagHealth := health[row.Tag]
+		tagHealth.TotalProxies++
+		if row.Healthy {
+			tagHealth.HealthyProxies++
+		} else {
+			tagHealth.UnhealthyProxies++
+		}
+		totals[row.Tag] += row.ResponseTimeMs
+		health[row.Tag] = tagHealth
+	}
+
+	for tag, tagHealth := range health {
+		tagHealth.AvgResponseTime = float64(totals[tag]) / float64(tagHealth.TotalProxies)
+		tagHealth.HealthRate = float64(tagHealth.HealthyProxies) / float64(tagHealth.TotalProxies) * 100
+		health[tag] = tagHealth
+	}
+	return health
+}
+
 // GetPerformanceStats returns proxy performance statistics
 func (s *ProxyService) GetPerformanceStats(ctx context.Context, days int) (*ProxyPerformanceStatsResponse, error) {
 	stats := &ProxyPerformanceStatsResponse{
@@ -770,7 +1387,7 @@ func (s *ProxyService) GetPerformanceStats(ctx context.Context, days int) (*Prox
 		}
 
 		// Mock data for demonstration
-		detail.TotalRequests = utils.RandomInt(50, 200)
+		detail.TotalRequests = s.randomInt(50, 200)
 		if isHealthy {
 			detail.SuccessfulRequests = int(float64(detail.TotalRequests) * 0.95)
 		} else {
@@ -789,10 +1406,10 @@ func (s *ProxyService) GetPerformanceStats(ctx context.Context, days int) (*Prox
 		date := time.Now().AddDate(0, 0, -i).Format("2006-01-02")
 		dailyStat := DailyPerformanceStats{
 			Date:                date,
-			TotalRequests:       utils.RandomInt(100, 200),
+			TotalRequests:       s.randomInt(100, 200),
 			SuccessfulRequests:  0,
 			FailedRequests:      0,
-			AverageResponseTime: float64(utils.RandomInt(200, 400)),
+			AverageResponseTime: float64(s.randomInt(200, 400)),
 		}
 		dailyStat.SuccessfulRequests = int(float64(dailyStat.TotalRequests) * 0.9)
 		dailyStat.FailedRequests = dailyStat.TotalRequests - dailyStat.SuccessfulRequests
@@ -804,34 +1421,76 @@ func (s *ProxyService) GetPerformanceStats(ctx context.Context, days int) (*Prox
 	return stats, nil
 }
 
+// selectableStrategies are the strategies that pick a proxy on their own;
+// manual assignment needs an explicit proxy ID and cannot be a default
+var selectableStrategies = map[ProxyAssignmentStrategy]bool{
+	AssignmentStrategyAuto:        true,
+	AssignmentStrategyRoundRobin:  true,
+	AssignmentStrategyLeastUsed:   true,
+	AssignmentStrategyFastest:     true,
+	AssignmentStrategyFastestLive: true,
+	AssignmentStrategyConsistent:  true,
+}
+
+// defaultProxyStrategySetting is the system setting naming the assignment
+// strategy used when a request names none
+const defaultProxyStrategySetting = "default_proxy_strategy"
+
+// defaultAssignmentStrategy returns the strategy used when a request names
+// none: the default_proxy_strategy setting, so operators can change it
+// without a redeploy, else DEFAULT_PROXY_STRATEGY. Settings are cached, so a
+// change applies within a minute. An empty or missing setting falls through
+// to the environment; unknown values fall back to auto.
+func (s *ProxyService) defaultAssignmentStrategy(ctx context.Context) string {
+	if value, ok := s.settings.Get(ctx, defaultProxyStrategySetting); ok {
+		if selectableStrategies[ProxyAssignmentStrategy(value)] {
+			return value
+		}
+		log.Printf("Ignoring invalid %s setting %q, using DEFAULT_PROXY_STRATEGY", defaultProxyStrategySetting, value)
+	}
+
+	strategy := ProxyAssignmentStrategy(utils.GetEnvOrDefault("DEFAULT_PROXY_STRATEGY", string(AssignmentStrategyAuto)))
+	if !selectableStrategies[strategy] {
+		log.Printf("Ignoring invalid DEFAULT_PROXY_STRATEGY %q, using auto", strategy)
+		return string(AssignmentStrategyAuto)
+	}
+	return string(strategy)
+}
+
 // selectProxyByStrategy selects a proxy based on the given strategy
-func (s *ProxyService) selectProxyByStrategy(ctx context.Context, strategy string, proxyType *models.ProxyType) (int, error) {
+func (s *ProxyService) selectProxyByStrategy(ctx context.Context, strategy string, accountID int, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
 	switch strategy {
 	case "least_used":
-		return s.selectLeastUsedProxy(ctx, proxyType)
+		return s.selectLeastUsedProxy(ctx, proxyType, maxHealthAge)
 	case "fastest":
-		return s.selectFastestProxy(ctx, proxyType)
+		return s.selectFastestProxy(ctx, proxyType, maxHealthAge)
+	case "fastest_live":
+		return s.selectFastestLiveProxy(ctx, proxyType, maxHealthAge)
+	case "consistent_hash":
+		return s.selectConsistentHashProxy(ctx, accountID, proxyType, maxHealthAge)
 	case "round_robin":
-		return s.selectRoundRobinProxy(ctx, proxyType)
+		return s.selectRoundRobinProxy(ctx, proxyType, maxHealthAge)
 	default: // "auto"
-		return s.selectBestProxy(ctx, proxyType)
+		return s.selectBestProxy(ctx, proxyType, maxHealthAge)
 	}
 }
 
 // selectLeastUsedProxy selects the proxy with the least number of assigned accounts
-func (s *ProxyService) selectLeastUsedProxy(ctx context.Context, proxyType *models.ProxyType) (int, error) {
+func (s *ProxyService) selectLeastUsedProxy(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
 	query := `
 		SELECT p.id
 		FROM proxies p
 		LEFT JOIN accounts a ON p.id = a.proxy_id
 		WHERE p.status = 'active' AND p.health_check_success = true
 	`
+	query += assignableFilter("p.")
 
 	var args []interface{}
 	if proxyType != nil {
 		query += " AND p.type = $1"
 		args = append(args, *proxyType)
 	}
+	query, args = appendHealthAgeFilter(query, args, "p.last_health_check", maxHealthAge)
 
 	query += `
 		GROUP BY p.id
@@ -852,18 +1511,20 @@ func (s *ProxyService) selectLeastUsedProxy(ctx context.Context, proxyType *mode
 }
 
 // selectFastestProxy selects the proxy with the best response time
-func (s *ProxyService) selectFastestProxy(ctx context.Context, proxyType *models.ProxyType) (int, error) {
+func (s *ProxyService) selectFastestProxy(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
 	query := `
 		SELECT id
 		FROM proxies
 		WHERE status = 'active' AND health_check_success = true
 	`
+	query += assignableFilter("")
 
 	var args []interface{}
 	if proxyType != nil {
 		query += " AND type = $1"
 		args = append(args, *proxyType)
 	}
+	query, args = appendHealthAgeFilter(query, args, "last_health_check", maxHealthAge)
 
 	query += " ORDER BY response_time_ms ASC LIMIT 1"
 
@@ -879,8 +1540,120 @@ func (s *ProxyService) selectFastestProxy(ctx context.Context, proxyType *models
 	return proxyID, nil
 }
 
+// selectFastestLiveProxy probes the top candidates by recorded latency and
+// selects the one that responds first right now
+func (s *ProxyService) selectFastestLiveProxy(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
+	proxies, err := s.GetAvailableProxies(ctx, proxyType, maxHealthAge)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(proxies) == 0 {
+		return 0, fmt.Errorf("no available proxies found")
+	}
+
+	// GetAvailableProxies is ordered by recorded latency, so the head of the
+	// list holds the best candidates
+	maxCandidates := utils.GetEnvAsInt("FASTEST_LIVE_CANDIDATES", 3)
+	if maxCandidates < 1 {
+		maxCandidates = 1
+	}
+	if len(proxies) > maxCandidates {
+		proxies = proxies[:maxCandidates]
+	}
+
+	// Load full proxy records so probes can authenticate
+	candidates := make([]models.Proxy, 0, len(proxies))
+	for _, p := range proxies {
+		proxy, err := s.GetProxy(ctx, p.ID)
+		if err != nil {
+			continue
+		}
+		candidates = append(candidates, *proxy)
+	}
+
+	if len(candidates) == 0 {
+		return proxies[0].ID, nil
+	}
+
+	timeout := time.Duration(utils.GetEnvAsInt("FASTEST_LIVE_PROBE_TIMEOUT_MS", 2000)) * time.Millisecond
+	if proxyID, ok := pickFastestLive(ctx, candidates, s.probe, timeout); ok {
+		return proxyID, nil
+	}
+
+	// All probes failed or timed out, fall back to recorded latency
+	return candidates[0].ID, nil
+}
+
+// pickFastestLive probes all candidates concurrently and returns the ID of the
+// first one whose probe succeeds within the timeout
+func pickFastestLive(ctx context.Context, candidates []models.Proxy, probe func(context.Context, *models.Proxy) error, timeout time.Duration) (int, bool) {
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Buffered so late probes never block after we return
+	results := make(chan int, len(candidates))
+	for i := range candidates {
+		go func(p models.Proxy) {
+			if err := probe(probeCtx, &p); err != nil {
+				results <- 0
+				return
+			}
+			results <- p.ID
+		}(candidates[i])
+	}
+
+	for range candidates {
+		select {
+		case proxyID := <-results:
+			if proxyID != 0 {
+				return proxyID, true
+			}
+		case <-probeCtx.Done():
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
+
+// Virtual nodes per proxy on the consistent-hash ring: the
+// consistent_hash_vnodes setting, else defaultConsistentHashVnodes
+const (
+	consistentHashVnodesSetting = "consistent_hash_vnodes"
+	defaultConsistentHashVnodes = 100
+)
+
+// selectConsistentHashProxy maps the account onto a hash ring of healthy
+// proxies so the assignment stays stable as the proxy set changes
+func (s *ProxyService) selectConsistentHashProxy(ctx context.Context, accountID int, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
+	proxies, err := s.GetAvailableProxies(ctx, proxyType, maxHealthAge)
+	if err != nil {
+		return 0, err
+	}
+
+	proxyIDs := make([]int, 0, len(proxies))
+	for _, p := range proxies {
+		proxyIDs = append(proxyIDs, p.ID)
+	}
+
+	ring := newHashRing(proxyIDs, s.settings.PositiveInt(ctx, consistentHashVnodesSetting, defaultConsistentHashVnodes))
+	proxyID, ok := ring.Get(strconv.Itoa(accountID))
+	if !ok {
+		return 0, fmt.Errorf("no available proxies found")
+	}
+
+	return proxyID, nil
+}
+
+// randomInt returns a random integer in [min, max] using the service's
+// random source when one is set
+func (s *ProxyService) randomInt(min, max int) int {
+	return utils.RandomInt(min, max, s.randSource)
+}
+
 // selectRoundRobinProxy selects proxy using round-robin algorithm
-func (s *ProxyService) selectRoundRobinProxy(ctx context.Context, proxyType *models.ProxyType) (int, error) {
+func (s *ProxyService) selectRoundRobinProxy(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
 	// For simplicity, use Redis to store round-robin state
 	key := "proxy_round_robin"
 	if proxyType != nil {
@@ -894,7 +1667,7 @@ func (s *ProxyService) selectRoundRobinProxy(ctx context.Context, proxyType *mod
 	}
 
 	// Get available proxies
-	proxies, err := s.GetAvailableProxies(ctx, proxyType)
+	proxies, err := s.GetAvailableProxies(ctx, proxyType, maxHealthAge)
 	if err != nil {
 		return 0, err
 	}
@@ -914,7 +1687,7 @@ func (s *ProxyService) selectRoundRobinProxy(ctx context.Context, proxyType *mod
 }
 
 // selectBestProxy selects the best proxy based on multiple factors
-func (s *ProxyService) selectBestProxy(ctx context.Context, proxyType *models.ProxyType) (int, error) {
+func (s *ProxyService) selectBestProxy(ctx context.Context, proxyType *models.ProxyType, maxHealthAge time.Duration) (int, error) {
 	// Combine least used and fastest strategies
 	query := `
 		SELECT p.id, COUNT(a.id) as usage_count, p.response_time_ms
@@ -922,12 +1695,14 @@ func (s *ProxyService) selectBestProxy(ctx context.Context, proxyType *models.Pr
 		LEFT JOIN accounts a ON p.id = a.proxy_id
 		WHERE p.status = 'active' AND p.health_check_success = true
 	`
+	query += assignableFilter("p.")
 
 	var args []interface{}
 	if proxyType != nil {
 		query += " AND p.type = $1"
 		args = append(args, *proxyType)
 	}
+	query, args = appendHealthAgeFilter(query, args, "p.last_health_check", maxHealthAge)
 
 	query += `
 		GROUP BY p.id, p.response_time_ms
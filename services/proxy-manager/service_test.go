@@ -0,0 +1,1013 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+)
+
+// mockProbe returns a probe that sleeps for the configured delay per proxy ID
+// and fails for IDs listed in failing
+func mockProbe(delays map[int]time.Duration, failing map[int]bool) func(context.Context, *models.Proxy) error {
+	return func(ctx context.Context, proxy *models.Proxy) error {
+		select {
+		case <-time.After(delays[proxy.ID]):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if failing[proxy.ID] {
+			return fmt.Errorf("proxy connection failed")
+		}
+		return nil
+	}
+}
+
+func TestPickFastestLive(t *testing.T) {
+	candidates := []models.Proxy{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	t.Run("picks fastest live probe", func(t *testing.T) {
+		probe := mockProbe(map[int]time.Duration{
+			1: 80 * time.Millisecond,
+			2: 5 * time.Millisecond,
+			3: 40 * time.Millisecond,
+		}, nil)
+
+		proxyID, ok := pickFastestLive(context.Background(), candidates, probe, time.Second)
+		assert.True(t, ok)
+		assert.Equal(t, 2, proxyID)
+	})
+
+	t.Run("skips failed probes", func(t *testing.T) {
+		probe := mockProbe(map[int]time.Duration{
+			1: 5 * time.Millisecond,
+			2: 10 * time.Millisecond,
+			3: 40 * time.Millisecond,
+		}, map[int]bool{1: true, 2: true})
+
+		proxyID, ok := pickFastestLive(context.Background(), candidates, probe, time.Second)
+		assert.True(t, ok)
+		assert.Equal(t, 3, proxyID)
+	})
+
+	t.Run("all probes fail", func(t *testing.T) {
+		probe := mockProbe(nil, map[int]bool{1: true, 2: true, 3: true})
+
+		_, ok := pickFastestLive(context.Background(), candidates, probe, time.Second)
+		assert.False(t, ok)
+	})
+
+	t.Run("probes exceed timeout", func(t *testing.T) {
+		probe := mockProbe(map[int]time.Duration{
+			1: time.Second,
+			2: time.Second,
+			3: time.Second,
+		}, nil)
+
+		start := time.Now()
+		_, ok := pickFastestLive(context.Background(), candidates, probe, 20*time.Millisecond)
+		assert.False(t, ok)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
+
+func TestGetHealthStatsByTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Seeded proxies, one row per proxy and tag: vendor-a has 3 (2 healthy),
+	// vendor-b has 2 (none healthy), and proxy 3 carries both tags
+	rows := sqlmock.NewRows([]string{"tag", "health_check_success", "response_time_ms"}).
+		AddRow("vendor-a", true, 100).
+		AddRow("vendor-a", true, 150).
+		AddRow("vendor-a", false, 200).
+		AddRow("vendor-b", false, 200).
+		AddRow("vendor-b", false, 1600)
+	mock.ExpectQuery(`SELECT t.tag, p.health_check_success, p.response_time_ms\s+FROM proxy_tags t\s+JOIN proxies p ON p.id = t.proxy_id\s+WHERE p.status = 'active'`).
+		WillReturnRows(rows)
+
+	service := &ProxyService{db: db}
+	stats, err := service.GetHealthStatsByTag(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, stats.HealthByTag, 2)
+
+	vendorA := stats.HealthByTag["vendor-a"]
+	assert.Equal(t, 3, vendorA.TotalProxies)
+	assert.Equal(t, 2, vendorA.HealthyProxies)
+	assert.Equal(t, 1, vendorA.UnhealthyProxies)
+	assert.InDelta(t, 66.67, vendorA.HealthRate, 0.01)
+	assert.Equal(t, 150.0, vendorA.AvgResponseTime)
+
+	vendorB := stats.HealthByTag["vendor-b"]
+	assert.Equal(t, 2, vendorB.TotalProxies)
+	assert.Equal(t, 2, vendorB.UnhealthyProxies)
+	assert.Equal(t, 0.0, vendorB.HealthRate)
+	assert.Equal(t, 900.0, vendorB.AvgResponseTime)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateProxyStoresTagsInTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	req := func() *models.CreateProxyRequest {
+		return &models.CreateProxyRequest{Name: "p", Type: models.ProxyTypeHTTP, Host: "10.0.0.1", Port: 8080, Tags: []string{"vendor-a", "eu"}}
+	}
+	service := &ProxyService{db: db}
+
+	// A failed tag insert rolls the proxy back
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(1, time.Now(), time.Now()))
+	mock.ExpectExec(`INSERT INTO proxy_tags`).WithArgs(1, "vendor-a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO proxy_tags`).WithArgs(1, "eu").WillReturnError(fmt.Errorf("connection reset"))
+	mock.ExpectRollback()
+
+	_, err = service.insertProxy(context.Background(), req())
+	assert.ErrorContains(t, err, "failed to add proxy tag")
+
+	mock.ExpectQuery(`SELECT EXISTS`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(2, time.Now(), time.Now()))
+	mock.ExpectExec(`INSERT INTO proxy_tags`).WithArgs(2, "vendor-a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO proxy_tags`).WithArgs(2, "eu").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	proxy, err := service.insertProxy(context.Background(), req())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, proxy.ID)
+	assert.Equal(t, []string{"vendor-a", "eu"}, proxy.Tags)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAndListProxiesIncludeTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	service := &ProxyService{db: db}
+
+	mock.ExpectQuery(`ARRAY\(SELECT tag FROM proxy_tags WHERE proxy_id = proxies.id ORDER BY tag\) AS tags\s+FROM proxies\s+WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(proxyColumns).
+			AddRow(1, utils.GenerateUUID().String(), "proxy", "http", "10.0.0.1", 8080, nil, nil, "active",
+				nil, time.Now(), true, true, nil, 100, time.Now(), time.Now(), "{eu,vendor-a}"))
+
+	proxy, err := service.GetProxy(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu", "vendor-a"}, proxy.Tags)
+
+	mock.ExpectQuery(`AS tags FROM proxies ORDER BY created_at DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "name", "type", "host", "port", "status",
+			"health_check_success", "response_time_ms", "last_health_check", "created_at", "tags"}).
+			AddRow(1, utils.GenerateUUID().String(), "proxy", "http", "10.0.0.1", 8080, "active",
+				true, 100, time.Now(), time.Now(), "{vendor-a}").
+			AddRow(2, utils.GenerateUUID().String(), "untagged", "http", "10.0.0.2", 8080, "active",
+				true, 100, time.Now(), time.Now(), "{}"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	list, err := service.ListProxies(context.Background(), 1, 10, nil, nil, false)
+	assert.NoError(t, err)
+	proxies := list.Data.([]models.Proxy)
+	assert.Equal(t, []string{"vendor-a"}, proxies[0].Tags)
+	assert.Empty(t, proxies[1].Tags)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPerformanceStatsReproducible(t *testing.T) {
+	performanceStats := func(seed int64) *ProxyPerformanceStatsResponse {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "host", "port", "type", "response_time_ms", "health_check_success"})
+		for id := 1; id <= 3; id++ {
+			rows.AddRow(id, fmt.Sprintf("proxy-%d", id), "10.0.0.1", 8000+id, "http", 100*id, id != 3)
+		}
+		mock.ExpectQuery(`FROM proxies\s+WHERE status = 'active'\s+ORDER BY response_time_ms ASC`).
+			WillReturnRows(rows)
+
+		service := &ProxyService{db: db, randSource: rand.NewSource(seed)}
+		stats, err := service.GetPerformanceStats(context.Background(), 7)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		return stats
+	}
+
+	first := performanceStats(7)
+	assert.Equal(t, first, performanceStats(7))
+	assert.Len(t, first.ProxyPerformanceDetails, 3)
+	for _, detail := range first.ProxyPerformanceDetails {
+		assert.GreaterOrEqual(t, detail.TotalRequests, 50)
+		assert.LessOrEqual(t, detail.TotalRequests, 200)
+	}
+}
+
+// newFakeForwardProxy returns an HTTP proxy that answers 200 for every host
+// except blockedHost, which gets 403 as if the upstream rejected the proxy IP
+func newFakeForwardProxy(t *testing.T, blockedHost string) *models.Proxy {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Hostname() == blockedHost {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	assert.NoError(t, err)
+
+	healthURL := "http://generic.test/ip"
+	return &models.Proxy{
+		ID:             1,
+		Name:           "fake",
+		Type:           models.ProxyTypeHTTP,
+		Host:           serverURL.Hostname(),
+		Port:           port,
+		HealthCheckURL: &healthURL,
+	}
+}
+
+func TestBskyReachabilityDistinctFromGenericHealth(t *testing.T) {
+	t.Setenv("BSKY_PROBE_URL", "http://bsky.test/xrpc/com.atproto.server.describeServer")
+	service := &ProxyService{}
+
+	blocked := newFakeForwardProxy(t, "bsky.test")
+	assert.NoError(t, service.testProxyConnection(context.Background(), blocked))
+	err := service.testBskyReachability(context.Background(), blocked)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bluesky unreachable")
+
+	open := newFakeForwardProxy(t, "")
+	assert.NoError(t, service.testProxyConnection(context.Background(), open))
+	assert.NoError(t, service.testBskyReachability(context.Background(), open))
+}
+
+func TestBskyReachableFilter(t *testing.T) {
+	assert.Equal(t, "", bskyReachableFilter("p.bsky_reachable"))
+
+	t.Setenv("PROXY_REQUIRE_BSKY_REACHABLE", "true")
+	assert.Equal(t, " AND p.bsky_reachable = true", bskyReachableFilter("p.bsky_reachable"))
+}
+
+func TestUncheckedProxyNotAssigned(t *testing.T) {
+	availableColumns := []string{"id", "uuid", "name", "type", "host", "port",
+		"status", "health_check_success", "response_time_ms", "created_at"}
+
+	t.Run("strict excludes unchecked proxies", func(t *testing.T) {
+		t.Setenv("PROXY_REQUIRE_HEALTH_CHECK", "true")
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		// Only proxy 1 was never checked, so the strict query matches nothing
+		mock.ExpectQuery(`WHERE status = 'active' AND health_check_success = true\s+AND last_health_check IS NOT NULL`).
+			WillReturnRows(sqlmock.NewRows(availableColumns))
+
+		service := &ProxyService{db: db}
+		_, err = service.selectProxyByStrategy(context.Background(), "consistent_hash", 1, nil, 0)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unchecked proxies are assignable by default", func(t *testing.T) {
+		assert.Equal(t, "", uncheckedProxyFilter("last_health_check"))
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows(availableColumns).
+			AddRow(1, utils.GenerateUUID().String(), "fresh", "http", "10.0.0.1", 8001, "active", true, 0, time.Now())
+		mock.ExpectQuery(`WHERE status = 'active' AND health_check_success = true\s+AND \(proxies\.max_accounts IS NULL OR .*\) ORDER BY`).
+			WillReturnRows(rows)
+
+		service := &ProxyService{db: db}
+		proxyID, err := service.selectProxyByStrategy(context.Background(), "consistent_hash", 1, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, proxyID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetAvailableProxiesMaxHealthAge(t *testing.T) {
+	availableColumns := []string{"id", "uuid", "name", "type", "host", "port",
+		"status", "health_check_success", "response_time_ms", "created_at"}
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Proxy 1 was checked a minute ago and proxy 2 an hour ago; the database
+	// applies the cutoff so only proxy 1 comes back
+	mock.ExpectQuery(`AND type = \$1 AND last_health_check >= NOW\(\) - \$2 \* INTERVAL '1 second'`).
+		WithArgs(models.ProxyTypeHTTP, 600).
+		WillReturnRows(sqlmock.NewRows(availableColumns).
+			AddRow(1, utils.GenerateUUID().String(), "recent", "http", "10.0.0.1", 8001, "active", true, 100, time.Now()))
+
+	// Without a limit the stale proxy is still returned
+	mock.ExpectQuery(`WHERE status = 'active' AND health_check_success = true\s+AND \(proxies\.max_accounts IS NULL OR .*\) ORDER BY`).
+		WithArgs().
+		WillReturnRows(sqlmock.NewRows(availableColumns).
+			AddRow(1, utils.GenerateUUID().String(), "recent", "http", "10.0.0.1", 8001, "active", true, 100, time.Now()).
+			AddRow(2, utils.GenerateUUID().String(), "stale", "http", "10.0.0.2", 8002, "active", true, 200, time.Now()))
+
+	service := &ProxyService{db: db}
+	proxyType := models.ProxyTypeHTTP
+
+	proxies, err := service.GetAvailableProxies(context.Background(), &proxyType, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 1)
+	assert.Equal(t, "recent", proxies[0].Name)
+
+	proxies, err = service.GetAvailableProxies(context.Background(), nil, 0)
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 2)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsistentHashProxyUsesVnodeSetting(t *testing.T) {
+	availableColumns := []string{"id", "uuid", "name", "type", "host", "port",
+		"status", "health_check_success", "response_time_ms", "created_at"}
+	proxyIDs := []int{1, 2, 3, 4, 5}
+	owner := func(vnodes, accountID int) int {
+		proxyID, _ := newHashRing(proxyIDs, vnodes).Get(strconv.Itoa(accountID))
+		return proxyID
+	}
+
+	// Find an account the two ring sizes place differently
+	accountID := 1
+	for owner(1, accountID) == owner(defaultConsistentHashVnodes, accountID) {
+		accountID++
+	}
+
+	for _, tt := range []struct {
+		setting string
+		vnodes  int
+	}{
+		{"1", 1},
+		{"lots", defaultConsistentHashVnodes},
+	} {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		rows := sqlmock.NewRows(availableColumns)
+		for _, id := range proxyIDs {
+			rows.AddRow(id, utils.GenerateUUID().String(), fmt.Sprintf("proxy-%d", id), "http", "10.0.0.1", 8000+id, "active", true, 100, time.Now())
+		}
+		mock.ExpectQuery(`WHERE status = 'active' AND health_check_success = true`).WillReturnRows(rows)
+		mock.ExpectQuery(`SELECT key, value FROM system_settings`).
+			WillReturnRows(sqlmock.NewRows([]string{"key", "value"}).AddRow(consistentHashVnodesSetting, tt.setting))
+
+		service := &ProxyService{db: db, settings: utils.NewSettings(db)}
+		proxyID, err := service.selectProxyByStrategy(context.Background(), "consistent_hash", accountID, nil, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, owner(tt.vnodes, accountID), proxyID, tt.setting)
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	}
+}
+
+func TestParseMaxHealthAge(t *testing.T) {
+	maxAge, err := parseMaxHealthAge("")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), maxAge)
+
+	maxAge, err = parseMaxHealthAge("15m")
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, maxAge)
+
+	_, err = parseMaxHealthAge("soon")
+	assert.Error(t, err)
+	_, err = parseMaxHealthAge("-5m")
+	assert.Error(t, err)
+}
+
+var proxyColumns = []string{"id", "uuid", "name", "type", "host", "port", "username", "password", "status",
+	"health_check_url", "last_health_check", "health_check_success",
+	"health_check_enabled", "max_accounts", "response_time_ms", "created_at", "updated_at", "tags"}
+
+func expectGetProxy(mock sqlmock.Sqlmock, id int) {
+	mock.ExpectQuery(`FROM proxies\s+WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows(proxyColumns).
+			AddRow(id, utils.GenerateUUID().String(), "proxy", "http", "10.0.0.1", 8080, nil, nil, "active",
+				nil, time.Now(), true, true, nil, 100, time.Now(), time.Now(), "{}"))
+}
+
+func expectCapacityCheck(mock sqlmock.Sqlmock, proxyID, accountID int, maxAccounts interface{}, assigned int) {
+	mock.ExpectQuery(`SELECT max_accounts,[\s\S]*FROM proxies\s+WHERE id = \$1\s+FOR UPDATE`).
+		WithArgs(proxyID, accountID).
+		WillReturnRows(sqlmock.NewRows([]string{"max_accounts", "count"}).AddRow(maxAccounts, assigned))
+}
+
+func TestAssignProxyNonexistentAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectGetProxy(mock, 3)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT proxy_id FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(99).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.POST("/assignment/assign", handler.AssignProxy)
+
+	req, _ := http.NewRequest("POST", "/assignment/assign", strings.NewReader(`{"account_id": 99, "proxy_id": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignProxyRollsBackOnHistoryFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	proxyID := 3
+	expectGetProxy(mock, proxyID)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT proxy_id FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"proxy_id"}).AddRow(2))
+	expectCapacityCheck(mock, proxyID, 1, nil, 0)
+	mock.ExpectExec(`UPDATE accounts SET proxy_id = \$1`).
+		WithArgs(proxyID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WillReturnError(fmt.Errorf("disk full"))
+	mock.ExpectRollback()
+
+	service := &ProxyService{db: db}
+	_, err = service.AssignProxy(context.Background(), &ProxyAssignmentRequest{AccountID: 1, ProxyID: &proxyID})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to record proxy assignment")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseProxyUnknownAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE accounts SET proxy_id = NULL, updated_at = NOW\(\) WHERE id = \$1`).
+		WithArgs(99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.POST("/assignment/release", handler.ReleaseProxy)
+
+	req, _ := http.NewRequest("POST", "/assignment/release", strings.NewReader(`{"account_id": 99}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignProxyUpdateMatchesNoRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The account disappears between the lock and the update
+	proxyID := 3
+	expectGetProxy(mock, proxyID)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT proxy_id FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(99).
+		WillReturnRows(sqlmock.NewRows([]string{"proxy_id"}).AddRow(nil))
+	expectCapacityCheck(mock, proxyID, 99, nil, 0)
+	mock.ExpectExec(`UPDATE accounts SET proxy_id = \$1`).
+		WithArgs(proxyID, 99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	service := &ProxyService{db: db}
+	_, err = service.AssignProxy(context.Background(), &ProxyAssignmentRequest{AccountID: 99, ProxyID: &proxyID})
+	assert.EqualError(t, err, "account not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignProxyUsesConfiguredDefaultStrategy(t *testing.T) {
+	t.Setenv("DEFAULT_PROXY_STRATEGY", "least_used")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// No default_proxy_strategy setting, so the environment decides
+	expectDefaultStrategySetting(mock, nil)
+	mock.ExpectQuery(`LEFT JOIN accounts a ON p.id = a.proxy_id[\s\S]*ORDER BY COUNT\(a.id\) ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+	expectGetProxy(mock, 4)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT proxy_id FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"proxy_id"}).AddRow(nil))
+	expectCapacityCheck(mock, 4, 1, nil, 0)
+	mock.ExpectExec(`UPDATE accounts SET proxy_id = \$1`).
+		WithArgs(4, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_logs`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE proxy_assignments SET released_at = NOW\(\) WHERE account_id = \$1 AND released_at IS NULL`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO proxy_assignments`).
+		WithArgs(1, 4, "least_used").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	service := &ProxyService{db: db, settings: utils.NewSettings(db)}
+	resp, err := service.AssignProxy(context.Background(), &ProxyAssignmentRequest{AccountID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, resp.ProxyID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDefaultAssignmentStrategyFallsBackToAuto(t *testing.T) {
+	service := &ProxyService{}
+
+	t.Setenv("DEFAULT_PROXY_STRATEGY", "manual")
+	assert.Equal(t, "auto", service.defaultAssignmentStrategy(context.Background()))
+
+	t.Setenv("DEFAULT_PROXY_STRATEGY", "fastest")
+	assert.Equal(t, "fastest", service.defaultAssignmentStrategy(context.Background()))
+}
+
+// expectDefaultStrategySetting expects the settings read; a nil value means
+// the setting row does not exist
+func expectDefaultStrategySetting(mock sqlmock.Sqlmock, value interface{}) {
+	rows := sqlmock.NewRows([]string{"key", "value"})
+	if value != nil {
+		rows.AddRow(defaultProxyStrategySetting, value)
+	}
+	mock.ExpectQuery(`SELECT key, value FROM system_settings`).WillReturnRows(rows)
+}
+
+func TestDefaultAssignmentStrategyPrefersSetting(t *testing.T) {
+	t.Setenv("DEFAULT_PROXY_STRATEGY", "fastest")
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	for value, want := range map[string]string{
+		"round_robin": "round_robin",
+		"manual":      "fastest",
+	} {
+		service := &ProxyService{db: db, settings: utils.NewSettings(db)}
+		expectDefaultStrategySetting(mock, value)
+		assert.Equal(t, want, service.defaultAssignmentStrategy(context.Background()), value)
+	}
+
+	// A missing or empty setting falls back to the environment, and the
+	// settings are read once while cached
+	service := &ProxyService{db: db, settings: utils.NewSettings(db)}
+	expectDefaultStrategySetting(mock, nil)
+	assert.Equal(t, "fastest", service.defaultAssignmentStrategy(context.Background()))
+	assert.Equal(t, "fastest", service.defaultAssignmentStrategy(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateProxyNormalizesHost(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// The bracketed IPv6 URL is looked up in its stored, bare form
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM proxies WHERE host = \$1 AND port = \$2\)`).
+		WithArgs("2001:db8::1", 8080).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}, validator: validator.New()}
+	router := gin.New()
+	router.POST("/proxies", handler.CreateProxy)
+
+	for body, want := range map[string]int{
+		`{"name": "v6", "type": "http", "host": "http://[2001:db8::1]", "port": 8080}`: http.StatusInternalServerError,
+		`{"name": "bad", "type": "http", "host": "http://1.2.3.4/path", "port": 8080}`: http.StatusBadRequest,
+		`{"name": "bad", "type": "http", "host": "1.2.3.4:8080", "port": 8080}`:        http.StatusBadRequest,
+		`{"name": "bad", "type": "http", "host": "not a host", "port": 8080}`:          http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("POST", "/proxies", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Code, body)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignProxyOnlyHealthyProxyAtCapacity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Automatic selection: the capacity filter leaves nothing to pick, and the
+	// follow-up count explains why
+	expectDefaultStrategySetting(mock, nil)
+	mock.ExpectQuery(`p.max_accounts IS NULL OR \(SELECT COUNT\(\*\) FROM accounts cap WHERE cap.proxy_id = p.id\) < p.max_accounts\)[\s\S]*ORDER BY COUNT\(a.id\) ASC`).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM proxies[\s\S]*>= max_accounts`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// Manual assignment to the full proxy is rejected inside the transaction
+	expectGetProxy(mock, 3)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT proxy_id FROM accounts WHERE id = \$1 FOR UPDATE`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"proxy_id"}).AddRow(nil))
+	expectCapacityCheck(mock, 3, 1, 2, 2)
+	mock.ExpectRollback()
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db, settings: utils.NewSettings(db)}}
+	router := gin.New()
+	router.POST("/assignment/assign", handler.AssignProxy)
+
+	t.Setenv("DEFAULT_PROXY_STRATEGY", "least_used")
+	for _, body := range []string{
+		`{"account_id": 1}`,
+		`{"account_id": 1, "proxy_id": 3}`,
+	} {
+		req, _ := http.NewRequest("POST", "/assignment/assign", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code, body)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignProxyRejectsUnhealthyProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Proxy 3 failed its last health check, proxy 4 is marked error; neither
+	// reaches the assignment transaction
+	mock.ExpectQuery(`FROM proxies\s+WHERE id = \$1`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(proxyColumns).
+			AddRow(3, utils.GenerateUUID().String(), "failing", "http", "10.0.0.3", 8080, nil, nil, "active",
+				nil, time.Now(), false, true, nil, 100, time.Now(), time.Now(), "{}"))
+	mock.ExpectQuery(`FROM proxies\s+WHERE id = \$1`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows(proxyColumns).
+			AddRow(4, utils.GenerateUUID().String(), "broken", "http", "10.0.0.4", 8080, nil, nil, "error",
+				nil, time.Now(), true, true, nil, 100, time.Now(), time.Now(), "{}"))
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.POST("/assignment/assign", handler.AssignProxy)
+
+	for _, body := range []string{
+		`{"account_id": 1, "proxy_id": 3}`,
+		`{"account_id": 1, "proxy_id": 4}`,
+	} {
+		req, _ := http.NewRequest("POST", "/assignment/assign", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusConflict, w.Code, body)
+		assert.Contains(t, w.Body.String(), "not healthy", body)
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateCredentials(t *testing.T) {
+	newService := func(t *testing.T, probeErr error) (*ProxyService, sqlmock.Sqlmock, *[]string) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		var probed []string
+		service := &ProxyService{db: db}
+		service.probe = func(ctx context.Context, proxy *models.Proxy) error {
+			probed = append(probed, *proxy.Username+":"+*proxy.Password)
+			return probeErr
+		}
+		return service, mock, &probed
+	}
+	req := &RotateCredentialsRequest{Username: "user2", Password: "secret2"}
+
+	t.Run("success", func(t *testing.T) {
+		service, mock, probed := newService(t, nil)
+		expectGetProxy(mock, 5)
+		mock.ExpectExec(`UPDATE proxies SET username = \$1, password = \$2, updated_at = NOW\(\)\s+WHERE id = \$3 AND username IS NOT DISTINCT FROM \$4 AND password IS NOT DISTINCT FROM \$5`).
+			WithArgs("user2", "secret2", 5, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE proxies\s+SET health_check_success`).
+			WithArgs(true, sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO proxy_health_checks`).
+			WithArgs(5, true, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		result, err := service.RotateCredentials(context.Background(), 5, req)
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.True(t, result.Applied)
+		assert.Equal(t, []string{"user2:secret2"}, *probed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failed test writes nothing", func(t *testing.T) {
+		service, mock, probed := newService(t, fmt.Errorf("proxy returned status code: 407"))
+		// Any write would be unexpected
+		expectGetProxy(mock, 5)
+
+		result, err := service.RotateCredentials(context.Background(), 5, req)
+		assert.NoError(t, err)
+		assert.False(t, result.Success)
+		assert.False(t, result.Applied)
+		assert.Contains(t, result.Error, "407")
+		assert.Equal(t, []string{"user2:secret2"}, *probed, "the new credentials are tested")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent change", func(t *testing.T) {
+		service, mock, _ := newService(t, nil)
+		expectGetProxy(mock, 5)
+		// Another rotation stored different credentials during the test
+		mock.ExpectExec(`UPDATE proxies SET username = \$1, password = \$2`).
+			WithArgs("user2", "secret2", 5, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := service.RotateCredentials(context.Background(), 5, req)
+		assert.ErrorIs(t, err, ErrCredentialsChanged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func setTestCredentialsKey(t *testing.T) {
+	t.Setenv(utils.CredentialsKeyEnv, "a2V5LWtleS1rZXkta2V5LWtleS1rZXkta2V5LWtleS0=")
+}
+
+func TestProxyPasswordNotSerialized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setTestCredentialsKey(t)
+
+	stored, err := utils.EncryptCredential("s3cret")
+	assert.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`FROM proxies\s+WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(proxyColumns).
+			AddRow(7, utils.GenerateUUID().String(), "proxy", "http", "10.0.0.1", 8080, "user", stored, "active",
+				nil, time.Now(), true, true, nil, 100, time.Now(), time.Now(), "{}"))
+
+	mock.ExpectQuery(`AS tags FROM proxies ORDER BY created_at DESC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "name", "type", "host", "port", "status",
+			"health_check_success", "response_time_ms", "last_health_check", "created_at", "tags"}).
+			AddRow(7, utils.GenerateUUID().String(), "proxy", "http", "10.0.0.1", 8080, "active",
+				true, 100, time.Now(), time.Now(), "{}"))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}, validator: validator.New()}
+	router := gin.New()
+	router.GET("/proxies", handler.ListProxies)
+	router.GET("/proxies/:id", handler.GetProxy)
+
+	for _, path := range []string{"/proxies/7", "/proxies"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Contains(t, w.Body.String(), `"id":7`, path)
+		assert.NotContains(t, w.Body.String(), "password", path)
+		assert.NotContains(t, w.Body.String(), stored, path)
+		assert.NotContains(t, w.Body.String(), "s3cret", path)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateProxyEncryptsPassword(t *testing.T) {
+	setTestCredentialsKey(t)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var stored string
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO proxies`).
+		WithArgs(sqlmock.AnyArg(), "p", models.ProxyTypeHTTP, "10.0.0.1", 8080, "user",
+			capturedArg(func(v interface{}) bool {
+				stored, _ = v.(string)
+				return utils.IsEncryptedCredential(stored)
+			}),
+			models.ProxyStatusActive, nil, nil).
+		WillReturnError(fmt.Errorf("stop here"))
+	mock.ExpectRollback()
+
+	username, password := "user", "s3cret"
+	service := &ProxyService{db: db}
+	_, err = service.CreateProxy(context.Background(), &models.CreateProxyRequest{
+		Name: "p", Type: models.ProxyTypeHTTP, Host: "10.0.0.1", Port: 8080,
+		Username: &username, Password: &password,
+	})
+	assert.ErrorContains(t, err, "stop here")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	plaintext, err := utils.DecryptCredential(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", plaintext)
+}
+
+// capturedArg adapts a predicate to sqlmock.Argument
+type capturedArg func(interface{}) bool
+
+func (f capturedArg) Match(v driver.Value) bool { return f(v) }
+
+func TestEncryptStoredPasswords(t *testing.T) {
+	setTestCredentialsKey(t)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, password FROM proxies WHERE password IS NOT NULL AND password NOT LIKE 'enc:%'`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow(1, "one").AddRow(2, "two"))
+	for id, password := range []string{"one", "two"} {
+		mock.ExpectExec(`UPDATE proxies SET password = \$1 WHERE id = \$2 AND password = \$3`).
+			WithArgs(capturedArg(func(v interface{}) bool {
+				plaintext, err := utils.DecryptCredential(v.(string))
+				return err == nil && plaintext == password
+			}), id+1, password).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	service := &ProxyService{db: db}
+	n, err := service.EncryptStoredPasswords(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	t.Setenv(utils.CredentialsKeyEnv, "")
+	_, err = service.EncryptStoredPasswords(context.Background())
+	assert.ErrorIs(t, err, utils.ErrCredentialsKeyMissing)
+}
+
+func TestExportCredentialsRequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setTestCredentialsKey(t)
+	t.Setenv("PROXY_EXPORT_CREDENTIALS_TOKEN", "export-token")
+
+	stored, err := utils.EncryptCredential("s3cret")
+	assert.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}, validator: validator.New()}
+	router := gin.New()
+	router.GET("/proxies/export", handler.ExportProxies)
+
+	req, _ := http.NewRequest("GET", "/proxies/export?include_credentials=true", nil)
+	req.Header.Set("X-Export-Token", "wrong")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	mock.ExpectQuery(`FROM proxies`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid", "name", "type", "host", "port", "status",
+			"health_check_url", "last_health_check", "health_check_success", "health_check_enabled",
+			"response_time_ms", "created_at", "updated_at", "username", "password"}).
+			AddRow(1, utils.GenerateUUID().String(), "p", "http", "10.0.0.1", 8080, "active",
+				nil, nil, true, true, 100, time.Now(), time.Now(), "user", stored))
+
+	req, _ = http.NewRequest("GET", "/proxies/export?include_credentials=true", nil)
+	req.Header.Set("X-Export-Token", "export-token")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"password":"s3cret"`)
+	assert.Contains(t, w.Body.String(), `"username":"user"`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectAssignableCount(mock sqlmock.Sqlmock, id, self, others int) {
+	mock.ExpectQuery(`SELECT COUNT\(CASE WHEN id = \$1 THEN 1 END\), COUNT\(CASE WHEN id <> \$1 THEN 1 END\)\s+FROM proxies`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"self", "others"}).AddRow(self, others))
+}
+
+func TestLastAssignableProxyGuard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.PUT("/proxies/:id", handler.UpdateProxy)
+	router.DELETE("/proxies/:id", handler.DeleteProxy)
+
+	serve := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Deleting or deactivating the only assignable proxy is refused
+	expectGetProxy(mock, 1)
+	expectAssignableCount(mock, 1, 1, 0)
+	assert.Equal(t, http.StatusConflict, serve("DELETE", "/proxies/1", ""))
+
+	expectGetProxy(mock, 1)
+	expectAssignableCount(mock, 1, 1, 0)
+	assert.Equal(t, http.StatusConflict, serve("PUT", "/proxies/1", `{"status": "inactive"}`))
+
+	// Another assignable proxy remains
+	expectGetProxy(mock, 1)
+	expectAssignableCount(mock, 1, 1, 2)
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE proxy_id = \$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`DELETE FROM proxies WHERE id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/proxies/1", ""))
+
+	// force skips the check
+	expectGetProxy(mock, 2)
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM accounts WHERE proxy_id = \$1\)`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`DELETE FROM proxies WHERE id = \$1`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.Equal(t, http.StatusNoContent, serve("DELETE", "/proxies/2?force=true", ""))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
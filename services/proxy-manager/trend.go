@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// Response time trend classifications
+const (
+	TrendImproving        = "improving"
+	TrendStable           = "stable"
+	TrendDegrading        = "degrading"
+	TrendInsufficientData = "insufficient_data"
+)
+
+// minTrendSamples is the fewest successful checks a trend is fitted to
+const minTrendSamples = 5
+
+// recordHealthCheck appends a check result to proxy_health_checks. The history
+// only feeds trends, so failures are logged rather than returned.
+func recordHealthCheck(ctx context.Context, db *sql.DB, proxyID int, success bool, responseTimeMs int) {
+	query := "INSERT INTO proxy_health_checks (proxy_id, success, response_time_ms) VALUES ($1, $2, $3)"
+	if _, err := db.ExecContext(ctx, query, proxyID, success, responseTimeMs); err != nil {
+		log.Printf("Failed to record health check history for proxy %d: %v", proxyID, err)
+	}
+}
+
+// GetResponseTimeTrend fits a line through the proxy's successful health check
+// response times over the last days and classifies the direction. The fitted
+// change across the sampled period is compared with the average: beyond
+// PROXY_TREND_STABLE_PERCENT either way (default 10) the proxy is improving or
+// degrading. Fewer than minTrendSamples checks give insufficient_data.
+func (s *ProxyService) GetResponseTimeTrend(ctx context.Context, proxyID, days int) (*ResponseTimeTrend, error) {
+	if _, err := s.GetProxy(ctx, proxyID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT checked_at, response_time_ms
+		FROM proxy_health_checks
+		WHERE proxy_id = $1 AND success = true AND response_time_ms IS NOT NULL
+		  AND checked_at >= NOW() - $2 * INTERVAL '1 day'
+		ORDER BY checked_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, proxyID, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health check history: %w", err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	var values []float64
+	for rows.Next() {
+		var checkedAt time.Time
+		var responseTimeMs int
+		if err := rows.Scan(&checkedAt, &responseTimeMs); err != nil {
+			return nil, fmt.Errorf("failed to scan health check: %w", err)
+		}
+		times = append(times, checkedAt)
+		values = append(values, float64(responseTimeMs))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read health check history: %w", err)
+	}
+
+	threshold := float64(utils.GetEnvAsInt("PROXY_TREND_STABLE_PERCENT", 10))
+	return classifyResponseTimes(proxyID, days, times, values, threshold), nil
+}
+
+// classifyResponseTimes computes the least-squares slope of values over times
+// in milliseconds per day and classifies it against thresholdPercent
+func classifyResponseTimes(proxyID, days int, times []time.Time, values []float64, thresholdPercent float64) *ResponseTimeTrend {
+	trend := &ResponseTimeTrend{ProxyID: proxyID, Days: days, Samples: len(values), Trend: TrendInsufficientData}
+	if len(values) == 0 {
+		return trend
+	}
+
+	var sumX, sumY float64
+	xs := make([]float64, len(values))
+	for i := range values {
+		xs[i] = times[i].Sub(times[0]).Hours() / 24
+		sumX += xs[i]
+		sumY += values[i]
+	}
+	n := float64(len(values))
+	meanX, meanY := sumX/n, sumY/n
+	trend.AverageMs = math.Round(meanY*100) / 100
+
+	span := xs[len(xs)-1]
+	if len(values) < minTrendSamples || span == 0 || meanY == 0 {
+		return trend
+	}
+
+	var covariance, variance float64
+	for i := range values {
+		covariance += (xs[i] - meanX) * (values[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	slope := covariance / variance
+
+	change := slope * span / meanY * 100
+	trend.SlopeMsPerDay = math.Round(slope*100) / 100
+	trend.ChangePercent = math.Round(change*100) / 100
+
+	switch {
+	case change > thresholdPercent:
+		trend.Trend = TrendDegrading
+	case change < -thresholdPercent:
+		trend.Trend = TrendImproving
+	default:
+		trend.Trend = TrendStable
+	}
+	return trend
+}
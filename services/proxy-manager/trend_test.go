@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyResponseTimes(t *testing.T) {
+	start := time.Now().Add(-6 * 24 * time.Hour)
+	samples := func(values ...float64) ([]time.Time, []float64) {
+		times := make([]time.Time, len(values))
+		for i := range values {
+			times[i] = start.Add(time.Duration(i) * 24 * time.Hour)
+		}
+		return times, values
+	}
+
+	times, values := samples(100, 120, 140, 160, 180, 200, 220)
+	trend := classifyResponseTimes(1, 7, times, values, 10)
+	assert.Equal(t, TrendDegrading, trend.Trend)
+	assert.Equal(t, 20.0, trend.SlopeMsPerDay)
+	assert.Equal(t, 160.0, trend.AverageMs)
+
+	times, values = samples(300, 280, 250, 240, 200, 190, 150)
+	assert.Equal(t, TrendImproving, classifyResponseTimes(1, 7, times, values, 10).Trend)
+
+	// Noise around a flat line stays stable
+	times, values = samples(100, 104, 98, 101, 97, 103, 100)
+	assert.Equal(t, TrendStable, classifyResponseTimes(1, 7, times, values, 10).Trend)
+
+	// Too few samples to call it
+	times, values = samples(100, 400)
+	trend = classifyResponseTimes(1, 7, times, values, 10)
+	assert.Equal(t, TrendInsufficientData, trend.Trend)
+	assert.Equal(t, 2, trend.Samples)
+
+	assert.Equal(t, TrendInsufficientData, classifyResponseTimes(1, 7, nil, nil, 10).Trend)
+}
+
+func TestGetResponseTimeTrendEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now().Add(-5 * 24 * time.Hour)
+	rows := sqlmock.NewRows([]string{"checked_at", "response_time_ms"})
+	for i, ms := range []int{500, 450, 400, 350, 300, 250} {
+		rows.AddRow(start.Add(time.Duration(i)*24*time.Hour), ms)
+	}
+
+	expectGetProxy(mock, 4)
+	mock.ExpectQuery(`FROM proxy_health_checks\s+WHERE proxy_id = \$1 AND success = true`).
+		WithArgs(4, 3).
+		WillReturnRows(rows)
+
+	handler := &ProxyHandler{proxyService: &ProxyService{db: db}}
+	router := gin.New()
+	router.GET("/proxies/:id/trend", handler.GetResponseTimeTrend)
+
+	req, _ := http.NewRequest("GET", "/proxies/4/trend?days=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"trend":"improving"`)
+	assert.Contains(t, w.Body.String(), `"slope_ms_per_day":-50`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	req, _ = http.NewRequest("GET", "/proxies/4/trend?days=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -15,6 +15,39 @@ type UpdateProxyRequest struct {
 	Password       *string              `json:"password,omitempty"`
 	Status         *models.ProxyStatus  `json:"status,omitempty"`
 	HealthCheckURL *string              `json:"health_check_url,omitempty"`
+	// MaxAccounts caps the accounts assigned to the proxy; 0 removes the cap
+	MaxAccounts *int `json:"max_accounts,omitempty" validate:"omitempty,min=0"`
+}
+
+// BulkStatusRequest sets the status of every proxy matching all of the given
+// filters. At least one filter is required.
+type BulkStatusRequest struct {
+	Tag         string             `json:"tag,omitempty"`
+	Type        *models.ProxyType  `json:"type,omitempty" validate:"omitempty,oneof=http socks5"`
+	HostPattern string             `json:"host_pattern,omitempty"` // * matches any characters, e.g. "*.provider.net"
+	Status      models.ProxyStatus `json:"status" validate:"required,oneof=active inactive error"`
+}
+
+// BulkStatusResponse reports how many proxies a bulk update changed
+type BulkStatusResponse struct {
+	Updated int64 `json:"updated"`
+}
+
+// ProxyImportResult reports what an import did. When Cancelled or Truncated
+// is set, the entries after Processed were not read.
+type ProxyImportResult struct {
+	Processed int                  `json:"processed"`
+	Created   int                  `json:"created"`
+	Failures  []ProxyImportFailure `json:"failures"`
+	Cancelled bool                 `json:"cancelled"`
+	Truncated bool                 `json:"truncated"`
+	Note      string               `json:"note,omitempty"`
+}
+
+// ProxyImportFailure reports an import line that was not stored
+type ProxyImportFailure struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
 }
 
 // ProxyTestResult represents the result of testing a proxy
@@ -23,7 +56,25 @@ type ProxyTestResult struct {
 	Success      bool          `json:"success"`
 	ResponseTime time.Duration `json:"response_time"`
 	Error        string        `json:"error,omitempty"`
-	Timestamp    time.Time     `json:"timestamp"`
+	// Code classifies Error as timeout, connection_refused, auth_failed,
+	// bad_status, dns_failure, tls_error or target_not_allowed; empty when it
+	// fits none
+	Code      string    `json:"code,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RotateCredentialsRequest represents new credentials for a proxy
+type RotateCredentialsRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RotateCredentialsResult is the test run against the new credentials.
+// Applied is set when the test passed and the new credentials were stored; a
+// failed test leaves the old ones in place.
+type RotateCredentialsResult struct {
+	ProxyTestResult
+	Applied bool `json:"applied"`
 }
 
 // ProxyAssignmentRequest represents a request to assign a proxy
@@ -31,7 +82,10 @@ type ProxyAssignmentRequest struct {
 	AccountID int                `json:"account_id" validate:"required"`
 	ProxyID   *int               `json:"proxy_id,omitempty"`
 	ProxyType *models.ProxyType  `json:"proxy_type,omitempty"`
-	Strategy  string             `json:"strategy,omitempty"` // auto, manual, round_robin, least_used
+	Strategy  string             `json:"strategy,omitempty"` // auto, manual, round_robin, least_used, fastest, fastest_live, consistent_hash
+	// MaxHealthAge excludes proxies last checked longer ago than this
+	// duration (e.g. "10m"). Empty means no limit.
+	MaxHealthAge string `json:"max_health_age,omitempty"`
 }
 
 // ProxyAssignmentResponse represents the result of proxy assignment
@@ -49,6 +103,18 @@ type ProxyReleaseRequest struct {
 	AccountID int `json:"account_id" validate:"required"`
 }
 
+// AssignmentHistoryEntry is one period an account spent on a proxy.
+// ReleasedAt is nil while the assignment is active; ProxyID is nil once the
+// proxy has been deleted.
+type AssignmentHistoryEntry struct {
+	ID         int        `json:"id"`
+	AccountID  int        `json:"account_id"`
+	ProxyID    *int       `json:"proxy_id"`
+	Strategy   string     `json:"strategy"`
+	AssignedAt time.Time  `json:"assigned_at"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+}
+
 // ProxyUsageResponse represents proxy usage statistics
 type ProxyUsageResponse struct {
 	TotalProxies     int                    `json:"total_proxies"`
@@ -92,6 +158,10 @@ type ProxyHealthStatsResponse struct {
 	HealthChecksPassed int                    `json:"health_checks_passed"`
 	HealthChecksFailed int                    `json:"health_checks_failed"`
 	HealthRate         float64                `json:"health_rate"`
+	// Proxies that passed or failed the Bluesky-specific probe; unchecked
+	// proxies count towards neither
+	BskyReachableProxies int                  `json:"bsky_reachable_proxies"`
+	BskyBlockedProxies   int                  `json:"bsky_blocked_proxies"`
 	ProxyHealthDetails []ProxyHealthDetail    `json:"proxy_health_details"`
 	HealthByType       map[models.ProxyType]ProxyTypeHealth `json:"health_by_type"`
 }
@@ -104,6 +174,7 @@ type ProxyHealthDetail struct {
 	ProxyPort          int       `json:"proxy_port"`
 	ProxyType          string    `json:"proxy_type"`
 	IsHealthy          bool      `json:"is_healthy"`
+	BskyReachable      *bool     `json:"bsky_reachable"`
 	LastHealthCheck    *time.Time `json:"last_health_check"`
 	ResponseTimeMs     int       `json:"response_time_ms"`
 	ConsecutiveFailures int      `json:"consecutive_failures"`
@@ -118,6 +189,11 @@ type ProxyTypeHealth struct {
 	AvgResponseTime  float64 `json:"avg_response_time_ms"`
 }
 
+// ProxyTagHealthStatsResponse represents proxy health statistics grouped by tag
+type ProxyTagHealthStatsResponse struct {
+	HealthByTag map[string]ProxyTypeHealth `json:"health_by_tag"`
+}
+
 // ProxyPerformanceStatsResponse represents proxy performance statistics
 type ProxyPerformanceStatsResponse struct {
 	TimeRange              string                     `json:"time_range"`
@@ -163,11 +239,13 @@ type DailyPerformanceStats struct {
 type ProxyAssignmentStrategy string
 
 const (
-	AssignmentStrategyAuto       ProxyAssignmentStrategy = "auto"
-	AssignmentStrategyManual     ProxyAssignmentStrategy = "manual"
-	AssignmentStrategyRoundRobin ProxyAssignmentStrategy = "round_robin"
-	AssignmentStrategyLeastUsed  ProxyAssignmentStrategy = "least_used"
-	AssignmentStrategyFastest    ProxyAssignmentStrategy = "fastest"
+	AssignmentStrategyAuto        ProxyAssignmentStrategy = "auto"
+	AssignmentStrategyManual      ProxyAssignmentStrategy = "manual"
+	AssignmentStrategyRoundRobin  ProxyAssignmentStrategy = "round_robin"
+	AssignmentStrategyLeastUsed   ProxyAssignmentStrategy = "least_used"
+	AssignmentStrategyFastest     ProxyAssignmentStrategy = "fastest"
+	AssignmentStrategyFastestLive ProxyAssignmentStrategy = "fastest_live"
+	AssignmentStrategyConsistent  ProxyAssignmentStrategy = "consistent_hash"
 )
 
 // ProxyMetric represents a proxy performance metric
@@ -200,3 +278,71 @@ type ProxyPool struct {
 	CreatedAt   time.Time               `json:"created_at"`
 	UpdatedAt   time.Time               `json:"updated_at"`
 }
+
+// RankingWeights are the relative weights of the proxy ranking components
+type RankingWeights struct {
+	Health float64 `json:"health"`
+	Speed  float64 `json:"speed"`
+	Load   float64 `json:"load"`
+}
+
+// ProxyRanking represents one proxy's composite score and its components
+type ProxyRanking struct {
+	ProxyID          int     `json:"proxy_id"`
+	Name             string  `json:"name"`
+	Host             string  `json:"host"`
+	Port             int     `json:"port"`
+	Type             string  `json:"type"`
+	Status           string  `json:"status"`
+	Healthy          bool    `json:"healthy"`
+	ResponseTimeMs   int     `json:"response_time_ms"`
+	AssignedAccounts int     `json:"assigned_accounts"`
+	HealthScore      float64 `json:"health_score"`
+	SpeedScore       float64 `json:"speed_score"`
+	LoadScore        float64 `json:"load_score"`
+	Score            float64 `json:"score"`
+}
+
+// ProxyRankingResponse lists proxies from best to worst score
+type ProxyRankingResponse struct {
+	Weights RankingWeights `json:"weights"`
+	Proxies []ProxyRanking `json:"proxies"`
+}
+
+// ResponseTimeTrend describes how a proxy's response times are moving
+type ResponseTimeTrend struct {
+	ProxyID       int     `json:"proxy_id"`
+	Days          int     `json:"days"`
+	Samples       int     `json:"samples"`
+	AverageMs     float64 `json:"average_ms"`
+	SlopeMsPerDay float64 `json:"slope_ms_per_day"`
+	// ChangePercent is the fitted change across the sampled period relative
+	// to the average response time
+	ChangePercent float64 `json:"change_percent"`
+	Trend         string  `json:"trend"` // improving, stable, degrading, insufficient_data
+}
+
+// StrategyEffectiveness summarizes login outcomes of accounts after they were
+// assigned a proxy by one strategy. Rates are percentages and stay 0 when
+// there is nothing to divide by.
+type StrategyEffectiveness struct {
+	Strategy    string `json:"strategy"`
+	Assignments int    `json:"assignments"`
+	// AssignmentsWithOutcomes counts assignments followed by at least one
+	// login attempt within the window
+	AssignmentsWithOutcomes int     `json:"assignments_with_outcomes"`
+	AuthSuccesses           int     `json:"auth_successes"`
+	AuthFailures            int     `json:"auth_failures"`
+	SuccessRate             float64 `json:"success_rate"` // Share of login attempts that succeeded
+	// HealthyAssignmentRate is the share of assignments with outcomes that
+	// saw no failed login
+	HealthyAssignmentRate float64 `json:"healthy_assignment_rate"`
+}
+
+// AssignmentEffectivenessResponse compares assignment strategies by the login
+// outcomes that followed each assignment
+type AssignmentEffectivenessResponse struct {
+	Days        int                     `json:"days"`
+	WindowHours int                     `json:"window_hours"`
+	Strategies  []StrategyEffectiveness `json:"strategies"`
+}
@@ -0,0 +1,195 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+// Finding codes reported in AccountHealth.Findings
+const (
+	// AccountFindingInactive: the PDS reports the account deactivated,
+	// suspended or taken down
+	AccountFindingInactive = "inactive"
+	// AccountFindingProfileHidden: the AppView does not serve the profile
+	AccountFindingProfileHidden = "profile_hidden"
+	// AccountFindingModerationLabel: the profile carries a label that hides it
+	AccountFindingModerationLabel = "moderation_label"
+	// AccountFindingPostNotIndexed: the latest post is in the repo but
+	// missing from the AppView
+	AccountFindingPostNotIndexed = "post_not_indexed"
+)
+
+// Check names, used as keys in AccountHealth.Failed
+const (
+	AccountCheckIdentity = "identity"
+	AccountCheckProfile  = "profile"
+	AccountCheckPosts    = "posts"
+)
+
+// repoStatusErrors maps the errors getRepoStatus answers for a repo the PDS
+// no longer serves to the matching account status
+var repoStatusErrors = map[string]string{
+	"RepoTakendown":   "takendown",
+	"RepoSuspended":   "suspended",
+	"RepoDeactivated": "deactivated",
+	"RepoNotFound":    "not_found",
+}
+
+// postIndexGracePeriod is how old the latest post must be before a missing
+// AppView copy counts as not indexed rather than not indexed yet
+const postIndexGracePeriod = 5 * time.Minute
+
+// hidingLabels are moderation label values that hide an account from others
+var hidingLabels = map[string]bool{"!hide": true, "!takedown": true, "!suspend": true}
+
+// AccountFinding is one sign the account is restricted
+type AccountFinding struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// AccountHealth is the outcome of CheckAccountStatus. An account can log in
+// and still be effectively restricted, e.g. hidden from the AppView; each
+// such sign is listed in Findings. Checks that could not run are listed in
+// Failed with their error and produce no finding.
+type AccountHealth struct {
+	DID    string `json:"did"`
+	Active bool   `json:"active"`
+	// Status is the PDS's reason for an inactive account, e.g. "deactivated"
+	Status         string   `json:"status,omitempty"`
+	ProfileVisible bool     `json:"profile_visible"`
+	Labels         []string `json:"labels,omitempty"`
+	LatestPostURI  string   `json:"latest_post_uri,omitempty"`
+	// LatestPostIndexed is nil when there is no post old enough to check
+	LatestPostIndexed *bool             `json:"latest_post_indexed,omitempty"`
+	Findings          []AccountFinding  `json:"findings"`
+	Failed            map[string]string `json:"failed,omitempty"`
+	CheckedAt         time.Time         `json:"checked_at"`
+}
+
+// Healthy reports whether no check found the account restricted
+func (h *AccountHealth) Healthy() bool {
+	return len(h.Findings) == 0
+}
+
+func (h *AccountHealth) addFinding(code, format string, args ...interface{}) {
+	h.Findings = append(h.Findings, AccountFinding{Code: code, Detail: fmt.Sprintf(format, args...)})
+}
+
+// CheckAccountStatus looks for signs that the account with did is
+// deactivated or shadow-banned: whether its PDS reports the repo active,
+// whether the AppView at appViewHost serves its profile and which moderation
+// labels it carries, and whether its latest post has been indexed. It makes
+// only unauthenticated reads, so the session is neither used nor refreshed
+// and a taken-down account is not logged in. The PDS is taken from the DID
+// document, falling back to the account's host if that cannot be resolved.
+// Nothing is posted to probe indexing, so an account without posts skips
+// that check. It fails only when the repo status cannot be read.
+func (c *Client) CheckAccountStatus(ctx context.Context, did, appViewHost string) (*AccountHealth, error) {
+	health := &AccountHealth{DID: did, Findings: []AccountFinding{}, CheckedAt: time.Now()}
+
+	pdsHost := c.xrpcc.Host
+	if doc, err := c.ResolveDIDDocument(ctx, did); err != nil {
+		health.recordFailure(AccountCheckIdentity, err)
+	} else if doc.PDSEndpoint != "" {
+		pdsHost = doc.PDSEndpoint
+	}
+	pds := &xrpc.Client{Client: c.xrpcc.Client, Host: pdsHost}
+	appView := &xrpc.Client{Client: c.xrpcc.Client, Host: appViewHost}
+
+	repo, err := comatproto.SyncGetRepoStatus(ctx, pds, did)
+	if err != nil {
+		// A repo the PDS stopped serving is refused outright
+		name, _ := xrpcErrorName(err)
+		status, ok := repoStatusErrors[name]
+		if !ok {
+			return nil, fmt.Errorf("failed to get repo status: %w", err)
+		}
+		health.Status = status
+		health.addFinding(AccountFindingInactive, "the PDS refused the repo: %s", name)
+		c.checkProfileVisible(ctx, appView, health)
+		return health, nil
+	}
+
+	health.Active = repo.Active
+	if !health.Active {
+		if repo.Status != nil {
+			health.Status = *repo.Status
+		}
+		health.addFinding(AccountFindingInactive, "the PDS reports the account inactive (%s)", health.Status)
+	}
+
+	c.checkProfileVisible(ctx, appView, health)
+	c.checkLatestPostIndexed(ctx, pds, appView, health)
+
+	return health, nil
+}
+
+// checkProfileVisible fetches the account's profile from the AppView
+func (c *Client) checkProfileVisible(ctx context.Context, appView *xrpc.Client, health *AccountHealth) {
+	profile, err := bsky.ActorGetProfile(ctx, appView, health.DID)
+	if err != nil {
+		name, status := xrpcErrorName(err)
+		if status == http.StatusBadRequest || status == http.StatusNotFound {
+			health.addFinding(AccountFindingProfileHidden, "the AppView did not return the profile: %s", name)
+			return
+		}
+		health.recordFailure(AccountCheckProfile, err)
+		return
+	}
+
+	health.ProfileVisible = true
+	for _, label := range profile.Labels {
+		health.Labels = append(health.Labels, label.Val)
+		if hidingLabels[label.Val] {
+			health.addFinding(AccountFindingModerationLabel, "the profile is labelled %s by %s", label.Val, label.Src)
+		}
+	}
+}
+
+// checkLatestPostIndexed looks the repo's latest post up in the AppView. Posts
+// younger than postIndexGracePeriod are skipped, as indexing takes a moment.
+func (c *Client) checkLatestPostIndexed(ctx context.Context, pds, appView *xrpc.Client, health *AccountHealth) {
+	records, err := comatproto.RepoListRecords(ctx, pds, "app.bsky.feed.post", "", 1, health.DID, false)
+	if err != nil {
+		health.recordFailure(AccountCheckPosts, fmt.Errorf("failed to list posts: %w", err))
+		return
+	}
+	if len(records.Records) == 0 {
+		return
+	}
+
+	latest := records.Records[0]
+	if post, ok := latest.Value.Val.(*bsky.FeedPost); ok {
+		if createdAt, err := syntax.ParseDatetimeLenient(post.CreatedAt); err == nil && time.Since(createdAt.Time()) < postIndexGracePeriod {
+			return
+		}
+	}
+
+	health.LatestPostURI = latest.Uri
+	resp, err := bsky.FeedGetPosts(ctx, appView, []string{latest.Uri})
+	if err != nil {
+		health.recordFailure(AccountCheckPosts, fmt.Errorf("failed to get posts: %w", err))
+		return
+	}
+
+	indexed := len(resp.Posts) > 0
+	health.LatestPostIndexed = &indexed
+	if !indexed {
+		health.addFinding(AccountFindingPostNotIndexed, "the AppView does not have %s", latest.Uri)
+	}
+}
+
+func (h *AccountHealth) recordFailure(check string, err error) {
+	if h.Failed == nil {
+		h.Failed = make(map[string]string)
+	}
+	h.Failed[check] = err.Error()
+}
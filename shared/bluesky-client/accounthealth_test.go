@@ -0,0 +1,207 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// accountHealthPDS answers the calls CheckAccountStatus makes, acting as PLC
+// directory, PDS and AppView at once. repoStatus is the getRepoStatus
+// response; profile is the getProfile response, or nil for a not-found
+// error; indexed controls whether getPosts returns the latest post.
+type accountHealthPDS struct {
+	repoStatus map[string]interface{}
+	profile    map[string]interface{}
+	postAge    time.Duration
+	indexed    bool
+}
+
+func (p *accountHealthPDS) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"), "%s must not be authenticated", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/" + testDID:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"id": testDID,
+				"service": []map[string]string{
+					{"id": "#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "http://" + r.Host},
+				},
+			})
+		case "/" + testDID + "/data":
+			json.NewEncoder(w).Encode(map[string]interface{}{"did": testDID, "rotationKeys": []string{}})
+		case "/xrpc/com.atproto.sync.getRepoStatus":
+			assert.Equal(t, testDID, r.URL.Query().Get("did"))
+			json.NewEncoder(w).Encode(p.repoStatus)
+		case "/xrpc/app.bsky.actor.getProfile":
+			assert.Equal(t, testDID, r.URL.Query().Get("actor"))
+			if p.profile == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "Profile not found"})
+				return
+			}
+			json.NewEncoder(w).Encode(p.profile)
+		case "/xrpc/com.atproto.repo.listRecords":
+			assert.Equal(t, testDID, r.URL.Query().Get("repo"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"records": []map[string]interface{}{{
+					"uri": "at://" + testDID + "/app.bsky.feed.post/3kpost",
+					"cid": "bafypost",
+					"value": map[string]string{
+						"$type":     "app.bsky.feed.post",
+						"text":      "hello",
+						"createdAt": time.Now().Add(-p.postAge).UTC().Format(time.RFC3339),
+					},
+				}},
+			})
+		case "/xrpc/app.bsky.feed.getPosts":
+			posts := []map[string]interface{}{}
+			if p.indexed {
+				posts = append(posts, map[string]interface{}{
+					"uri":       "at://" + testDID + "/app.bsky.feed.post/3kpost",
+					"cid":       "bafypost",
+					"author":    map[string]string{"did": testDID, "handle": "test.bsky.social"},
+					"record":    map[string]string{"$type": "app.bsky.feed.post", "text": "hello", "createdAt": "2024-01-01T00:00:00.000Z"},
+					"indexedAt": "2024-01-01T00:00:00.000Z",
+				})
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"posts": posts})
+		case "/xrpc/com.atproto.server.createSession", "/xrpc/com.atproto.server.refreshSession",
+			"/xrpc/com.atproto.server.getSession":
+			t.Errorf("the status check must not use a session, got %s", r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+func activeRepo() map[string]interface{} {
+	return map[string]interface{}{"did": testDID, "active": true}
+}
+
+// newAccountHealthClient returns a client whose PLC directory, PDS and
+// AppView are all served by handler, without a session
+func newAccountHealthClient(t *testing.T, handler http.HandlerFunc) (*Client, string) {
+	t.Helper()
+	client := newTestClient(t, handler)
+	client.xrpcc.Auth = &xrpc.AuthInfo{Handle: "test.bsky.social"}
+	client.plcDirectory = client.xrpcc.Host
+	return client, client.xrpcc.Host
+}
+
+func TestCheckAccountStatusHealthy(t *testing.T) {
+	pds := &accountHealthPDS{
+		repoStatus: activeRepo(),
+		profile:    map[string]interface{}{"did": testDID, "handle": "test.bsky.social"},
+		postAge:    time.Hour,
+		indexed:    true,
+	}
+	client, appView := newAccountHealthClient(t, pds.handler(t))
+
+	health, err := client.CheckAccountStatus(context.Background(), testDID, appView)
+	require.NoError(t, err)
+	assert.True(t, health.Healthy(), "%+v", health.Findings)
+	assert.Equal(t, testDID, health.DID)
+	assert.True(t, health.Active)
+	assert.True(t, health.ProfileVisible)
+	assert.Equal(t, "at://"+testDID+"/app.bsky.feed.post/3kpost", health.LatestPostURI)
+	require.NotNil(t, health.LatestPostIndexed)
+	assert.True(t, *health.LatestPostIndexed)
+	assert.Empty(t, health.Failed)
+}
+
+func TestCheckAccountStatusProfileNotFound(t *testing.T) {
+	pds := &accountHealthPDS{repoStatus: activeRepo(), postAge: time.Hour}
+	client, appView := newAccountHealthClient(t, pds.handler(t))
+
+	health, err := client.CheckAccountStatus(context.Background(), testDID, appView)
+	require.NoError(t, err)
+	assert.False(t, health.Healthy())
+	assert.True(t, health.Active)
+	assert.False(t, health.ProfileVisible)
+
+	codes := make([]string, 0, len(health.Findings))
+	for _, finding := range health.Findings {
+		codes = append(codes, finding.Code)
+	}
+	assert.Equal(t, []string{AccountFindingProfileHidden, AccountFindingPostNotIndexed}, codes)
+}
+
+func TestCheckAccountStatusLabelledAndInactive(t *testing.T) {
+	pds := &accountHealthPDS{
+		repoStatus: map[string]interface{}{"did": testDID, "active": false, "status": "deactivated"},
+		profile: map[string]interface{}{
+			"did": testDID, "handle": "test.bsky.social",
+			"labels": []map[string]interface{}{
+				{"src": "did:plc:mod", "uri": "at://" + testDID, "val": "!hide", "cts": "2024-01-01T00:00:00.000Z"},
+				{"src": testDID, "uri": "at://" + testDID, "val": "!no-unauthenticated", "cts": "2024-01-01T00:00:00.000Z"},
+			},
+		},
+		// Too recent to tell whether indexing is lagging
+		postAge: time.Minute,
+	}
+	client, appView := newAccountHealthClient(t, pds.handler(t))
+
+	health, err := client.CheckAccountStatus(context.Background(), testDID, appView)
+	require.NoError(t, err)
+	assert.False(t, health.Active)
+	assert.Equal(t, "deactivated", health.Status)
+	assert.True(t, health.ProfileVisible)
+	assert.Equal(t, []string{"!hide", "!no-unauthenticated"}, health.Labels)
+	assert.Nil(t, health.LatestPostIndexed)
+
+	if assert.Len(t, health.Findings, 2) {
+		assert.Equal(t, AccountFindingInactive, health.Findings[0].Code)
+		assert.Equal(t, AccountFindingModerationLabel, health.Findings[1].Code)
+		assert.Contains(t, health.Findings[1].Detail, "did:plc:mod")
+	}
+}
+
+func TestCheckAccountStatusRecordsFailedChecks(t *testing.T) {
+	client, appView := newAccountHealthClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.sync.getRepoStatus":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(activeRepo())
+		case "/" + testDID, "/xrpc/app.bsky.actor.getProfile", "/xrpc/com.atproto.repo.listRecords":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	health, err := client.CheckAccountStatus(context.Background(), testDID, appView)
+	require.NoError(t, err)
+	assert.True(t, health.Healthy(), "a check that could not run is not a finding")
+	assert.Contains(t, health.Failed, AccountCheckIdentity, "the account's host is used instead")
+	assert.Contains(t, health.Failed, AccountCheckProfile)
+	assert.Contains(t, health.Failed, AccountCheckPosts)
+}
+
+func TestCheckAccountStatusTakenDown(t *testing.T) {
+	pds := &accountHealthPDS{}
+	handler := pds.handler(t)
+	client, appView := newAccountHealthClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/xrpc/com.atproto.sync.getRepoStatus" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "RepoTakendown", "message": "Repo has been takendown"})
+			return
+		}
+		handler(w, r)
+	})
+
+	health, err := client.CheckAccountStatus(context.Background(), testDID, appView)
+	require.NoError(t, err)
+	assert.False(t, health.Active)
+	assert.Equal(t, "takendown", health.Status)
+	assert.False(t, health.ProfileVisible)
+	if assert.Len(t, health.Findings, 2) {
+		assert.Equal(t, AccountFindingInactive, health.Findings[0].Code)
+		assert.Equal(t, AccountFindingProfileHidden, health.Findings[1].Code)
+	}
+}
@@ -0,0 +1,166 @@
+package bluesky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// RateLimiter paces outgoing actions; *rate.Limiter from golang.org/x/time/rate
+// satisfies it
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// BatchResult represents the outcome of one target in a batch operation
+type BatchResult struct {
+	Target  string `json:"target"`
+	Success bool   `json:"success"`
+	Skipped bool   `json:"skipped,omitempty"` // Target was deleted or is the account itself; safe to drop
+	URI     string `json:"uri,omitempty"`
+	CID     string `json:"cid,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// BatchLike likes each post URI in turn. Failures are recorded per target and
+// do not stop the batch.
+func (c *Client) BatchLike(ctx context.Context, uris []string) []BatchResult {
+	return c.runBatch(ctx, uris, func(ctx context.Context, uri string) (string, string, error) {
+		result, err := c.Like(ctx, uri)
+		if err != nil {
+			return "", "", err
+		}
+		return result.URI, result.CID, nil
+	})
+}
+
+// BatchFollow follows each handle in turn. Failures are recorded per target
+// and do not stop the batch.
+func (c *Client) BatchFollow(ctx context.Context, handles []string) []BatchResult {
+	return c.runBatch(ctx, handles, func(ctx context.Context, handle string) (string, string, error) {
+		result, err := c.Follow(ctx, handle, nil)
+		if err != nil {
+			return "", "", err
+		}
+		return result.URI, result.CID, nil
+	})
+}
+
+// runBatch applies action to each target, waiting on the rate limiter before
+// every call. If the context ends or the account is found to be inactive, the
+// remaining targets are marked failed.
+func (c *Client) runBatch(ctx context.Context, targets []string, action func(context.Context, string) (string, string, error)) []BatchResult {
+	results := make([]BatchResult, len(targets))
+
+	for i, target := range targets {
+		results[i].Target = target
+
+		err := c.waitForRateLimit(ctx)
+		if err == nil {
+			results[i].URI, results[i].CID, err = action(ctx, target)
+		}
+
+		if err != nil {
+			results[i].Err = err
+			results[i].Error = err.Error()
+			results[i].Skipped = errors.Is(err, ErrTargetGone) || errors.Is(err, ErrSelfAction)
+			// Stop early when nothing further can succeed
+			stop := ctx.Err()
+			if errors.Is(err, ErrAccountNotActive) {
+				stop = err
+			}
+			if stop != nil {
+				for j := i + 1; j < len(targets); j++ {
+					results[j] = BatchResult{Target: targets[j], Err: stop, Error: stop.Error()}
+				}
+				break
+			}
+			continue
+		}
+
+		results[i].Success = true
+	}
+
+	return results
+}
+
+// waitForRateLimit blocks until the configured rate limiter allows another
+// action. Clients without a limiter proceed immediately.
+func (c *Client) waitForRateLimit(ctx context.Context) error {
+	if c.limiter == nil {
+		return ctx.Err()
+	}
+	return c.limiter.Wait(ctx)
+}
+
+// DeleteRecentPostsOptions controls DeleteRecentPosts
+type DeleteRecentPostsOptions struct {
+	DryRun bool `json:"dry_run,omitempty"` // Count matching posts without deleting them
+}
+
+// DeleteRecentPosts deletes up to limit of the account's own most recent
+// posts, replies included, by paging its author feed. Reposts and other
+// authors' posts in the feed are left alone. Each delete waits on the rate
+// limiter and a failed delete does not stop the rest. It returns how many
+// posts were deleted, or would be in a dry run, with the failures joined.
+func (c *Client) DeleteRecentPosts(ctx context.Context, limit int, options *DeleteRecentPostsOptions) (int, error) {
+	if options == nil {
+		options = &DeleteRecentPostsOptions{}
+	}
+	if !options.DryRun {
+		if err := c.checkWritable(); err != nil {
+			return 0, err
+		}
+	}
+
+	own := c.xrpcc.Auth.Did
+	var uris []string
+	cursor := ""
+	for len(uris) < limit {
+		page, err := bsky.FeedGetAuthorFeed(ctx, c.xrpcc, own, cursor, "posts_with_replies", false, int64(min(limit-len(uris), 100)))
+		if err != nil {
+			return 0, fmt.Errorf("failed to get author feed: %w", err)
+		}
+
+		for _, item := range page.Feed {
+			if item.Reason != nil || item.Post == nil || item.Post.Author == nil || item.Post.Author.Did != own {
+				continue
+			}
+			uris = append(uris, item.Post.Uri)
+			if len(uris) == limit {
+				break
+			}
+		}
+
+		if page.Cursor == nil || *page.Cursor == "" || len(page.Feed) == 0 {
+			break
+		}
+		cursor = *page.Cursor
+	}
+
+	if options.DryRun {
+		return len(uris), nil
+	}
+
+	deleted := 0
+	var errs []error
+	for _, uri := range uris {
+		if err := c.waitForRateLimit(ctx); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if err := c.DeletePost(ctx, uri); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", uri, err))
+			if errors.Is(err, ErrAccountNotActive) {
+				break
+			}
+			continue
+		}
+		deleted++
+	}
+
+	return deleted, errors.Join(errs...)
+}
@@ -0,0 +1,213 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingLimiter records how often Wait is called
+type countingLimiter struct {
+	calls int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.calls++
+	return ctx.Err()
+}
+
+// newBatchTestClient serves getRecord, getProfile and createRecord. Posts with
+// rkey "deleted" and the handle "blocked.bsky.social" fail.
+func newBatchTestClient(t *testing.T, limiter RateLimiter) *Client {
+	t.Helper()
+
+	writeJSON := func(w http.ResponseWriter, status int, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		rkey := r.URL.Query().Get("rkey")
+		if rkey == "deleted" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "RecordNotFound", "message": "Could not locate record"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"uri": "at://did:plc:author/app.bsky.feed.post/" + rkey,
+			"cid": "bafypost",
+			"value": map[string]string{
+				"$type":     "app.bsky.feed.post",
+				"text":      "hello",
+				"createdAt": "2024-01-01T00:00:00.000Z",
+			},
+		})
+	})
+	mux.HandleFunc("/xrpc/app.bsky.actor.getProfile", func(w http.ResponseWriter, r *http.Request) {
+		actor := r.URL.Query().Get("actor")
+		if actor == "blocked.bsky.social" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "BlockedActor", "message": "Requester has blocked actor"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"did":    "did:plc:" + strings.Split(actor, ".")[0],
+			"handle": actor,
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"uri": "at://did:plc:test/app.bsky.feed.like/new",
+			"cid": "bafynew",
+		})
+	})
+
+	return newTestClient(t, muxHandler(mux), func(config *ClientConfig) {
+		config.RateLimiter = limiter
+	})
+}
+
+func TestBatchLike(t *testing.T) {
+	limiter := &countingLimiter{}
+	client := newBatchTestClient(t, limiter)
+
+	uris := []string{
+		"at://did:plc:author/app.bsky.feed.post/one",
+		"at://did:plc:author/app.bsky.feed.post/deleted",
+		"not-a-uri",
+		"at://did:plc:author/app.bsky.feed.post/two",
+	}
+
+	results := client.BatchLike(context.Background(), uris)
+	assert.Len(t, results, 4)
+	assert.Equal(t, 4, limiter.calls)
+
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "bafynew", results[0].CID)
+
+	assert.False(t, results[1].Success)
+	assert.Contains(t, results[1].Error, "RecordNotFound")
+
+	assert.False(t, results[2].Success)
+	assert.Contains(t, results[2].Error, "invalid post URI")
+
+	assert.True(t, results[3].Success)
+	assert.Equal(t, uris[3], results[3].Target)
+}
+
+func TestBatchFollow(t *testing.T) {
+	client := newBatchTestClient(t, nil)
+
+	results := client.BatchFollow(context.Background(), []string{
+		"alice.bsky.social", "blocked.bsky.social", "bob.bsky.social",
+	})
+	assert.Len(t, results, 3)
+
+	assert.True(t, results[0].Success)
+	assert.False(t, results[1].Success)
+	assert.Error(t, results[1].Err)
+	assert.True(t, results[2].Success)
+}
+
+func TestBatchStopsOnCancelledContext(t *testing.T) {
+	client := newBatchTestClient(t, &countingLimiter{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := client.BatchFollow(ctx, []string{"alice.bsky.social", "bob.bsky.social"})
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.False(t, result.Success)
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
+
+// newDeletePostsTestClient serves a two-page author feed for did:plc:test
+// mixing own posts with a repost of someone else's post. Deleting
+// rkey "stuck" fails; every other delete is recorded.
+func newDeletePostsTestClient(t *testing.T, limiter RateLimiter, deleted *[]string) *Client {
+	t.Helper()
+
+	post := func(author, rkey string) map[string]interface{} {
+		return map[string]interface{}{
+			"uri":       "at://" + author + "/app.bsky.feed.post/" + rkey,
+			"cid":       "bafy" + rkey,
+			"author":    map[string]string{"did": author, "handle": "someone.bsky.social"},
+			"record":    map[string]string{"$type": "app.bsky.feed.post", "text": rkey, "createdAt": "2024-01-01T00:00:00.000Z"},
+			"indexedAt": "2024-01-01T00:00:00.000Z",
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/app.bsky.feed.getAuthorFeed", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "did:plc:test", r.URL.Query().Get("actor"))
+		resp := map[string]interface{}{}
+		if r.URL.Query().Get("cursor") == "" {
+			resp["feed"] = []map[string]interface{}{
+				{"post": post("did:plc:test", "one")},
+				{"post": post("did:plc:other", "reposted"), "reason": map[string]interface{}{
+					"$type":     "app.bsky.feed.defs#reasonRepost",
+					"by":        map[string]string{"did": "did:plc:test", "handle": "test.bsky.social"},
+					"indexedAt": "2024-01-01T00:00:00.000Z",
+				}},
+				{"post": post("did:plc:test", "stuck")},
+			}
+			resp["cursor"] = "page2"
+		} else {
+			resp["feed"] = []map[string]interface{}{
+				{"post": post("did:plc:test", "two")},
+				{"post": post("did:plc:test", "three")},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.deleteRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Rkey string `json:"rkey"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		w.Header().Set("Content-Type", "application/json")
+		if input.Rkey == "stuck" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "InternalServerError", "message": "try again"})
+			return
+		}
+		*deleted = append(*deleted, input.Rkey)
+		json.NewEncoder(w).Encode(map[string]interface{}{})
+	})
+
+	return newTestClient(t, muxHandler(mux), func(config *ClientConfig) {
+		config.RateLimiter = limiter
+	})
+}
+
+func TestDeleteRecentPosts(t *testing.T) {
+	var deleted []string
+	limiter := &countingLimiter{}
+	client := newDeletePostsTestClient(t, limiter, &deleted)
+
+	count, err := client.DeleteRecentPosts(context.Background(), 3, nil)
+	assert.Equal(t, 2, count)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at://did:plc:test/app.bsky.feed.post/stuck")
+	assert.Equal(t, []string{"one", "two"}, deleted)
+	assert.Equal(t, 3, limiter.calls)
+}
+
+func TestDeleteRecentPostsDryRun(t *testing.T) {
+	var deleted []string
+	limiter := &countingLimiter{}
+	client := newDeletePostsTestClient(t, limiter, &deleted)
+
+	count, err := client.DeleteRecentPosts(context.Background(), 10, &DeleteRecentPostsOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	assert.Empty(t, deleted)
+	assert.Equal(t, 0, limiter.calls)
+}
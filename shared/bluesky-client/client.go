@@ -2,9 +2,14 @@ package bluesky
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	comatproto "github.com/bluesky-social/indigo/api/atproto"
@@ -12,20 +17,68 @@ import (
 	lexutil "github.com/bluesky-social/indigo/lex/util"
 	"github.com/bluesky-social/indigo/xrpc"
 	"github.com/bsky-automation/shared/models"
+	"github.com/bsky-automation/shared/utils"
+	netproxy "golang.org/x/net/proxy"
+	"golang.org/x/sync/singleflight"
 )
 
 // Client represents a Bluesky client with proxy support
 type Client struct {
-	xrpcc   *xrpc.Client
-	account *models.Account
-	proxy   *models.Proxy
+	xrpcc         *xrpc.Client
+	account       *models.Account
+	proxy         *models.Proxy
+	limiter       RateLimiter
+	images        ImageLimits
+	plcDirectory  string
+	allowSelf     bool
+	enforceStatus bool
+
+	// timelineAlgorithms are accepted by GetTimeline besides the default
+	timelineAlgorithms []string
+
+	// statsMu guards repoStats only; it is never held while fetching
+	statsMu     sync.Mutex
+	repoStats   *RepoStats
+	statsFlight singleflight.Group
+
+	rateMu    sync.Mutex
+	rateLimit *RateLimitStatus
+
+	// authFlight collapses concurrent Authenticate calls into one
+	authFlight singleflight.Group
 }
 
 // ClientConfig represents configuration for creating a client
 type ClientConfig struct {
-	Account *models.Account
-	Proxy   *models.Proxy
-	Timeout time.Duration
+	Account      *models.Account
+	Proxy        *models.Proxy
+	Timeout      time.Duration
+	RateLimiter  RateLimiter  // Optional; paces batch operations
+	ImageLimits  *ImageLimits // Optional; defaults to DefaultImageLimits
+	PLCDirectory string       // Optional; defaults to DefaultPLCDirectory
+
+	// DialTimeout bounds establishing the connection (to the proxy, when one
+	// is set) separately from Timeout, which covers the whole request. Dead
+	// proxies then fail fast while slow uploads still get the full Timeout.
+	// Optional; clients using Transports get the pool's dial timeout instead.
+	DialTimeout time.Duration
+
+	// Transports shares one transport per proxy across clients. Optional;
+	// without it each proxied client gets its own transport.
+	Transports *TransportPool
+
+	// AllowSelfActions lets the account follow, like and repost itself.
+	// Off by default; meant for test repos.
+	AllowSelfActions bool
+
+	// EnforceAccountStatus refuses writes with ErrAccountNotActive while the
+	// account's status is anything but active. Reads are still allowed.
+	EnforceAccountStatus bool
+
+	// TimelineAlgorithms lists home feed algorithms GetTimeline accepts in
+	// addition to DefaultTimelineAlgorithm, for PDSes that serve other
+	// variants. Optional.
+	TimelineAlgorithms []string
 }
 
 // NewClient creates a new Bluesky client with optional proxy support
@@ -34,13 +87,36 @@ func NewClient(config ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("account is required")
 	}
 
+	if config.Account.Handle == "" {
+		return nil, fmt.Errorf("%w: account handle is required", ErrMissingCredentials)
+	}
+
+	// A password is only needed when there is no cached session to refresh
+	hasTokens := config.Account.DID != nil && config.Account.AccessJWT != nil && config.Account.RefreshJWT != nil
+	if config.Account.Password == "" && !hasTokens {
+		return nil, fmt.Errorf("%w: account %s has no password or cached session", ErrMissingCredentials, config.Account.Handle)
+	}
+
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
 
 	client := &Client{
-		account: config.Account,
-		proxy:   config.Proxy,
+		account:       config.Account,
+		proxy:         config.Proxy,
+		limiter:       config.RateLimiter,
+		images:        DefaultImageLimits,
+		allowSelf:     config.AllowSelfActions,
+		enforceStatus: config.EnforceAccountStatus,
+
+		timelineAlgorithms: append([]string(nil), config.TimelineAlgorithms...),
+	}
+	if config.ImageLimits != nil {
+		client.images = *config.ImageLimits
+	}
+	client.plcDirectory = config.PLCDirectory
+	if client.plcDirectory == "" {
+		client.plcDirectory = DefaultPLCDirectory
 	}
 
 	// Create HTTP client with optional proxy
@@ -49,17 +125,32 @@ func NewClient(config ClientConfig) (*Client, error) {
 	}
 
 	// Configure proxy if provided
-	if config.Proxy != nil {
+	if config.Proxy != nil && config.Transports != nil {
+		transport, err := config.Transports.Get(config.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.Transport = transport
+	} else if config.Proxy != nil {
 		proxyURL, err := buildProxyURL(config.Proxy)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build proxy URL: %w", err)
 		}
 
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
+		transport := &http.Transport{}
+		if err := configureProxy(transport, proxyURL, config.DialTimeout); err != nil {
+			return nil, err
 		}
 		httpClient.Transport = transport
+	} else if config.DialTimeout > 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.DialContext = dialContext(config.DialTimeout)
+		httpClient.Transport = transport
+	}
+	if httpClient.Transport == nil {
+		httpClient.Transport = http.DefaultTransport
 	}
+	httpClient.Transport = &rateLimitTransport{base: httpClient.Transport, client: client}
 
 	// Create XRPC client
 	client.xrpcc = &xrpc.Client{
@@ -71,6 +162,68 @@ func NewClient(config ClientConfig) (*Client, error) {
 	return client, nil
 }
 
+// dialContext returns a DialContext that gives up connecting after timeout.
+// It is a variable so tests can observe the dials.
+var dialContext = func(timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	return dialer.DialContext
+}
+
+// contextDialer adapts a DialContext function to the golang.org/x/net/proxy
+// dialer interfaces
+type contextDialer func(ctx context.Context, network, addr string) (net.Conn, error)
+
+func (d contextDialer) Dial(network, addr string) (net.Conn, error) {
+	return d(context.Background(), network, addr)
+}
+
+func (d contextDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return d(ctx, network, addr)
+}
+
+// configureProxy routes transport through proxyURL. HTTP proxies use the
+// transport's own CONNECT support. SOCKS5 proxies get a dialer from
+// golang.org/x/net/proxy, which performs the username/password handshake
+// with the credentials in proxyURL. A positive dialTimeout bounds connecting
+// to the proxy.
+func configureProxy(transport *http.Transport, proxyURL *url.URL, dialTimeout time.Duration) error {
+	if proxyURL.Scheme != "socks5" {
+		transport.Proxy = http.ProxyURL(proxyURL)
+		if dialTimeout > 0 {
+			transport.DialContext = dialContext(dialTimeout)
+		}
+		return nil
+	}
+
+	var auth *netproxy.Auth
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth = &netproxy.Auth{User: proxyURL.User.Username(), Password: password}
+	}
+	var forward netproxy.Dialer
+	if dialTimeout > 0 {
+		forward = contextDialer(dialContext(dialTimeout))
+	}
+
+	dialer, err := netproxy.SOCKS5("tcp", proxyURL.Host, auth, forward)
+	if err != nil {
+		return fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+	}
+	socksDialer, ok := dialer.(netproxy.ContextDialer)
+	if !ok {
+		return fmt.Errorf("SOCKS5 dialer for %s does not support contexts", proxyURL.Host)
+	}
+	transport.Proxy = nil
+	transport.DialContext = socksDialer.DialContext
+	return nil
+}
+
+// formatATTime formats a timestamp for AT Protocol records as UTC RFC3339
+// with millisecond precision
+func formatATTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
 // buildProxyURL constructs a proxy URL from proxy configuration
 func buildProxyURL(proxy *models.Proxy) (*url.URL, error) {
 	var scheme string
@@ -85,18 +238,41 @@ func buildProxyURL(proxy *models.Proxy) (*url.URL, error) {
 
 	proxyURL := &url.URL{
 		Scheme: scheme,
-		Host:   fmt.Sprintf("%s:%d", proxy.Host, proxy.Port),
+		Host:   utils.ProxyHostPort(proxy.Host, proxy.Port),
 	}
 
 	if proxy.Username != nil && proxy.Password != nil {
-		proxyURL.User = url.UserPassword(*proxy.Username, *proxy.Password)
+		password, err := utils.DecryptCredential(*proxy.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt proxy password: %w", err)
+		}
+		proxyURL.User = url.UserPassword(*proxy.Username, password)
 	}
 
 	return proxyURL, nil
 }
 
-// Authenticate authenticates the client with Bluesky
+// Authenticate authenticates the client with Bluesky. Concurrent calls share
+// one refresh or login and its result: the refresh token is rotated on use,
+// so a second refresh racing the first would fail. The shared login is not
+// cancelled with any one caller's ctx; a caller whose ctx ends stops waiting.
 func (c *Client) Authenticate(ctx context.Context) error {
+	result := c.authFlight.DoChan("authenticate", func() (interface{}, error) {
+		return nil, c.authenticate(context.WithoutCancel(ctx))
+	})
+
+	select {
+	case res := <-result:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// authenticate refreshes the cached session, or creates a new one
+func (c *Client) authenticate(ctx context.Context) error {
+	var refreshErr error
+
 	// Try to load existing auth from cache first
 	if c.account.AccessJWT != nil && c.account.RefreshJWT != nil {
 		c.xrpcc.Auth.AccessJwt = *c.account.AccessJWT
@@ -119,6 +295,7 @@ func (c *Client) Authenticate(ctx context.Context) error {
 
 			return nil
 		}
+		refreshErr = err
 	}
 
 	// Create new session
@@ -127,6 +304,9 @@ func (c *Client) Authenticate(ctx context.Context) error {
 		Password:   c.account.Password,
 	})
 	if err != nil {
+		if reason := classifyAuthError(refreshErr, err); reason != nil {
+			return fmt.Errorf("failed to create session: %w: %w", reason, err)
+		}
 		return fmt.Errorf("failed to create session: %w", err)
 	}
 
@@ -144,19 +324,105 @@ func (c *Client) Authenticate(ctx context.Context) error {
 	return nil
 }
 
+// sessionExpiryMargin is how long a stored access token must still be valid
+// for ResumeSession to use it
+const sessionExpiryMargin = time.Minute
+
+// ResumeSession puts the account's stored session on the client without
+// contacting the PDS. It reports false, leaving the client unauthenticated,
+// when there is no stored session or its access token has expired or is about
+// to; Authenticate is needed then.
+func (c *Client) ResumeSession() bool {
+	if c.account.DID == nil || c.account.AccessJWT == nil || c.account.RefreshJWT == nil {
+		return false
+	}
+	expiry, ok := accessTokenExpiry(*c.account.AccessJWT)
+	if !ok || time.Until(expiry) < sessionExpiryMargin {
+		return false
+	}
+
+	c.xrpcc.Auth.Did = *c.account.DID
+	c.xrpcc.Auth.AccessJwt = *c.account.AccessJWT
+	c.xrpcc.Auth.RefreshJwt = *c.account.RefreshJWT
+	return true
+}
+
+// accessTokenExpiry reads the exp claim of a JWT without verifying it; the
+// PDS does that. ok is false for anything that does not parse.
+func accessTokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
+
 // Post creates a new post
 func (c *Client) Post(ctx context.Context, text string, options *PostOptions) (*PostResult, error) {
+	return c.createPost(ctx, text, options, nil)
+}
+
+// createPost creates a post. A non-nil reply is used as-is in place of
+// looking up options.ReplyTo.
+func (c *Client) createPost(ctx context.Context, text string, options *PostOptions, reply *bsky.FeedPost_ReplyRef) (*PostResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	if options == nil {
 		options = &PostOptions{}
 	}
 
+	createdAt := time.Now()
+	if options.CreatedAt != nil {
+		if options.CreatedAt.After(createdAt.Add(maxCreatedAtSkew)) {
+			return nil, fmt.Errorf("createdAt %s is too far in the future", options.CreatedAt.Format(time.RFC3339))
+		}
+		createdAt = *options.CreatedAt
+	}
+
+	facets := options.Facets
+	if len(facets) > 0 {
+		if err := validateFacets(text, facets); err != nil {
+			return nil, err
+		}
+	}
+	if options.Sanitize {
+		text, facets = sanitizePostText(text, facets)
+	}
+	if len(facets) == 0 && !options.SkipFacetDetection {
+		facets = c.addMentionFacets(ctx, text, detectFacets(text))
+	}
+
+	langs := options.Langs
+	var detectedLang string
+	if len(langs) == 0 && options.AutoDetectLang {
+		if detectedLang = detectLanguage(text); detectedLang != "" {
+			langs = []string{detectedLang}
+		}
+	}
+
 	post := &bsky.FeedPost{
 		Text:      text,
-		CreatedAt: time.Now().Local().Format(time.RFC3339),
+		CreatedAt: formatATTime(createdAt),
+		Facets:    richtextFacets(facets),
+		Langs:     langs,
 	}
 
 	// Handle reply
-	if options.ReplyTo != "" {
+	if reply != nil {
+		post.Reply = reply
+	} else if options.ReplyTo != "" {
 		reply, err := c.buildReply(ctx, options.ReplyTo)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build reply: %w", err)
@@ -175,7 +441,7 @@ func (c *Client) Post(ctx context.Context, text string, options *PostOptions) (*
 
 	// Handle images
 	if len(options.Images) > 0 {
-		embed, err := c.buildImageEmbed(ctx, options.Images)
+		embed, err := c.buildImageEmbed(ctx, options.Images, options.ImageAlts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build image embed: %w", err)
 		}
@@ -198,21 +464,65 @@ func (c *Client) Post(ctx context.Context, text string, options *PostOptions) (*
 	}
 
 	return &PostResult{
-		URI: resp.Uri,
-		CID: resp.Cid,
+		URI:  resp.Uri,
+		CID:  resp.Cid,
+		Lang: detectedLang,
 	}, nil
 }
 
+// checkWritable refuses writes for inactive accounts when the client enforces
+// account status. The account is read on every call, so a status change made
+// through GetAccount takes effect immediately.
+func (c *Client) checkWritable() error {
+	if !c.enforceStatus {
+		return nil
+	}
+	return CheckAccountActive(c.account)
+}
+
+// checkSelfTarget returns ErrSelfAction when did is the authenticated
+// account, unless self actions were allowed in the config
+func (c *Client) checkSelfTarget(did string) error {
+	if c.allowSelf || c.xrpcc.Auth == nil || c.xrpcc.Auth.Did == "" {
+		return nil
+	}
+	if did == c.xrpcc.Auth.Did {
+		return fmt.Errorf("%w: %s", ErrSelfAction, did)
+	}
+	return nil
+}
+
 // Follow follows a user
-func (c *Client) Follow(ctx context.Context, handle string) (*FollowResult, error) {
+func (c *Client) Follow(ctx context.Context, handle string, options *FollowOptions) (*FollowResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if options == nil {
+		options = &FollowOptions{}
+	}
+
 	profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, handle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profile: %w", err)
 	}
+	if err := c.checkSelfTarget(profile.Did); err != nil {
+		return nil, err
+	}
+
+	if options.SkipIfFollowing && profile.Viewer != nil && profile.Viewer.Following != nil {
+		existing, err := c.existingFollow(ctx, *profile.Viewer.Following, profile.Did)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
 
 	follow := bsky.GraphFollow{
 		LexiconTypeID: "app.bsky.graph.follow",
-		CreatedAt:     time.Now().Local().Format(time.RFC3339),
+		CreatedAt:     formatATTime(time.Now()),
 		Subject:       profile.Did,
 	}
 
@@ -234,21 +544,59 @@ func (c *Client) Follow(ctx context.Context, handle string) (*FollowResult, erro
 	}, nil
 }
 
+// existingFollow looks up the follow record named by the target's viewer
+// state. The AppView can lag behind the repo, so the record is read back and
+// nil is returned when it has already been deleted.
+func (c *Client) existingFollow(ctx context.Context, uri, targetDID string) (*FollowResult, error) {
+	parts := parseATURI(uri)
+	if parts == nil {
+		return nil, nil
+	}
+
+	resp, err := comatproto.RepoGetRecord(ctx, c.xrpcc, "", parts.Collection, parts.DID, parts.RKey)
+	if err != nil {
+		if name, _ := xrpcErrorName(err); name == "RecordNotFound" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get follow record: %w", err)
+	}
+
+	result := &FollowResult{URI: resp.Uri, TargetDID: targetDID, AlreadyFollowing: true}
+	if resp.Cid != nil {
+		result.CID = *resp.Cid
+	}
+	return result, nil
+}
+
 // Like likes a post
 func (c *Client) Like(ctx context.Context, postURI string) (*LikeResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	// Get the post to like
 	parts := parseATURI(postURI)
 	if parts == nil {
 		return nil, fmt.Errorf("invalid post URI: %s", postURI)
 	}
 
+	if err := c.checkSelfTarget(parts.DID); err != nil {
+		return nil, err
+	}
+
 	resp, err := comatproto.RepoGetRecord(ctx, c.xrpcc, "", parts.Collection, parts.DID, parts.RKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get record: %w", err)
+		return nil, wrapTargetError("failed to get record", err)
+	}
+	// The URI may name the repo by handle; the returned URI carries the DID
+	if resolved := parseATURI(resp.Uri); resolved != nil {
+		if err := c.checkSelfTarget(resolved.DID); err != nil {
+			return nil, err
+		}
 	}
 
 	like := &bsky.FeedLike{
-		CreatedAt: time.Now().Format("2006-01-02T15:04:05.000Z"),
+		CreatedAt: formatATTime(time.Now()),
 		Subject:   &comatproto.RepoStrongRef{Uri: resp.Uri, Cid: *resp.Cid},
 	}
 
@@ -272,19 +620,33 @@ func (c *Client) Like(ctx context.Context, postURI string) (*LikeResult, error)
 
 // Repost reposts a post
 func (c *Client) Repost(ctx context.Context, postURI string) (*RepostResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	// Get the post to repost
 	parts := parseATURI(postURI)
 	if parts == nil {
 		return nil, fmt.Errorf("invalid post URI: %s", postURI)
 	}
 
+	if err := c.checkSelfTarget(parts.DID); err != nil {
+		return nil, err
+	}
+
 	resp, err := comatproto.RepoGetRecord(ctx, c.xrpcc, "", parts.Collection, parts.DID, parts.RKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get record: %w", err)
+		return nil, wrapTargetError("failed to get record", err)
+	}
+	// The URI may name the repo by handle; the returned URI carries the DID
+	if resolved := parseATURI(resp.Uri); resolved != nil {
+		if err := c.checkSelfTarget(resolved.DID); err != nil {
+			return nil, err
+		}
 	}
 
 	repost := &bsky.FeedRepost{
-		CreatedAt: time.Now().Local().Format(time.RFC3339),
+		CreatedAt: formatATTime(time.Now()),
 		Subject: &comatproto.RepoStrongRef{
 			Uri: resp.Uri,
 			Cid: *resp.Cid,
@@ -315,7 +677,15 @@ func (c *Client) GetTimeline(ctx context.Context, options *TimelineOptions) (*Ti
 		options = &TimelineOptions{Limit: 30}
 	}
 
-	resp, err := bsky.FeedGetTimeline(ctx, c.xrpcc, "reverse-chronological", options.Cursor, int64(options.Limit))
+	algorithm := options.Algorithm
+	if algorithm == "" {
+		algorithm = DefaultTimelineAlgorithm
+	}
+	if algorithm != DefaultTimelineAlgorithm && !utils.SliceContains(c.timelineAlgorithms, algorithm) {
+		return nil, fmt.Errorf("unsupported timeline algorithm: %s", algorithm)
+	}
+
+	resp, err := bsky.FeedGetTimeline(ctx, c.xrpcc, algorithm, options.Cursor, int64(options.Limit))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get timeline: %w", err)
 	}
@@ -342,15 +712,31 @@ func (c *Client) GetProfile(ctx context.Context, handle string) (*ProfileResult,
 	}, nil
 }
 
-// Search searches for posts
+// Search searches for posts, optionally filtered by author, language and a
+// time window. Pass the returned cursor back in options for the next page.
 func (c *Client) Search(ctx context.Context, query string, options *SearchOptions) (*SearchResult, error) {
 	if options == nil {
-		options = &SearchOptions{Limit: 50}
+		options = &SearchOptions{}
+	}
+
+	limit := options.Limit
+	switch {
+	case limit <= 0:
+		limit = defaultSearchLimit
+	case limit > maxSearchLimit:
+		limit = maxSearchLimit
+	}
+
+	var since, until string
+	if options.Since != nil {
+		since = formatATTime(*options.Since)
+	}
+	if options.Until != nil {
+		until = formatATTime(*options.Until)
 	}
 
-	// Note: This is a simplified implementation
-	// The actual API may require different parameters
-	resp, err := bsky.FeedSearchPosts(ctx, c.xrpcc, "", "", "", "", int64(options.Limit), "", "", "", "", []string{}, "", query)
+	resp, err := bsky.FeedSearchPosts(ctx, c.xrpcc, options.Author, options.Cursor, "", options.Lang, int64(limit),
+		"", query, since, "", nil, until, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to search posts: %w", err)
 	}
@@ -0,0 +1,486 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// newRecordTestClient returns an authenticated client whose createRecord
+// calls are captured into records
+func newRecordTestClient(t *testing.T, records *[]map[string]interface{}) *Client {
+	t.Helper()
+
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.repo.createRecord" {
+			return
+		}
+		var input struct {
+			Record map[string]interface{} `json:"record"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		*records = append(*records, input.Record)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"uri": "at://did:plc:test/app.bsky.feed.post/abc",
+			"cid": "bafytest",
+		})
+	})
+}
+
+func TestNewClientCredentials(t *testing.T) {
+	_, err := NewClient(ClientConfig{
+		Account: &models.Account{Handle: "test.bsky.social"},
+	})
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+
+	_, err = NewClient(ClientConfig{
+		Account: &models.Account{Password: "password"},
+	})
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+
+	// Cached session tokens are enough without a password
+	did := "did:plc:test"
+	access := "access-token"
+	refresh := "refresh-token"
+	client, err := NewClient(ClientConfig{
+		Account: &models.Account{
+			Handle:     "test.bsky.social",
+			DID:        &did,
+			AccessJWT:  &access,
+			RefreshJWT: &refresh,
+		},
+	})
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+
+	_, err = NewClient(ClientConfig{
+		Account: &models.Account{Handle: "test.bsky.social", Password: "password"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestPostCustomCreatedAt(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	createdAt := time.Date(2024, 3, 1, 12, 30, 45, 123000000, time.FixedZone("UTC+8", 8*3600))
+	_, err := client.Post(context.Background(), "backfilled", &PostOptions{CreatedAt: &createdAt})
+	assert.NoError(t, err)
+
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2024-03-01T04:30:45.123Z", records[0]["createdAt"])
+}
+
+func TestPostDefaultCreatedAt(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	_, err := client.Post(context.Background(), "hello", nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, records, 1)
+	createdAt, err := time.Parse(time.RFC3339, records[0]["createdAt"].(string))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), createdAt, time.Minute)
+}
+
+func TestPostRejectsFutureCreatedAt(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	future := time.Now().Add(24 * time.Hour)
+	_, err := client.Post(context.Background(), "from the future", &PostOptions{CreatedAt: &future})
+	assert.Error(t, err)
+	assert.Empty(t, records)
+}
+
+func TestFormatATTime(t *testing.T) {
+	local := time.Date(2024, 3, 1, 20, 30, 45, 123456789, time.FixedZone("UTC+8", 8*3600))
+	assert.Equal(t, "2024-03-01T12:30:45.123Z", formatATTime(local))
+
+	// Output must not depend on the server's local timezone
+	original := time.Local
+	time.Local = time.FixedZone("UTC-5", -5*3600)
+	defer func() { time.Local = original }()
+
+	now := time.Now()
+	formatted := formatATTime(now)
+	assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`, formatted)
+
+	parsed, err := time.Parse(time.RFC3339, formatted)
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, parsed.Location())
+	assert.WithinDuration(t, now, parsed, time.Millisecond)
+}
+
+// newTargetGoneClient returns a client whose getRecord lookups all fail as if
+// the target post had been deleted
+func newTargetGoneClient(t *testing.T) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "RecordNotFound", "message": "Could not locate record"})
+	})
+
+	return newTestClient(t, muxHandler(mux))
+}
+
+func TestActionsOnDeletedTarget(t *testing.T) {
+	const deleted = "at://did:plc:author/app.bsky.feed.post/gone"
+	client := newTargetGoneClient(t)
+	ctx := context.Background()
+
+	_, err := client.Like(ctx, deleted)
+	assert.ErrorIs(t, err, ErrTargetGone, "like")
+
+	_, err = client.Repost(ctx, deleted)
+	assert.ErrorIs(t, err, ErrTargetGone, "repost")
+
+	_, err = client.Post(ctx, "reply", &PostOptions{ReplyTo: deleted})
+	assert.ErrorIs(t, err, ErrTargetGone, "reply")
+
+	_, err = client.Post(ctx, "quote", &PostOptions{QuoteTo: deleted})
+	assert.ErrorIs(t, err, ErrTargetGone, "quote")
+
+	results := client.BatchLike(ctx, []string{deleted})
+	assert.False(t, results[0].Success)
+	assert.True(t, results[0].Skipped)
+}
+
+// newSelfActionTestClient returns a client authenticated as did:plc:test
+// whose profile and record lookups all resolve to that same account
+func newSelfActionTestClient(t *testing.T, allowSelf bool, records *[]map[string]interface{}) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/app.bsky.actor.getProfile", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"did": "did:plc:test", "handle": "test.bsky.social"})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.getRecord", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"uri":   "at://did:plc:test/app.bsky.feed.post/own",
+			"cid":   "bafyown",
+			"value": map[string]string{"$type": "app.bsky.feed.post", "text": "my own post", "createdAt": "2024-01-01T00:00:00.000Z"},
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.createRecord", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Record map[string]interface{} `json:"record"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		*records = append(*records, input.Record)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"uri": "at://did:plc:test/app.bsky.feed.like/abc", "cid": "bafytest"})
+	})
+
+	return newTestClient(t, muxHandler(mux), func(config *ClientConfig) {
+		config.AllowSelfActions = allowSelf
+	})
+}
+
+func TestSelfActionsBlocked(t *testing.T) {
+	var records []map[string]interface{}
+	client := newSelfActionTestClient(t, false, &records)
+	ctx := context.Background()
+
+	_, err := client.Follow(ctx, "test.bsky.social", nil)
+	assert.ErrorIs(t, err, ErrSelfAction, "follow")
+
+	_, err = client.Like(ctx, "at://did:plc:test/app.bsky.feed.post/own")
+	assert.ErrorIs(t, err, ErrSelfAction, "like")
+
+	// A handle-based URI is caught once the record lookup returns the DID
+	_, err = client.Repost(ctx, "at://test.bsky.social/app.bsky.feed.post/own")
+	assert.ErrorIs(t, err, ErrSelfAction, "repost")
+
+	results := client.BatchFollow(ctx, []string{"test.bsky.social"})
+	assert.False(t, results[0].Success)
+	assert.True(t, results[0].Skipped)
+
+	assert.Empty(t, records)
+}
+
+func TestSelfActionsAllowed(t *testing.T) {
+	var records []map[string]interface{}
+	client := newSelfActionTestClient(t, true, &records)
+	ctx := context.Background()
+
+	_, err := client.Follow(ctx, "test.bsky.social", nil)
+	assert.NoError(t, err)
+
+	_, err = client.Like(ctx, "at://did:plc:test/app.bsky.feed.post/own")
+	assert.NoError(t, err)
+
+	assert.Len(t, records, 2)
+}
+
+func TestSuspendedAccountBlockedFromPosting(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+	client.enforceStatus = true
+	client.account.Status = models.AccountStatusSuspended
+	ctx := context.Background()
+
+	_, err := client.Post(ctx, "should not be published", nil)
+	assert.ErrorIs(t, err, ErrAccountNotActive)
+
+	results := client.BatchLike(ctx, []string{
+		"at://did:plc:author/app.bsky.feed.post/one",
+		"at://did:plc:author/app.bsky.feed.post/two",
+	})
+	for _, result := range results {
+		assert.False(t, result.Success)
+		assert.ErrorIs(t, result.Err, ErrAccountNotActive)
+	}
+	assert.Empty(t, records)
+
+	// Reactivating the account lifts the block without a new client
+	client.GetAccount().Status = models.AccountStatusActive
+	_, err = client.Post(ctx, "back in business", nil)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestGetTimelineAlgorithm(t *testing.T) {
+	var algorithms []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/xrpc/app.bsky.feed.getTimeline" {
+			algorithms = append(algorithms, r.URL.Query().Get("algorithm"))
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"feed": []interface{}{}})
+		}
+	})
+	ctx := context.Background()
+
+	_, err := client.GetTimeline(ctx, nil)
+	assert.NoError(t, err)
+	_, err = client.GetTimeline(ctx, &TimelineOptions{Limit: 10})
+	assert.NoError(t, err)
+
+	_, err = client.GetTimeline(ctx, &TimelineOptions{Limit: 10, Algorithm: "hot-classic"})
+	assert.ErrorContains(t, err, "unsupported timeline algorithm")
+
+	extra := []string{"hot-classic"}
+	configured, err := NewClient(ClientConfig{
+		Account:            &models.Account{Handle: "test.bsky.social", Password: "password"},
+		TimelineAlgorithms: extra,
+	})
+	assert.NoError(t, err)
+	extra[0] = "changed-later"
+	client.timelineAlgorithms = configured.timelineAlgorithms
+	_, err = client.GetTimeline(ctx, &TimelineOptions{Limit: 10, Algorithm: "hot-classic"})
+	assert.NoError(t, err)
+
+	_, err = client.GetTimeline(ctx, &TimelineOptions{Algorithm: "made-up"})
+	assert.ErrorContains(t, err, "unsupported timeline algorithm")
+
+	assert.Equal(t, []string{DefaultTimelineAlgorithm, DefaultTimelineAlgorithm, "hot-classic"}, algorithms)
+}
+
+// testAccessJWT returns an unsigned JWT whose exp claim is expiry
+func testAccessJWT(expiry time.Time) string {
+	claims, _ := json.Marshal(map[string]int64{"exp": expiry.Unix()})
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+}
+
+func TestResumeSession(t *testing.T) {
+	did := "did:plc:test"
+	refresh := "refresh-token"
+	newClient := func(access string) *Client {
+		client, err := NewClient(ClientConfig{
+			Account: &models.Account{Handle: "test.bsky.social", DID: &did, AccessJWT: &access, RefreshJWT: &refresh},
+		})
+		assert.NoError(t, err)
+		return client
+	}
+
+	valid := testAccessJWT(time.Now().Add(time.Hour))
+	client := newClient(valid)
+	assert.True(t, client.ResumeSession())
+	assert.Equal(t, valid, client.xrpcc.Auth.AccessJwt)
+	assert.Equal(t, did, client.xrpcc.Auth.Did)
+
+	for name, access := range map[string]string{
+		"expired":      testAccessJWT(time.Now().Add(-time.Minute)),
+		"about to":     testAccessJWT(time.Now().Add(10 * time.Second)),
+		"not a jwt":    "access-token",
+		"bad payload":  "a.!!!.c",
+		"no exp claim": "a." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".c",
+	} {
+		client := newClient(access)
+		assert.False(t, client.ResumeSession(), name)
+		assert.Empty(t, client.xrpcc.Auth.AccessJwt, name)
+	}
+
+	client, err := NewClient(ClientConfig{Account: &models.Account{Handle: "test.bsky.social", Password: "password"}})
+	assert.NoError(t, err)
+	assert.False(t, client.ResumeSession(), "no stored session")
+}
+
+// newFollowTestClient reports the account as already following
+// following.bsky.social via viewer state and counts created follow records
+func newFollowTestClient(t *testing.T, created *int, followRecordExists bool) *Client {
+	t.Helper()
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/app.bsky.actor.getProfile":
+			actor := r.URL.Query().Get("actor")
+			profile := map[string]interface{}{"did": cannedDID(actor), "handle": actor}
+			if actor == "following.bsky.social" {
+				profile["viewer"] = map[string]string{"following": "at://" + testDID + "/app.bsky.graph.follow/existing"}
+			}
+			json.NewEncoder(w).Encode(profile)
+		case "/xrpc/com.atproto.repo.getRecord":
+			if !followRecordExists {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "RecordNotFound", "message": "Could not locate record"})
+			}
+		case "/xrpc/com.atproto.repo.createRecord":
+			*created++
+		}
+	})
+}
+
+func TestFollowSkipIfFollowing(t *testing.T) {
+	ctx := context.Background()
+	created := 0
+	client := newFollowTestClient(t, &created, true)
+	skip := &FollowOptions{SkipIfFollowing: true}
+
+	// A new target is followed as usual
+	result, err := client.Follow(ctx, "alice.bsky.social", skip)
+	assert.NoError(t, err)
+	assert.False(t, result.AlreadyFollowing)
+	assert.Equal(t, "at://did:plc:test/app.bsky.graph.follow/3ktest", result.URI)
+	assert.Equal(t, 1, created)
+
+	// An existing follow is returned without creating a duplicate
+	result, err = client.Follow(ctx, "following.bsky.social", skip)
+	assert.NoError(t, err)
+	assert.True(t, result.AlreadyFollowing)
+	assert.Equal(t, "at://did:plc:test/app.bsky.graph.follow/existing", result.URI)
+	assert.Equal(t, "bafyrecord", result.CID)
+	assert.Equal(t, "did:plc:following", result.TargetDID)
+	assert.Equal(t, 1, created)
+
+	// Without the option the follow is always created
+	result, err = client.Follow(ctx, "following.bsky.social", nil)
+	assert.NoError(t, err)
+	assert.False(t, result.AlreadyFollowing)
+	assert.Equal(t, 2, created)
+}
+
+func TestFollowSkipIfFollowingStaleViewerState(t *testing.T) {
+	created := 0
+	client := newFollowTestClient(t, &created, false)
+
+	// The AppView still lists a follow that was deleted from the repo
+	result, err := client.Follow(context.Background(), "following.bsky.social", &FollowOptions{SkipIfFollowing: true})
+	assert.NoError(t, err)
+	assert.False(t, result.AlreadyFollowing)
+	assert.Equal(t, 1, created)
+}
+
+func TestSearchForwardsFilters(t *testing.T) {
+	var query map[string][]string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/app.bsky.feed.searchPosts", r.URL.Path)
+		query = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"posts": []interface{}{}, "cursor": "next"})
+	})
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	result, err := client.Search(context.Background(), "golang", &SearchOptions{
+		Cursor: "page2",
+		Limit:  500,
+		Author: "alice.bsky.social",
+		Since:  &since,
+		Until:  &until,
+		Lang:   "en",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "next", result.Cursor)
+
+	assert.Equal(t, []string{"golang"}, query["q"])
+	assert.Equal(t, []string{"alice.bsky.social"}, query["author"])
+	assert.Equal(t, []string{"2024-01-01T00:00:00.000Z"}, query["since"])
+	assert.Equal(t, []string{"2024-02-01T00:00:00.000Z"}, query["until"])
+	assert.Equal(t, []string{"en"}, query["lang"])
+	assert.Equal(t, []string{"page2"}, query["cursor"])
+	assert.Equal(t, []string{"100"}, query["limit"])
+	assert.NotContains(t, query, "url")
+}
+
+func TestAuthenticateSharesConcurrentRefresh(t *testing.T) {
+	var refreshes, logins atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		if refreshes.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"did": "did:plc:test", "handle": "test.bsky.social",
+			"accessJwt": "new-access", "refreshJwt": "new-refresh",
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		logins.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newAuthTestClient(t, mux)
+
+	const callers = 10
+	errs := make(chan error, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.Authenticate(context.Background())
+		}()
+	}
+
+	// A caller that gives up stops waiting without failing the others
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, client.Authenticate(ctx), context.Canceled)
+
+	// Let the other callers join the refresh in flight before it returns
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), refreshes.Load())
+	assert.Zero(t, logins.Load())
+	assert.Equal(t, "new-refresh", *client.GetAccount().RefreshJWT)
+}
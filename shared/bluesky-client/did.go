@@ -0,0 +1,146 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultPLCDirectory is the directory used to resolve did:plc identifiers
+const DefaultPLCDirectory = "https://plc.directory"
+
+// DIDDocument represents the parts of a DID document relevant to account
+// management
+type DIDDocument struct {
+	DID          string   `json:"did"`
+	AlsoKnownAs  []string `json:"also_known_as"`
+	Handles      []string `json:"handles"`
+	PDSEndpoint  string   `json:"pds_endpoint"`
+	RotationKeys []string `json:"rotation_keys,omitempty"`
+}
+
+// rawDIDDocument is the W3C DID document as served by plc.directory and
+// did:web hosts
+type rawDIDDocument struct {
+	ID          string   `json:"id"`
+	AlsoKnownAs []string `json:"alsoKnownAs"`
+	Service     []struct {
+		ID              string `json:"id"`
+		Type            string `json:"type"`
+		ServiceEndpoint string `json:"serviceEndpoint"`
+	} `json:"service"`
+}
+
+// ResolveDIDDocument fetches and parses the DID document for did, using
+// plc.directory for did:plc and the host's well-known path for did:web.
+// Rotation keys are only available for did:plc.
+func (c *Client) ResolveDIDDocument(ctx context.Context, did string) (*DIDDocument, error) {
+	docURL, err := didDocumentURL(c.plcDirectory, did)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.fetchDIDResource(ctx, docURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DID document for %s: %w", did, err)
+	}
+
+	doc, err := parseDIDDocument(body)
+	if err != nil {
+		return nil, err
+	}
+	if doc.DID != did {
+		return nil, fmt.Errorf("DID document id %s does not match %s", doc.DID, did)
+	}
+
+	if strings.HasPrefix(did, "did:plc:") {
+		data, err := c.fetchDIDResource(ctx, docURL+"/data")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch PLC data for %s: %w", did, err)
+		}
+		var plcData struct {
+			RotationKeys []string `json:"rotationKeys"`
+		}
+		if err := json.Unmarshal(data, &plcData); err != nil {
+			return nil, fmt.Errorf("failed to parse PLC data for %s: %w", did, err)
+		}
+		doc.RotationKeys = plcData.RotationKeys
+	}
+
+	return doc, nil
+}
+
+// didDocumentURL returns where the DID document for did is published
+func didDocumentURL(plcDirectory, did string) (string, error) {
+	switch {
+	case strings.HasPrefix(did, "did:plc:"):
+		return strings.TrimSuffix(plcDirectory, "/") + "/" + did, nil
+	case strings.HasPrefix(did, "did:web:"):
+		// atproto only allows bare hostnames, so a raw colon (a path
+		// separator in did:web) is rejected; a port arrives percent-encoded
+		id := strings.TrimPrefix(did, "did:web:")
+		host, err := url.PathUnescape(id)
+		if err != nil || host == "" || strings.Contains(id, ":") || strings.Contains(host, "/") {
+			return "", fmt.Errorf("invalid did:web identifier %s", did)
+		}
+		return "https://" + host + "/.well-known/did.json", nil
+	default:
+		return "", fmt.Errorf("unsupported DID method: %s", did)
+	}
+}
+
+// parseDIDDocument extracts the PDS endpoint and handles from a DID document
+func parseDIDDocument(data []byte) (*DIDDocument, error) {
+	var raw rawDIDDocument
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse DID document: %w", err)
+	}
+	if raw.ID == "" {
+		return nil, fmt.Errorf("DID document has no id")
+	}
+
+	doc := &DIDDocument{
+		DID:         raw.ID,
+		AlsoKnownAs: raw.AlsoKnownAs,
+		Handles:     []string{},
+	}
+	for _, aka := range raw.AlsoKnownAs {
+		if handle := strings.TrimPrefix(aka, "at://"); handle != aka && handle != "" {
+			doc.Handles = append(doc.Handles, handle)
+		}
+	}
+	for _, svc := range raw.Service {
+		if svc.Type == "AtprotoPersonalDataServer" && (svc.ID == "#atproto_pds" || svc.ID == raw.ID+"#atproto_pds") {
+			doc.PDSEndpoint = svc.ServiceEndpoint
+			break
+		}
+	}
+
+	return doc, nil
+}
+
+// fetchDIDResource performs a GET through the client's HTTP transport, so
+// DID lookups go out over the same proxy as the account's other traffic
+func (c *Client) fetchDIDResource(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.xrpcc.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, target)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,126 @@
+package bluesky
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+const samplePLCDocument = `{
+	"@context": ["https://www.w3.org/ns/did/v1", "https://w3id.org/security/multikey/v1"],
+	"id": "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+	"alsoKnownAs": ["at://atproto.com"],
+	"verificationMethod": [{
+		"id": "did:plc:ewvi7nxzyoun6zhxrhs64oiz#atproto",
+		"type": "Multikey",
+		"controller": "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+		"publicKeyMultibase": "zQ3shunBKsXixLxKtC5qeSG9E4J5RkGN57im31pcTzbNQnm5w"
+	}],
+	"service": [{
+		"id": "#atproto_pds",
+		"type": "AtprotoPersonalDataServer",
+		"serviceEndpoint": "https://enoki.us-east.host.bsky.network"
+	}]
+}`
+
+const samplePLCData = `{
+	"did": "did:plc:ewvi7nxzyoun6zhxrhs64oiz",
+	"rotationKeys": ["did:key:zQ3shhCGUqDKjStzuDxPkTxN6ujddP4RkEKJJouJGRRkaLGbg", "did:key:zQ3shpKnbdPx3g3CmPf5cRVTPe1HtSwVn5ish3wSnDPQCbLJK"],
+	"alsoKnownAs": ["at://atproto.com"]
+}`
+
+const sampleWebDocument = `{
+	"@context": ["https://www.w3.org/ns/did/v1"],
+	"id": "did:web:example.com",
+	"alsoKnownAs": ["at://example.com", "https://example.com/profile"],
+	"service": [
+		{"id": "#bsky_notif", "type": "BskyNotificationService", "serviceEndpoint": "https://api.bsky.app"},
+		{"id": "did:web:example.com#atproto_pds", "type": "AtprotoPersonalDataServer", "serviceEndpoint": "https://pds.example.com"}
+	]
+}`
+
+func TestParseDIDDocument(t *testing.T) {
+	plc, err := parseDIDDocument([]byte(samplePLCDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, "did:plc:ewvi7nxzyoun6zhxrhs64oiz", plc.DID)
+	assert.Equal(t, "https://enoki.us-east.host.bsky.network", plc.PDSEndpoint)
+	assert.Equal(t, []string{"atproto.com"}, plc.Handles)
+
+	web, err := parseDIDDocument([]byte(sampleWebDocument))
+	assert.NoError(t, err)
+	assert.Equal(t, "did:web:example.com", web.DID)
+	assert.Equal(t, "https://pds.example.com", web.PDSEndpoint)
+	assert.Equal(t, []string{"example.com"}, web.Handles)
+	assert.Len(t, web.AlsoKnownAs, 2)
+
+	_, err = parseDIDDocument([]byte(`{"alsoKnownAs": []}`))
+	assert.Error(t, err)
+}
+
+func TestDIDDocumentURL(t *testing.T) {
+	plcURL, err := didDocumentURL("https://plc.directory/", "did:plc:abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://plc.directory/did:plc:abc", plcURL)
+
+	webURL, err := didDocumentURL(DefaultPLCDirectory, "did:web:localhost%3A8443")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://localhost:8443/.well-known/did.json", webURL)
+
+	_, err = didDocumentURL(DefaultPLCDirectory, "did:web:example.com:users:alice")
+	assert.Error(t, err)
+	_, err = didDocumentURL(DefaultPLCDirectory, "did:key:zQ3sh")
+	assert.Error(t, err)
+}
+
+func TestResolveDIDDocument(t *testing.T) {
+	plcServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/did:plc:ewvi7nxzyoun6zhxrhs64oiz":
+			w.Write([]byte(samplePLCDocument))
+		case "/did:plc:ewvi7nxzyoun6zhxrhs64oiz/data":
+			w.Write([]byte(samplePLCData))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer plcServer.Close()
+
+	client, err := NewClient(ClientConfig{
+		Account:      &models.Account{Handle: "test.bsky.social", Password: "password", Host: plcServer.URL},
+		PLCDirectory: plcServer.URL,
+	})
+	assert.NoError(t, err)
+
+	doc, err := client.ResolveDIDDocument(context.Background(), "did:plc:ewvi7nxzyoun6zhxrhs64oiz")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://enoki.us-east.host.bsky.network", doc.PDSEndpoint)
+	assert.Len(t, doc.RotationKeys, 2)
+
+	_, err = client.ResolveDIDDocument(context.Background(), "did:plc:missing")
+	assert.Error(t, err)
+
+	webServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/.well-known/did.json", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		did := "did:web:" + strings.ReplaceAll(r.Host, ":", "%3A")
+		w.Write([]byte(strings.ReplaceAll(sampleWebDocument, "did:web:example.com", did)))
+	}))
+	defer webServer.Close()
+
+	client.xrpcc.Client = webServer.Client()
+	host := strings.TrimPrefix(webServer.URL, "https://")
+	webDID := "did:web:" + strings.ReplaceAll(host, ":", "%3A")
+
+	doc, err = client.ResolveDIDDocument(context.Background(), webDID)
+	assert.NoError(t, err)
+	assert.Equal(t, webDID, doc.DID)
+	assert.Equal(t, "https://pds.example.com", doc.PDSEndpoint)
+	assert.Empty(t, doc.RotationKeys)
+}
@@ -0,0 +1,197 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// Page sizes for the engagement listings and ListRecords; the server caps
+// them at 100
+const (
+	defaultEngagementLimit = 50
+	maxEngagementLimit     = 100
+)
+
+// QuotesResult represents one page of posts quoting a post
+type QuotesResult struct {
+	Posts  []*bsky.FeedDefs_PostView `json:"posts"`
+	Cursor string                    `json:"cursor,omitempty"`
+}
+
+// PostActorsResult represents one page of accounts that liked or reposted a
+// post
+type PostActorsResult struct {
+	Actors []*bsky.ActorDefs_ProfileView `json:"actors"`
+	Cursor string                        `json:"cursor,omitempty"`
+}
+
+// GetQuotes gets a page of posts quoting postURI. The URI may name the author
+// by handle. Limit is clamped to 1-100 and defaults to 50; pass the returned
+// cursor to get the next page.
+func (c *Client) GetQuotes(ctx context.Context, postURI, cursor string, limit int) (*QuotesResult, error) {
+	postURI, err := c.resolvePostURI(ctx, postURI)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bsky.FeedGetQuotes(ctx, c.xrpcc, "", cursor, engagementLimit(limit), postURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quotes: %w", err)
+	}
+
+	result := &QuotesResult{Posts: resp.Posts}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+	return result, nil
+}
+
+// AllQuotes iterates over every post quoting postURI, fetching pages of
+// limit as it goes. Iteration stops after yielding the first error.
+func (c *Client) AllQuotes(ctx context.Context, postURI string, limit int) iter.Seq2[*bsky.FeedDefs_PostView, error] {
+	return iteratePages(func(cursor string) ([]*bsky.FeedDefs_PostView, string, error) {
+		page, err := c.GetQuotes(ctx, postURI, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Posts, page.Cursor, nil
+	})
+}
+
+// GetLikes gets a page of accounts that liked postURI. The URI may name the
+// author by handle. Limit is clamped to 1-100 and defaults to 50.
+func (c *Client) GetLikes(ctx context.Context, postURI, cursor string, limit int) (*PostActorsResult, error) {
+	postURI, err := c.resolvePostURI(ctx, postURI)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bsky.FeedGetLikes(ctx, c.xrpcc, "", cursor, engagementLimit(limit), postURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get likes: %w", err)
+	}
+
+	result := &PostActorsResult{Actors: make([]*bsky.ActorDefs_ProfileView, 0, len(resp.Likes))}
+	for _, like := range resp.Likes {
+		if like.Actor != nil {
+			result.Actors = append(result.Actors, like.Actor)
+		}
+	}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+	return result, nil
+}
+
+// AllLikes iterates over every account that liked postURI, fetching pages of
+// limit as it goes. Iteration stops after yielding the first error.
+func (c *Client) AllLikes(ctx context.Context, postURI string, limit int) iter.Seq2[*bsky.ActorDefs_ProfileView, error] {
+	return iteratePages(func(cursor string) ([]*bsky.ActorDefs_ProfileView, string, error) {
+		page, err := c.GetLikes(ctx, postURI, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Actors, page.Cursor, nil
+	})
+}
+
+// GetRepostedBy gets a page of accounts that reposted postURI. The URI may
+// name the author by handle. Limit is clamped to 1-100 and defaults to 50.
+func (c *Client) GetRepostedBy(ctx context.Context, postURI, cursor string, limit int) (*PostActorsResult, error) {
+	postURI, err := c.resolvePostURI(ctx, postURI)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bsky.FeedGetRepostedBy(ctx, c.xrpcc, "", cursor, engagementLimit(limit), postURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reposts: %w", err)
+	}
+
+	result := &PostActorsResult{Actors: resp.RepostedBy}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+	return result, nil
+}
+
+// AllRepostedBy iterates over every account that reposted postURI, fetching
+// pages of limit as it goes. Iteration stops after yielding the first error.
+func (c *Client) AllRepostedBy(ctx context.Context, postURI string, limit int) iter.Seq2[*bsky.ActorDefs_ProfileView, error] {
+	return iteratePages(func(cursor string) ([]*bsky.ActorDefs_ProfileView, string, error) {
+		page, err := c.GetRepostedBy(ctx, postURI, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Actors, page.Cursor, nil
+	})
+}
+
+// iteratePages yields the items of each page fetch returns, following its
+// cursor until a page is empty or has no cursor. A fetch error is yielded
+// once and ends the iteration.
+func iteratePages[T any](fetch func(cursor string) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		cursor := ""
+		for {
+			items, next, err := fetch(cursor)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next == "" || len(items) == 0 {
+				return
+			}
+			cursor = next
+		}
+	}
+}
+
+// resolvePostURI validates postURI and replaces a handle authority with the
+// author's DID, which the app view needs to find the post
+func (c *Client) resolvePostURI(ctx context.Context, postURI string) (string, error) {
+	if err := validatePostURI(postURI); err != nil {
+		return "", err
+	}
+	parts := parseATURI(postURI)
+	if strings.HasPrefix(parts.DID, "did:") {
+		return postURI, nil
+	}
+
+	out, err := comatproto.IdentityResolveHandle(ctx, c.xrpcc, parts.DID)
+	if err != nil {
+		return "", wrapTargetError("failed to resolve post author", err)
+	}
+	return "at://" + out.Did + "/" + parts.Collection + "/" + parts.RKey, nil
+}
+
+// validatePostURI checks postURI is an AT URI of a post record
+func validatePostURI(postURI string) error {
+	parts := parseATURI(postURI)
+	if parts == nil || parts.DID == "" || parts.Collection != "app.bsky.feed.post" || parts.RKey == "" {
+		return fmt.Errorf("invalid post URI: %s", postURI)
+	}
+	return nil
+}
+
+// engagementLimit clamps a requested page size to what the app view accepts
+func engagementLimit(limit int) int64 {
+	switch {
+	case limit <= 0:
+		return defaultEngagementLimit
+	case limit > maxEngagementLimit:
+		return maxEngagementLimit
+	}
+	return int64(limit)
+}
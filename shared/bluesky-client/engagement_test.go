@@ -0,0 +1,171 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPostURI = "at://did:plc:alice/app.bsky.feed.post/3kpost"
+
+// testPostView returns a minimal post view by author with record key rkey
+func testPostView(author, rkey string) map[string]interface{} {
+	return map[string]interface{}{
+		"uri":       "at://" + author + "/app.bsky.feed.post/" + rkey,
+		"cid":       "bafy" + rkey,
+		"author":    map[string]string{"did": author, "handle": "quoter.bsky.social"},
+		"record":    map[string]string{"$type": "app.bsky.feed.post", "text": rkey, "createdAt": "2024-01-01T00:00:00.000Z"},
+		"indexedAt": "2024-01-01T00:00:00.000Z",
+	}
+}
+
+func TestAllQuotesPages(t *testing.T) {
+	var cursors []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/app.bsky.feed.getQuotes", r.URL.Path)
+		assert.Equal(t, testPostURI, r.URL.Query().Get("uri"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+
+		resp := map[string]interface{}{"uri": testPostURI}
+		switch cursor {
+		case "":
+			resp["posts"] = []interface{}{testPostView("did:plc:bob", "one"), testPostView("did:plc:carol", "two")}
+			resp["cursor"] = "page2"
+		case "page2":
+			resp["posts"] = []interface{}{testPostView("did:plc:dave", "three")}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	var uris []string
+	for post, err := range client.AllQuotes(context.Background(), testPostURI, 2) {
+		require.NoError(t, err)
+		uris = append(uris, post.Uri)
+	}
+
+	assert.Equal(t, []string{"", "page2"}, cursors)
+	assert.Equal(t, []string{
+		"at://did:plc:bob/app.bsky.feed.post/one",
+		"at://did:plc:carol/app.bsky.feed.post/two",
+		"at://did:plc:dave/app.bsky.feed.post/three",
+	}, uris)
+}
+
+func TestGetQuotesRejectsInvalidURI(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+
+	for _, uri := range []string{"", "https://bsky.app/post", "at://did:plc:alice/app.bsky.feed.like/3klike"} {
+		_, err := client.GetQuotes(context.Background(), uri, "", 0)
+		assert.Error(t, err, uri)
+	}
+
+	var errs int
+	for _, err := range client.AllQuotes(context.Background(), "at://did:plc:alice", 0) {
+		assert.Error(t, err)
+		errs++
+	}
+	assert.Equal(t, 1, errs)
+}
+
+// testActor returns a minimal profile view for did
+func testActor(did string) map[string]interface{} {
+	return map[string]interface{}{"did": did, "handle": strings.TrimPrefix(did, "did:plc:") + ".bsky.social"}
+}
+
+func TestAllLikesResolvesHandleAndPages(t *testing.T) {
+	resolved := "at://" + cannedDID("alice.bsky.social") + "/app.bsky.feed.post/3kpost"
+	var cursors []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/app.bsky.feed.getLikes" {
+			return
+		}
+		assert.Equal(t, resolved, r.URL.Query().Get("uri"))
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+
+		like := func(did string) map[string]interface{} {
+			return map[string]interface{}{"actor": testActor(did), "createdAt": "2024-01-01T00:00:00.000Z", "indexedAt": "2024-01-01T00:00:00.000Z"}
+		}
+		resp := map[string]interface{}{"uri": resolved}
+		switch cursor {
+		case "":
+			resp["likes"] = []interface{}{like("did:plc:bob"), like("did:plc:carol")}
+			resp["cursor"] = "page2"
+		case "page2":
+			resp["likes"] = []interface{}{like("did:plc:dave")}
+			resp["cursor"] = "page3"
+		case "page3":
+			resp["likes"] = []interface{}{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	var dids []string
+	for actor, err := range client.AllLikes(context.Background(), "at://alice.bsky.social/app.bsky.feed.post/3kpost", 0) {
+		require.NoError(t, err)
+		dids = append(dids, actor.Did)
+	}
+
+	assert.Equal(t, []string{"", "page2", "page3"}, cursors)
+	assert.Equal(t, []string{"did:plc:bob", "did:plc:carol", "did:plc:dave"}, dids)
+}
+
+func TestGetRepostedByPages(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/app.bsky.feed.getRepostedBy", r.URL.Path)
+		assert.Equal(t, testPostURI, r.URL.Query().Get("uri"))
+		assert.Equal(t, "100", r.URL.Query().Get("limit"))
+
+		resp := map[string]interface{}{"uri": testPostURI}
+		if r.URL.Query().Get("cursor") == "" {
+			resp["repostedBy"] = []interface{}{testActor("did:plc:bob")}
+			resp["cursor"] = "page2"
+		} else {
+			resp["repostedBy"] = []interface{}{testActor("did:plc:carol")}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	page, err := client.GetRepostedBy(context.Background(), testPostURI, "", 1000)
+	require.NoError(t, err)
+	require.Len(t, page.Actors, 1)
+	assert.Equal(t, "did:plc:bob", page.Actors[0].Did)
+	assert.Equal(t, "page2", page.Cursor)
+
+	var dids []string
+	for actor, err := range client.AllRepostedBy(context.Background(), testPostURI, 1000) {
+		require.NoError(t, err)
+		dids = append(dids, actor.Did)
+	}
+	assert.Equal(t, []string{"did:plc:bob", "did:plc:carol"}, dids)
+}
+
+func TestAllRepostedByStopsEarly(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"uri":        testPostURI,
+			"repostedBy": []interface{}{testActor("did:plc:bob"), testActor("did:plc:carol")},
+			"cursor":     "more",
+		})
+	})
+
+	for range client.AllRepostedBy(context.Background(), testPostURI, 2) {
+		break
+	}
+	assert.Equal(t, 1, requests, "breaking out of the loop fetches no further pages")
+}
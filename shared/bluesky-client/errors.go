@@ -0,0 +1,168 @@
+package bluesky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+var (
+	// ErrMissingCredentials indicates the account has neither a password nor
+	// cached session tokens to authenticate with
+	ErrMissingCredentials = errors.New("missing credentials")
+	// ErrInvalidCredentials indicates the PDS rejected the handle or password
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrReauthRequired indicates the stored session expired and a fresh
+	// login could not be completed without operator intervention
+	ErrReauthRequired = errors.New("re-authentication required")
+	// ErrTargetGone indicates the post or repo an action targets no longer
+	// exists, e.g. it was deleted after being discovered
+	ErrTargetGone = errors.New("target no longer exists")
+	// ErrSelfAction indicates a follow, like or repost targeted the
+	// authenticated account's own profile or post
+	ErrSelfAction = errors.New("action targets the account itself")
+	// ErrAccountNotActive indicates a write was refused because the account
+	// is suspended, errored or otherwise not active
+	ErrAccountNotActive = errors.New("account is not active")
+	// ErrInvalidFacet indicates a caller-supplied facet falls outside the
+	// post text, overlaps another facet or has no feature
+	ErrInvalidFacet = errors.New("invalid facet")
+)
+
+// CheckAccountActive returns ErrAccountNotActive unless the account's status
+// is active. Workers call it on a freshly loaded account before running a
+// task; clients with EnforceAccountStatus call it before every write.
+func CheckAccountActive(account *models.Account) error {
+	if account.Status != models.AccountStatusActive {
+		return fmt.Errorf("%w: %s is %s", ErrAccountNotActive, account.Handle, account.Status)
+	}
+	return nil
+}
+
+// xrpcErrorName returns the XRPC error name (e.g. "ExpiredToken") and HTTP
+// status carried by err, if any
+func xrpcErrorName(err error) (string, int) {
+	var xe *xrpc.Error
+	if !errors.As(err, &xe) {
+		return "", 0
+	}
+
+	var inner *xrpc.XRPCError
+	if errors.As(xe.Wrapped, &inner) {
+		return inner.ErrStr, xe.StatusCode
+	}
+
+	return "", xe.StatusCode
+}
+
+// wrapTargetError wraps a failed lookup of an action's target, tagging it
+// with ErrTargetGone when the record or its repo has been deleted
+func wrapTargetError(msg string, err error) error {
+	name, _ := xrpcErrorName(err)
+	switch name {
+	case "RecordNotFound", "RepoNotFound", "RepoDeactivated":
+		return fmt.Errorf("%s: %w: %w", msg, ErrTargetGone, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// IsSessionExpired reports whether a request failed because the session's
+// token was rejected, so logging in again may fix it
+func IsSessionExpired(err error) bool {
+	return isExpiredSessionError(err)
+}
+
+// isExpiredSessionError reports whether a refresh failed because the refresh
+// token itself is no longer valid
+func isExpiredSessionError(err error) bool {
+	name, status := xrpcErrorName(err)
+	switch name {
+	case "ExpiredToken", "InvalidToken":
+		return true
+	}
+	return name == "" && status == http.StatusUnauthorized
+}
+
+// classifyAuthError maps the refresh and create-session failures from
+// Authenticate onto ErrInvalidCredentials or ErrReauthRequired
+func classifyAuthError(refreshErr, createErr error) error {
+	name, status := xrpcErrorName(createErr)
+
+	switch name {
+	case "AuthFactorTokenRequired":
+		return ErrReauthRequired
+	case "AuthenticationRequired", "InvalidPassword":
+		return ErrInvalidCredentials
+	}
+
+	if name == "" && status == http.StatusUnauthorized {
+		return ErrInvalidCredentials
+	}
+
+	// Login failed for another reason, but the stored session is dead too
+	if refreshErr != nil && isExpiredSessionError(refreshErr) {
+		return ErrReauthRequired
+	}
+
+	return nil
+}
+
+// RateLimitReset reports whether err is a rate-limit response and, if the
+// server sent a RateLimit-Reset header, when the limit resets. The returned
+// time is zero when the reset is unknown.
+func RateLimitReset(err error) (time.Time, bool) {
+	if ClassifyError(err) != models.ErrorKindRateLimit {
+		return time.Time{}, false
+	}
+
+	var xe *xrpc.Error
+	if errors.As(err, &xe) && xe.Ratelimit != nil {
+		return xe.Ratelimit.Reset, true
+	}
+	return time.Time{}, true
+}
+
+// ClassifyError sorts a client error into auth, proxy, rate-limit, network or
+// other, so callers can tell a bad password from a flaky proxy
+func ClassifyError(err error) models.ErrorKind {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrReauthRequired) ||
+		errors.Is(err, ErrMissingCredentials) {
+		return models.ErrorKindAuth
+	}
+
+	name, status := xrpcErrorName(err)
+	switch {
+	case status == http.StatusTooManyRequests || name == "RateLimitExceeded":
+		return models.ErrorKindRateLimit
+	case status == http.StatusUnauthorized || name == "AuthenticationRequired" ||
+		name == "ExpiredToken" || name == "InvalidToken":
+		return models.ErrorKindAuth
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return models.ErrorKindProxy
+	}
+	if strings.Contains(err.Error(), "socks connect") {
+		return models.ErrorKindProxy
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return models.ErrorKindNetwork
+	}
+
+	return models.ErrorKindOther
+}
@@ -0,0 +1,151 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bluesky-social/indigo/xrpc"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// newAuthTestMux serves refreshSession and createSession with the given
+// status codes and XRPC error names
+func newAuthTestMux(refreshStatus int, refreshErr string, createStatus int, createErr string) *http.ServeMux {
+	writeError := func(w http.ResponseWriter, status int, name string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": name, "message": name})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.server.refreshSession", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, refreshStatus, refreshErr)
+	})
+	mux.HandleFunc("/xrpc/com.atproto.server.createSession", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, createStatus, createErr)
+	})
+	return mux
+}
+
+// newAuthTestClient returns a client with a stored session, so Authenticate
+// refreshes before it logs in again
+func newAuthTestClient(t *testing.T, mux *http.ServeMux) *Client {
+	t.Helper()
+
+	return newTestClient(t, muxHandler(mux), func(config *ClientConfig) {
+		did := testDID
+		access := "access-token"
+		refresh := "refresh-token"
+		config.Account.DID = &did
+		config.Account.AccessJWT = &access
+		config.Account.RefreshJWT = &refresh
+	})
+}
+
+func TestAuthenticateFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		refreshStatus int
+		refreshErr    string
+		createStatus  int
+		createErr     string
+		expected      error
+	}{
+		{
+			name:          "refresh expired and password rejected",
+			refreshStatus: http.StatusBadRequest,
+			refreshErr:    "ExpiredToken",
+			createStatus:  http.StatusUnauthorized,
+			createErr:     "AuthenticationRequired",
+			expected:      ErrInvalidCredentials,
+		},
+		{
+			name:          "refresh expired and login needs 2FA",
+			refreshStatus: http.StatusBadRequest,
+			refreshErr:    "ExpiredToken",
+			createStatus:  http.StatusUnauthorized,
+			createErr:     "AuthFactorTokenRequired",
+			expected:      ErrReauthRequired,
+		},
+		{
+			name:          "refresh expired and login unavailable",
+			refreshStatus: http.StatusBadRequest,
+			refreshErr:    "ExpiredToken",
+			createStatus:  http.StatusInternalServerError,
+			createErr:     "InternalServerError",
+			expected:      ErrReauthRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := newAuthTestMux(tt.refreshStatus, tt.refreshErr, tt.createStatus, tt.createErr)
+			client := newAuthTestClient(t, mux)
+
+			err := client.Authenticate(context.Background())
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, tt.expected), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestAuthenticateGenericFailure(t *testing.T) {
+	// Refresh failed for a transient reason, so the session may still be valid
+	mux := newAuthTestMux(http.StatusInternalServerError, "InternalServerError",
+		http.StatusInternalServerError, "InternalServerError")
+	client := newAuthTestClient(t, mux)
+
+	err := client.Authenticate(context.Background())
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrInvalidCredentials))
+	assert.False(t, errors.Is(err, ErrReauthRequired))
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected models.ErrorKind
+	}{
+		{"invalid credentials", fmt.Errorf("failed to create session: %w", ErrInvalidCredentials), models.ErrorKindAuth},
+		{"rate limited", &xrpc.Error{StatusCode: http.StatusTooManyRequests}, models.ErrorKindRateLimit},
+		{"proxy refused", &url.Error{Op: "Post", URL: "https://bsky.social",
+			Err: &net.OpError{Op: "proxyconnect", Net: "tcp", Err: errors.New("connection refused")}}, models.ErrorKindProxy},
+		{"dial timeout", &net.OpError{Op: "dial", Net: "tcp", Err: context.DeadlineExceeded}, models.ErrorKindNetwork},
+		{"other", errors.New("record not found"), models.ErrorKindOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestRateLimitReset(t *testing.T) {
+	reset := time.Unix(1736942400, 0)
+	limited := fmt.Errorf("failed to like post: %w", &xrpc.Error{
+		StatusCode: http.StatusTooManyRequests,
+		Ratelimit:  &xrpc.RatelimitInfo{Reset: reset},
+	})
+
+	got, ok := RateLimitReset(limited)
+	assert.True(t, ok)
+	assert.Equal(t, reset, got)
+
+	got, ok = RateLimitReset(&xrpc.Error{StatusCode: http.StatusTooManyRequests})
+	assert.True(t, ok)
+	assert.True(t, got.IsZero(), "no reset header")
+
+	_, ok = RateLimitReset(errors.New("record not found"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package bluesky
+
+import (
+	"regexp"
+	"strings"
+)
+
+// These patterns come from the CLI's extract.go. The mention pattern also
+// allows hyphens, which handles may contain.
+const (
+	urlPattern     = `https?://[-A-Za-z0-9+&@#\/%?=~_|!:,.;\(\)]+`
+	mentionPattern = `@[a-zA-Z0-9.-]+`
+	tagPattern     = `\B#\S+`
+)
+
+var (
+	urlRe     = regexp.MustCompile(urlPattern)
+	mentionRe = regexp.MustCompile(mentionPattern)
+	tagRe     = regexp.MustCompile(tagPattern)
+)
+
+// textEntry is a link, mention or tag found in post text. Mentions and tags
+// drop their leading @ or #, but start and end cover it.
+type textEntry struct {
+	start int64
+	end   int64
+	text  string
+}
+
+// extractLinks finds links, with offsets counted in runes
+func extractLinks(text string) []textEntry {
+	return extractEntries(urlRe, text, "", runeOffset)
+}
+
+// extractLinksBytes finds links, with offsets counted in bytes as facets need
+func extractLinksBytes(text string) []textEntry {
+	return extractEntries(urlRe, text, "", byteOffset)
+}
+
+// extractMentions finds @mentions, with offsets counted in runes
+func extractMentions(text string) []textEntry {
+	return extractEntries(mentionRe, text, "@", runeOffset)
+}
+
+// extractMentionsBytes finds @mentions, with offsets counted in bytes
+func extractMentionsBytes(text string) []textEntry {
+	return extractEntries(mentionRe, text, "@", byteOffset)
+}
+
+// extractTags finds #tags, with offsets counted in runes
+func extractTags(text string) []textEntry {
+	return extractEntries(tagRe, text, "#", runeOffset)
+}
+
+// extractTagsBytes finds #tags, with offsets counted in bytes
+func extractTagsBytes(text string) []textEntry {
+	return extractEntries(tagRe, text, "#", byteOffset)
+}
+
+func runeOffset(text string, i int) int64 { return int64(len([]rune(text[:i]))) }
+func byteOffset(text string, i int) int64 { return int64(i) }
+
+func extractEntries(re *regexp.Regexp, text, prefix string, offset func(string, int) int64) []textEntry {
+	var result []textEntry
+	for _, m := range re.FindAllStringIndex(text, -1) {
+		result = append(result, textEntry{
+			text:  strings.TrimPrefix(text[m[0]:m[1]], prefix),
+			start: offset(text, m[0]),
+			end:   offset(text, m[1]),
+		})
+	}
+	return result
+}
@@ -0,0 +1,104 @@
+package bluesky
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []textEntry
+		bytes []textEntry
+	}{
+		{
+			input: `検索は https://google.com です`,
+			want:  []textEntry{{text: "https://google.com", start: 4, end: 22}},
+			bytes: []textEntry{{text: "https://google.com", start: 10, end: 28}},
+		},
+		{
+			input: `https://google.com です`,
+			want:  []textEntry{{text: "https://google.com", start: 0, end: 18}},
+			bytes: []textEntry{{text: "https://google.com", start: 0, end: 18}},
+		},
+		{
+			input: `https://google.com`,
+			want:  []textEntry{{text: "https://google.com", start: 0, end: 18}},
+			bytes: []textEntry{{text: "https://google.com", start: 0, end: 18}},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, extractLinks(test.input), test.input)
+		assert.Equal(t, test.bytes, extractLinksBytes(test.input), test.input)
+	}
+}
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []textEntry
+		bytes []textEntry
+	}{
+		{
+			input: `返事は @mattn へ`,
+			want:  []textEntry{{text: "mattn", start: 4, end: 10}},
+			bytes: []textEntry{{text: "mattn", start: 10, end: 16}},
+		},
+		{
+			input: `返事は @mattn-jp.bsky.social へ`,
+			want:  []textEntry{{text: "mattn-jp.bsky.social", start: 4, end: 25}},
+			bytes: []textEntry{{text: "mattn-jp.bsky.social", start: 10, end: 31}},
+		},
+		{
+			input: `返事は @mattn.jp へ`,
+			want:  []textEntry{{text: "mattn.jp", start: 4, end: 13}},
+			bytes: []textEntry{{text: "mattn.jp", start: 10, end: 19}},
+		},
+		{
+			input: `返事は @@mattn へ`,
+			want:  []textEntry{{text: "mattn", start: 5, end: 11}},
+			bytes: []textEntry{{text: "mattn", start: 11, end: 17}},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, extractMentions(test.input), test.input)
+		assert.Equal(t, test.bytes, extractMentionsBytes(test.input), test.input)
+	}
+}
+
+func TestExtractTags(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []textEntry
+		bytes []textEntry
+	}{
+		{
+			input: `Hi, #Bluesky!`,
+			want:  []textEntry{{text: "Bluesky!", start: 4, end: 13}},
+			bytes: []textEntry{{text: "Bluesky!", start: 4, end: 13}},
+		},
+		{
+			input: `bsky から#テスト`,
+			want:  []textEntry{{text: "テスト", start: 7, end: 11}},
+			bytes: []textEntry{{text: "テスト", start: 11, end: 21}},
+		},
+		{
+			input: `Emoji hashtags: #🦋 #🟦🈳 #🌌`,
+			want: []textEntry{
+				{text: "🦋", start: 16, end: 18},
+				{text: "🟦🈳", start: 19, end: 22},
+				{text: "🌌", start: 23, end: 25},
+			},
+			bytes: []textEntry{
+				{text: "🦋", start: 16, end: 21},
+				{text: "🟦🈳", start: 22, end: 31},
+				{text: "🌌", start: 32, end: 37},
+			},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, extractTags(test.input), test.input)
+		assert.Equal(t, test.bytes, extractTagsBytes(test.input), test.input)
+	}
+}
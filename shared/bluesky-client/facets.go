@@ -0,0 +1,128 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// facetTrailingPunctuation is trimmed from the end of detected links, tags
+// and mentions so "see https://example.com." doesn't swallow the full stop
+const facetTrailingPunctuation = `.,;:!?'")]`
+
+// detectFacets finds links and hashtags in text using the extractors in
+// extract.go. Mentions need a handle lookup to resolve; see
+// Client.addMentionFacets.
+func detectFacets(text string) []Facet {
+	var facets []Facet
+
+	for _, link := range extractLinksBytes(text) {
+		uri := strings.TrimRight(link.text, facetTrailingPunctuation)
+		start := int(link.start)
+		facets = append(facets, Facet{ByteStart: start, ByteEnd: start + len(uri), URI: uri})
+	}
+
+	for _, tag := range extractTagsBytes(text) {
+		name := strings.TrimRight(tag.text, facetTrailingPunctuation)
+		start := int(tag.start)
+		if name == "" || overlapsFacet(facets, start) {
+			continue
+		}
+		facets = append(facets, Facet{ByteStart: start, ByteEnd: start + len("#") + len(name), Tag: name})
+	}
+
+	sort.Slice(facets, func(i, j int) bool { return facets[i].ByteStart < facets[j].ByteStart })
+	return facets
+}
+
+// mention is an @handle found in post text, before its DID is known
+type mention struct {
+	ByteStart int
+	ByteEnd   int
+	Handle    string
+}
+
+// detectMentions finds @handle mentions in text that don't overlap facets.
+// A mention must start the text or follow whitespace or "(", so addresses
+// like "a@b.com" are not mentions. Candidates that are not valid handles,
+// such as "@home", are skipped.
+func detectMentions(text string, facets []Facet) []mention {
+	var mentions []mention
+	for _, m := range extractMentionsBytes(text) {
+		start := int(m.start)
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && !unicode.IsSpace(before) && before != '(' {
+			continue
+		}
+		raw := strings.TrimRight(m.text, facetTrailingPunctuation)
+		handle, err := syntax.ParseHandle(raw)
+		if err != nil || overlapsFacet(facets, start) {
+			continue
+		}
+		mentions = append(mentions, mention{ByteStart: start, ByteEnd: start + len("@") + len(raw), Handle: handle.Normalize().String()})
+	}
+	return mentions
+}
+
+// addMentionFacets adds a mention facet for each @handle in text that
+// resolves to an account. Like the Bluesky app, mentions of handles that
+// don't resolve are left as plain text rather than failing the post.
+func (c *Client) addMentionFacets(ctx context.Context, text string, facets []Facet) []Facet {
+	mentions := detectMentions(text, facets)
+	if len(mentions) == 0 {
+		return facets
+	}
+
+	dids := make(map[string]string, len(mentions))
+	for _, m := range mentions {
+		did, looked := dids[m.Handle]
+		if !looked {
+			if profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, m.Handle); err == nil {
+				did = profile.Did
+			}
+			dids[m.Handle] = did
+		}
+		if did != "" {
+			facets = append(facets, Facet{ByteStart: m.ByteStart, ByteEnd: m.ByteEnd, DID: did})
+		}
+	}
+
+	sort.Slice(facets, func(i, j int) bool { return facets[i].ByteStart < facets[j].ByteStart })
+	return facets
+}
+
+// validateFacets checks that caller-supplied facets lie within text, don't
+// overlap and each carry a feature
+func validateFacets(text string, facets []Facet) error {
+	sorted := make([]Facet, len(facets))
+	copy(sorted, facets)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ByteStart < sorted[j].ByteStart })
+
+	for i, facet := range sorted {
+		if facet.ByteStart < 0 || facet.ByteEnd > len(text) || facet.ByteStart >= facet.ByteEnd {
+			return fmt.Errorf("%w: range %d-%d is outside the %d byte text", ErrInvalidFacet, facet.ByteStart, facet.ByteEnd, len(text))
+		}
+		if facet.URI == "" && facet.DID == "" && facet.Tag == "" {
+			return fmt.Errorf("%w: range %d-%d has no uri, did or tag", ErrInvalidFacet, facet.ByteStart, facet.ByteEnd)
+		}
+		if i > 0 && facet.ByteStart < sorted[i-1].ByteEnd {
+			return fmt.Errorf("%w: range %d-%d overlaps %d-%d", ErrInvalidFacet,
+				facet.ByteStart, facet.ByteEnd, sorted[i-1].ByteStart, sorted[i-1].ByteEnd)
+		}
+	}
+	return nil
+}
+
+func overlapsFacet(facets []Facet, offset int) bool {
+	for _, facet := range facets {
+		if offset >= facet.ByteStart && offset < facet.ByteEnd {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func recordFacetRanges(record map[string]interface{}) [][2]int {
+	facets, _ := record["facets"].([]interface{})
+	ranges := make([][2]int, 0, len(facets))
+	for _, facet := range facets {
+		index := facet.(map[string]interface{})["index"].(map[string]interface{})
+		ranges = append(ranges, [2]int{int(index["byteStart"].(float64)), int(index["byteEnd"].(float64))})
+	}
+	return ranges
+}
+
+func TestDetectFacets(t *testing.T) {
+	text := "café #golang, see https://example.com/a#b. #1 #"
+	facets := detectFacets(text)
+
+	if assert.Len(t, facets, 3) {
+		assert.Equal(t, "golang", facets[0].Tag)
+		assert.Equal(t, "#golang", text[facets[0].ByteStart:facets[0].ByteEnd])
+		assert.Equal(t, "https://example.com/a#b", facets[1].URI)
+		assert.Equal(t, facets[1].URI, text[facets[1].ByteStart:facets[1].ByteEnd])
+		assert.Equal(t, "1", facets[2].Tag)
+	}
+}
+
+func TestPostExplicitFacetsSkipDetection(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	text := "read the docs #golang"
+	_, err := client.Post(context.Background(), text, &PostOptions{
+		Facets: []Facet{{ByteStart: 0, ByteEnd: len("read the docs"), URI: "https://go.dev/doc"}},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, [][2]int{{0, 13}}, recordFacetRanges(records[0]))
+		facets := records[0]["facets"].([]interface{})
+		features := facets[0].(map[string]interface{})["features"].([]interface{})
+		assert.Equal(t, "https://go.dev/doc", features[0].(map[string]interface{})["uri"])
+	}
+}
+
+func TestPostDetectsFacets(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	text := "✨ https://example.com #golang"
+	_, err := client.Post(context.Background(), text, nil)
+	assert.NoError(t, err)
+	_, err = client.Post(context.Background(), text, &PostOptions{SkipFacetDetection: true})
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 2) {
+		link := strings.Index(text, "https")
+		tag := strings.Index(text, "#golang")
+		assert.Equal(t, [][2]int{{link, link + len("https://example.com")}, {tag, len(text)}}, recordFacetRanges(records[0]))
+		assert.Empty(t, recordFacetRanges(records[1]), "detection was skipped")
+	}
+}
+
+func TestPostRejectsInvalidFacets(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	invalid := [][]Facet{
+		{{ByteStart: 5, ByteEnd: 50, URI: "https://example.com"}},
+		{{ByteStart: -1, ByteEnd: 3, Tag: "x"}},
+		{{ByteStart: 0, ByteEnd: 4, Tag: "a"}, {ByteStart: 2, ByteEnd: 6, Tag: "b"}},
+		{{ByteStart: 0, ByteEnd: 4}},
+	}
+	for _, facets := range invalid {
+		_, err := client.Post(context.Background(), "short text", &PostOptions{Facets: facets})
+		assert.ErrorIs(t, err, ErrInvalidFacet)
+	}
+	assert.Empty(t, records)
+}
+
+func TestDetectMentions(t *testing.T) {
+	text := "héllo @Alice.bsky.social, (@bob.test) mail a@b.com @home https://x.com/@carol.test"
+	links := detectFacets(text)
+
+	mentions := detectMentions(text, links)
+	if assert.Len(t, mentions, 2) {
+		assert.Equal(t, "alice.bsky.social", mentions[0].Handle)
+		assert.Equal(t, "@Alice.bsky.social", text[mentions[0].ByteStart:mentions[0].ByteEnd])
+		assert.Equal(t, "bob.test", mentions[1].Handle)
+		assert.Equal(t, "@bob.test", text[mentions[1].ByteStart:mentions[1].ByteEnd])
+	}
+}
+
+func TestPostDetectsMentions(t *testing.T) {
+	var records []map[string]interface{}
+	var lookups []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/app.bsky.actor.getProfile":
+			actor := r.URL.Query().Get("actor")
+			lookups = append(lookups, actor)
+			if actor == "ghost.bsky.social" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "Profile not found"})
+			}
+		case "/xrpc/com.atproto.repo.createRecord":
+			var input struct {
+				Record map[string]interface{} `json:"record"`
+			}
+			json.NewDecoder(r.Body).Decode(&input)
+			records = append(records, input.Record)
+			json.NewEncoder(w).Encode(map[string]string{
+				"uri": "at://" + testDID + "/app.bsky.feed.post/abc",
+				"cid": "bafytest",
+			})
+		}
+	})
+
+	text := "✨ hi @alice.bsky.social and @ghost.bsky.social, @Alice.bsky.social again #golang"
+	_, err := client.Post(context.Background(), text, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice.bsky.social", "ghost.bsky.social"}, lookups, "each handle is looked up once")
+
+	if assert.Len(t, records, 1) {
+		first := strings.Index(text, "@alice")
+		again := strings.Index(text, "@Alice")
+		tag := strings.Index(text, "#golang")
+		assert.Equal(t, [][2]int{
+			{first, first + len("@alice.bsky.social")},
+			{again, again + len("@Alice.bsky.social")},
+			{tag, len(text)},
+		}, recordFacetRanges(records[0]), "the unresolved mention stays plain text")
+
+		facets := records[0]["facets"].([]interface{})
+		features := facets[0].(map[string]interface{})["features"].([]interface{})
+		feature := features[0].(map[string]interface{})
+		assert.Equal(t, "app.bsky.richtext.facet#mention", feature["$type"])
+		assert.Equal(t, "did:plc:alice", feature["did"])
+	}
+
+	// Without detection no profile is looked up
+	lookups = nil
+	_, err = client.Post(context.Background(), text, &PostOptions{SkipFacetDetection: true})
+	assert.NoError(t, err)
+	assert.Empty(t, lookups)
+}
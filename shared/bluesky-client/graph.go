@@ -0,0 +1,85 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// GraphOptions represents options for paging through followers or follows
+type GraphOptions struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// GraphResult represents one page of followers or follows
+type GraphResult struct {
+	Subject *bsky.ActorDefs_ProfileView   `json:"subject"`
+	Actors  []*bsky.ActorDefs_ProfileView `json:"actors"`
+	Cursor  string                        `json:"cursor,omitempty"`
+	// TotalCount is the subject's followersCount or followsCount from its
+	// profile. It is only hydrated on the first page (empty cursor), so
+	// callers should keep it for the rest of the iteration.
+	TotalCount *int64 `json:"total_count,omitempty"`
+}
+
+// GetFollowers gets a page of accounts following actor
+func (c *Client) GetFollowers(ctx context.Context, actor string, options *GraphOptions) (*GraphResult, error) {
+	if options == nil {
+		options = &GraphOptions{Limit: 50}
+	}
+
+	resp, err := bsky.GraphGetFollowers(ctx, c.xrpcc, actor, options.Cursor, int64(options.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+
+	result := &GraphResult{
+		Subject: resp.Subject,
+		Actors:  resp.Followers,
+	}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+
+	if options.Cursor == "" {
+		profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, actor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get profile: %w", err)
+		}
+		result.TotalCount = profile.FollowersCount
+	}
+
+	return result, nil
+}
+
+// GetFollows gets a page of accounts actor follows
+func (c *Client) GetFollows(ctx context.Context, actor string, options *GraphOptions) (*GraphResult, error) {
+	if options == nil {
+		options = &GraphOptions{Limit: 50}
+	}
+
+	resp, err := bsky.GraphGetFollows(ctx, c.xrpcc, actor, options.Cursor, int64(options.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get follows: %w", err)
+	}
+
+	result := &GraphResult{
+		Subject: resp.Subject,
+		Actors:  resp.Follows,
+	}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+
+	if options.Cursor == "" {
+		profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, actor)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get profile: %w", err)
+		}
+		result.TotalCount = profile.FollowsCount
+	}
+
+	return result, nil
+}
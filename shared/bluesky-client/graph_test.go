@@ -0,0 +1,61 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFollowersTotalCount(t *testing.T) {
+	profileCalls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/app.bsky.actor.getProfile", func(w http.ResponseWriter, r *http.Request) {
+		profileCalls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"did":            "did:plc:alice",
+			"handle":         "alice.bsky.social",
+			"followersCount": 3,
+			"followsCount":   1,
+		})
+	})
+	mux.HandleFunc("/xrpc/app.bsky.graph.getFollowers", func(w http.ResponseWriter, r *http.Request) {
+		subject := map[string]string{"did": "did:plc:alice", "handle": "alice.bsky.social"}
+		resp := map[string]interface{}{"subject": subject}
+		if r.URL.Query().Get("cursor") == "" {
+			resp["followers"] = []map[string]string{
+				{"did": "did:plc:bob", "handle": "bob.bsky.social"},
+				{"did": "did:plc:carol", "handle": "carol.bsky.social"},
+			}
+			resp["cursor"] = "page2"
+		} else {
+			resp["followers"] = []map[string]string{
+				{"did": "did:plc:dave", "handle": "dave.bsky.social"},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	client := newTestClient(t, muxHandler(mux))
+
+	first, err := client.GetFollowers(context.Background(), "alice.bsky.social", &GraphOptions{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, first.Actors, 2)
+	assert.Equal(t, "page2", first.Cursor)
+	if assert.NotNil(t, first.TotalCount) {
+		assert.Equal(t, int64(3), *first.TotalCount)
+	}
+
+	second, err := client.GetFollowers(context.Background(), "alice.bsky.social", &GraphOptions{Cursor: first.Cursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, second.Actors, 1)
+	assert.Empty(t, second.Cursor)
+	assert.Nil(t, second.TotalCount)
+
+	assert.Equal(t, 1, profileCalls)
+}
@@ -0,0 +1,93 @@
+package bluesky
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"unicode/utf8"
+)
+
+// ErrImageRejected indicates an image failed the client-side checks run
+// before upload
+var ErrImageRejected = errors.New("image rejected")
+
+// ErrInvalidAltText indicates image alt text that cannot be posted
+var ErrInvalidAltText = errors.New("invalid alt text")
+
+// maxAltTextRunes caps an image's alt text, counted in runes rather than
+// grapheme clusters. Bluesky's clients limit alt text to 2000 graphemes;
+// a rune count is never smaller, so text passing here is within that limit.
+const maxAltTextRunes = 2000
+
+// ImageLimits restricts which images are uploaded. Zero dimensions and an
+// empty AllowedMimeTypes disable the respective check.
+type ImageLimits struct {
+	AllowedMimeTypes []string
+	MaxWidth         int
+	MaxHeight        int
+}
+
+// DefaultImageLimits are used when ClientConfig.ImageLimits is nil
+var DefaultImageLimits = ImageLimits{
+	AllowedMimeTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+	MaxWidth:         4096,
+	MaxHeight:        4096,
+}
+
+// checkImageAlts checks the alt texts given for imagePaths: there may be
+// fewer alts than images but not more, and each must fit the length limit
+func checkImageAlts(imagePaths, alts []string) error {
+	if len(alts) > len(imagePaths) {
+		return fmt.Errorf("%w: %d alt texts given for %d images", ErrInvalidAltText, len(alts), len(imagePaths))
+	}
+	for i, alt := range alts {
+		if n := utf8.RuneCountInString(alt); n > maxAltTextRunes {
+			return fmt.Errorf("%w: alt text for image %s is %d characters, maximum is %d", ErrInvalidAltText, imagePaths[i], n, maxAltTextRunes)
+		}
+	}
+	return nil
+}
+
+// checkImage validates image data against limits and returns its MIME type
+func checkImage(data []byte, limits ImageLimits) (string, error) {
+	contentType := http.DetectContentType(data)
+
+	if len(limits.AllowedMimeTypes) > 0 {
+		allowed := false
+		for _, mimeType := range limits.AllowedMimeTypes {
+			if mimeType == contentType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "", fmt.Errorf("%w: format %s is not allowed", ErrImageRejected, contentType)
+		}
+	}
+
+	if limits.MaxWidth == 0 && limits.MaxHeight == 0 {
+		return contentType, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			// No decoder registered (e.g. webp), so dimensions can't be checked
+			return contentType, nil
+		}
+		return "", fmt.Errorf("%w: failed to decode image: %v", ErrImageRejected, err)
+	}
+
+	if (limits.MaxWidth > 0 && config.Width > limits.MaxWidth) ||
+		(limits.MaxHeight > 0 && config.Height > limits.MaxHeight) {
+		return "", fmt.Errorf("%w: %dx%d exceeds maximum %dx%d", ErrImageRejected,
+			config.Width, config.Height, limits.MaxWidth, limits.MaxHeight)
+	}
+
+	return contentType, nil
+}
@@ -0,0 +1,174 @@
+package bluesky
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestCheckImage(t *testing.T) {
+	limits := ImageLimits{
+		AllowedMimeTypes: []string{"image/png", "image/jpeg"},
+		MaxWidth:         100,
+		MaxHeight:        50,
+	}
+
+	contentType, err := checkImage(encodeTestPNG(t, 100, 50), limits)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	_, err = checkImage(encodeTestPNG(t, 101, 10), limits)
+	assert.ErrorIs(t, err, ErrImageRejected)
+	assert.Contains(t, err.Error(), "101x10")
+
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+	_, err = checkImage(gif, limits)
+	assert.ErrorIs(t, err, ErrImageRejected)
+	assert.Contains(t, err.Error(), "image/gif")
+
+	// Zero limits disable both checks
+	_, err = checkImage(encodeTestPNG(t, 5000, 5000), ImageLimits{})
+	assert.NoError(t, err)
+}
+
+func TestUploadImageRejectedBeforeUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "huge.png")
+	assert.NoError(t, os.WriteFile(path, encodeTestPNG(t, 300, 300), 0o644))
+
+	// The host is unreachable, so reaching the upload would fail differently
+	client, err := NewClient(ClientConfig{
+		Account:     &models.Account{Handle: "test.bsky.social", Password: "password", Host: "http://127.0.0.1:0"},
+		ImageLimits: &ImageLimits{MaxWidth: 200, MaxHeight: 200},
+	})
+	assert.NoError(t, err)
+
+	_, err = client.UploadImage(context.Background(), path, nil)
+	assert.ErrorIs(t, err, ErrImageRejected)
+}
+
+// newImagePostTestClient counts uploadBlob calls in uploads and captures
+// createRecord's record in records
+func newImagePostTestClient(t *testing.T, uploads *int, records *[]map[string]interface{}) *Client {
+	t.Helper()
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.repo.uploadBlob":
+			*uploads++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"blob": map[string]interface{}{
+					"$type":    "blob",
+					"ref":      map[string]string{"$link": "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+					"mimeType": "image/png",
+					"size":     42,
+				},
+			})
+		case "/xrpc/com.atproto.repo.createRecord":
+			var input struct {
+				Record map[string]interface{} `json:"record"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+			*records = append(*records, input.Record)
+			json.NewEncoder(w).Encode(map[string]string{
+				"uri": "at://" + testDID + "/app.bsky.feed.post/abc",
+				"cid": "bafytest",
+			})
+		}
+	})
+}
+
+func writeTestImages(t *testing.T, n int) []string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make([]string, n)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, string(rune('a'+i))+".png")
+		require.NoError(t, os.WriteFile(paths[i], encodeTestPNG(t, 10, 10), 0o600))
+	}
+	return paths
+}
+
+func TestPostImageAltText(t *testing.T) {
+	var uploads int
+	var records []map[string]interface{}
+	client := newImagePostTestClient(t, &uploads, &records)
+
+	_, err := client.Post(context.Background(), "pictures", &PostOptions{
+		Images:    writeTestImages(t, 3),
+		ImageAlts: []string{"a cat", "a dog"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, uploads)
+
+	require.Len(t, records, 1)
+	embed := records[0]["embed"].(map[string]interface{})
+	images := embed["images"].([]interface{})
+	require.Len(t, images, 3)
+	assert.Equal(t, "a cat", images[0].(map[string]interface{})["alt"])
+	assert.Equal(t, "a dog", images[1].(map[string]interface{})["alt"])
+	assert.Equal(t, "", images[2].(map[string]interface{})["alt"], "images without an alt default to empty")
+}
+
+func TestPostImageAltTextRejectedBeforeUpload(t *testing.T) {
+	var uploads int
+	var records []map[string]interface{}
+	client := newImagePostTestClient(t, &uploads, &records)
+	paths := writeTestImages(t, 2)
+
+	_, err := client.Post(context.Background(), "pictures", &PostOptions{
+		Images:    paths,
+		ImageAlts: []string{"fine", strings.Repeat("é", maxAltTextRunes+1)},
+	})
+	assert.ErrorIs(t, err, ErrInvalidAltText)
+
+	_, err = client.Post(context.Background(), "pictures", &PostOptions{
+		Images:    paths[:1],
+		ImageAlts: []string{"one", "two"},
+	})
+	assert.ErrorIs(t, err, ErrInvalidAltText)
+
+	// An alt at the limit is accepted
+	_, err = client.Post(context.Background(), "pictures", &PostOptions{
+		Images:    paths[:1],
+		ImageAlts: []string{strings.Repeat("é", maxAltTextRunes)},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, uploads, "rejected posts must not upload any blobs")
+	assert.Len(t, records, 1)
+}
+
+func TestPostImageRejectedBeforeAnyUpload(t *testing.T) {
+	var uploads int
+	var records []map[string]interface{}
+	client := newImagePostTestClient(t, &uploads, &records)
+	client.images = ImageLimits{MaxWidth: 200, MaxHeight: 200}
+
+	paths := writeTestImages(t, 2)
+	require.NoError(t, os.WriteFile(paths[1], encodeTestPNG(t, 300, 300), 0o600))
+
+	_, err := client.Post(context.Background(), "pictures", &PostOptions{Images: paths})
+	assert.ErrorIs(t, err, ErrImageRejected)
+	assert.Zero(t, uploads, "the valid first image must not be uploaded either")
+	assert.Empty(t, records)
+}
@@ -0,0 +1,138 @@
+package bluesky
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLangShare is the fraction of letters (or stopword hits, for Latin text)
+// the leading language needs before detectLanguage commits to it
+const minLangShare = 0.6
+
+// minLangLetters and minLangStopwords keep very short posts undetected
+const (
+	minLangLetters   = 3
+	minLangStopwords = 2
+)
+
+// scriptLangs maps scripts used by a single language to its BCP-47 tag. Han
+// and Cyrillic are handled separately because several languages share them.
+var scriptLangs = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+}
+
+// latinStopwords lists frequent function words that set Latin-script
+// languages apart from each other
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "was", "of", "to", "in", "it", "that", "this", "with", "for", "you", "on", "be", "have", "not", "just", "my"},
+	"es": {"el", "la", "los", "las", "es", "y", "que", "de", "en", "un", "una", "por", "con", "para", "no", "muy", "pero", "está"},
+	"fr": {"le", "la", "les", "est", "et", "que", "de", "des", "un", "une", "pour", "avec", "dans", "pas", "je", "ce", "sur", "très"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "für", "ich", "auf", "zu", "den", "sehr", "auch"},
+	"pt": {"o", "os", "as", "é", "e", "que", "de", "um", "uma", "para", "com", "não", "em", "muito", "mas", "está"},
+	"it": {"il", "lo", "gli", "è", "e", "che", "di", "un", "una", "per", "con", "non", "sono", "molto", "ma", "anche"},
+}
+
+// detectLanguage guesses the language of post text from its script and, for
+// Latin text, its stopwords. It returns "" when no language is clearly
+// dominant, so callers leave langs unset rather than tagging a post wrongly.
+func detectLanguage(text string) string {
+	words := langWords(text)
+
+	var letters, kana, han int
+	scripts := make(map[string]int)
+	var latin int
+	for _, word := range words {
+		for _, r := range word {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			letters++
+			switch {
+			case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+				kana++
+			case unicode.Is(unicode.Han, r):
+				han++
+			case unicode.Is(unicode.Latin, r):
+				latin++
+			default:
+				for _, script := range scriptLangs {
+					if unicode.Is(script.table, r) {
+						scripts[script.lang]++
+						break
+					}
+				}
+			}
+		}
+	}
+	if letters < minLangLetters {
+		return ""
+	}
+
+	dominant := func(n int) bool { return float64(n) >= minLangShare*float64(letters) }
+	switch {
+	case kana > 0 && dominant(kana+han):
+		// Kanji alongside kana is Japanese
+		return "ja"
+	case dominant(han):
+		return "zh"
+	case dominant(latin):
+		return detectLatinLanguage(words)
+	}
+	for lang, n := range scripts {
+		if dominant(n) {
+			return lang
+		}
+	}
+	return ""
+}
+
+// detectLatinLanguage picks the language whose stopwords occur most often,
+// provided it accounts for a clear majority of all stopword hits
+func detectLatinLanguage(words []string) string {
+	scores := make(map[string]int)
+	total := 0
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }))
+		for lang, stopwords := range latinStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					scores[lang]++
+					total++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore := "", 0
+	for lang, score := range scores {
+		if score > bestScore || (score == bestScore && lang < best) {
+			best, bestScore = lang, score
+		}
+	}
+	if bestScore < minLangStopwords || float64(bestScore) < minLangShare*float64(total) {
+		return ""
+	}
+	return best
+}
+
+// langWords splits text into words, dropping links, mentions and hashtags,
+// which say nothing about the language the post is written in
+func langWords(text string) []string {
+	fields := strings.Fields(text)
+	words := fields[:0]
+	for _, field := range fields {
+		if strings.HasPrefix(field, "@") || strings.HasPrefix(field, "#") || strings.Contains(field, "://") {
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
@@ -0,0 +1,50 @@
+package bluesky
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"This is just the best thing that happened to me all week, and I love it": "en",
+		"今日はとても良い天気ですね。散歩に行きましょう":                                                 "ja",
+		"Check this out https://example.com #golang @alice.bsky.social":           "",
+		"ok": "",
+		"🎉🎉": "",
+	}
+	for text, want := range cases {
+		assert.Equal(t, want, detectLanguage(text), text)
+	}
+}
+
+func TestPostAutoDetectLang(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+	ctx := context.Background()
+
+	english, err := client.Post(ctx, "This is the first post of the day and it is going well", &PostOptions{AutoDetectLang: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "en", english.Lang)
+
+	japanese, err := client.Post(ctx, "新しいアプリを作っています。よろしくお願いします", &PostOptions{AutoDetectLang: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "ja", japanese.Lang)
+
+	explicit, err := client.Post(ctx, "This is the first post of the day", &PostOptions{AutoDetectLang: true, Langs: []string{"en-GB"}})
+	assert.NoError(t, err)
+	assert.Empty(t, explicit.Lang, "explicit langs skip detection")
+
+	unclear, err := client.Post(ctx, "lol", &PostOptions{AutoDetectLang: true})
+	assert.NoError(t, err)
+	assert.Empty(t, unclear.Lang)
+
+	if assert.Len(t, records, 4) {
+		assert.Equal(t, []interface{}{"en"}, records[0]["langs"])
+		assert.Equal(t, []interface{}{"ja"}, records[1]["langs"])
+		assert.Equal(t, []interface{}{"en-GB"}, records[2]["langs"])
+		assert.NotContains(t, records[3], "langs")
+	}
+}
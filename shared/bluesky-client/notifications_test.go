@@ -0,0 +1,55 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newNotificationTestClient returns a client whose unread count is served
+// from *unread, counting how often notifications are listed
+func newNotificationTestClient(t *testing.T, unread *int, listCalls *int) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/app.bsky.notification.getUnreadCount", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"count": *unread})
+	})
+	mux.HandleFunc("/xrpc/app.bsky.notification.listNotifications", func(w http.ResponseWriter, r *http.Request) {
+		*listCalls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"notifications": []interface{}{}})
+	})
+
+	return newTestClient(t, muxHandler(mux))
+}
+
+func TestGetUnreadCount(t *testing.T) {
+	unread, listCalls := 7, 0
+	client := newNotificationTestClient(t, &unread, &listCalls)
+
+	count, err := client.GetUnreadCount(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 7, count)
+	assert.Equal(t, 0, listCalls)
+}
+
+func TestGetNotificationsSkipsWhenNoneUnread(t *testing.T) {
+	unread, listCalls := 0, 0
+	client := newNotificationTestClient(t, &unread, &listCalls)
+	options := &NotificationOptions{Limit: 50, SkipIfNoneUnread: true}
+
+	result, err := client.GetNotifications(context.Background(), options)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Notifications)
+	assert.Equal(t, 0, listCalls)
+
+	unread = 2
+	_, err = client.GetNotifications(context.Background(), options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, listCalls)
+}
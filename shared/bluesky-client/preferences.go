@@ -0,0 +1,112 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+)
+
+const adultContentPrefType = "app.bsky.actor.defs#adultContentPref"
+
+// preferencesPayload is the body of getPreferences and putPreferences. Entries
+// are kept as raw JSON so preference types this client does not know about
+// survive a read-modify-write unchanged.
+type preferencesPayload struct {
+	Preferences []json.RawMessage `json:"preferences"`
+}
+
+// PreferencesResult represents the account's actor preferences
+type PreferencesResult struct {
+	Preferences         []json.RawMessage `json:"preferences"`
+	AdultContentEnabled bool              `json:"adult_content_enabled"`
+}
+
+// GetPreferences gets the account's actor preferences
+func (c *Client) GetPreferences(ctx context.Context) (*PreferencesResult, error) {
+	prefs, err := c.getRawPreferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PreferencesResult{Preferences: prefs}
+	for _, pref := range prefs {
+		if preferenceType(pref) != adultContentPrefType {
+			continue
+		}
+		var adult struct {
+			Enabled bool `json:"enabled"`
+		}
+		if err := json.Unmarshal(pref, &adult); err == nil {
+			result.AdultContentEnabled = adult.Enabled
+		}
+	}
+
+	return result, nil
+}
+
+// SetAdultContentEnabled updates the adult content preference, leaving every
+// other preference entry untouched
+func (c *Client) SetAdultContentEnabled(ctx context.Context, enabled bool) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+
+	prefs, err := c.getRawPreferences(ctx)
+	if err != nil {
+		return err
+	}
+
+	entry, err := json.Marshal(map[string]interface{}{
+		"$type":   adultContentPrefType,
+		"enabled": enabled,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode adult content preference: %w", err)
+	}
+
+	updated := make([]json.RawMessage, 0, len(prefs)+1)
+	replaced := false
+	for _, pref := range prefs {
+		if preferenceType(pref) == adultContentPrefType {
+			if !replaced {
+				updated = append(updated, entry)
+				replaced = true
+			}
+			continue
+		}
+		updated = append(updated, pref)
+	}
+	if !replaced {
+		updated = append(updated, entry)
+	}
+
+	err = c.xrpcc.LexDo(ctx, lexutil.Procedure, "application/json", "app.bsky.actor.putPreferences", nil,
+		&preferencesPayload{Preferences: updated}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to put preferences: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) getRawPreferences(ctx context.Context) ([]json.RawMessage, error) {
+	var out preferencesPayload
+	err := c.xrpcc.LexDo(ctx, lexutil.Query, "", "app.bsky.actor.getPreferences", map[string]interface{}{}, nil, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return out.Preferences, nil
+}
+
+// preferenceType returns the $type of a raw preference entry
+func preferenceType(pref json.RawMessage) string {
+	var typed struct {
+		Type string `json:"$type"`
+	}
+	if err := json.Unmarshal(pref, &typed); err != nil {
+		return ""
+	}
+	return typed.Type
+}
@@ -0,0 +1,64 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPreferences = `{"preferences": [
+	{"$type": "app.bsky.actor.defs#savedFeedsPrefV2", "items": [{"id": "1", "type": "timeline", "value": "following", "pinned": true}]},
+	{"$type": "app.bsky.actor.defs#adultContentPref", "enabled": false},
+	{"$type": "app.bsky.actor.defs#someFuturePref", "flavour": "unknown"}
+]}`
+
+func newPreferencesTestClient(t *testing.T, stored *[]map[string]interface{}) *Client {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/app.bsky.actor.getPreferences", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testPreferences))
+	})
+	mux.HandleFunc("/xrpc/app.bsky.actor.putPreferences", func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Preferences []map[string]interface{} `json:"preferences"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		*stored = input.Preferences
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return newTestClient(t, muxHandler(mux))
+}
+
+func TestGetPreferences(t *testing.T) {
+	var stored []map[string]interface{}
+	client := newPreferencesTestClient(t, &stored)
+
+	prefs, err := client.GetPreferences(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, prefs.Preferences, 3)
+	assert.False(t, prefs.AdultContentEnabled)
+}
+
+func TestSetAdultContentEnabledPreservesOtherPreferences(t *testing.T) {
+	var stored []map[string]interface{}
+	client := newPreferencesTestClient(t, &stored)
+
+	assert.NoError(t, client.SetAdultContentEnabled(context.Background(), true))
+	assert.Len(t, stored, 3)
+
+	byType := make(map[string]map[string]interface{})
+	for _, pref := range stored {
+		byType[pref["$type"].(string)] = pref
+	}
+
+	assert.Equal(t, true, byType["app.bsky.actor.defs#adultContentPref"]["enabled"])
+	assert.Equal(t, "unknown", byType["app.bsky.actor.defs#someFuturePref"]["flavour"])
+	items := byType["app.bsky.actor.defs#savedFeedsPrefV2"]["items"].([]interface{})
+	assert.Equal(t, true, items[0].(map[string]interface{})["pinned"])
+}
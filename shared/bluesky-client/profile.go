@@ -0,0 +1,199 @@
+package bluesky
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"unicode/utf8"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	lexutil "github.com/bluesky-social/indigo/lex/util"
+)
+
+const profileCollection = "app.bsky.actor.profile"
+
+// Limits from the app.bsky.actor.profile lexicon. Lengths are counted in
+// runes, which never undercounts graphemes, so a value passing here is not
+// rejected by the PDS.
+const (
+	maxDisplayNameGraphemes = 64
+	maxDisplayNameBytes     = 640
+	maxDescriptionGraphemes = 256
+	maxDescriptionBytes     = 2560
+	maxProfileImageBytes    = 1000000
+)
+
+// profileImageLimits restricts avatars and banners to the formats the
+// profile lexicon accepts
+var profileImageLimits = ImageLimits{AllowedMimeTypes: []string{"image/png", "image/jpeg"}}
+
+// ErrInvalidProfile indicates a profile field exceeds the lexicon's limits
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// ProfileUpdateOptions lists the profile fields to change. Nil strings and
+// empty image paths leave the field as it is; an empty string clears it.
+type ProfileUpdateOptions struct {
+	DisplayName *string `json:"display_name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Avatar      string  `json:"avatar,omitempty"` // Path to a PNG or JPEG image
+	Banner      string  `json:"banner,omitempty"` // Path to a PNG or JPEG image
+}
+
+// ProfileUpdateResult represents the written profile record
+type ProfileUpdateResult struct {
+	URI     string `json:"uri"`
+	CID     string `json:"cid"`
+	Created bool   `json:"created"` // The account had no profile record before
+}
+
+// profileRecordOutput is getRecord's response with the record kept as raw
+// JSON fields, so fields this client does not know about survive the update
+type profileRecordOutput struct {
+	Cid   *string                    `json:"cid,omitempty"`
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+// profilePutInput is putRecord's input for a raw profile record. Unlike the
+// generated input type it omits swapRecord when creating the record.
+type profilePutInput struct {
+	Repo       string                     `json:"repo"`
+	Collection string                     `json:"collection"`
+	Rkey       string                     `json:"rkey"`
+	Record     map[string]json.RawMessage `json:"record"`
+	SwapRecord *string                    `json:"swapRecord,omitempty"`
+}
+
+// UpdateProfile changes the account's display name, description, avatar or
+// banner. The existing profile record is read and written back with only the
+// requested fields changed, or created when the account has none yet. The
+// write is conditional on the record read, so a concurrent update fails
+// instead of being overwritten.
+func (c *Client) UpdateProfile(ctx context.Context, opts ProfileUpdateOptions) (*ProfileUpdateResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := validateProfileUpdate(opts); err != nil {
+		return nil, err
+	}
+
+	repo := c.xrpcc.Auth.Did
+	var existing profileRecordOutput
+	err := c.xrpcc.LexDo(ctx, lexutil.Query, "", "com.atproto.repo.getRecord", map[string]interface{}{
+		"repo":       repo,
+		"collection": profileCollection,
+		"rkey":       "self",
+	}, nil, &existing)
+	created := false
+	if err != nil {
+		if name, _ := xrpcErrorName(err); name != "RecordNotFound" {
+			return nil, fmt.Errorf("failed to get profile record: %w", err)
+		}
+		created = true
+	}
+
+	record := existing.Value
+	if created || record == nil {
+		record = make(map[string]json.RawMessage)
+	}
+	record["$type"] = jsonString(profileCollection)
+	if created {
+		record["createdAt"] = jsonString(formatATTime(time.Now()))
+	}
+
+	setText := func(field string, value *string) {
+		switch {
+		case value == nil:
+		case *value == "":
+			delete(record, field)
+		default:
+			record[field] = jsonString(*value)
+		}
+	}
+	setText("displayName", opts.DisplayName)
+	setText("description", opts.Description)
+
+	images := []struct{ field, path string }{{"avatar", opts.Avatar}, {"banner", opts.Banner}}
+	for _, image := range images {
+		if image.path == "" {
+			continue
+		}
+		blob, err := c.uploadProfileImage(ctx, image.field, image.path)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(blob)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s blob: %w", image.field, err)
+		}
+		record[image.field] = encoded
+	}
+
+	input := &profilePutInput{
+		Repo:       repo,
+		Collection: profileCollection,
+		Rkey:       "self",
+		Record:     record,
+	}
+	if !created {
+		input.SwapRecord = existing.Cid
+	}
+
+	var out comatproto.RepoPutRecord_Output
+	err = c.xrpcc.LexDo(ctx, lexutil.Procedure, "application/json", "com.atproto.repo.putRecord", nil, input, &out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put profile record: %w", err)
+	}
+
+	return &ProfileUpdateResult{URI: out.Uri, CID: out.Cid, Created: created}, nil
+}
+
+// validateProfileUpdate checks text lengths against the profile lexicon
+func validateProfileUpdate(opts ProfileUpdateOptions) error {
+	if opts.DisplayName != nil {
+		if utf8.RuneCountInString(*opts.DisplayName) > maxDisplayNameGraphemes || len(*opts.DisplayName) > maxDisplayNameBytes {
+			return fmt.Errorf("%w: display name exceeds %d characters", ErrInvalidProfile, maxDisplayNameGraphemes)
+		}
+	}
+	if opts.Description != nil {
+		if utf8.RuneCountInString(*opts.Description) > maxDescriptionGraphemes || len(*opts.Description) > maxDescriptionBytes {
+			return fmt.Errorf("%w: description exceeds %d characters", ErrInvalidProfile, maxDescriptionGraphemes)
+		}
+	}
+	return nil
+}
+
+// uploadProfileImage checks and uploads an avatar or banner image
+func (c *Client) uploadProfileImage(ctx context.Context, field, path string) (*lexutil.LexBlob, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s: %w", field, path, err)
+	}
+	if len(data) > maxProfileImageBytes {
+		return nil, fmt.Errorf("%w: %s is %d bytes, maximum is %d", ErrInvalidProfile, field, len(data), maxProfileImageBytes)
+	}
+	contentType, err := checkImage(data, profileImageLimits)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", field, path, err)
+	}
+
+	resp, err := comatproto.RepoUploadBlob(ctx, c.xrpcc, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", field, err)
+	}
+
+	return &lexutil.LexBlob{
+		Ref:      resp.Blob.Ref,
+		MimeType: contentType,
+		Size:     resp.Blob.Size,
+	}, nil
+}
+
+// jsonString encodes value as a JSON string
+func jsonString(value string) json.RawMessage {
+	encoded, _ := json.Marshal(value)
+	return encoded
+}
@@ -0,0 +1,120 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newProfileTestClient serves the account's profile record from existing, or
+// RecordNotFound when it is nil, and captures putRecord's input in put
+func newProfileTestClient(t *testing.T, existing map[string]interface{}, put *map[string]interface{}) *Client {
+	t.Helper()
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.repo.getRecord":
+			if existing == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "RecordNotFound", "message": "Could not locate record"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"uri":   "at://" + testDID + "/app.bsky.actor.profile/self",
+				"cid":   "bafyold",
+				"value": existing,
+			})
+		case "/xrpc/com.atproto.repo.uploadBlob":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"blob": map[string]interface{}{
+					"$type":    "blob",
+					"ref":      map[string]string{"$link": "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku"},
+					"mimeType": "image/png",
+					"size":     42,
+				},
+			})
+		case "/xrpc/com.atproto.repo.putRecord":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(put))
+			json.NewEncoder(w).Encode(map[string]string{
+				"uri": "at://" + testDID + "/app.bsky.actor.profile/self",
+				"cid": "bafynew",
+			})
+		}
+	})
+}
+
+func TestUpdateProfileCreatesRecord(t *testing.T) {
+	var put map[string]interface{}
+	client := newProfileTestClient(t, nil, &put)
+
+	name := "Test Account"
+	result, err := client.UpdateProfile(context.Background(), ProfileUpdateOptions{DisplayName: &name})
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, "bafynew", result.CID)
+
+	assert.Equal(t, "self", put["rkey"])
+	assert.NotContains(t, put, "swapRecord")
+	record := put["record"].(map[string]interface{})
+	assert.Equal(t, "app.bsky.actor.profile", record["$type"])
+	assert.Equal(t, "Test Account", record["displayName"])
+	assert.NotEmpty(t, record["createdAt"])
+	assert.NotContains(t, record, "description")
+}
+
+func TestUpdateProfilePreservesUntouchedFields(t *testing.T) {
+	var put map[string]interface{}
+	client := newProfileTestClient(t, map[string]interface{}{
+		"$type":       "app.bsky.actor.profile",
+		"displayName": "Old Name",
+		"description": "Old bio",
+		"pinnedPost":  map[string]string{"uri": "at://" + testDID + "/app.bsky.feed.post/pinned", "cid": "bafypinned"},
+		"createdAt":   "2024-01-01T00:00:00.000Z",
+		"futureField": "kept",
+	}, &put)
+
+	avatar := filepath.Join(t.TempDir(), "avatar.png")
+	require.NoError(t, os.WriteFile(avatar, encodeTestPNG(t, 10, 10), 0o600))
+
+	cleared := ""
+	result, err := client.UpdateProfile(context.Background(), ProfileUpdateOptions{
+		Description: &cleared,
+		Avatar:      avatar,
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+
+	assert.Equal(t, "bafyold", put["swapRecord"])
+	record := put["record"].(map[string]interface{})
+	assert.Equal(t, "Old Name", record["displayName"])
+	assert.NotContains(t, record, "description")
+	assert.Equal(t, "2024-01-01T00:00:00.000Z", record["createdAt"])
+	assert.Equal(t, "kept", record["futureField"])
+	assert.Equal(t, "bafypinned", record["pinnedPost"].(map[string]interface{})["cid"])
+
+	blob := record["avatar"].(map[string]interface{})
+	assert.Equal(t, "blob", blob["$type"])
+	assert.Equal(t, "image/png", blob["mimeType"])
+}
+
+func TestUpdateProfileValidatesLengths(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+
+	long := strings.Repeat("a", maxDisplayNameGraphemes+1)
+	_, err := client.UpdateProfile(context.Background(), ProfileUpdateOptions{DisplayName: &long})
+	assert.True(t, errors.Is(err, ErrInvalidProfile))
+
+	long = strings.Repeat("é", maxDescriptionGraphemes+1)
+	_, err = client.UpdateProfile(context.Background(), ProfileUpdateOptions{Description: &long})
+	assert.True(t, errors.Is(err, ErrInvalidProfile))
+}
@@ -0,0 +1,78 @@
+package bluesky
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitStatus is the rate-limit state the server reported in the
+// RateLimit-* headers of a response
+type RateLimitStatus struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	Policy    string    `json:"policy,omitempty"`
+}
+
+// LastRateLimit returns the rate-limit status from the client's most recent
+// response, so callers can slow down before they are throttled. It is nil
+// before the first response and when that response carried no rate-limit
+// headers.
+func (c *Client) LastRateLimit() *RateLimitStatus {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+
+	if c.rateLimit == nil {
+		return nil
+	}
+	status := *c.rateLimit
+	return &status
+}
+
+// rateLimitTransport records the rate-limit headers of every XRPC response
+// passing through base. Other requests sharing the HTTP client, such as PLC
+// directory lookups, are not rate-limited by the PDS and leave the status alone.
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	client *Client
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !strings.HasPrefix(req.URL.Path, "/xrpc/") {
+		return resp, err
+	}
+
+	status := parseRateLimitHeaders(resp.Header)
+	t.client.rateMu.Lock()
+	t.client.rateLimit = status
+	t.client.rateMu.Unlock()
+
+	return resp, nil
+}
+
+// parseRateLimitHeaders reads the RateLimit-* headers, returning nil when
+// the limit or remaining count is missing or malformed. Reset is sent as
+// Unix seconds.
+func parseRateLimitHeaders(header http.Header) *RateLimitStatus {
+	limit, err := strconv.Atoi(header.Get("RateLimit-Limit"))
+	if err != nil {
+		return nil
+	}
+	remaining, err := strconv.Atoi(header.Get("RateLimit-Remaining"))
+	if err != nil {
+		return nil
+	}
+
+	status := &RateLimitStatus{
+		Limit:     limit,
+		Remaining: remaining,
+		Policy:    header.Get("RateLimit-Policy"),
+	}
+	if reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64); err == nil {
+		status.Reset = time.Unix(reset, 0)
+	}
+	return status
+}
@@ -0,0 +1,56 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastRateLimit(t *testing.T) {
+	withHeaders := true
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if withHeaders {
+			w.Header().Set("RateLimit-Limit", "3000")
+			w.Header().Set("RateLimit-Remaining", "2999")
+			w.Header().Set("RateLimit-Reset", "1717000000")
+			w.Header().Set("RateLimit-Policy", "3000;w=300")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"posts": []interface{}{}})
+	})
+	assert.Nil(t, client.LastRateLimit(), "no response yet")
+
+	_, err := client.Search(context.Background(), "golang", nil)
+	require.NoError(t, err)
+
+	status := client.LastRateLimit()
+	require.NotNil(t, status)
+	assert.Equal(t, 3000, status.Limit)
+	assert.Equal(t, 2999, status.Remaining)
+	assert.True(t, time.Unix(1717000000, 0).Equal(status.Reset))
+	assert.Equal(t, "3000;w=300", status.Policy)
+
+	// The status always reflects the most recent response
+	withHeaders = false
+	_, err = client.Search(context.Background(), "golang", nil)
+	require.NoError(t, err)
+	assert.Nil(t, client.LastRateLimit())
+}
+
+func TestParseRateLimitHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("RateLimit-Limit", "100")
+	assert.Nil(t, parseRateLimitHeaders(header), "remaining is required")
+
+	header.Set("RateLimit-Remaining", "0")
+	header.Set("RateLimit-Reset", "soon")
+	status := parseRateLimitHeaders(header)
+	require.NotNil(t, status)
+	assert.Equal(t, 0, status.Remaining)
+	assert.True(t, status.Reset.IsZero(), "a malformed reset is left unset")
+}
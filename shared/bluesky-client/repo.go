@@ -0,0 +1,174 @@
+package bluesky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/atproto/syntax"
+)
+
+// repoStatsTTL is how long GetRepoStats serves a cached result
+const repoStatsTTL = 5 * time.Minute
+
+// ErrInvalidCollection indicates a collection name is not a valid NSID
+var ErrInvalidCollection = errors.New("invalid collection")
+
+// RecordsResult represents one page of records from a collection in the
+// account's repo
+type RecordsResult struct {
+	Records []*comatproto.RepoListRecords_Record `json:"records"`
+	Cursor  string                               `json:"cursor,omitempty"`
+}
+
+// RepoStats summarizes what the account's repo holds. The PDS API has no
+// cheap way to get the repo's size in bytes, so record counts stand in for it.
+type RepoStats struct {
+	DID    string `json:"did"`
+	Handle string `json:"handle"`
+	// Collections is the record count for every collection in the repo
+	Collections map[string]int `json:"collections"`
+	Posts       int            `json:"posts"`
+	Follows     int            `json:"follows"`
+	Likes       int            `json:"likes"`
+	Reposts     int            `json:"reposts"`
+	Total       int            `json:"total"`
+	FetchedAt   time.Time      `json:"fetched_at"`
+}
+
+// GetRepoStats counts the records in each of the account's collections,
+// listed via describeRepo. Counting pages through every record, so results
+// are cached for a few minutes. Concurrent cache misses share one count, and
+// like Authenticate a caller whose ctx ends stops waiting without cancelling
+// it for the others. Each caller gets its own copy of the result.
+func (c *Client) GetRepoStats(ctx context.Context) (*RepoStats, error) {
+	c.statsMu.Lock()
+	cached := c.repoStats
+	c.statsMu.Unlock()
+	if cached != nil && time.Since(cached.FetchedAt) < repoStatsTTL {
+		return cached.clone(), nil
+	}
+
+	result := c.statsFlight.DoChan("repo-stats", func() (interface{}, error) {
+		stats, err := c.fetchRepoStats(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.statsMu.Lock()
+		c.repoStats = stats
+		c.statsMu.Unlock()
+		return stats, nil
+	})
+
+	select {
+	case res := <-result:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*RepoStats).clone(), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchRepoStats describes the repo and counts every collection's records
+func (c *Client) fetchRepoStats(ctx context.Context) (*RepoStats, error) {
+	repo := c.xrpcc.Auth.Did
+	if repo == "" {
+		repo = c.account.Handle
+	}
+
+	desc, err := comatproto.RepoDescribeRepo(ctx, c.xrpcc, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe repo: %w", err)
+	}
+
+	stats := &RepoStats{
+		DID:         desc.Did,
+		Handle:      desc.Handle,
+		Collections: make(map[string]int, len(desc.Collections)),
+	}
+	for _, collection := range desc.Collections {
+		count, err := c.countRecords(ctx, desc.Did, collection)
+		if err != nil {
+			return nil, err
+		}
+		stats.Collections[collection] = count
+		stats.Total += count
+	}
+	stats.Posts = stats.Collections["app.bsky.feed.post"]
+	stats.Follows = stats.Collections["app.bsky.graph.follow"]
+	stats.Likes = stats.Collections["app.bsky.feed.like"]
+	stats.Reposts = stats.Collections["app.bsky.feed.repost"]
+	stats.FetchedAt = time.Now()
+
+	return stats, nil
+}
+
+// clone returns a copy of s that shares no memory with it, so callers can
+// modify what they get without touching the cache
+func (s *RepoStats) clone() *RepoStats {
+	copied := *s
+	copied.Collections = make(map[string]int, len(s.Collections))
+	for collection, count := range s.Collections {
+		copied.Collections[collection] = count
+	}
+	return &copied
+}
+
+// countRecords pages through a collection and counts its records
+func (c *Client) countRecords(ctx context.Context, repo, collection string) (int, error) {
+	count := 0
+	cursor := ""
+	for {
+		resp, err := comatproto.RepoListRecords(ctx, c.xrpcc, collection, cursor, 100, repo, false)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list %s records: %w", collection, err)
+		}
+		count += len(resp.Records)
+		if resp.Cursor == nil || *resp.Cursor == "" || len(resp.Records) == 0 {
+			return count, nil
+		}
+		cursor = *resp.Cursor
+	}
+}
+
+// ListRecords gets a page of records from collection in the account's own
+// repo, e.g. "app.bsky.feed.threadgate". Limit is clamped to 1-100 and
+// defaults to 50; pass the returned cursor to get the next page.
+func (c *Client) ListRecords(ctx context.Context, collection, cursor string, limit int) (*RecordsResult, error) {
+	if _, err := syntax.ParseNSID(collection); err != nil {
+		return nil, fmt.Errorf("%w: %q is not an NSID", ErrInvalidCollection, collection)
+	}
+
+	repo := c.xrpcc.Auth.Did
+	if repo == "" {
+		repo = c.account.Handle
+	}
+
+	resp, err := comatproto.RepoListRecords(ctx, c.xrpcc, collection, cursor, engagementLimit(limit), repo, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s records: %w", collection, err)
+	}
+
+	result := &RecordsResult{Records: resp.Records}
+	if resp.Cursor != nil {
+		result.Cursor = *resp.Cursor
+	}
+	return result, nil
+}
+
+// AllRecords iterates over every record in collection, fetching pages of
+// limit as it goes. Iteration stops after yielding the first error.
+func (c *Client) AllRecords(ctx context.Context, collection string, limit int) iter.Seq2[*comatproto.RepoListRecords_Record, error] {
+	return iteratePages(func(cursor string) ([]*comatproto.RepoListRecords_Record, string, error) {
+		page, err := c.ListRecords(ctx, collection, cursor, limit)
+		if err != nil {
+			return nil, "", err
+		}
+		return page.Records, page.Cursor, nil
+	})
+}
@@ -0,0 +1,177 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRepoStats(t *testing.T) {
+	counts := map[string]int{
+		"app.bsky.feed.post":    230,
+		"app.bsky.graph.follow": 12,
+		"app.bsky.feed.like":    0,
+	}
+	describeCalls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xrpc/com.atproto.repo.describeRepo", func(w http.ResponseWriter, r *http.Request) {
+		describeCalls++
+		assert.Equal(t, "did:plc:test", r.URL.Query().Get("repo"))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"did":             "did:plc:test",
+			"handle":          "test.bsky.social",
+			"didDoc":          map[string]interface{}{},
+			"handleIsCorrect": true,
+			"collections":     []string{"app.bsky.feed.post", "app.bsky.graph.follow", "app.bsky.feed.like"},
+		})
+	})
+	mux.HandleFunc("/xrpc/com.atproto.repo.listRecords", func(w http.ResponseWriter, r *http.Request) {
+		collection := r.URL.Query().Get("collection")
+		offset, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		records := []map[string]interface{}{}
+		for i := offset; i < counts[collection] && len(records) < limit; i++ {
+			records = append(records, map[string]interface{}{
+				"uri":   fmt.Sprintf("at://did:plc:test/%s/%d", collection, i),
+				"cid":   "bafyrecord",
+				"value": map[string]string{"$type": collection, "createdAt": "2024-01-01T00:00:00.000Z"},
+			})
+		}
+		resp := map[string]interface{}{"records": records}
+		if next := offset + len(records); next < counts[collection] {
+			resp["cursor"] = strconv.Itoa(next)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	client := newTestClient(t, muxHandler(mux))
+
+	stats, err := client.GetRepoStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 230, stats.Posts)
+	assert.Equal(t, 12, stats.Follows)
+	assert.Equal(t, 0, stats.Likes)
+	assert.Equal(t, 242, stats.Total)
+	assert.Len(t, stats.Collections, 3)
+
+	// A second call within the TTL is served from the cache, as a copy the
+	// first caller's changes do not reach
+	stats.Collections["app.bsky.feed.post"] = -1
+	stats.Posts = -1
+	again, err := client.GetRepoStats(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, describeCalls)
+	assert.Equal(t, 230, again.Posts)
+	assert.Equal(t, 230, again.Collections["app.bsky.feed.post"])
+}
+
+func TestGetRepoStatsDoesNotHoldLockWhileFetching(t *testing.T) {
+	release := make(chan struct{})
+	fetching := make(chan struct{}, 1)
+
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fetching <- struct{}{}
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"did": testDID, "handle": "test.bsky.social", "didDoc": map[string]interface{}{},
+			"handleIsCorrect": true, "collections": []string{},
+		})
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.GetRepoStats(context.Background())
+		done <- err
+	}()
+	<-fetching
+
+	// While the first fetch is stuck on the network, a caller that gives up
+	// is not blocked behind it
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.GetRepoStats(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, <-done)
+}
+
+func TestListRecords(t *testing.T) {
+	var cursors []string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/com.atproto.repo.listRecords", r.URL.Path)
+		assert.Equal(t, testDID, r.URL.Query().Get("repo"))
+		assert.Equal(t, "app.bsky.feed.threadgate", r.URL.Query().Get("collection"))
+		assert.Equal(t, "2", r.URL.Query().Get("limit"))
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+
+		record := func(rkey string) map[string]interface{} {
+			return map[string]interface{}{
+				"uri":   "at://" + testDID + "/app.bsky.feed.threadgate/" + rkey,
+				"cid":   "bafy" + rkey,
+				"value": map[string]interface{}{"$type": "app.bsky.feed.threadgate", "post": "at://" + testDID + "/app.bsky.feed.post/" + rkey, "createdAt": "2024-01-01T00:00:00.000Z"},
+			}
+		}
+		resp := map[string]interface{}{}
+		switch cursor {
+		case "":
+			resp["records"] = []interface{}{record("one"), record("two")}
+			resp["cursor"] = "two"
+		case "two":
+			resp["records"] = []interface{}{record("three")}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	page, err := client.ListRecords(context.Background(), "app.bsky.feed.threadgate", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Records, 2)
+	assert.Equal(t, "bafyone", page.Records[0].Cid)
+	assert.Equal(t, "two", page.Cursor)
+
+	cursors = nil
+	var uris []string
+	for record, err := range client.AllRecords(context.Background(), "app.bsky.feed.threadgate", 2) {
+		require.NoError(t, err)
+		uris = append(uris, record.Uri)
+	}
+	assert.Equal(t, []string{"", "two"}, cursors)
+	assert.Equal(t, []string{
+		"at://did:plc:test/app.bsky.feed.threadgate/one",
+		"at://did:plc:test/app.bsky.feed.threadgate/two",
+		"at://did:plc:test/app.bsky.feed.threadgate/three",
+	}, uris)
+}
+
+func TestListRecordsRejectsInvalidCollection(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+
+	for _, collection := range []string{"", "threadgate", "app.bsky.feed.", "app bsky.feed.post"} {
+		_, err := client.ListRecords(context.Background(), collection, "", 0)
+		assert.True(t, errors.Is(err, ErrInvalidCollection), collection)
+	}
+
+	var errs int
+	for _, err := range client.AllRecords(context.Background(), "bad", 0) {
+		assert.True(t, errors.Is(err, ErrInvalidCollection))
+		errs++
+	}
+	assert.Equal(t, 1, errs)
+}
@@ -0,0 +1,27 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/xrpc"
+)
+
+// ResolveHandle resolves a handle to its DID without authenticating, using
+// the given PDS or AppView host
+func ResolveHandle(ctx context.Context, host, handle string) (string, error) {
+	xrpcc := &xrpc.Client{
+		Client: &http.Client{Timeout: 10 * time.Second},
+		Host:   host,
+	}
+
+	out, err := comatproto.IdentityResolveHandle(ctx, xrpcc, handle)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve handle %s: %w", handle, err)
+	}
+
+	return out.Did, nil
+}
@@ -0,0 +1,32 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveHandle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/xrpc/com.atproto.identity.resolveHandle", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("handle") != "alice.bsky.social" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "Unable to resolve handle"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"did": "did:plc:alice"})
+	}))
+	defer server.Close()
+
+	did, err := ResolveHandle(context.Background(), server.URL, "alice.bsky.social")
+	assert.NoError(t, err)
+	assert.Equal(t, "did:plc:alice", did)
+
+	_, err = ResolveHandle(context.Background(), server.URL, "missing.bsky.social")
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package bluesky
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// sanitizePostText strips control characters and collapses runs of spaces,
+// keeping newlines, emoji and zero-width joiners intact. Leading and trailing
+// whitespace is dropped and spaces next to a newline are removed. Facet byte
+// ranges are moved so they cover the same characters in the cleaned text.
+func sanitizePostText(text string, facets []Facet) (string, []Facet) {
+	// offsets[i] is where original byte i lands in the cleaned text
+	offsets := make([]int, len(text)+1)
+
+	var out strings.Builder
+	out.Grow(len(text))
+	pendingSpace := false
+	pendingNewlines := 0
+
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\r' && i+size < len(text) && text[i+size] == '\n' {
+			// CRLF counts as the single newline that follows
+			offsets[i] = out.Len()
+			i += size
+			continue
+		}
+
+		switch {
+		case r == '\n' || r == '\r':
+			pendingNewlines++
+		case unicode.IsSpace(r):
+			pendingSpace = true
+		case unicode.IsControl(r):
+			// dropped
+		default:
+			if out.Len() > 0 {
+				if pendingNewlines > 0 {
+					out.WriteString(strings.Repeat("\n", pendingNewlines))
+				} else if pendingSpace {
+					out.WriteByte(' ')
+				}
+			}
+			pendingSpace = false
+			pendingNewlines = 0
+
+			for j := 0; j < size; j++ {
+				offsets[i+j] = out.Len()
+			}
+			out.WriteString(text[i : i+size])
+			i += size
+			continue
+		}
+
+		for j := 0; j < size; j++ {
+			offsets[i+j] = out.Len()
+		}
+		i += size
+	}
+	offsets[len(text)] = out.Len()
+
+	if len(facets) == 0 {
+		return out.String(), facets
+	}
+
+	moved := make([]Facet, len(facets))
+	for i, facet := range facets {
+		moved[i] = facet
+		if facet.ByteStart >= 0 && facet.ByteStart <= len(text) {
+			moved[i].ByteStart = offsets[facet.ByteStart]
+		}
+		if facet.ByteEnd >= 0 && facet.ByteEnd <= len(text) {
+			moved[i].ByteEnd = offsets[facet.ByteEnd]
+		}
+	}
+	return out.String(), moved
+}
+
+// richtextFacets converts facets to their lexicon form
+func richtextFacets(facets []Facet) []*bsky.RichtextFacet {
+	if len(facets) == 0 {
+		return nil
+	}
+
+	out := make([]*bsky.RichtextFacet, 0, len(facets))
+	for _, facet := range facets {
+		feature := &bsky.RichtextFacet_Features_Elem{}
+		switch {
+		case facet.URI != "":
+			feature.RichtextFacet_Link = &bsky.RichtextFacet_Link{Uri: facet.URI}
+		case facet.DID != "":
+			feature.RichtextFacet_Mention = &bsky.RichtextFacet_Mention{Did: facet.DID}
+		case facet.Tag != "":
+			feature.RichtextFacet_Tag = &bsky.RichtextFacet_Tag{Tag: facet.Tag}
+		default:
+			continue
+		}
+
+		out = append(out, &bsky.RichtextFacet{
+			Index: &bsky.RichtextFacet_ByteSlice{
+				ByteStart: int64(facet.ByteStart),
+				ByteEnd:   int64(facet.ByteEnd),
+			},
+			Features: []*bsky.RichtextFacet_Features_Elem{feature},
+		})
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package bluesky
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizePostText(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"  hello   world  ", "hello world"},
+		{"line one  \r\n  line two", "line one\nline two"},
+		{"para\n\nbreak", "para\n\nbreak"},
+		{"bell\x07 and\x00 nul", "bell and nul"},
+		{"tab\tseparated", "tab separated"},
+		{"family 👨‍👩‍👧 ok", "family 👨‍👩‍👧 ok"},
+		{"\n\ntrailing\n\n", "trailing"},
+	}
+
+	for _, test := range tests {
+		result, _ := sanitizePostText(test.input, nil)
+		assert.Equal(t, test.expected, result, "input %q", test.input)
+	}
+}
+
+func TestSanitizePostTextKeepsFacetsAligned(t *testing.T) {
+	text := "\x01 héllo   @alice.bsky.social\t\tvisit  https://example.com 🎉  #golang  "
+	facet := func(target string) Facet {
+		start := strings.Index(text, target)
+		return Facet{ByteStart: start, ByteEnd: start + len(target)}
+	}
+
+	mention := facet("@alice.bsky.social")
+	mention.DID = "did:plc:alice"
+	link := facet("https://example.com")
+	link.URI = "https://example.com"
+	tag := facet("#golang")
+	tag.Tag = "golang"
+
+	cleaned, facets := sanitizePostText(text, []Facet{mention, link, tag})
+
+	assert.Equal(t, "héllo @alice.bsky.social visit https://example.com 🎉 #golang", cleaned)
+	assert.Equal(t, "@alice.bsky.social", cleaned[facets[0].ByteStart:facets[0].ByteEnd])
+	assert.Equal(t, "https://example.com", cleaned[facets[1].ByteStart:facets[1].ByteEnd])
+	assert.Equal(t, "#golang", cleaned[facets[2].ByteStart:facets[2].ByteEnd])
+	assert.Equal(t, "did:plc:alice", facets[0].DID)
+}
+
+func TestPostSanitizesTextAndFacets(t *testing.T) {
+	var records []map[string]interface{}
+	client := newRecordTestClient(t, &records)
+
+	text := "  see   https://example.com  "
+	start := strings.Index(text, "https")
+	_, err := client.Post(context.Background(), text, &PostOptions{
+		Sanitize: true,
+		Facets:   []Facet{{ByteStart: start, ByteEnd: start + len("https://example.com"), URI: "https://example.com"}},
+	})
+	assert.NoError(t, err)
+
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, "see https://example.com", records[0]["text"])
+
+		facets := records[0]["facets"].([]interface{})
+		index := facets[0].(map[string]interface{})["index"].(map[string]interface{})
+		assert.Equal(t, float64(4), index["byteStart"])
+		assert.Equal(t, float64(23), index["byteEnd"])
+	}
+}
@@ -0,0 +1,190 @@
+package bluesky
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// snapshotConcurrency bounds how many sections ExportProfile fetches at once
+const snapshotConcurrency = 2
+
+// snapshotRecentPosts is how many of the account's latest posts a snapshot holds
+const snapshotRecentPosts = 50
+
+// maxSnapshotListEntries caps the blocks and mutes a snapshot pages through
+const maxSnapshotListEntries = 1000
+
+// Snapshot section names, used as keys in ProfileSnapshot.Failed
+const (
+	SnapshotSectionProfile = "profile"
+	SnapshotSectionPosts   = "posts"
+	SnapshotSectionBlocks  = "blocks"
+	SnapshotSectionMutes   = "mutes"
+)
+
+// ProfileSnapshot is the account's Bluesky-side state at one point in time.
+// Sections that could not be fetched are left empty and listed in Failed with
+// the error, so one failing call does not lose the rest of the snapshot.
+type ProfileSnapshot struct {
+	DID            string                              `json:"did"`
+	Handle         string                              `json:"handle"`
+	Profile        *bsky.ActorDefs_ProfileViewDetailed `json:"profile,omitempty"`
+	FollowersCount int64                               `json:"followers_count"`
+	FollowsCount   int64                               `json:"follows_count"`
+	PostsCount     int64                               `json:"posts_count"`
+	RecentPosts    []*bsky.FeedDefs_FeedViewPost       `json:"recent_posts"`
+	Blocks         []*bsky.ActorDefs_ProfileView       `json:"blocks"`
+	Mutes          []*bsky.ActorDefs_ProfileView       `json:"mutes"`
+	// BlocksTruncated and MutesTruncated report lists cut off at the cap
+	BlocksTruncated bool              `json:"blocks_truncated,omitempty"`
+	MutesTruncated  bool              `json:"mutes_truncated,omitempty"`
+	Failed          map[string]string `json:"failed,omitempty"`
+	CapturedAt      time.Time         `json:"captured_at"`
+}
+
+// ExportProfile gathers the account's profile, follower and follow counts,
+// recent posts, blocks and mutes into one snapshot, fetching the sections
+// concurrently. It only fails when every section does; otherwise the failed
+// ones are recorded in the snapshot's Failed map.
+func (c *Client) ExportProfile(ctx context.Context) (*ProfileSnapshot, error) {
+	actor := c.xrpcc.Auth.Did
+	if actor == "" {
+		actor = c.account.Handle
+	}
+
+	snapshot := &ProfileSnapshot{
+		DID:         c.xrpcc.Auth.Did,
+		Handle:      c.account.Handle,
+		RecentPosts: []*bsky.FeedDefs_FeedViewPost{},
+		Blocks:      []*bsky.ActorDefs_ProfileView{},
+		Mutes:       []*bsky.ActorDefs_ProfileView{},
+	}
+
+	// Each section writes only its own fields, so only Failed needs the lock
+	sections := map[string]func(context.Context) error{
+		SnapshotSectionProfile: func(ctx context.Context) error {
+			profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, actor)
+			if err != nil {
+				return fmt.Errorf("failed to get profile: %w", err)
+			}
+			snapshot.Profile = profile
+			return nil
+		},
+		SnapshotSectionPosts: func(ctx context.Context) error {
+			feed, err := bsky.FeedGetAuthorFeed(ctx, c.xrpcc, actor, "", "posts_with_replies", false, snapshotRecentPosts)
+			if err != nil {
+				return fmt.Errorf("failed to get author feed: %w", err)
+			}
+			snapshot.RecentPosts = append(snapshot.RecentPosts, feed.Feed...)
+			return nil
+		},
+		SnapshotSectionBlocks: func(ctx context.Context) error {
+			blocks, truncated, err := collectProfileViews(ctx, func(ctx context.Context, cursor string) ([]*bsky.ActorDefs_ProfileView, *string, error) {
+				page, err := bsky.GraphGetBlocks(ctx, c.xrpcc, cursor, 100)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to get blocks: %w", err)
+				}
+				return page.Blocks, page.Cursor, nil
+			})
+			if err != nil {
+				return err
+			}
+			snapshot.Blocks, snapshot.BlocksTruncated = blocks, truncated
+			return nil
+		},
+		SnapshotSectionMutes: func(ctx context.Context) error {
+			mutes, truncated, err := collectProfileViews(ctx, func(ctx context.Context, cursor string) ([]*bsky.ActorDefs_ProfileView, *string, error) {
+				page, err := bsky.GraphGetMutes(ctx, c.xrpcc, cursor, 100)
+				if err != nil {
+					return nil, nil, fmt.Errorf("failed to get mutes: %w", err)
+				}
+				return page.Mutes, page.Cursor, nil
+			})
+			if err != nil {
+				return err
+			}
+			snapshot.Mutes, snapshot.MutesTruncated = mutes, truncated
+			return nil
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, snapshotConcurrency)
+	failed := make(map[string]string)
+	var lastErr error
+
+	for name, fetch := range sections {
+		wg.Add(1)
+		go func(name string, fetch func(context.Context) error) {
+			defer wg.Done()
+
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				mu.Lock()
+				failed[name], lastErr = ctx.Err().Error(), ctx.Err()
+				mu.Unlock()
+				return
+			}
+			defer func() { <-semaphore }()
+
+			if err := fetch(ctx); err != nil {
+				mu.Lock()
+				failed[name], lastErr = err.Error(), err
+				mu.Unlock()
+			}
+		}(name, fetch)
+	}
+	wg.Wait()
+
+	if len(failed) == len(sections) {
+		return nil, fmt.Errorf("failed to export profile: %w", lastErr)
+	}
+	if len(failed) > 0 {
+		snapshot.Failed = failed
+	}
+
+	if snapshot.Profile != nil {
+		snapshot.DID = snapshot.Profile.Did
+		snapshot.Handle = snapshot.Profile.Handle
+		if snapshot.Profile.FollowersCount != nil {
+			snapshot.FollowersCount = *snapshot.Profile.FollowersCount
+		}
+		if snapshot.Profile.FollowsCount != nil {
+			snapshot.FollowsCount = *snapshot.Profile.FollowsCount
+		}
+		if snapshot.Profile.PostsCount != nil {
+			snapshot.PostsCount = *snapshot.Profile.PostsCount
+		}
+	}
+	snapshot.CapturedAt = time.Now()
+
+	return snapshot, nil
+}
+
+// collectProfileViews pages through a list of actors up to
+// maxSnapshotListEntries, reporting whether entries were left over
+func collectProfileViews(ctx context.Context, fetch func(context.Context, string) ([]*bsky.ActorDefs_ProfileView, *string, error)) ([]*bsky.ActorDefs_ProfileView, bool, error) {
+	actors := []*bsky.ActorDefs_ProfileView{}
+	cursor := ""
+	for {
+		page, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, false, err
+		}
+		actors = append(actors, page...)
+		if len(actors) >= maxSnapshotListEntries {
+			truncated := len(actors) > maxSnapshotListEntries || (next != nil && *next != "")
+			return actors[:maxSnapshotListEntries], truncated, nil
+		}
+		if next == nil || *next == "" || len(page) == 0 {
+			return actors, false, nil
+		}
+		cursor = *next
+	}
+}
@@ -0,0 +1,88 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportProfile(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/xrpc/app.bsky.actor.getProfile":
+			assert.Equal(t, testDID, r.URL.Query().Get("actor"))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"did":            testDID,
+				"handle":         "test.bsky.social",
+				"followersCount": 12,
+				"followsCount":   34,
+				"postsCount":     56,
+			})
+		case "/xrpc/app.bsky.feed.getAuthorFeed":
+			post := func(rkey string) map[string]interface{} {
+				return map[string]interface{}{"post": map[string]interface{}{
+					"uri":       "at://" + testDID + "/app.bsky.feed.post/" + rkey,
+					"cid":       "bafy" + rkey,
+					"author":    map[string]string{"did": testDID, "handle": "test.bsky.social"},
+					"record":    map[string]string{"$type": "app.bsky.feed.post", "text": rkey, "createdAt": "2024-01-01T00:00:00.000Z"},
+					"indexedAt": "2024-01-01T00:00:00.000Z",
+				}}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"feed": []map[string]interface{}{post("one"), post("two")},
+			})
+		case "/xrpc/app.bsky.graph.getBlocks":
+			resp := map[string]interface{}{}
+			if r.URL.Query().Get("cursor") == "" {
+				resp["blocks"] = []map[string]string{{"did": "did:plc:bob", "handle": "bob.bsky.social"}}
+				resp["cursor"] = "page2"
+			} else {
+				resp["blocks"] = []map[string]string{{"did": "did:plc:carol", "handle": "carol.bsky.social"}}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/xrpc/app.bsky.graph.getMutes":
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "InternalServerError", "message": "mutes unavailable"})
+		}
+	})
+
+	snapshot, err := client.ExportProfile(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, testDID, snapshot.DID)
+	assert.Equal(t, "test.bsky.social", snapshot.Handle)
+	assert.EqualValues(t, 12, snapshot.FollowersCount)
+	assert.EqualValues(t, 34, snapshot.FollowsCount)
+	assert.EqualValues(t, 56, snapshot.PostsCount)
+
+	require.Len(t, snapshot.RecentPosts, 2)
+	assert.Equal(t, "at://did:plc:test/app.bsky.feed.post/one", snapshot.RecentPosts[0].Post.Uri)
+
+	require.Len(t, snapshot.Blocks, 2)
+	assert.Equal(t, "did:plc:carol", snapshot.Blocks[1].Did)
+	assert.False(t, snapshot.BlocksTruncated)
+
+	// The failed section is reported and left empty
+	assert.Empty(t, snapshot.Mutes)
+	require.Len(t, snapshot.Failed, 1)
+	assert.Contains(t, snapshot.Failed[SnapshotSectionMutes], "failed to get mutes")
+	assert.False(t, snapshot.CapturedAt.IsZero())
+}
+
+func TestExportProfileAllSectionsFail(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "InternalServerError", "message": "down"})
+	})
+
+	snapshot, err := client.ExportProfile(context.Background())
+	assert.Error(t, err)
+	assert.Nil(t, snapshot)
+	assert.Contains(t, err.Error(), "failed to export profile")
+}
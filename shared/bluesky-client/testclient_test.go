@@ -0,0 +1,158 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// testDID is the account newTestClient is authenticated as
+const testDID = "did:plc:test"
+
+// answeredWriter records whether a test handler wrote a response
+type answeredWriter struct {
+	http.ResponseWriter
+	answered bool
+}
+
+func (w *answeredWriter) WriteHeader(status int) {
+	w.answered = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *answeredWriter) Write(b []byte) (int, error) {
+	w.answered = true
+	return w.ResponseWriter.Write(b)
+}
+
+// newTestClient returns a client authenticated as testDID against a fake PDS.
+// handler sees every XRPC request first and may be nil; requests it leaves
+// unanswered fall through to canned createSession, createRecord, getRecord,
+// resolveHandle and getProfile responses. configure may adjust the client
+// config, e.g. to set a rate limiter, before the client is created.
+func newTestClient(t *testing.T, handler http.HandlerFunc, configure ...func(*ClientConfig)) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler != nil {
+			aw := &answeredWriter{ResponseWriter: w}
+			handler(aw, r)
+			if aw.answered {
+				return
+			}
+		}
+		cannedXRPCResponse(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	config := ClientConfig{
+		Account: &models.Account{Handle: "test.bsky.social", Password: "password", Host: server.URL},
+	}
+	for _, fn := range configure {
+		fn(&config)
+	}
+
+	client, err := NewClient(config)
+	assert.NoError(t, err)
+	client.xrpcc.Auth.Did = testDID
+	client.xrpcc.Auth.AccessJwt = "access-token"
+	return client
+}
+
+// muxHandler adapts mux for newTestClient: requests it has a route for are
+// served by it, and the rest are left to the canned responses
+func muxHandler(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(w, r)
+		}
+	}
+}
+
+// cannedXRPCResponse answers the calls most client methods depend on
+func cannedXRPCResponse(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	switch strings.TrimPrefix(r.URL.Path, "/xrpc/") {
+	case "com.atproto.server.createSession":
+		json.NewEncoder(w).Encode(map[string]string{
+			"did": testDID, "handle": "test.bsky.social",
+			"accessJwt": "access-token", "refreshJwt": "refresh-token",
+		})
+	case "com.atproto.repo.createRecord":
+		var input struct {
+			Collection string `json:"collection"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		json.NewEncoder(w).Encode(map[string]string{
+			"uri": "at://" + testDID + "/" + input.Collection + "/3ktest",
+			"cid": "bafytest",
+		})
+	case "com.atproto.repo.getRecord":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"uri":   "at://" + query.Get("repo") + "/" + query.Get("collection") + "/" + query.Get("rkey"),
+			"cid":   "bafyrecord",
+			"value": map[string]string{"$type": "app.bsky.feed.post", "text": "canned post", "createdAt": "2024-01-01T00:00:00.000Z"},
+		})
+	case "com.atproto.identity.resolveHandle":
+		json.NewEncoder(w).Encode(map[string]string{"did": cannedDID(query.Get("handle"))})
+	case "app.bsky.actor.getProfile":
+		actor := query.Get("actor")
+		did := actor
+		if !strings.HasPrefix(actor, "did:") {
+			did = cannedDID(actor)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"did": did, "handle": actor})
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(map[string]string{"error": "MethodNotImplemented", "message": r.URL.Path})
+	}
+}
+
+// cannedDID derives a stable DID from a handle, e.g. alice.bsky.social
+// resolves to did:plc:alice
+func cannedDID(handle string) string {
+	return "did:plc:" + strings.Split(handle, ".")[0]
+}
+
+func TestNewTestClientCannedResponses(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t, nil)
+
+	post, err := client.Post(ctx, "hello", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "at://did:plc:test/app.bsky.feed.post/3ktest", post.URI)
+
+	follow, err := client.Follow(ctx, "alice.bsky.social", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "did:plc:alice", follow.TargetDID)
+
+	like, err := client.Like(ctx, "at://did:plc:bob/app.bsky.feed.post/abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "at://did:plc:test/app.bsky.feed.like/3ktest", like.URI)
+
+	assert.NoError(t, client.Authenticate(ctx))
+	assert.Equal(t, testDID, *client.GetAccount().DID)
+}
+
+func TestNewTestClientHandlerOverrides(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/xrpc/com.atproto.repo.createRecord" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "rejected"})
+		}
+	})
+
+	// getRecord still falls through to the canned response
+	_, err := client.Repost(context.Background(), "at://did:plc:bob/app.bsky.feed.post/abc")
+	assert.ErrorContains(t, err, "failed to create repost")
+}
@@ -0,0 +1,57 @@
+package bluesky
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	comatproto "github.com/bluesky-social/indigo/api/atproto"
+	"github.com/bluesky-social/indigo/api/bsky"
+)
+
+// ThreadPost is one post in a thread created by PostThread
+type ThreadPost struct {
+	Text    string       `json:"text"`
+	Images  []string     `json:"images,omitempty"`
+	Options *PostOptions `json:"options,omitempty"` // ReplyTo and Images here are ignored
+}
+
+// PostThread creates posts as a thread: the first post is top-level and each
+// following one replies to the post before it, with every reply's root set to
+// the first post. If a post fails the thread stops there, returning the
+// results of the posts already created along with the error.
+func (c *Client) PostThread(ctx context.Context, posts []ThreadPost) ([]PostResult, error) {
+	if len(posts) == 0 {
+		return nil, errors.New("thread has no posts")
+	}
+
+	results := make([]PostResult, 0, len(posts))
+	var root, parent *comatproto.RepoStrongRef
+
+	for i, item := range posts {
+		options := PostOptions{}
+		if item.Options != nil {
+			options = *item.Options
+		}
+		options.ReplyTo = ""
+		options.Images = item.Images
+
+		var reply *bsky.FeedPost_ReplyRef
+		if root != nil {
+			reply = &bsky.FeedPost_ReplyRef{Root: root, Parent: parent}
+		}
+
+		result, err := c.createPost(ctx, item.Text, &options, reply)
+		if err != nil {
+			return results, fmt.Errorf("failed to create thread post %d of %d: %w", i+1, len(posts), err)
+		}
+		results = append(results, *result)
+
+		parent = &comatproto.RepoStrongRef{Uri: result.URI, Cid: result.CID}
+		if root == nil {
+			root = parent
+		}
+	}
+
+	return results, nil
+}
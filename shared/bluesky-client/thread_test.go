@@ -0,0 +1,89 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newThreadTestClient numbers each created post and fails the failAt-th
+// createRecord call (1-based; 0 never fails)
+func newThreadTestClient(t *testing.T, records *[]map[string]interface{}, failAt int) *Client {
+	t.Helper()
+
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/xrpc/com.atproto.repo.createRecord" {
+			return
+		}
+		var input struct {
+			Record map[string]interface{} `json:"record"`
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+
+		w.Header().Set("Content-Type", "application/json")
+		if len(*records)+1 == failAt {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "InvalidRequest", "message": "record rejected"})
+			return
+		}
+
+		*records = append(*records, input.Record)
+		n := len(*records)
+		json.NewEncoder(w).Encode(map[string]string{
+			"uri": fmt.Sprintf("at://%s/app.bsky.feed.post/post%d", testDID, n),
+			"cid": fmt.Sprintf("bafypost%d", n),
+		})
+	})
+}
+
+func replyRef(record map[string]interface{}, field string) [2]string {
+	ref := record["reply"].(map[string]interface{})[field].(map[string]interface{})
+	return [2]string{ref["uri"].(string), ref["cid"].(string)}
+}
+
+func TestPostThread(t *testing.T) {
+	var records []map[string]interface{}
+	client := newThreadTestClient(t, &records, 0)
+
+	results, err := client.PostThread(context.Background(), []ThreadPost{
+		{Text: "1/ a thread"},
+		{Text: "2/ more", Options: &PostOptions{Langs: []string{"en"}}},
+		{Text: "3/ the end"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Len(t, records, 3)
+
+	first := [2]string{results[0].URI, results[0].CID}
+	second := [2]string{results[1].URI, results[1].CID}
+
+	assert.Equal(t, "1/ a thread", records[0]["text"])
+	assert.NotContains(t, records[0], "reply", "the first post is top-level")
+
+	assert.Equal(t, first, replyRef(records[1], "root"))
+	assert.Equal(t, first, replyRef(records[1], "parent"))
+	assert.Equal(t, []interface{}{"en"}, records[1]["langs"])
+
+	assert.Equal(t, first, replyRef(records[2], "root"), "root stays the first post")
+	assert.Equal(t, second, replyRef(records[2], "parent"))
+}
+
+func TestPostThreadStopsOnFailure(t *testing.T) {
+	var records []map[string]interface{}
+	client := newThreadTestClient(t, &records, 2)
+
+	results, err := client.PostThread(context.Background(), []ThreadPost{
+		{Text: "1/"}, {Text: "2/"}, {Text: "3/"},
+	})
+	assert.ErrorContains(t, err, "failed to create thread post 2 of 3")
+	assert.Len(t, results, 1, "posts created before the failure are returned")
+	assert.Len(t, records, 1, "nothing is posted after the failure")
+
+	_, err = client.PostThread(context.Background(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package bluesky
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+const (
+	// DefaultMaxIdleConnsPerHost is how many idle connections a pooled
+	// transport keeps per PDS host
+	DefaultMaxIdleConnsPerHost = 10
+	// DefaultIdleConnTimeout is how long a pooled transport keeps an idle
+	// connection open
+	DefaultIdleConnTimeout = 90 * time.Second
+)
+
+// TransportPool shares one http.Transport per proxy, so clients for accounts
+// behind the same proxy reuse pooled connections instead of dialing through
+// the proxy for every client. Transports are keyed by proxy ID and URL: a
+// proxy whose address or credentials change gets a fresh transport. Proxies
+// are unassigned and deleted by proxy-manager, out of reach of this pool, so
+// cleanup relies only on the sweep: a transport left unused for several idle
+// timeouts is closed on a later Get. It is safe for concurrent use.
+type TransportPool struct {
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	dialTimeout         time.Duration
+
+	mu      sync.Mutex
+	entries map[int]*pooledTransport
+	now     func() time.Time
+}
+
+type pooledTransport struct {
+	key       string
+	transport *http.Transport
+	lastUsed  time.Time
+}
+
+// NewTransportPool creates a pool whose transports keep up to
+// maxIdleConnsPerHost idle connections per host for idleConnTimeout. Zero
+// values use the defaults. A positive dialTimeout bounds connecting to the
+// proxy, like ClientConfig.DialTimeout; zero leaves only the request timeout.
+func NewTransportPool(maxIdleConnsPerHost int, idleConnTimeout, dialTimeout time.Duration) *TransportPool {
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = DefaultIdleConnTimeout
+	}
+	return &TransportPool{
+		maxIdleConnsPerHost: maxIdleConnsPerHost,
+		idleConnTimeout:     idleConnTimeout,
+		dialTimeout:         dialTimeout,
+		entries:             make(map[int]*pooledTransport),
+		now:                 time.Now,
+	}
+}
+
+// Get returns the shared transport for proxy, creating it on first use
+func (p *TransportPool) Get(proxy *models.Proxy) (*http.Transport, error) {
+	proxyURL, err := buildProxyURL(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proxy URL: %w", err)
+	}
+	key := proxyURL.String()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	p.sweep(now)
+
+	entry, ok := p.entries[proxy.ID]
+	if ok && entry.key != key {
+		// The proxy was reconfigured; stop using the old address
+		entry.transport.CloseIdleConnections()
+		ok = false
+	}
+	if !ok {
+		transport := &http.Transport{
+			MaxIdleConns:        p.maxIdleConnsPerHost * 4,
+			MaxIdleConnsPerHost: p.maxIdleConnsPerHost,
+			IdleConnTimeout:     p.idleConnTimeout,
+		}
+		if err := configureProxy(transport, proxyURL, p.dialTimeout); err != nil {
+			return nil, err
+		}
+		entry = &pooledTransport{key: key, transport: transport}
+		p.entries[proxy.ID] = entry
+	}
+	entry.lastUsed = now
+
+	return entry.transport, nil
+}
+
+// Close closes the idle connections of every pooled transport
+func (p *TransportPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, entry := range p.entries {
+		entry.transport.CloseIdleConnections()
+		delete(p.entries, id)
+	}
+}
+
+// sweep drops transports unused for several idle timeouts; by then their
+// connections have closed and the proxy may no longer exist
+func (p *TransportPool) sweep(now time.Time) {
+	cutoff := now.Add(-4 * p.idleConnTimeout)
+	for id, entry := range p.entries {
+		if entry.lastUsed.Before(cutoff) {
+			entry.transport.CloseIdleConnections()
+			delete(p.entries, id)
+		}
+	}
+}
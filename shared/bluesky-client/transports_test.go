@@ -0,0 +1,321 @@
+package bluesky
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+func newTestProxy(id int, port int) *models.Proxy {
+	return &models.Proxy{ID: id, Type: models.ProxyTypeHTTP, Host: "10.0.0.1", Port: port}
+}
+
+func TestTransportPoolReusesTransportPerProxy(t *testing.T) {
+	pool := NewTransportPool(0, 0, 0)
+	proxy := newTestProxy(1, 8080)
+
+	newClient := func(handle string, proxy *models.Proxy) *Client {
+		client, err := NewClient(ClientConfig{
+			Account:    &models.Account{Handle: handle, Password: "password", Host: "https://bsky.social"},
+			Proxy:      proxy,
+			Transports: pool,
+		})
+		assert.NoError(t, err)
+		return client
+	}
+
+	first := newClient("alice.bsky.social", proxy).xrpcc.Client.Transport.(*rateLimitTransport).base
+	second := newClient("bob.bsky.social", proxy).xrpcc.Client.Transport.(*rateLimitTransport).base
+	assert.Same(t, first, second)
+
+	transport := first.(*http.Transport)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+
+	other := newClient("carol.bsky.social", newTestProxy(2, 8080)).xrpcc.Client.Transport.(*rateLimitTransport).base
+	assert.NotSame(t, first, other)
+}
+
+func TestTransportPoolReplacesChangedAndSweepsUnusedProxies(t *testing.T) {
+	pool := NewTransportPool(4, time.Minute, 0)
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	original, err := pool.Get(newTestProxy(1, 8080))
+	assert.NoError(t, err)
+
+	moved, err := pool.Get(newTestProxy(1, 9090))
+	assert.NoError(t, err)
+	assert.NotSame(t, original, moved, "a reconfigured proxy gets a new transport")
+
+	// A proxy nobody has used for a long time is swept on the next Get
+	_, err = pool.Get(newTestProxy(2, 8080))
+	assert.NoError(t, err)
+	now = now.Add(10 * time.Minute)
+	_, err = pool.Get(newTestProxy(1, 9090))
+	assert.NoError(t, err)
+	assert.Len(t, pool.entries, 1)
+}
+
+// recordDials swaps dialContext for one that records the timeout and address
+// of every dial made through it
+func recordDials(t *testing.T) *[]string {
+	t.Helper()
+	var dials []string
+	var mu sync.Mutex
+	original := dialContext
+	dialContext = func(timeout time.Duration) func(context.Context, string, string) (net.Conn, error) {
+		dial := original(timeout)
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			mu.Lock()
+			dials = append(dials, timeout.String()+" "+addr)
+			mu.Unlock()
+			return dial(ctx, network, addr)
+		}
+	}
+	t.Cleanup(func() { dialContext = original })
+	return &dials
+}
+
+func TestDialTimeoutApplied(t *testing.T) {
+	// Serves as the PDS for direct clients and as the HTTP proxy for
+	// proxied ones; either way it answers getProfile
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"did": "did:plc:alice", "handle": "alice.bsky.social"})
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	proxy := &models.Proxy{ID: 1, Type: models.ProxyTypeHTTP, Host: serverURL.Hostname(), Port: port}
+
+	getProfile := func(config ClientConfig) {
+		t.Helper()
+		client, err := NewClient(config)
+		require.NoError(t, err)
+		_, err = client.GetProfile(context.Background(), "alice.bsky.social")
+		require.NoError(t, err)
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		dials := recordDials(t)
+		getProfile(ClientConfig{
+			Account:     &models.Account{Handle: "test.bsky.social", Password: "password", Host: server.URL},
+			Timeout:     time.Minute,
+			DialTimeout: 2 * time.Second,
+		})
+		assert.Equal(t, []string{"2s " + serverURL.Host}, *dials)
+	})
+
+	t.Run("proxied", func(t *testing.T) {
+		dials := recordDials(t)
+		getProfile(ClientConfig{
+			Account:     &models.Account{Handle: "test.bsky.social", Password: "password", Host: "http://pds.example"},
+			Proxy:       proxy,
+			DialTimeout: 3 * time.Second,
+		})
+		assert.Equal(t, []string{"3s " + serverURL.Host}, *dials, "the proxy is dialed, not the PDS")
+	})
+
+	t.Run("pooled", func(t *testing.T) {
+		dials := recordDials(t)
+		getProfile(ClientConfig{
+			Account:     &models.Account{Handle: "test.bsky.social", Password: "password", Host: "http://pds.example"},
+			Proxy:       proxy,
+			DialTimeout: 3 * time.Second,
+			Transports:  NewTransportPool(0, 0, 4*time.Second),
+		})
+		assert.Equal(t, []string{"4s " + serverURL.Host}, *dials, "the pool's dial timeout wins")
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		dials := recordDials(t)
+		getProfile(ClientConfig{
+			Account: &models.Account{Handle: "test.bsky.social", Password: "password", Host: server.URL},
+		})
+		assert.Empty(t, *dials)
+	})
+}
+
+// newTestSOCKS5Server starts a SOCKS5 proxy that requires username/password
+// authentication with the given credentials and relays CONNECT requests. The
+// returned counter records successful authentications.
+func newTestSOCKS5Server(t *testing.T, username, password string) (*models.Proxy, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	authenticated := new(atomic.Int32)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if !socks5Handshake(conn, username, password) {
+					return
+				}
+				authenticated.Add(1)
+				socks5Relay(conn)
+			}()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return &models.Proxy{
+		ID: 1, Type: models.ProxyTypeSOCKS5, Host: addr.IP.String(), Port: addr.Port,
+		Username: &username, Password: &password,
+	}, authenticated
+}
+
+// socks5Handshake negotiates username/password authentication (RFC 1929) and
+// reports whether the client sent the expected credentials
+func socks5Handshake(conn net.Conn, username, password string) bool {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil || header[0] != 5 {
+		return false
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return false
+	}
+	offered := false
+	for _, method := range methods {
+		offered = offered || method == 2
+	}
+	if !offered {
+		conn.Write([]byte{5, 0xff})
+		return false
+	}
+	conn.Write([]byte{5, 2})
+
+	readField := func() string {
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return ""
+		}
+		field := make([]byte, size[0])
+		io.ReadFull(conn, field)
+		return string(field)
+	}
+	version := make([]byte, 1)
+	if _, err := io.ReadFull(conn, version); err != nil || version[0] != 1 {
+		return false
+	}
+	if readField() != username || readField() != password {
+		conn.Write([]byte{1, 1})
+		return false
+	}
+	conn.Write([]byte{1, 0})
+	return true
+}
+
+// socks5Relay reads a CONNECT request and pipes the connection to its target
+func socks5Relay(conn net.Conn) {
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[1] != 1 {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1, 4:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == 4 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		name := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+func TestSOCKS5ProxyAuthentication(t *testing.T) {
+	pds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"did": "did:plc:alice", "handle": "alice.bsky.social"})
+	}))
+	defer pds.Close()
+
+	getProfile := func(config ClientConfig) error {
+		t.Helper()
+		client, err := NewClient(config)
+		require.NoError(t, err)
+		_, err = client.GetProfile(context.Background(), "alice.bsky.social")
+		return err
+	}
+	account := &models.Account{Handle: "test.bsky.social", Password: "password", Host: pds.URL}
+
+	t.Run("direct", func(t *testing.T) {
+		proxy, authenticated := newTestSOCKS5Server(t, "user", "secret")
+		require.NoError(t, getProfile(ClientConfig{Account: account, Proxy: proxy}))
+		assert.Equal(t, int32(1), authenticated.Load())
+	})
+
+	t.Run("pooled with dial timeout", func(t *testing.T) {
+		proxy, authenticated := newTestSOCKS5Server(t, "user", "secret")
+		dials := recordDials(t)
+		require.NoError(t, getProfile(ClientConfig{
+			Account:    account,
+			Proxy:      proxy,
+			Transports: NewTransportPool(0, 0, 2*time.Second),
+		}))
+		assert.Equal(t, int32(1), authenticated.Load())
+		assert.Equal(t, []string{"2s " + net.JoinHostPort(proxy.Host, strconv.Itoa(proxy.Port))}, *dials, "the SOCKS5 proxy is dialed, not the PDS")
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		proxy, authenticated := newTestSOCKS5Server(t, "user", "secret")
+		wrong := "guess"
+		proxy.Password = &wrong
+		assert.Error(t, getProfile(ClientConfig{Account: account, Proxy: proxy}))
+		assert.Zero(t, authenticated.Load())
+	})
+}
@@ -3,7 +3,6 @@ package bluesky
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -15,22 +14,59 @@ import (
 
 // PostOptions represents options for creating a post
 type PostOptions struct {
-	ReplyTo string   `json:"reply_to,omitempty"`
-	QuoteTo string   `json:"quote_to,omitempty"`
-	Images  []string `json:"images,omitempty"`
+	ReplyTo   string     `json:"reply_to,omitempty"`
+	QuoteTo   string     `json:"quote_to,omitempty"`
+	Images    []string   `json:"images,omitempty"`
+	ImageAlts []string   `json:"image_alts,omitempty"` // Alt text for Images by position
+	CreatedAt *time.Time `json:"created_at,omitempty"` // Defaults to now; used for backfills
+	Facets    []Facet    `json:"facets,omitempty"`     // Used verbatim; skips facet detection
+	Sanitize  bool       `json:"sanitize,omitempty"`   // Strip control characters and collapse whitespace
+	// Links, mentions and hashtags in the text are marked when Facets is
+	// empty. Mentions are resolved to DIDs; unknown handles stay plain text.
+	// SkipFacetDetection posts the text without facets instead.
+	SkipFacetDetection bool `json:"skip_facet_detection,omitempty"`
+	// Langs sets the post's BCP-47 language tags verbatim
+	Langs []string `json:"langs,omitempty"`
+	// AutoDetectLang guesses the language when Langs is empty; posts whose
+	// language is unclear are left untagged
+	AutoDetectLang bool `json:"auto_detect_lang,omitempty"`
 }
 
+// Facet marks a range of post text as a link, mention or hashtag. Offsets are
+// UTF-8 byte offsets into the text; exactly one of URI, DID or Tag is set.
+type Facet struct {
+	ByteStart int    `json:"byte_start"`
+	ByteEnd   int    `json:"byte_end"`
+	URI       string `json:"uri,omitempty"`
+	DID       string `json:"did,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+}
+
+// maxCreatedAtSkew is how far in the future a custom createdAt may be before
+// it is rejected, leaving room for clock skew
+const maxCreatedAtSkew = 5 * time.Minute
+
 // PostResult represents the result of creating a post
 type PostResult struct {
-	URI string `json:"uri"`
-	CID string `json:"cid"`
+	URI  string `json:"uri"`
+	CID  string `json:"cid"`
+	Lang string `json:"lang,omitempty"` // Detected language, when AutoDetectLang found one
+}
+
+// FollowOptions represents options for following a user
+type FollowOptions struct {
+	// SkipIfFollowing returns the existing follow record instead of creating
+	// a duplicate. It costs a record lookup when the target's profile says
+	// the account already follows it.
+	SkipIfFollowing bool `json:"skip_if_following,omitempty"`
 }
 
 // FollowResult represents the result of following a user
 type FollowResult struct {
-	URI       string `json:"uri"`
-	CID       string `json:"cid"`
-	TargetDID string `json:"target_did"`
+	URI              string `json:"uri"`
+	CID              string `json:"cid"`
+	TargetDID        string `json:"target_did"`
+	AlreadyFollowing bool   `json:"already_following,omitempty"` // URI and CID are the existing follow's
 }
 
 // LikeResult represents the result of liking a post
@@ -49,10 +85,14 @@ type RepostResult struct {
 
 // TimelineOptions represents options for getting timeline
 type TimelineOptions struct {
-	Cursor string `json:"cursor,omitempty"`
-	Limit  int    `json:"limit,omitempty"`
+	Cursor    string `json:"cursor,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Algorithm string `json:"algorithm,omitempty"` // Defaults to DefaultTimelineAlgorithm
 }
 
+// DefaultTimelineAlgorithm is the home feed ordering used when none is given
+const DefaultTimelineAlgorithm = "reverse-chronological"
+
 // TimelineResult represents the result of getting timeline
 type TimelineResult struct {
 	Feed   []*bsky.FeedDefs_FeedViewPost `json:"feed"`
@@ -66,10 +106,20 @@ type ProfileResult struct {
 
 // SearchOptions represents options for searching
 type SearchOptions struct {
-	Cursor string `json:"cursor,omitempty"`
-	Limit  int    `json:"limit,omitempty"`
+	Cursor string     `json:"cursor,omitempty"`
+	Limit  int        `json:"limit,omitempty"`  // Clamped to 1-100; defaults to 50
+	Author string     `json:"author,omitempty"` // Handle or DID
+	Since  *time.Time `json:"since,omitempty"`  // Inclusive
+	Until  *time.Time `json:"until,omitempty"`  // Exclusive
+	Lang   string     `json:"lang,omitempty"`
 }
 
+// Search limits accepted by app.bsky.feed.searchPosts
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 100
+)
+
 // SearchResult represents the result of searching
 type SearchResult struct {
 	Posts  []*bsky.FeedDefs_PostView `json:"posts"`
@@ -111,7 +161,7 @@ func (c *Client) buildReply(ctx context.Context, replyToURI string) (*bsky.FeedP
 
 	resp, err := comatproto.RepoGetRecord(ctx, c.xrpcc, "", parts.Collection, parts.DID, parts.RKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get reply target: %w", err)
+		return nil, wrapTargetError("failed to get reply target", err)
 	}
 
 	reply := &bsky.FeedPost_ReplyRef{
@@ -137,7 +187,7 @@ func (c *Client) buildQuote(ctx context.Context, quoteURI string) (*bsky.FeedPos
 
 	resp, err := comatproto.RepoGetRecord(ctx, c.xrpcc, "", parts.Collection, parts.DID, parts.RKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get quote target: %w", err)
+		return nil, wrapTargetError("failed to get quote target", err)
 	}
 
 	embed := &bsky.FeedPost_Embed{
@@ -150,7 +200,7 @@ func (c *Client) buildQuote(ctx context.Context, quoteURI string) (*bsky.FeedPos
 }
 
 // buildImageEmbed builds an image embed for a post
-func (c *Client) buildImageEmbed(ctx context.Context, imagePaths []string) (*bsky.EmbedImages, error) {
+func (c *Client) buildImageEmbed(ctx context.Context, imagePaths, alts []string) (*bsky.EmbedImages, error) {
 	if len(imagePaths) == 0 {
 		return nil, fmt.Errorf("no images provided")
 	}
@@ -159,30 +209,48 @@ func (c *Client) buildImageEmbed(ctx context.Context, imagePaths []string) (*bsk
 		return nil, fmt.Errorf("maximum 4 images allowed")
 	}
 
-	var images []*bsky.EmbedImages_Image
-	for _, imagePath := range imagePaths {
-		// Read image file
-		imageData, err := os.ReadFile(imagePath)
+	if err := checkImageAlts(imagePaths, alts); err != nil {
+		return nil, err
+	}
+
+	// Read and check every image before uploading any, so a bad one doesn't
+	// leave the others as orphaned blobs
+	imageData := make([][]byte, len(imagePaths))
+	contentTypes := make([]string, len(imagePaths))
+	for i, imagePath := range imagePaths {
+		data, err := os.ReadFile(imagePath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read image %s: %w", imagePath, err)
 		}
 
+		// Check format and dimensions before uploading
+		contentType, err := checkImage(data, c.images)
+		if err != nil {
+			return nil, fmt.Errorf("image %s: %w", imagePath, err)
+		}
+
+		imageData[i] = data
+		contentTypes[i] = contentType
+	}
+
+	var images []*bsky.EmbedImages_Image
+	for i, imagePath := range imagePaths {
 		// Upload blob
-		resp, err := comatproto.RepoUploadBlob(ctx, c.xrpcc, strings.NewReader(string(imageData)))
+		resp, err := comatproto.RepoUploadBlob(ctx, c.xrpcc, strings.NewReader(string(imageData[i])))
 		if err != nil {
 			return nil, fmt.Errorf("failed to upload image %s: %w", imagePath, err)
 		}
 
-		// Detect content type
-		contentType := http.DetectContentType(imageData)
-
 		image := &bsky.EmbedImages_Image{
 			Image: &lexutil.LexBlob{
 				Ref:      resp.Blob.Ref,
-				MimeType: contentType,
+				MimeType: contentTypes[i],
 				Size:     resp.Blob.Size,
 			},
-			Alt: "", // Could be enhanced to support alt text
+		}
+		// Images without a matching alt are left without one
+		if i < len(alts) {
+			image.Alt = alts[i]
 		}
 
 		images = append(images, image)
@@ -200,18 +268,25 @@ type ImageUploadOptions struct {
 
 // UploadImage uploads an image and returns the blob reference
 func (c *Client) UploadImage(ctx context.Context, imagePath string, options *ImageUploadOptions) (*lexutil.LexBlob, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	imageData, err := os.ReadFile(imagePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read image: %w", err)
 	}
 
+	contentType, err := checkImage(imageData, c.images)
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := comatproto.RepoUploadBlob(ctx, c.xrpcc, strings.NewReader(string(imageData)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload image: %w", err)
 	}
 
-	contentType := http.DetectContentType(imageData)
-
 	return &lexutil.LexBlob{
 		Ref:      resp.Blob.Ref,
 		MimeType: contentType,
@@ -224,6 +299,10 @@ type NotificationOptions struct {
 	Cursor string `json:"cursor,omitempty"`
 	Limit  int    `json:"limit,omitempty"`
 	Seen   *bool  `json:"seen,omitempty"`
+
+	// SkipIfNoneUnread checks the unread count first and returns an empty
+	// result without listing when there is nothing new
+	SkipIfNoneUnread bool `json:"skip_if_none_unread,omitempty"`
 }
 
 // NotificationResult represents the result of getting notifications
@@ -238,6 +317,16 @@ func (c *Client) GetNotifications(ctx context.Context, options *NotificationOpti
 		options = &NotificationOptions{Limit: 50}
 	}
 
+	if options.SkipIfNoneUnread {
+		unread, err := c.GetUnreadCount(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if unread == 0 {
+			return &NotificationResult{Notifications: []*bsky.NotificationListNotifications_Notification{}}, nil
+		}
+	}
+
 	// Note: Simplified implementation - API signature may have changed
 	seenVal := false
 	if options.Seen != nil {
@@ -258,15 +347,29 @@ func (c *Client) GetNotifications(ctx context.Context, options *NotificationOpti
 	return result, nil
 }
 
+// GetUnreadCount returns how many notifications arrived since the last seen
+// marker. It is far cheaper than listing notifications.
+func (c *Client) GetUnreadCount(ctx context.Context) (int, error) {
+	resp, err := bsky.NotificationGetUnreadCount(ctx, c.xrpcc, false, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unread notification count: %w", err)
+	}
+	return int(resp.Count), nil
+}
+
 // MarkNotificationsRead marks notifications as read
 func (c *Client) MarkNotificationsRead(ctx context.Context, seenAt *time.Time) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+
 	if seenAt == nil {
 		now := time.Now()
 		seenAt = &now
 	}
 
 	err := bsky.NotificationUpdateSeen(ctx, c.xrpcc, &bsky.NotificationUpdateSeen_Input{
-		SeenAt: seenAt.Format(time.RFC3339),
+		SeenAt: formatATTime(*seenAt),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to mark notifications as read: %w", err)
@@ -277,6 +380,10 @@ func (c *Client) MarkNotificationsRead(ctx context.Context, seenAt *time.Time) e
 
 // DeletePost deletes a post
 func (c *Client) DeletePost(ctx context.Context, postURI string) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+
 	parts := parseATURI(postURI)
 	if parts == nil {
 		return fmt.Errorf("invalid post URI: %s", postURI)
@@ -296,6 +403,10 @@ func (c *Client) DeletePost(ctx context.Context, postURI string) error {
 
 // Block blocks a user
 func (c *Client) Block(ctx context.Context, handle string) (*BlockResult, error) {
+	if err := c.checkWritable(); err != nil {
+		return nil, err
+	}
+
 	profile, err := bsky.ActorGetProfile(ctx, c.xrpcc, handle)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get profile: %w", err)
@@ -303,7 +414,7 @@ func (c *Client) Block(ctx context.Context, handle string) (*BlockResult, error)
 
 	block := bsky.GraphBlock{
 		LexiconTypeID: "app.bsky.graph.block",
-		CreatedAt:     time.Now().Local().Format(time.RFC3339),
+		CreatedAt:     formatATTime(time.Now()),
 		Subject:       profile.Did,
 	}
 
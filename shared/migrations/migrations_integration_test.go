@@ -0,0 +1,94 @@
+//go:build integration
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bsky-automation/shared/utils"
+)
+
+// Run with: go test -tags integration ./migrations/...
+// Requires a reachable PostgreSQL configured through the DB_* variables. The
+// test creates and drops its own database, so the user needs CREATEDB.
+func TestRunAgainstFreshDatabase(t *testing.T) {
+	config := utils.DatabaseConfig{
+		Host:     utils.GetEnvOrDefault("DB_HOST", "localhost"),
+		Port:     utils.GetEnvAsInt("DB_PORT", 5432),
+		User:     utils.GetEnvOrDefault("DB_USER", "bsky_user"),
+		Password: utils.GetEnvOrDefault("DB_PASSWORD", "bsky_test_password"),
+		DBName:   utils.GetEnvOrDefault("DB_NAME", "bsky_automation"),
+	}
+	admin, err := utils.NewPostgresConnection(config)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Close() })
+
+	ctx := context.Background()
+	dbName := fmt.Sprintf("bsky_migrations_%s", utils.GenerateUUID().String()[:8])
+	_, err = admin.ExecContext(ctx, "CREATE DATABASE "+dbName)
+	require.NoError(t, err)
+	t.Cleanup(func() { admin.Exec("DROP DATABASE IF EXISTS " + dbName) })
+
+	config.DBName = dbName
+	db, err := utils.NewPostgresConnection(config)
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := Load()
+	require.NoError(t, err)
+
+	applied, err := Run(ctx, db)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	for _, table := range []string{"accounts", "proxies", "strategies", "tasks", "metrics", "audit_logs", "system_settings"} {
+		exists, err := utils.GetTableExists(db, table)
+		require.NoError(t, err)
+		assert.True(t, exists, table)
+	}
+
+	var settings int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM system_settings").Scan(&settings))
+	assert.Greater(t, settings, 0)
+
+	// A second run is a no-op
+	applied, err = Run(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	var recorded int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&recorded))
+	assert.Equal(t, len(migrations), recorded)
+
+	// Paused associations drop out of schedulable_account_strategies, so
+	// inserting tasks from the view creates none for them
+	var accountID, strategyID, otherStrategyID int
+	require.NoError(t, db.QueryRowContext(ctx,
+		"INSERT INTO accounts (handle, password) VALUES ('alice.bsky.social', 'x') RETURNING id").Scan(&accountID))
+	require.NoError(t, db.QueryRowContext(ctx,
+		"INSERT INTO strategies (name, type) VALUES ('Follow', 'follow') RETURNING id").Scan(&strategyID))
+	require.NoError(t, db.QueryRowContext(ctx,
+		"INSERT INTO strategies (name, type) VALUES ('Like', 'like') RETURNING id").Scan(&otherStrategyID))
+	_, err = db.ExecContext(ctx,
+		"INSERT INTO account_strategies (account_id, strategy_id, status) VALUES ($1, $2, 'active'), ($1, $3, 'paused')",
+		accountID, strategyID, otherStrategyID)
+	require.NoError(t, err)
+
+	result, err := db.ExecContext(ctx, `
+		INSERT INTO tasks (account_id, strategy_id, account_strategy_id, type)
+		SELECT account_id, strategy_id, id, strategy_type::text FROM schedulable_account_strategies`)
+	require.NoError(t, err)
+	created, err := result.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created)
+
+	var pausedTasks int
+	require.NoError(t, db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM tasks WHERE strategy_id = $1", otherStrategyID).Scan(&pausedTasks))
+	assert.Zero(t, pausedTasks)
+}
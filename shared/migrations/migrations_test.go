@@ -0,0 +1,86 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "versions are contiguous from 1")
+		assert.NotEmpty(t, migration.SQL)
+	}
+	assert.Equal(t, "initial_schema", migrations[0].Name)
+}
+
+func TestLoadRejectsBadFileNames(t *testing.T) {
+	_, err := load(fstest.MapFS{"sql/initial.sql": {Data: []byte("SELECT 1")}})
+	assert.ErrorContains(t, err, "invalid migration file name")
+
+	_, err = load(fstest.MapFS{
+		"sql/0001_a.sql": {Data: []byte("SELECT 1")},
+		"sql/1_b.sql":    {Data: []byte("SELECT 1")},
+	})
+	assert.ErrorContains(t, err, "duplicate migration version 1")
+}
+
+func TestApplySkipsRecordedMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations := []Migration{
+		{Version: 1, Name: "first", SQL: "CREATE TABLE one (id INT)"},
+		{Version: 2, Name: "second", SQL: "CREATE TABLE two (id INT)"},
+	}
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE two").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(2, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := apply(context.Background(), db, migrations)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, 2, applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestApplyStopsAtFailedMigration(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrations := []Migration{
+		{Version: 1, Name: "first", SQL: "CREATE TABLE one (id INT)"},
+		{Version: 2, Name: "second", SQL: "CREATE TABLE two (id INT)"},
+	}
+
+	mock.ExpectExec("SELECT pg_advisory_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version FROM schema_migrations").WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE one").WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	mock.ExpectExec("SELECT pg_advisory_unlock").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := apply(context.Background(), db, migrations)
+	assert.ErrorContains(t, err, "failed to apply migration 1 (first)")
+	assert.Empty(t, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,242 @@
+-- Core schema: accounts, proxies, strategies, tasks, metrics, audit logs and
+-- system settings. Every statement is idempotent so the migration also applies
+-- cleanly to databases created from configs/postgres/init.sql.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'account_status') THEN
+        CREATE TYPE account_status AS ENUM ('active', 'inactive', 'suspended', 'error', 'needs_reauth');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'proxy_type') THEN
+        CREATE TYPE proxy_type AS ENUM ('http', 'socks5');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'proxy_status') THEN
+        CREATE TYPE proxy_status AS ENUM ('active', 'inactive', 'error');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'strategy_type') THEN
+        CREATE TYPE strategy_type AS ENUM ('post', 'follow', 'like', 'repost', 'monitor', 'growth');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'strategy_status') THEN
+        CREATE TYPE strategy_status AS ENUM ('active', 'inactive', 'paused');
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'task_status') THEN
+        CREATE TYPE task_status AS ENUM ('pending', 'running', 'completed', 'failed', 'cancelled');
+    END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS proxies (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type proxy_type NOT NULL,
+    host VARCHAR(255) NOT NULL,
+    port INTEGER NOT NULL CHECK (port > 0 AND port <= 65535),
+    username VARCHAR(255),
+    password VARCHAR(255),
+    status proxy_status DEFAULT 'active',
+    health_check_url VARCHAR(500),
+    last_health_check TIMESTAMP,
+    health_check_success BOOLEAN DEFAULT true,
+    response_time_ms INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    handle VARCHAR(255) UNIQUE NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    host VARCHAR(255) DEFAULT 'https://bsky.social',
+    bgs VARCHAR(255) DEFAULT 'https://bsky.network',
+    status account_status DEFAULT 'active',
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE SET NULL,
+    did VARCHAR(255),
+    access_jwt TEXT,
+    refresh_jwt TEXT,
+    last_login TIMESTAMP,
+    last_activity TIMESTAMP,
+    error_count INTEGER DEFAULT 0,
+    error_message TEXT,
+    metadata JSONB DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS strategies (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    type strategy_type NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    schedule VARCHAR(255), -- cron expression
+    status strategy_status DEFAULT 'active',
+    priority INTEGER DEFAULT 5 CHECK (priority >= 1 AND priority <= 10),
+    max_concurrent_tasks INTEGER DEFAULT 1,
+    retry_count INTEGER DEFAULT 3,
+    timeout_seconds INTEGER DEFAULT 300,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS account_strategies (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    strategy_id INTEGER REFERENCES strategies(id) ON DELETE CASCADE,
+    config JSONB DEFAULT '{}', -- account-specific configuration overrides
+    status strategy_status DEFAULT 'active',
+    last_executed TIMESTAMP,
+    next_execution TIMESTAMP,
+    execution_count INTEGER DEFAULT 0,
+    success_count INTEGER DEFAULT 0,
+    error_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(account_id, strategy_id)
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    strategy_id INTEGER REFERENCES strategies(id) ON DELETE CASCADE,
+    account_strategy_id INTEGER REFERENCES account_strategies(id) ON DELETE CASCADE,
+    type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    status task_status DEFAULT 'pending',
+    priority INTEGER DEFAULT 5 CHECK (priority >= 1 AND priority <= 10),
+    retry_count INTEGER DEFAULT 0,
+    max_retries INTEGER DEFAULT 3,
+    timeout_seconds INTEGER DEFAULT 300,
+    scheduled_at TIMESTAMP DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    worker_id VARCHAR(255),
+    error_message TEXT,
+    result JSONB DEFAULT '{}',
+    execution_time_ms INTEGER,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on_task_id INTEGER REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE(task_id, depends_on_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS metrics (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    strategy_id INTEGER REFERENCES strategies(id) ON DELETE CASCADE,
+    metric_type VARCHAR(100) NOT NULL,
+    metric_name VARCHAR(255) NOT NULL,
+    metric_value NUMERIC,
+    metric_data JSONB DEFAULT '{}',
+    timestamp TIMESTAMP DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id SERIAL PRIMARY KEY,
+    uuid UUID DEFAULT uuid_generate_v4() UNIQUE NOT NULL,
+    entity_type VARCHAR(100) NOT NULL, -- accounts, strategies, tasks, etc.
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(100) NOT NULL, -- create, update, delete, execute
+    old_values JSONB,
+    new_values JSONB,
+    user_id VARCHAR(255),
+    ip_address INET,
+    user_agent TEXT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS system_settings (
+    id SERIAL PRIMARY KEY,
+    key VARCHAR(255) UNIQUE NOT NULL,
+    value TEXT,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_handle ON accounts(handle);
+CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts(status);
+CREATE INDEX IF NOT EXISTS idx_accounts_proxy_id ON accounts(proxy_id);
+CREATE INDEX IF NOT EXISTS idx_accounts_last_activity ON accounts(last_activity);
+
+CREATE INDEX IF NOT EXISTS idx_proxies_status ON proxies(status);
+CREATE INDEX IF NOT EXISTS idx_proxies_type ON proxies(type);
+CREATE INDEX IF NOT EXISTS idx_proxies_health ON proxies(health_check_success);
+
+CREATE INDEX IF NOT EXISTS idx_strategies_type ON strategies(type);
+CREATE INDEX IF NOT EXISTS idx_strategies_status ON strategies(status);
+CREATE INDEX IF NOT EXISTS idx_strategies_priority ON strategies(priority);
+
+CREATE INDEX IF NOT EXISTS idx_account_strategies_account_id ON account_strategies(account_id);
+CREATE INDEX IF NOT EXISTS idx_account_strategies_strategy_id ON account_strategies(strategy_id);
+CREATE INDEX IF NOT EXISTS idx_account_strategies_status ON account_strategies(status);
+CREATE INDEX IF NOT EXISTS idx_account_strategies_next_execution ON account_strategies(next_execution);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
+CREATE INDEX IF NOT EXISTS idx_tasks_account_id ON tasks(account_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_strategy_id ON tasks(strategy_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_scheduled_at ON tasks(scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
+CREATE INDEX IF NOT EXISTS idx_tasks_worker_id ON tasks(worker_id);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_account_id ON metrics(account_id);
+CREATE INDEX IF NOT EXISTS idx_metrics_strategy_id ON metrics(strategy_id);
+CREATE INDEX IF NOT EXISTS idx_metrics_type ON metrics(metric_type);
+CREATE INDEX IF NOT EXISTS idx_metrics_timestamp ON metrics(timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs(action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
+
+CREATE OR REPLACE FUNCTION update_updated_at_column()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE OR REPLACE TRIGGER update_accounts_updated_at BEFORE UPDATE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_proxies_updated_at BEFORE UPDATE ON proxies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_strategies_updated_at BEFORE UPDATE ON strategies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_account_strategies_updated_at BEFORE UPDATE ON account_strategies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_tasks_updated_at BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+CREATE OR REPLACE TRIGGER update_system_settings_updated_at BEFORE UPDATE ON system_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+INSERT INTO system_settings (key, value, description) VALUES
+('max_concurrent_tasks_per_account', '5', 'Maximum number of concurrent tasks per account'),
+('default_task_timeout', '300', 'Default task timeout in seconds'),
+('proxy_health_check_interval', '300', 'Proxy health check interval in seconds'),
+('proxy_health_check_url', 'https://httpbin.org/ip', 'Default proxy health check URL; add proxy_health_check_url:<tag> to override it for proxies with that tag'),
+('rate_limit_requests_per_minute', '60', 'Default rate limit per account per minute'),
+('strategy_execution_interval', '60', 'Strategy execution check interval in seconds'),
+('max_retry_attempts', '3', 'Maximum retry attempts for failed tasks'),
+('cleanup_completed_tasks_days', '7', 'Days to keep completed tasks before cleanup'),
+('cleanup_metrics_days', '30', 'Days to keep metrics data before cleanup')
+ON CONFLICT (key) DO NOTHING;
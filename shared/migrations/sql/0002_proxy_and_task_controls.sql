@@ -0,0 +1,49 @@
+-- Columns and tables added after the initial schema. ADD COLUMN IF NOT EXISTS
+-- brings older databases up to date without touching ones that already have them.
+
+ALTER TYPE account_status ADD VALUE IF NOT EXISTS 'needs_reauth';
+
+ALTER TABLE proxies ADD COLUMN IF NOT EXISTS bsky_reachable BOOLEAN; -- NULL until the Bluesky-specific probe has run
+ALTER TABLE proxies ADD COLUMN IF NOT EXISTS health_check_enabled BOOLEAN DEFAULT true;
+ALTER TABLE proxies ADD COLUMN IF NOT EXISTS max_accounts INTEGER CHECK (max_accounts > 0); -- NULL means no cap
+
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS proxy_tags (
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE CASCADE,
+    tag VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (proxy_id, tag)
+);
+
+CREATE TABLE IF NOT EXISTS account_errors (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    operation VARCHAR(100) NOT NULL, -- authenticate, refresh_auth, etc.
+    kind VARCHAR(50) NOT NULL, -- auth, proxy, rate_limit, network, other
+    message TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS proxy_assignments (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE SET NULL,
+    strategy VARCHAR(50) NOT NULL, -- manual, least_used, round_robin, etc.
+    assigned_at TIMESTAMP DEFAULT NOW(),
+    released_at TIMESTAMP -- NULL while the assignment is active
+);
+
+CREATE TABLE IF NOT EXISTS proxy_health_checks (
+    id SERIAL PRIMARY KEY,
+    proxy_id INTEGER REFERENCES proxies(id) ON DELETE CASCADE,
+    success BOOLEAN NOT NULL,
+    response_time_ms INTEGER,
+    checked_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_proxy_tags_tag ON proxy_tags(tag);
+CREATE INDEX IF NOT EXISTS idx_account_errors_account_id ON account_errors(account_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_proxy_assignments_account_id ON proxy_assignments(account_id, assigned_at DESC);
+CREATE INDEX IF NOT EXISTS idx_proxy_assignments_proxy_id ON proxy_assignments(proxy_id, assigned_at DESC);
+CREATE INDEX IF NOT EXISTS idx_proxy_health_checks_proxy_id ON proxy_health_checks(proxy_id, checked_at);
@@ -0,0 +1,46 @@
+-- Views over the tables above. They are dropped and recreated because
+-- CREATE OR REPLACE VIEW cannot change the column list that a.* / t.* expand to.
+
+DROP VIEW IF EXISTS active_accounts;
+CREATE VIEW active_accounts AS
+SELECT a.*, p.name as proxy_name, p.host as proxy_host, p.port as proxy_port
+FROM accounts a
+LEFT JOIN proxies p ON a.proxy_id = p.id
+WHERE a.status = 'active';
+
+DROP VIEW IF EXISTS pending_tasks;
+CREATE VIEW pending_tasks AS
+SELECT t.*, a.handle as account_handle, s.name as strategy_name
+FROM tasks t
+JOIN accounts a ON t.account_id = a.id
+JOIN strategies s ON t.strategy_id = s.id
+WHERE t.status = 'pending'
+ORDER BY t.priority DESC, t.scheduled_at ASC;
+
+-- Account strategies eligible for scheduling; paused or inactive associations,
+-- strategies and accounts are excluded so no new tasks are created for them
+DROP VIEW IF EXISTS schedulable_account_strategies;
+CREATE VIEW schedulable_account_strategies AS
+SELECT as_.*, a.handle as account_handle, s.name as strategy_name, s.type as strategy_type
+FROM account_strategies as_
+JOIN accounts a ON as_.account_id = a.id
+JOIN strategies s ON as_.strategy_id = s.id
+WHERE as_.status = 'active'
+  AND s.status = 'active'
+  AND a.status = 'active'
+  AND (as_.next_execution IS NULL OR as_.next_execution <= NOW());
+
+DROP VIEW IF EXISTS strategy_performance;
+CREATE VIEW strategy_performance AS
+SELECT
+    s.id,
+    s.name,
+    s.type,
+    COUNT(t.id) as total_tasks,
+    COUNT(CASE WHEN t.status = 'completed' THEN 1 END) as completed_tasks,
+    COUNT(CASE WHEN t.status = 'failed' THEN 1 END) as failed_tasks,
+    AVG(t.execution_time_ms) as avg_execution_time_ms,
+    MAX(t.completed_at) as last_execution
+FROM strategies s
+LEFT JOIN tasks t ON s.id = t.strategy_id
+GROUP BY s.id, s.name, s.type;
@@ -0,0 +1,12 @@
+-- Authentication history: one row per login attempt, successful or not, so
+-- outcomes can be compared across proxy assignment strategies.
+
+CREATE TABLE IF NOT EXISTS auth_attempts (
+    id SERIAL PRIMARY KEY,
+    account_id INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+    operation VARCHAR(100) NOT NULL, -- refresh_auth, test_auth, etc.
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_attempts_account_id ON auth_attempts(account_id, created_at);
@@ -0,0 +1,7 @@
+-- Page size limits for list endpoints, read at runtime so they can be tuned
+-- without a redeploy. Existing values are left alone.
+
+INSERT INTO system_settings (key, value, description) VALUES
+('default_page_size', '10', 'Page size of list endpoints when the request names none'),
+('max_page_size', '100', 'Largest page size list endpoints return')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Global kill switch checked by task workers and the proxy health scheduler.
+-- Existing values are left alone.
+
+INSERT INTO system_settings (key, value, description) VALUES
+('automation_paused', 'false', 'Halts task workers and scheduled health checks while true')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,16 @@
+-- schedulable_account_strategies lists the associations new tasks may be
+-- created for, whether or not a run is due: task creation and the schedule
+-- preview both select from it. It now carries the strategy's schedule and
+-- task defaults so callers need no second join.
+
+DROP VIEW IF EXISTS schedulable_account_strategies;
+CREATE VIEW schedulable_account_strategies AS
+SELECT as_.*, a.handle as account_handle, s.name as strategy_name, s.type as strategy_type,
+       s.schedule as strategy_schedule, s.priority as strategy_priority,
+       s.retry_count as strategy_retry_count, s.timeout_seconds as strategy_timeout_seconds
+FROM account_strategies as_
+JOIN accounts a ON as_.account_id = a.id
+JOIN strategies s ON as_.strategy_id = s.id
+WHERE as_.status = 'active'
+  AND s.status = 'active'
+  AND a.status = 'active';
@@ -0,0 +1,7 @@
+-- Default proxy assignment strategy, read at runtime so it can be changed
+-- without a redeploy. Empty means DEFAULT_PROXY_STRATEGY decides. Existing
+-- values are left alone.
+
+INSERT INTO system_settings (key, value, description) VALUES
+('default_proxy_strategy', '', 'Proxy assignment strategy when the request names none; empty uses DEFAULT_PROXY_STRATEGY')
+ON CONFLICT (key) DO NOTHING;
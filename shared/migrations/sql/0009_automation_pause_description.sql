@@ -0,0 +1,6 @@
+-- Describe what the kill switch actually stops: task creation and retries,
+-- task claims and scheduled health checks.
+
+UPDATE system_settings
+SET description = 'Refuses new and retried tasks, stops task claims and skips scheduled health checks while true'
+WHERE key = 'automation_paused';
@@ -0,0 +1,6 @@
+-- Virtual nodes per proxy on the consistent-hash ring, read at runtime so it
+-- can be tuned without a redeploy. Existing values are left alone.
+
+INSERT INTO system_settings (key, value, description) VALUES
+('consistent_hash_vnodes', '100', 'Virtual nodes per proxy on the consistent_hash assignment ring')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+)
+
+// AccountFlagsKey is the Account.Metadata key holding the account's feature
+// flags, an object of flag name to boolean
+const AccountFlagsKey = "flags"
+
+// Recognized account feature flags
+const (
+	// AccountFlagFollowPDSMigration moves the account to the PDS its DID
+	// document names before logging in
+	AccountFlagFollowPDSMigration = "follow_pds_migration"
+	// AccountFlagAllowSelfActions lets the account follow, like and repost
+	// itself, e.g. for test accounts
+	AccountFlagAllowSelfActions = "allow_self_actions"
+)
+
+// AccountFlagDefaults maps each recognized flag to its value for accounts
+// that do not set it
+var AccountFlagDefaults = map[string]bool{
+	AccountFlagFollowPDSMigration: true,
+	AccountFlagAllowSelfActions:   false,
+}
+
+// IsAccountFlag reports whether name is a recognized account flag
+func IsAccountFlag(name string) bool {
+	_, ok := AccountFlagDefaults[name]
+	return ok
+}
+
+// AccountFlagNames returns the recognized flags in sorted order
+func AccountFlagNames() []string {
+	names := make([]string, 0, len(AccountFlagDefaults))
+	for name := range AccountFlagDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Flag returns the value of a feature flag from the account's metadata,
+// falling back to its default when unset or not a boolean. Strings such as
+// "true" or "0" are accepted, since metadata is often edited by hand.
+// Unrecognized flags are false.
+func (a *Account) Flag(name string) bool {
+	def := AccountFlagDefaults[name]
+
+	flags, ok := a.Metadata[AccountFlagsKey].(map[string]interface{})
+	if !ok {
+		return def
+	}
+	switch value := flags[name].(type) {
+	case bool:
+		return value
+	case string:
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return def
+}
+
+// Flags returns the effective value of every recognized flag
+func (a *Account) Flags() map[string]bool {
+	flags := make(map[string]bool, len(AccountFlagDefaults))
+	for name := range AccountFlagDefaults {
+		flags[name] = a.Flag(name)
+	}
+	return flags
+}
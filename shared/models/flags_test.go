@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountFlagDefaults(t *testing.T) {
+	for _, metadata := range []JSONB{nil, {}, {"flags": "not an object"}, {"flags": map[string]interface{}{}}} {
+		account := &Account{Metadata: metadata}
+		assert.True(t, account.Flag(AccountFlagFollowPDSMigration))
+		assert.False(t, account.Flag(AccountFlagAllowSelfActions))
+		assert.False(t, account.Flag("unknown"))
+	}
+}
+
+func TestAccountFlagParsing(t *testing.T) {
+	// Metadata as scanned from the database
+	var metadata JSONB
+	require.NoError(t, metadata.Scan([]byte(`{"flags": {
+		"follow_pds_migration": "false",
+		"allow_self_actions": 1
+	}}`)))
+	account := &Account{Metadata: metadata}
+
+	assert.False(t, account.Flag(AccountFlagFollowPDSMigration), "boolean strings are accepted")
+	assert.False(t, account.Flag(AccountFlagAllowSelfActions), "numbers use the default")
+
+	assert.Equal(t, map[string]bool{
+		AccountFlagFollowPDSMigration: false,
+		AccountFlagAllowSelfActions:   false,
+	}, account.Flags())
+
+	// Unparseable values use the default
+	require.NoError(t, metadata.Scan([]byte(`{"flags": {"follow_pds_migration": "maybe"}}`)))
+	account = &Account{Metadata: metadata}
+	assert.True(t, account.Flag(AccountFlagFollowPDSMigration))
+
+	assert.Equal(t, []string{"allow_self_actions", "follow_pds_migration"}, AccountFlagNames())
+}
@@ -43,6 +43,9 @@ const (
 	AccountStatusInactive  AccountStatus = "inactive"
 	AccountStatusSuspended AccountStatus = "suspended"
 	AccountStatusError     AccountStatus = "error"
+	// AccountStatusNeedsReauth marks accounts whose session expired and
+	// require a fresh login before they can be used again
+	AccountStatusNeedsReauth AccountStatus = "needs_reauth"
 )
 
 // Proxy type enumeration
@@ -94,6 +97,17 @@ const (
 	TaskStatusCancelled TaskStatus = "cancelled"
 )
 
+// Error kind enumeration, used to tell chronic failures from transient ones
+type ErrorKind string
+
+const (
+	ErrorKindAuth      ErrorKind = "auth"
+	ErrorKindProxy     ErrorKind = "proxy"
+	ErrorKindRateLimit ErrorKind = "rate_limit"
+	ErrorKindNetwork   ErrorKind = "network"
+	ErrorKindOther     ErrorKind = "other"
+)
+
 // Proxy represents a proxy server configuration
 type Proxy struct {
 	ID                   int         `json:"id" db:"id"`
@@ -103,12 +117,17 @@ type Proxy struct {
 	Host                 string      `json:"host" db:"host"`
 	Port                 int         `json:"port" db:"port"`
 	Username             *string     `json:"username,omitempty" db:"username"`
-	Password             *string     `json:"password,omitempty" db:"password"`
+	// Password is stored encrypted and never serialized
+	Password             *string     `json:"-" db:"password"`
 	Status               ProxyStatus `json:"status" db:"status"`
 	HealthCheckURL       *string     `json:"health_check_url,omitempty" db:"health_check_url"`
 	LastHealthCheck      *time.Time  `json:"last_health_check,omitempty" db:"last_health_check"`
 	HealthCheckSuccess   bool        `json:"health_check_success" db:"health_check_success"`
 	ResponseTimeMs       int         `json:"response_time_ms" db:"response_time_ms"`
+	BskyReachable        *bool       `json:"bsky_reachable,omitempty" db:"bsky_reachable"`
+	HealthCheckEnabled   bool        `json:"health_check_enabled" db:"health_check_enabled"`
+	MaxAccounts          *int        `json:"max_accounts,omitempty" db:"max_accounts"`
+	Tags                 []string    `json:"tags,omitempty" db:"-"`
 	CreatedAt            time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time   `json:"updated_at" db:"updated_at"`
 }
@@ -185,7 +204,7 @@ type Task struct {
 	AccountID          int        `json:"account_id" db:"account_id"`
 	StrategyID         int        `json:"strategy_id" db:"strategy_id"`
 	AccountStrategyID  int        `json:"account_strategy_id" db:"account_strategy_id"`
-	Type               string     `json:"type" db:"type"`
+	Type               TaskType   `json:"type" db:"type"`
 	Payload            JSONB      `json:"payload" db:"payload"`
 	Status             TaskStatus `json:"status" db:"status"`
 	Priority           int        `json:"priority" db:"priority"`
@@ -196,6 +215,7 @@ type Task struct {
 	StartedAt          *time.Time `json:"started_at,omitempty" db:"started_at"`
 	CompletedAt        *time.Time `json:"completed_at,omitempty" db:"completed_at"`
 	WorkerID           *string    `json:"worker_id,omitempty" db:"worker_id"`
+	CancelRequested    bool       `json:"cancel_requested" db:"cancel_requested"`
 	ErrorMessage       *string    `json:"error_message,omitempty" db:"error_message"`
 	Result             JSONB      `json:"result" db:"result"`
 	ExecutionTimeMs    *int       `json:"execution_time_ms,omitempty" db:"execution_time_ms"`
@@ -229,6 +249,16 @@ type Metric struct {
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
+// AccountError represents one logged failure of an account operation
+type AccountError struct {
+	ID        int       `json:"id" db:"id"`
+	AccountID int       `json:"account_id" db:"account_id"`
+	Operation string    `json:"operation" db:"operation"`
+	Kind      ErrorKind `json:"kind" db:"kind"`
+	Message   string    `json:"message" db:"message"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
 // AuditLog represents an audit log entry
 type AuditLog struct {
 	ID         int       `json:"id" db:"id"`
@@ -277,12 +307,14 @@ type UpdateAccountRequest struct {
 // CreateProxyRequest represents a request to create a proxy
 type CreateProxyRequest struct {
 	Name           string     `json:"name" validate:"required"`
-	Type           ProxyType  `json:"type" validate:"required"`
+	Type           ProxyType  `json:"type" validate:"required,oneof=http socks5"`
 	Host           string     `json:"host" validate:"required"`
 	Port           int        `json:"port" validate:"required,min=1,max=65535"`
 	Username       *string    `json:"username,omitempty"`
 	Password       *string    `json:"password,omitempty"`
 	HealthCheckURL *string    `json:"health_check_url,omitempty"`
+	MaxAccounts    *int       `json:"max_accounts,omitempty" validate:"omitempty,min=1"`
+	Tags           []string   `json:"tags,omitempty" validate:"omitempty,dive,required,max=100"`
 }
 
 // CreateStrategyRequest represents a request to create a strategy
@@ -302,10 +334,10 @@ type CreateStrategyRequest struct {
 type CreateTaskRequest struct {
 	AccountID      int    `json:"account_id" validate:"required"`
 	StrategyID     int    `json:"strategy_id" validate:"required"`
-	Type           string `json:"type" validate:"required"`
-	Payload        JSONB  `json:"payload" validate:"required"`
-	Priority       *int   `json:"priority,omitempty"`
-	TimeoutSeconds *int   `json:"timeout_seconds,omitempty"`
+	Type           TaskType `json:"type" validate:"required"`
+	Payload        JSONB    `json:"payload" validate:"required"`
+	Priority       *int   `json:"priority,omitempty" validate:"omitempty,min=1,max=10"`
+	TimeoutSeconds *int   `json:"timeout_seconds,omitempty" validate:"omitempty,min=1"`
 	ScheduledAt    *time.Time `json:"scheduled_at,omitempty"`
 }
 
@@ -335,6 +367,9 @@ type PaginationResponse struct {
 type ListResponse struct {
 	Data       interface{}        `json:"data"`
 	Pagination PaginationResponse `json:"pagination"`
+	// SkippedRows counts rows left out because they failed to scan; only
+	// non-zero when the caller asked to skip malformed rows
+	SkippedRows int `json:"skipped_rows,omitempty"`
 }
 
 // ErrorResponse represents an error response
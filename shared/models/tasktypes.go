@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TaskType identifies what a task does; workers dispatch on it
+type TaskType string
+
+const (
+	TaskTypeCreatePost     TaskType = "create_post"
+	TaskTypeFollowUser     TaskType = "follow_user"
+	TaskTypeLikePost       TaskType = "like_post"
+	TaskTypeRepostPost     TaskType = "repost_post"
+	TaskTypeMonitorKeyword TaskType = "monitor_keyword"
+	TaskTypeAnalyzeGrowth  TaskType = "analyze_growth"
+)
+
+// ErrInvalidTaskType indicates a task type that is unknown or not allowed for
+// the task's strategy
+var ErrInvalidTaskType = errors.New("invalid task type")
+
+// ErrInvalidTaskPayload indicates a task payload missing a required field or
+// holding a field of the wrong kind
+var ErrInvalidTaskPayload = errors.New("invalid task payload")
+
+// PayloadKind is the JSON kind a payload field must have
+type PayloadKind string
+
+const (
+	PayloadString PayloadKind = "string"
+	PayloadNumber PayloadKind = "number"
+	PayloadArray  PayloadKind = "array"
+)
+
+// PayloadField describes one field of a task payload
+type PayloadField struct {
+	Name     string      `json:"name"`
+	Kind     PayloadKind `json:"kind"`
+	Required bool        `json:"required"`
+}
+
+// TaskTypeSpec describes a task type and the payload it needs
+type TaskTypeSpec struct {
+	Type   TaskType       `json:"type"`
+	Fields []PayloadField `json:"fields"`
+	// AnyOf lists fields of which at least one must be present, for tasks
+	// that accept alternative ways of naming their target
+	AnyOf []string `json:"any_of,omitempty"`
+}
+
+var (
+	createPostSpec = TaskTypeSpec{Type: TaskTypeCreatePost, Fields: []PayloadField{
+		{Name: "content", Kind: PayloadString, Required: true},
+		{Name: "template_id", Kind: PayloadNumber},
+	}}
+	followUserSpec = TaskTypeSpec{Type: TaskTypeFollowUser, Fields: []PayloadField{
+		{Name: "target_handle", Kind: PayloadString},
+		{Name: "target_did", Kind: PayloadString},
+		{Name: "reason", Kind: PayloadString},
+	}, AnyOf: []string{"target_handle", "target_did"}}
+	likePostSpec = TaskTypeSpec{Type: TaskTypeLikePost, Fields: []PayloadField{
+		{Name: "post_uri", Kind: PayloadString, Required: true},
+		{Name: "reason", Kind: PayloadString},
+	}}
+	repostPostSpec = TaskTypeSpec{Type: TaskTypeRepostPost, Fields: []PayloadField{
+		{Name: "post_uri", Kind: PayloadString, Required: true},
+		{Name: "reason", Kind: PayloadString},
+	}}
+	monitorKeywordSpec = TaskTypeSpec{Type: TaskTypeMonitorKeyword, Fields: []PayloadField{
+		{Name: "keyword", Kind: PayloadString, Required: true},
+		{Name: "search_limit", Kind: PayloadNumber},
+	}}
+	analyzeGrowthSpec = TaskTypeSpec{Type: TaskTypeAnalyzeGrowth, Fields: []PayloadField{
+		{Name: "metrics", Kind: PayloadArray, Required: true},
+	}}
+)
+
+// taskTypeRegistry maps each strategy type to the task types its tasks may
+// have. It is the single source for task creation validation and worker
+// dispatch; a strategy type missing here cannot create tasks. Read it through
+// ValidateTask, TaskTypesFor and LookupTaskType.
+var taskTypeRegistry = map[StrategyType][]TaskTypeSpec{
+	StrategyTypePost:    {createPostSpec},
+	StrategyTypeFollow:  {followUserSpec},
+	StrategyTypeLike:    {likePostSpec},
+	StrategyTypeRepost:  {repostPostSpec},
+	StrategyTypeMonitor: {monitorKeywordSpec},
+	StrategyTypeGrowth:  {analyzeGrowthSpec, followUserSpec, likePostSpec},
+}
+
+// Valid reports whether t is a registered strategy type
+func (t StrategyType) Valid() bool {
+	_, ok := taskTypeRegistry[t]
+	return ok
+}
+
+// LookupTaskType returns the spec of a task type registered under any
+// strategy. Workers use it to check a claimed task before dispatching it.
+func LookupTaskType(taskType TaskType) (TaskTypeSpec, bool) {
+	for _, specs := range taskTypeRegistry {
+		for _, spec := range specs {
+			if spec.Type == taskType {
+				return spec, true
+			}
+		}
+	}
+	return TaskTypeSpec{}, false
+}
+
+// TaskTypesFor returns the task types allowed for a strategy type, sorted
+func TaskTypesFor(strategy StrategyType) []TaskType {
+	specs := taskTypeRegistry[strategy]
+	types := make([]TaskType, 0, len(specs))
+	for _, spec := range specs {
+		types = append(types, spec.Type)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// ValidateTask checks that taskType is allowed for strategy and that payload
+// has what the task type needs
+func ValidateTask(strategy StrategyType, taskType TaskType, payload JSONB) error {
+	specs, ok := taskTypeRegistry[strategy]
+	if !ok {
+		return fmt.Errorf("%w: unknown strategy type %q", ErrInvalidTaskType, strategy)
+	}
+	for _, spec := range specs {
+		if spec.Type == taskType {
+			return spec.ValidatePayload(payload)
+		}
+	}
+
+	allowed := make([]string, 0, len(specs))
+	for _, t := range TaskTypesFor(strategy) {
+		allowed = append(allowed, string(t))
+	}
+	return fmt.Errorf("%w: %q is not a %s task (allowed: %s)", ErrInvalidTaskType, taskType, strategy, strings.Join(allowed, ", "))
+}
+
+// Validate checks the request's task type and payload against the registry,
+// given the type of the strategy named by StrategyID. CreateTask calls it
+// before inserting.
+func (r *CreateTaskRequest) Validate(strategy StrategyType) error {
+	return ValidateTask(strategy, r.Type, r.Payload)
+}
+
+// ValidatePayload checks payload against the spec's fields. Fields the spec
+// does not list are allowed.
+func (s TaskTypeSpec) ValidatePayload(payload JSONB) error {
+	for _, field := range s.Fields {
+		value, ok := payload[field.Name]
+		if !ok || value == nil {
+			if field.Required {
+				return fmt.Errorf("%w: %s requires %q", ErrInvalidTaskPayload, s.Type, field.Name)
+			}
+			continue
+		}
+		if !payloadKindMatches(field.Kind, value) {
+			return fmt.Errorf("%w: %s field %q must be a %s", ErrInvalidTaskPayload, s.Type, field.Name, field.Kind)
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		for _, name := range s.AnyOf {
+			if value, ok := payload[name]; ok && value != nil && value != "" {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s requires one of %s", ErrInvalidTaskPayload, s.Type, strings.Join(s.AnyOf, ", "))
+	}
+	return nil
+}
+
+// payloadKindMatches reports whether a decoded JSON value has the given kind.
+// Empty strings do not count as strings.
+func payloadKindMatches(kind PayloadKind, value interface{}) bool {
+	switch kind {
+	case PayloadString:
+		s, ok := value.(string)
+		return ok && s != ""
+	case PayloadNumber:
+		switch value.(type) {
+		case float64, float32, int, int64, int32:
+			return true
+		}
+		return false
+	case PayloadArray:
+		_, ok := value.([]interface{})
+		if !ok {
+			_, ok = value.([]string)
+		}
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTaskAcceptsRegisteredCombinations(t *testing.T) {
+	// Payloads as scanned from the seed data
+	var post, follow, growth JSONB
+	require.NoError(t, post.Scan([]byte(`{"content": "hello", "template_id": 1}`)))
+	require.NoError(t, follow.Scan([]byte(`{"target_handle": "techuser1.bsky.social", "reason": "keyword_match"}`)))
+	require.NoError(t, growth.Scan([]byte(`{"metrics": ["followers", "engagement"]}`)))
+
+	assert.NoError(t, ValidateTask(StrategyTypePost, TaskTypeCreatePost, post))
+	assert.NoError(t, ValidateTask(StrategyTypeFollow, TaskTypeFollowUser, follow))
+	assert.NoError(t, ValidateTask(StrategyTypeGrowth, TaskTypeAnalyzeGrowth, growth))
+	assert.NoError(t, ValidateTask(StrategyTypeGrowth, TaskTypeFollowUser, JSONB{"target_did": "did:plc:bob"}))
+	assert.NoError(t, ValidateTask(StrategyTypeRepost, TaskTypeRepostPost, JSONB{"post_uri": "at://did:plc:bob/app.bsky.feed.post/1", "extra": true}))
+}
+
+func TestValidateTaskRejectsInvalidCombinations(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy StrategyType
+		taskType TaskType
+		payload  JSONB
+		want     error
+	}{
+		{"task type of another strategy", StrategyTypeLike, TaskTypeCreatePost, JSONB{"content": "hi"}, ErrInvalidTaskType},
+		{"monitor cannot write", StrategyTypeMonitor, TaskTypeLikePost, JSONB{"post_uri": "at://x"}, ErrInvalidTaskType},
+		{"unknown task type", StrategyTypePost, "delete_everything", JSONB{}, ErrInvalidTaskType},
+		{"unknown strategy type", "spam", TaskTypeCreatePost, JSONB{"content": "hi"}, ErrInvalidTaskType},
+		{"missing required field", StrategyTypeLike, TaskTypeLikePost, JSONB{"reason": "x"}, ErrInvalidTaskPayload},
+		{"empty required string", StrategyTypePost, TaskTypeCreatePost, JSONB{"content": ""}, ErrInvalidTaskPayload},
+		{"wrong kind", StrategyTypeMonitor, TaskTypeMonitorKeyword, JSONB{"keyword": "go", "search_limit": "fifty"}, ErrInvalidTaskPayload},
+		{"array expected", StrategyTypeGrowth, TaskTypeAnalyzeGrowth, JSONB{"metrics": "followers"}, ErrInvalidTaskPayload},
+		{"no follow target", StrategyTypeFollow, TaskTypeFollowUser, JSONB{"reason": "keyword_match"}, ErrInvalidTaskPayload},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTask(tc.strategy, tc.taskType, tc.payload)
+			assert.True(t, errors.Is(err, tc.want), "got %v", err)
+		})
+	}
+
+	req := &CreateTaskRequest{Type: TaskTypeFollowUser, Payload: JSONB{"target_handle": "bob.bsky.social"}}
+	assert.NoError(t, req.Validate(StrategyTypeFollow))
+	assert.True(t, errors.Is(req.Validate(StrategyTypePost), ErrInvalidTaskType))
+}
+
+func TestTaskTypeRegistryCoversStrategyTypes(t *testing.T) {
+	for _, strategy := range []StrategyType{
+		StrategyTypePost, StrategyTypeFollow, StrategyTypeLike,
+		StrategyTypeRepost, StrategyTypeMonitor, StrategyTypeGrowth,
+	} {
+		assert.True(t, strategy.Valid(), strategy)
+		assert.NotEmpty(t, TaskTypesFor(strategy), strategy)
+	}
+	assert.False(t, StrategyType("spam").Valid())
+
+	assert.Equal(t, []TaskType{TaskTypeAnalyzeGrowth, TaskTypeFollowUser, TaskTypeLikePost}, TaskTypesFor(StrategyTypeGrowth))
+
+	spec, ok := LookupTaskType(TaskTypeRepostPost)
+	require.True(t, ok)
+	assert.Equal(t, TaskTypeRepostPost, spec.Type)
+	_, ok = LookupTaskType("delete_everything")
+	assert.False(t, ok)
+}
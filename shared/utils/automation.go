@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// AutomationPausedSetting is the system_settings key of the global kill
+// switch. While it is true no tasks are created or retried, workers claim
+// none through ClaimNextTask and the proxy health scheduler skips its cycles;
+// the APIs keep serving reads.
+const AutomationPausedSetting = "automation_paused"
+
+// AutomationPaused reports whether the global kill switch is on. It reads the
+// setting on every call so a pause takes effect at the next check. A missing
+// setting means automation runs; a value that is not a boolean is treated as
+// paused, since halting is the safe reading of a mistyped kill switch.
+func AutomationPaused(ctx context.Context, db *sql.DB) (bool, error) {
+	var value sql.NullString
+	err := db.QueryRowContext(ctx, "SELECT value FROM system_settings WHERE key = $1", AutomationPausedSetting).Scan(&value)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read automation pause setting: %w", err)
+	}
+
+	paused, err := strconv.ParseBool(value.String)
+	if err != nil {
+		log.Printf("Treating setting %s=%q as paused: must be true or false", AutomationPausedSetting, value.String)
+		return true, nil
+	}
+	return paused, nil
+}
+
+// SetAutomationPaused turns the global kill switch on or off
+func SetAutomationPaused(ctx context.Context, db *sql.DB, paused bool) error {
+	query := `
+		INSERT INTO system_settings (key, value, description)
+		VALUES ($1, $2, 'Refuses new and retried tasks, stops task claims and skips scheduled health checks while true')
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()
+	`
+	if _, err := db.ExecContext(ctx, query, AutomationPausedSetting, strconv.FormatBool(paused)); err != nil {
+		return fmt.Errorf("failed to update automation pause setting: %w", err)
+	}
+	return nil
+}
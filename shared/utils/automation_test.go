@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectAutomationPaused expects the kill switch to be read and returns value
+func expectAutomationPaused(mock sqlmock.Sqlmock, value string) {
+	mock.ExpectQuery(`SELECT value FROM system_settings WHERE key = \$1`).
+		WithArgs(AutomationPausedSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}).AddRow(value))
+}
+
+func TestAutomationPaused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT value FROM system_settings`).
+		WillReturnRows(sqlmock.NewRows([]string{"value"}))
+	paused, err := AutomationPaused(ctx, db)
+	require.NoError(t, err)
+	assert.False(t, paused, "a missing setting leaves automation running")
+
+	expectAutomationPaused(mock, "false")
+	paused, err = AutomationPaused(ctx, db)
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	expectAutomationPaused(mock, "yes please")
+	paused, err = AutomationPaused(ctx, db)
+	require.NoError(t, err)
+	assert.True(t, paused, "an unreadable value halts automation")
+
+	mock.ExpectExec(`INSERT INTO system_settings .* ON CONFLICT \(key\) DO UPDATE`).
+		WithArgs(AutomationPausedSetting, "true").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, SetAutomationPaused(ctx, db, true))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
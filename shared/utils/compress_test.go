@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzipMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(GzipMiddleware(1024))
+	router.GET("/large", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": strings.Repeat("proxy ", 1000)})
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"message": "test"})
+	})
+	router.GET("/image", func(c *gin.Context) {
+		c.Data(http.StatusOK, "image/png", bytes.Repeat([]byte{0x89}, 4096))
+	})
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	large := request("/large")
+	assert.Equal(t, http.StatusOK, large.Code)
+	assert.Equal(t, "gzip", large.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", large.Header().Get("Vary"))
+
+	reader, err := gzip.NewReader(large.Body)
+	assert.NoError(t, err)
+	var body map[string]string
+	assert.NoError(t, json.NewDecoder(reader).Decode(&body))
+	assert.Equal(t, strings.Repeat("proxy ", 1000), body["data"])
+
+	small := request("/small")
+	assert.Equal(t, http.StatusCreated, small.Code)
+	assert.Empty(t, small.Header().Get("Content-Encoding"))
+	assert.JSONEq(t, `{"message": "test"}`, small.Body.String())
+
+	image := request("/image")
+	assert.Empty(t, image.Header().Get("Content-Encoding"))
+	assert.Equal(t, 4096, image.Body.Len())
+
+	// Clients that don't ask for gzip get the plain body
+	req, _ := http.NewRequest("GET", "/large", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AccountCooldownsKey is a Redis sorted set of account IDs scored by the Unix
+// millisecond time their rate-limit cooldown ends
+const AccountCooldownsKey = "account_cooldowns"
+
+// DefaultRateLimitCooldown backs an account off when a rate-limit response
+// carries no RateLimit-Reset time
+const DefaultRateLimitCooldown = time.Minute
+
+// maxRateLimitCooldown caps a cooldown so a bogus reset time cannot park an
+// account indefinitely
+const maxRateLimitCooldown = 24 * time.Hour
+
+// RateLimitCooldownUntil returns when an account rate-limited at now may run
+// again, given the reset time reported by the server. A zero or past reset
+// falls back to DefaultRateLimitCooldown.
+func RateLimitCooldownUntil(reset, now time.Time) time.Time {
+	if !reset.After(now) {
+		return now.Add(DefaultRateLimitCooldown)
+	}
+	if reset.Sub(now) > maxRateLimitCooldown {
+		return now.Add(maxRateLimitCooldown)
+	}
+	return reset
+}
+
+// SetAccountCooldown defers tasks created for an account until the given
+// time. A later cooldown already in place is kept.
+func SetAccountCooldown(ctx context.Context, rdb *redis.Client, accountID int, until time.Time) error {
+	err := rdb.ZAddGT(ctx, AccountCooldownsKey, redis.Z{
+		Score:  float64(until.UnixMilli()),
+		Member: strconv.Itoa(accountID),
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set account cooldown: %w", err)
+	}
+	return nil
+}
+
+// AccountCooldownRemaining returns how long an account is still cooling down
+// at now, or zero if it may run
+func AccountCooldownRemaining(ctx context.Context, rdb *redis.Client, accountID int, now time.Time) (time.Duration, error) {
+	score, err := rdb.ZScore(ctx, AccountCooldownsKey, strconv.Itoa(accountID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read account cooldown: %w", err)
+	}
+
+	remaining := time.UnixMilli(int64(score)).Sub(now)
+	if remaining < 0 {
+		return 0, nil
+	}
+	return remaining, nil
+}
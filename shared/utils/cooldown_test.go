@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitCooldownUntil(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, now.Add(90*time.Second), RateLimitCooldownUntil(now.Add(90*time.Second), now))
+	assert.Equal(t, now.Add(DefaultRateLimitCooldown), RateLimitCooldownUntil(time.Time{}, now), "no reset header")
+	assert.Equal(t, now.Add(DefaultRateLimitCooldown), RateLimitCooldownUntil(now.Add(-time.Second), now))
+	assert.Equal(t, now.Add(maxRateLimitCooldown), RateLimitCooldownUntil(now.Add(30*24*time.Hour), now))
+}
+
+func TestAccountCooldown(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	// Account 1 hit its rate limit and resets in a minute
+	require.NoError(t, SetAccountCooldown(ctx, rdb, 1, now.Add(time.Minute)))
+	// A shorter cooldown reported later does not cut the existing one short
+	require.NoError(t, SetAccountCooldown(ctx, rdb, 1, now.Add(time.Second)))
+
+	remaining, err := AccountCooldownRemaining(ctx, rdb, 1, now)
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, remaining, float64(time.Millisecond))
+
+	remaining, err = AccountCooldownRemaining(ctx, rdb, 2, now)
+	require.NoError(t, err)
+	assert.Zero(t, remaining)
+
+	// Once the cooldown expires the account may run again
+	remaining, err = AccountCooldownRemaining(ctx, rdb, 1, now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Zero(t, remaining)
+}
@@ -0,0 +1,217 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds how far ahead NextCronTime looks before deciding an
+// expression never fires (e.g. "0 0 30 2 *")
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	cronMonthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	cronDayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// cronSchedule holds one bit per allowed value of each field
+type cronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	// domStar and dowStar record an unrestricted day field; when both day
+	// fields are restricted a day matching either one fires
+	domStar, dowStar bool
+	location         *time.Location
+}
+
+// parseCron parses a standard five-field expression (minute hour day month
+// weekday), a six-field one with a leading seconds field, or an @descriptor.
+// A CRON_TZ=<zone> or TZ=<zone> prefix evaluates the schedule in that zone
+// instead of defaultLocation.
+func parseCron(expr string, defaultLocation *time.Location) (*cronSchedule, error) {
+	schedule := &cronSchedule{location: defaultLocation}
+
+	fields := strings.Fields(expr)
+	if len(fields) > 0 {
+		zone, ok := strings.CutPrefix(fields[0], "CRON_TZ=")
+		if !ok {
+			zone, ok = strings.CutPrefix(fields[0], "TZ=")
+		}
+		if ok {
+			location, err := time.LoadLocation(zone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cron time zone %q: %w", zone, err)
+			}
+			schedule.location = location
+			fields = fields[1:]
+		}
+	}
+
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(fields[0])]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron descriptor %s", fields[0])
+		}
+		fields = strings.Fields(descriptor)
+	}
+
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron expression must have 5 or 6 fields, got %d", len(fields))
+	}
+
+	specs := []struct {
+		target   *uint64
+		name     string
+		min, max int
+		names    map[string]int
+	}{
+		{&schedule.second, "second", 0, 59, nil},
+		{&schedule.minute, "minute", 0, 59, nil},
+		{&schedule.hour, "hour", 0, 23, nil},
+		{&schedule.dom, "day of month", 1, 31, nil},
+		{&schedule.month, "month", 1, 12, cronMonthNames},
+		{&schedule.dow, "day of week", 0, 7, cronDayNames},
+	}
+	for i, spec := range specs {
+		bits, err := parseCronField(fields[i], spec.min, spec.max, spec.names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field %q: %w", spec.name, fields[i], err)
+		}
+		*spec.target = bits
+	}
+
+	// 7 is an alias for Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow = schedule.dow&^(1<<7) | 1
+	}
+	schedule.domStar = fields[3] == "*" || fields[3] == "?"
+	schedule.dowStar = fields[5] == "*" || fields[5] == "?"
+	return schedule, nil
+}
+
+// parseCronField parses a comma-separated list of *, values, ranges and steps
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			start, end = min, max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(lo, names); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(hi, names); err != nil {
+				return 0, err
+			}
+		default:
+			value, err := parseCronValue(rangePart, names)
+			if err != nil {
+				return 0, err
+			}
+			start, end = value, value
+			if hasStep {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("%s is outside %d-%d", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	return n, nil
+}
+
+// NextCronTime returns the first time strictly after after at which the cron
+// expression fires. Expressions without a CRON_TZ prefix are evaluated in
+// after's location. The result is returned in that location too.
+func NextCronTime(expr string, after time.Time) (time.Time, error) {
+	schedule, err := parseCron(expr, after.Location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	next, ok := schedule.next(after)
+	if !ok {
+		return time.Time{}, fmt.Errorf("cron expression %q never fires", expr)
+	}
+	return next.In(after.Location()), nil
+}
+
+func (s *cronSchedule) next(after time.Time) (time.Time, bool) {
+	t := after.In(s.location).Truncate(time.Second).Add(time.Second)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case s.second&(1<<uint(t.Second())) == 0:
+			t = t.Add(time.Second)
+		default:
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
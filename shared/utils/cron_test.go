@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextCronTime(t *testing.T) {
+	// Wednesday
+	after := time.Date(2025, 1, 15, 10, 7, 30, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 8-18/2 * * MON-FRI", time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"45 */10 * * * *", time.Date(2025, 1, 15, 10, 10, 45, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matching fires
+		{"0 0 20 * MON", time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)},
+		// 09:00 in Tokyo is 00:00 UTC
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		got, err := NextCronTime(c.expr, after)
+		require.NoError(t, err, c.expr)
+		assert.Equal(t, c.want, got, c.expr)
+	}
+
+	// A time exactly on the schedule returns the following run
+	got, err := NextCronTime("0 * * * *", time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC), got)
+}
+
+func TestNextCronTimeErrors(t *testing.T) {
+	after := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"* * *", "60 * * * *", "0 0 * 13 *", "*/0 * * * *", "@sometimes", "TZ=Nowhere/City * * * * *"} {
+		_, err := NextCronTime(expr, after)
+		assert.Error(t, err, expr)
+		assert.Error(t, ParseCronExpression(expr), expr)
+	}
+
+	_, err := NextCronTime("0 0 30 2 *", after)
+	assert.ErrorContains(t, err, "never fires")
+
+	assert.NoError(t, ParseCronExpression(""))
+	assert.NoError(t, ParseCronExpression("0 9 * * 1-5"))
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// CredentialsKeyEnv names the environment variable holding the base64-encoded
+// 32-byte AES-256 key used to encrypt stored credentials
+const CredentialsKeyEnv = "CREDENTIALS_ENCRYPTION_KEY"
+
+// encryptedCredentialPrefix marks a stored value as AES-GCM ciphertext, so
+// plaintext rows written before encryption was enabled can still be read
+const encryptedCredentialPrefix = "enc:v1:"
+
+// ErrCredentialsKeyMissing is returned when an encrypted credential is read
+// without CREDENTIALS_ENCRYPTION_KEY configured
+var ErrCredentialsKeyMissing = errors.New("credentials encryption key not configured")
+
+// CredentialsKey returns the configured encryption key, or nil if none is set
+func CredentialsKey() ([]byte, error) {
+	value := os.Getenv(CredentialsKeyEnv)
+	if value == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", CredentialsKeyEnv, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid %s: key must be 32 bytes, got %d", CredentialsKeyEnv, len(key))
+	}
+	return key, nil
+}
+
+// IsEncryptedCredential reports whether a stored value is ciphertext
+func IsEncryptedCredential(stored string) bool {
+	return strings.HasPrefix(stored, encryptedCredentialPrefix)
+}
+
+// PlaintextCredentialsAllowed reports whether credentials may be stored
+// unencrypted when no key is configured. Only development setups, where
+// ENVIRONMENT is development or unset, may do so.
+func PlaintextCredentialsAllowed() bool {
+	return GetEnvOrDefault("ENVIRONMENT", "development") == "development"
+}
+
+// EncryptCredential encrypts a credential for storage with AES-256-GCM.
+// Without a configured key it fails with ErrCredentialsKeyMissing, unless
+// PlaintextCredentialsAllowed, in which case the value is returned unchanged
+// so development setups keep working. Already-encrypted values are returned
+// as-is.
+func EncryptCredential(plaintext string) (string, error) {
+	if IsEncryptedCredential(plaintext) {
+		return plaintext, nil
+	}
+
+	key, err := CredentialsKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		if PlaintextCredentialsAllowed() {
+			return plaintext, nil
+		}
+		return "", ErrCredentialsKeyMissing
+	}
+
+	gcm, err := newCredentialsCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedCredentialPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptCredential returns the plaintext of a stored credential. Values
+// stored before encryption was enabled are returned unchanged.
+func DecryptCredential(stored string) (string, error) {
+	if !IsEncryptedCredential(stored) {
+		return stored, nil
+	}
+
+	key, err := CredentialsKey()
+	if err != nil {
+		return "", err
+	}
+	if key == nil {
+		return "", ErrCredentialsKeyMissing
+	}
+
+	gcm, err := newCredentialsCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedCredentialPrefix))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted credential")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt credential: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+func newCredentialsCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
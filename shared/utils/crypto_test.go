@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setTestCredentialsKey(t *testing.T) {
+	t.Setenv(CredentialsKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+}
+
+func TestCredentialRoundTrip(t *testing.T) {
+	setTestCredentialsKey(t)
+
+	stored, err := EncryptCredential("hunter2")
+	assert.NoError(t, err)
+	assert.True(t, IsEncryptedCredential(stored))
+	assert.NotContains(t, stored, "hunter2")
+
+	again, err := EncryptCredential("hunter2")
+	assert.NoError(t, err)
+	assert.NotEqual(t, stored, again, "each encryption uses a fresh nonce")
+
+	plaintext, err := DecryptCredential(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", plaintext)
+
+	// Re-encrypting stored ciphertext is a no-op
+	unchanged, err := EncryptCredential(stored)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, unchanged)
+}
+
+func TestDecryptCredentialPlaintextAndErrors(t *testing.T) {
+	t.Setenv(CredentialsKeyEnv, "")
+
+	plaintext, err := DecryptCredential("legacy-password")
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-password", plaintext)
+
+	t.Setenv("ENVIRONMENT", "development")
+	unencrypted, err := EncryptCredential("secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", unencrypted, "without a key development stores values as-is")
+
+	// Anywhere else a missing key refuses the write instead of storing plaintext
+	t.Setenv("ENVIRONMENT", "production")
+	_, err = EncryptCredential("secret")
+	assert.ErrorIs(t, err, ErrCredentialsKeyMissing)
+
+	setTestCredentialsKey(t)
+	stored, err := EncryptCredential("secret")
+	assert.NoError(t, err)
+
+	t.Setenv(CredentialsKeyEnv, "")
+	_, err = DecryptCredential(stored)
+	assert.ErrorIs(t, err, ErrCredentialsKeyMissing)
+
+	t.Setenv(CredentialsKeyEnv, base64.StdEncoding.EncodeToString([]byte(strings.Repeat("x", 32))))
+	_, err = DecryptCredential(stored)
+	assert.Error(t, err, "wrong key")
+
+	t.Setenv(CredentialsKeyEnv, base64.StdEncoding.EncodeToString([]byte("short")))
+	_, err = CredentialsKey()
+	assert.Error(t, err)
+}
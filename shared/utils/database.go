@@ -4,11 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/bsky-automation/shared/models"
 )
 
 // DatabaseConfig represents database configuration
@@ -19,6 +22,13 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// StatementTimeout cancels statements running longer than this.
+	// Zero leaves the server default in place.
+	StatementTimeout time.Duration
+	// IdleInTransactionTimeout terminates sessions left idle inside an open
+	// transaction for longer than this. Zero leaves the server default in place.
+	IdleInTransactionTimeout time.Duration
 }
 
 // RedisConfig represents Redis configuration
@@ -35,10 +45,7 @@ func NewPostgresConnection(config DatabaseConfig) (*sql.DB, error) {
 		config.SSLMode = "disable"
 	}
 
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
-
-	db, err := sql.Open("postgres", dsn)
+	db, err := sql.Open("postgres", buildPostgresDSN(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -57,6 +64,26 @@ func NewPostgresConnection(config DatabaseConfig) (*sql.DB, error) {
 	return db, nil
 }
 
+// buildPostgresDSN builds a lib/pq connection string. Timeouts are passed as
+// startup options so they apply to every pooled connection, not just one.
+func buildPostgresDSN(config DatabaseConfig) string {
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+
+	var options []string
+	if config.StatementTimeout > 0 {
+		options = append(options, fmt.Sprintf("-c statement_timeout=%d", config.StatementTimeout.Milliseconds()))
+	}
+	if config.IdleInTransactionTimeout > 0 {
+		options = append(options, fmt.Sprintf("-c idle_in_transaction_session_timeout=%d", config.IdleInTransactionTimeout.Milliseconds()))
+	}
+	if len(options) > 0 {
+		dsn += fmt.Sprintf(" options='%s'", strings.Join(options, " "))
+	}
+
+	return dsn
+}
+
 // NewRedisClient creates a new Redis client
 func NewRedisClient(config RedisConfig) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
@@ -132,7 +159,7 @@ func HealthCheckRedis(rdb *redis.Client) error {
 }
 
 // Transaction executes a function within a database transaction
-func Transaction(db *sql.DB, fn func(*sql.Tx) error) error {
+func Transaction(db *sql.DB, fn func(*sql.Tx) error) (err error) {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -153,16 +180,24 @@ func Transaction(db *sql.DB, fn func(*sql.Tx) error) error {
 	return err
 }
 
-// Paginate calculates pagination parameters
+// Paginate calculates pagination parameters with DefaultPageLimits
 func Paginate(page, pageSize int, totalItems int64) (offset int, limit int, totalPages int) {
+	return PaginateWithLimits(page, pageSize, totalItems, DefaultPageLimits)
+}
+
+// PaginateWithLimits calculates pagination parameters, using limits.Default
+// for a missing page size and capping it at limits.Max. Unset limits fall
+// back to DefaultPageLimits.
+func PaginateWithLimits(page, pageSize int, totalItems int64, limits PageLimits) (offset int, limit int, totalPages int) {
+	limits = limits.orDefault()
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 {
-		pageSize = 10
+		pageSize = limits.Default
 	}
-	if pageSize > 100 {
-		pageSize = 100
+	if pageSize > limits.Max {
+		pageSize = limits.Max
 	}
 
 	offset = (page - 1) * pageSize
@@ -172,6 +207,97 @@ func Paginate(page, pageSize int, totalItems int64) (offset int, limit int, tota
 	return offset, limit, totalPages
 }
 
+// PaginatedQuery describes a list query for RunPaginatedQuery. From holds
+// the FROM clause and any joins; it is shared by the page query and the count
+// query so both see the same rows.
+type PaginatedQuery struct {
+	Select     string
+	From       string
+	Conditions []string
+	Args       []interface{}
+	OrderBy    string
+	Page       int
+	PageSize   int
+	// Limits bounds PageSize; the zero value uses DefaultPageLimits
+	Limits PageLimits
+}
+
+// Where adds a condition whose single %d verb is replaced by the placeholder
+// number of arg
+func (q *PaginatedQuery) Where(condition string, arg interface{}) {
+	q.Args = append(q.Args, arg)
+	q.Conditions = append(q.Conditions, fmt.Sprintf(condition, len(q.Args)))
+}
+
+// RunPaginatedQuery runs one page of q, calling scan for each row, then counts
+// every row matching the same conditions. Page and page size are normalized
+// as in PaginateWithLimits.
+func RunPaginatedQuery(ctx context.Context, db *sql.DB, q PaginatedQuery, scan func(rows *sql.Rows) error) (*models.PaginationResponse, error) {
+	offset, limit, _ := PaginateWithLimits(q.Page, q.PageSize, 0, q.Limits)
+
+	whereClause := ""
+	if len(q.Conditions) > 0 {
+		whereClause = " WHERE " + strings.Join(q.Conditions, " AND ")
+	}
+
+	query := q.Select + " " + q.From + whereClause
+	if q.OrderBy != "" {
+		query += " ORDER BY " + q.OrderBy
+	}
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(q.Args)+1, len(q.Args)+2)
+
+	args := make([]interface{}, 0, len(q.Args)+2)
+	args = append(args, q.Args...)
+	args = append(args, limit, offset)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run page query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read page rows: %w", err)
+	}
+
+	var totalItems int64
+	countQuery := "SELECT COUNT(*) " + q.From + whereClause
+	if err := db.QueryRowContext(ctx, countQuery, q.Args...).Scan(&totalItems); err != nil {
+		return nil, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	_, _, totalPages := PaginateWithLimits(q.Page, q.PageSize, totalItems, q.Limits)
+
+	return &models.PaginationResponse{
+		Page:       offset/limit + 1,
+		PageSize:   limit,
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// SkipMalformedRows wraps a RunPaginatedQuery scan callback so a row that
+// fails to scan, e.g. a NULL left in a non-nullable column by data drift, is
+// logged and counted in skipped instead of failing the whole page. With skip
+// false scan is returned unchanged and the first bad row still fails the query.
+func SkipMalformedRows(skip bool, skipped *int, scan func(rows *sql.Rows) error) func(rows *sql.Rows) error {
+	if !skip {
+		return scan
+	}
+	return func(rows *sql.Rows) error {
+		if err := scan(rows); err != nil {
+			log.Printf("Skipping malformed row: %v", err)
+			*skipped++
+		}
+		return nil
+	}
+}
+
 // BuildWhereClause builds a WHERE clause with parameters
 func BuildWhereClause(conditions map[string]interface{}) (string, []interface{}) {
 	if len(conditions) == 0 {
@@ -0,0 +1,38 @@
+//go:build integration
+
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run with: go test -tags integration ./utils/...
+// Requires a reachable PostgreSQL configured through the DB_* variables.
+func TestStatementTimeoutCancelsSlowQuery(t *testing.T) {
+	db, err := NewPostgresConnection(DatabaseConfig{
+		Host:     GetEnvOrDefault("DB_HOST", "localhost"),
+		Port:     GetEnvAsInt("DB_PORT", 5432),
+		User:     GetEnvOrDefault("DB_USER", "bsky_user"),
+		Password: GetEnvOrDefault("DB_PASSWORD", "bsky_test_password"),
+		DBName:   GetEnvOrDefault("DB_NAME", "bsky_automation"),
+
+		StatementTimeout: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer db.Close()
+
+	start := time.Now()
+	_, err = db.ExecContext(context.Background(), "SELECT pg_sleep(5)")
+	require.Error(t, err)
+
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok, "unexpected error type: %v", err)
+	assert.Equal(t, pq.ErrorCode("57014"), pqErr.Code) // query_canceled
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPostgresDSN(t *testing.T) {
+	config := DatabaseConfig{
+		Host:     "localhost",
+		Port:     5432,
+		User:     "bsky_user",
+		Password: "secret",
+		DBName:   "bsky_automation",
+		SSLMode:  "disable",
+	}
+
+	assert.Equal(t,
+		"host=localhost port=5432 user=bsky_user password=secret dbname=bsky_automation sslmode=disable",
+		buildPostgresDSN(config))
+
+	config.StatementTimeout = 30 * time.Second
+	config.IdleInTransactionTimeout = time.Minute
+	assert.Equal(t,
+		"host=localhost port=5432 user=bsky_user password=secret dbname=bsky_automation sslmode=disable"+
+			" options='-c statement_timeout=30000 -c idle_in_transaction_session_timeout=60000'",
+		buildPostgresDSN(config))
+}
+
+func TestRunPaginatedQueryCountsWithSameFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	query := PaginatedQuery{
+		Select:   "SELECT a.id, a.handle",
+		From:     "FROM accounts a LEFT JOIN proxies p ON a.proxy_id = p.id",
+		OrderBy:  "a.created_at DESC",
+		Page:     2,
+		PageSize: 2,
+	}
+	query.Where("a.status = $%d", "active")
+	query.Where("p.type = $%d", "http")
+
+	mock.ExpectQuery(`SELECT a.id, a.handle FROM accounts a LEFT JOIN proxies p ON a.proxy_id = p.id `+
+		`WHERE a.status = \$1 AND p.type = \$2 ORDER BY a.created_at DESC LIMIT \$3 OFFSET \$4`).
+		WithArgs("active", "http", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "handle"}).
+			AddRow(3, "carol.bsky.social").
+			AddRow(4, "dave.bsky.social"))
+	// The count must keep the alias and filter but not the limit and offset
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM accounts a LEFT JOIN proxies p ON a.proxy_id = p.id `+
+		`WHERE a.status = \$1 AND p.type = \$2$`).
+		WithArgs("active", "http").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+	var handles []string
+	pagination, err := RunPaginatedQuery(context.Background(), db, query, func(rows *sql.Rows) error {
+		var id int
+		var handle string
+		if err := rows.Scan(&id, &handle); err != nil {
+			return err
+		}
+		handles = append(handles, handle)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"carol.bsky.social", "dave.bsky.social"}, handles)
+	assert.Equal(t, 2, pagination.Page)
+	assert.Equal(t, 2, pagination.PageSize)
+	assert.Equal(t, int64(5), pagination.TotalItems)
+	assert.Equal(t, 3, pagination.TotalPages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunPaginatedQueryWithoutConditions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id FROM proxies LIMIT \$1 OFFSET \$2`).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxies$`).
+		WithArgs().
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	pagination, err := RunPaginatedQuery(context.Background(), db,
+		PaginatedQuery{Select: "SELECT id", From: "FROM proxies"},
+		func(rows *sql.Rows) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, pagination.Page)
+	assert.Equal(t, 10, pagination.PageSize)
+	assert.Equal(t, 0, pagination.TotalPages)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
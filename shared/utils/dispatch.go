@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// TaskHandler runs one claimed task
+type TaskHandler func(ctx context.Context, task *models.Task) error
+
+// TaskDispatcher routes claimed tasks to the handler registered for their
+// type, in place of a switch on the type string. Only task types known to
+// models.LookupTaskType can be registered, and a task's payload is checked
+// against its spec before the handler sees it.
+type TaskDispatcher struct {
+	handlers map[models.TaskType]TaskHandler
+}
+
+// NewTaskDispatcher creates a dispatcher with no handlers
+func NewTaskDispatcher() *TaskDispatcher {
+	return &TaskDispatcher{handlers: make(map[models.TaskType]TaskHandler)}
+}
+
+// Register sets the handler for a task type. It panics on a type missing
+// from the registry, since that is a programming error caught at startup.
+func (d *TaskDispatcher) Register(taskType models.TaskType, handler TaskHandler) {
+	if _, ok := models.LookupTaskType(taskType); !ok {
+		panic(fmt.Sprintf("utils: cannot register handler for unknown task type %q", taskType))
+	}
+	d.handlers[taskType] = handler
+}
+
+// Dispatch validates the task against its type's spec and runs its handler.
+// Unknown types, types without a handler and invalid payloads fail with
+// models.ErrInvalidTaskType or models.ErrInvalidTaskPayload.
+func (d *TaskDispatcher) Dispatch(ctx context.Context, task *models.Task) error {
+	spec, ok := models.LookupTaskType(task.Type)
+	if !ok {
+		return fmt.Errorf("%w: task %d has unknown type %q", models.ErrInvalidTaskType, task.ID, task.Type)
+	}
+	handler, ok := d.handlers[task.Type]
+	if !ok {
+		return fmt.Errorf("%w: no handler registered for %q", models.ErrInvalidTaskType, task.Type)
+	}
+	if err := spec.ValidatePayload(task.Payload); err != nil {
+		return fmt.Errorf("task %d: %w", task.ID, err)
+	}
+	return handler(ctx, task)
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+func TestTaskDispatcher(t *testing.T) {
+	var liked []string
+	dispatcher := NewTaskDispatcher()
+	dispatcher.Register(models.TaskTypeLikePost, func(ctx context.Context, task *models.Task) error {
+		liked = append(liked, task.Payload["post_uri"].(string))
+		return nil
+	})
+
+	ctx := context.Background()
+	err := dispatcher.Dispatch(ctx, &models.Task{ID: 1, Type: models.TaskTypeLikePost, Payload: models.JSONB{"post_uri": "at://did:plc:bob/app.bsky.feed.post/1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"at://did:plc:bob/app.bsky.feed.post/1"}, liked)
+
+	// Invalid tasks never reach a handler
+	err = dispatcher.Dispatch(ctx, &models.Task{ID: 2, Type: models.TaskTypeLikePost, Payload: models.JSONB{}})
+	assert.True(t, errors.Is(err, models.ErrInvalidTaskPayload))
+	err = dispatcher.Dispatch(ctx, &models.Task{ID: 3, Type: "delete_everything"})
+	assert.True(t, errors.Is(err, models.ErrInvalidTaskType))
+	err = dispatcher.Dispatch(ctx, &models.Task{ID: 4, Type: models.TaskTypeCreatePost, Payload: models.JSONB{"content": "hi"}})
+	assert.True(t, errors.Is(err, models.ErrInvalidTaskType), "registered type without a handler")
+	assert.Len(t, liked, 1)
+
+	assert.Panics(t, func() {
+		dispatcher.Register("delete_everything", func(context.Context, *models.Task) error { return nil })
+	})
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// EventType names a kind of event published on an EventBus
+type EventType string
+
+const (
+	EventProxyFailed       EventType = "proxy_failed"
+	EventAccountAuthFailed EventType = "account_auth_failed"
+	EventProxyAssigned     EventType = "proxy_assigned"
+)
+
+// Event is a typed notification published on an EventBus
+type Event interface {
+	Type() EventType
+}
+
+// ProxyFailed is published when a proxy is marked as error after too many
+// consecutive failed health checks
+type ProxyFailed struct {
+	ProxyID  int
+	Name     string
+	Host     string
+	Port     int
+	Failures int
+	At       time.Time
+}
+
+// AccountAuthFailed is published when logging in as an account fails
+type AccountAuthFailed struct {
+	AccountID int
+	Handle    string
+	Operation string // e.g. "refresh_auth" or "test_auth"
+	Error     string
+	At        time.Time
+}
+
+// ProxyAssigned is published after a proxy is assigned to an account
+type ProxyAssigned struct {
+	AccountID int
+	ProxyID   int
+	Strategy  string
+	At        time.Time
+}
+
+func (ProxyFailed) Type() EventType       { return EventProxyFailed }
+func (AccountAuthFailed) Type() EventType { return EventAccountAuthFailed }
+func (ProxyAssigned) Type() EventType     { return EventProxyAssigned }
+
+// EventHandler reacts to a published event
+type EventHandler func(ctx context.Context, event Event)
+
+type subscriber struct {
+	name    string
+	handler EventHandler
+	async   bool
+}
+
+// EventBus delivers events published by services to the subscribers
+// registered for their type, such as alerting, metrics and audit, so the
+// publisher does not need to know about them. Synchronous subscribers run in
+// registration order before Publish returns; asynchronous ones run on their
+// own goroutine. A panicking subscriber is logged and does not affect the
+// publisher or other subscribers. A nil EventBus drops every event.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[EventType][]subscriber
+	async       sync.WaitGroup
+}
+
+// NewEventBus creates an event bus with no subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[EventType][]subscriber)}
+}
+
+// Subscribe registers handler to run synchronously for events of type t.
+// name identifies the subscriber in logs.
+func (b *EventBus) Subscribe(t EventType, name string, handler EventHandler) {
+	b.subscribe(t, subscriber{name: name, handler: handler})
+}
+
+// SubscribeAsync registers handler to run on its own goroutine for events of
+// type t, for slow side effects the publisher should not wait for. The
+// handler's context is not cancelled with the publisher's.
+func (b *EventBus) SubscribeAsync(t EventType, name string, handler EventHandler) {
+	b.subscribe(t, subscriber{name: name, handler: handler, async: true})
+}
+
+func (b *EventBus) subscribe(t EventType, sub subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[t] = append(b.subscribers[t], sub)
+}
+
+// Publish delivers event to its subscribers
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subs := b.subscribers[event.Type()]
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		if sub.async {
+			b.async.Add(1)
+			go func(sub subscriber) {
+				defer b.async.Done()
+				deliver(context.WithoutCancel(ctx), sub, event)
+			}(sub)
+			continue
+		}
+		deliver(ctx, sub, event)
+	}
+}
+
+// Wait blocks until asynchronous deliveries started so far have finished or
+// ctx ends, e.g. during shutdown
+func (b *EventBus) Wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		b.async.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event deliveries did not finish: %w", ctx.Err())
+	}
+}
+
+// deliver runs one subscriber, recovering from a panic in it
+func deliver(ctx context.Context, sub subscriber, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber %q panicked handling %s: %v\n%s", sub.name, event.Type(), r, debug.Stack())
+		}
+	}()
+	sub.handler(ctx, event)
+}
+
+// EventCountsKey is a Redis hash counting published events by type
+const EventCountsKey = "event_counts"
+
+// CountEvents returns a subscriber that counts events by type in the
+// EventCountsKey hash, for dashboards and alerting thresholds
+func CountEvents(rdb *redis.Client) EventHandler {
+	return func(ctx context.Context, event Event) {
+		if err := rdb.HIncrBy(ctx, EventCountsKey, string(event.Type()), 1).Err(); err != nil {
+			log.Printf("Failed to count %s event: %v", event.Type(), err)
+		}
+	}
+}
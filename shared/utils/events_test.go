@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBusPublishSubscribe(t *testing.T) {
+	bus := NewEventBus()
+
+	var got []string
+	bus.Subscribe(EventProxyFailed, "first", func(ctx context.Context, event Event) {
+		got = append(got, "first:"+event.(ProxyFailed).Name)
+	})
+	bus.Subscribe(EventProxyFailed, "second", func(ctx context.Context, event Event) {
+		got = append(got, "second")
+	})
+	bus.Subscribe(EventProxyAssigned, "other", func(ctx context.Context, event Event) {
+		t.Error("subscriber of another event type was called")
+	})
+
+	bus.Publish(context.Background(), ProxyFailed{ProxyID: 1, Name: "p1", Failures: 3})
+	assert.Equal(t, []string{"first:p1", "second"}, got, "synchronous subscribers run in order before Publish returns")
+
+	// Events without subscribers, and a nil bus, are dropped
+	bus.Publish(context.Background(), AccountAuthFailed{AccountID: 1})
+	var nilBus *EventBus
+	nilBus.Publish(context.Background(), ProxyFailed{})
+	assert.NoError(t, nilBus.Wait(context.Background()))
+}
+
+func TestEventBusAsyncDelivery(t *testing.T) {
+	bus := NewEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var delivered []int
+	bus.SubscribeAsync(EventProxyAssigned, "slow", func(ctx context.Context, event Event) {
+		<-release
+		assert.NoError(t, ctx.Err(), "async delivery outlives the publisher's context")
+		mu.Lock()
+		delivered = append(delivered, event.(ProxyAssigned).AccountID)
+		mu.Unlock()
+	})
+
+	bus.Publish(ctx, ProxyAssigned{AccountID: 7, ProxyID: 2})
+	cancel()
+
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+	assert.Error(t, bus.Wait(waitCtx), "Publish did not wait for the async subscriber")
+
+	close(release)
+	require.NoError(t, bus.Wait(context.Background()))
+	assert.Equal(t, []int{7}, delivered)
+}
+
+func TestEventBusIsolatesPanickingSubscriber(t *testing.T) {
+	bus := NewEventBus()
+
+	calls := 0
+	bus.Subscribe(EventAccountAuthFailed, "broken", func(ctx context.Context, event Event) {
+		panic("boom")
+	})
+	bus.Subscribe(EventAccountAuthFailed, "healthy", func(ctx context.Context, event Event) {
+		calls++
+	})
+	bus.SubscribeAsync(EventAccountAuthFailed, "broken async", func(ctx context.Context, event Event) {
+		var m map[string]int
+		m["x"] = 1
+	})
+
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), AccountAuthFailed{AccountID: 1, Operation: "refresh_auth"})
+	})
+	require.NoError(t, bus.Wait(context.Background()))
+	assert.Equal(t, 1, calls, "later subscribers still run")
+}
+
+func TestCountEvents(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+
+	bus := NewEventBus()
+	bus.Subscribe(EventProxyFailed, "metrics", CountEvents(rdb))
+
+	bus.Publish(context.Background(), ProxyFailed{ProxyID: 1})
+	bus.Publish(context.Background(), ProxyFailed{ProxyID: 2})
+
+	count, err := rdb.HGet(context.Background(), EventCountsKey, string(EventProxyFailed)).Int()
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrHandleUnreachable is returned by VerifyHandle when neither resolution
+// method confirmed the DID and at least one failed on the network, so a
+// mismatch cannot be told apart from an outage
+var ErrHandleUnreachable = errors.New("handle resolution unreachable")
+
+// HandleVerifier checks handle ownership through the atproto DNS TXT and
+// HTTPS well-known resolution methods
+type HandleVerifier struct {
+	// LookupTXT resolves TXT records; nil uses the system resolver
+	LookupTXT func(ctx context.Context, name string) ([]string, error)
+	// Client fetches /.well-known/atproto-did; nil uses a client with a
+	// 10 second timeout
+	Client *http.Client
+}
+
+var defaultHandleVerifier = &HandleVerifier{}
+
+// VerifyHandle reports whether handle resolves to expectedDID through either
+// the _atproto.<handle> TXT record or https://<handle>/.well-known/atproto-did.
+// A handle that resolves elsewhere, or not at all, returns false with a nil
+// error; ErrHandleUnreachable is returned when the answer is unknown.
+func VerifyHandle(ctx context.Context, handle, expectedDID string) (bool, error) {
+	return defaultHandleVerifier.Verify(ctx, handle, expectedDID)
+}
+
+// Verify is VerifyHandle using v's resolvers
+func (v *HandleVerifier) Verify(ctx context.Context, handle, expectedDID string) (bool, error) {
+	handle = strings.ToLower(strings.TrimSpace(handle))
+	if !ValidateHandle(handle) {
+		return false, fmt.Errorf("invalid handle: %s", handle)
+	}
+
+	var errs []error
+
+	dids, err := v.resolveDNS(ctx, handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("dns: %w", err))
+	}
+	if SliceContains(dids, expectedDID) {
+		return true, nil
+	}
+
+	did, err := v.resolveWellKnown(ctx, handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("well-known: %w", err))
+	}
+	if did != "" && did == expectedDID {
+		return true, nil
+	}
+
+	if len(errs) > 0 {
+		return false, fmt.Errorf("%w: %w", ErrHandleUnreachable, errors.Join(errs...))
+	}
+	return false, nil
+}
+
+// resolveDNS returns the DIDs published in the handle's _atproto TXT record.
+// A missing record is not an error.
+func (v *HandleVerifier) resolveDNS(ctx context.Context, handle string) ([]string, error) {
+	lookup := v.LookupTXT
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupTXT
+	}
+
+	records, err := lookup(ctx, "_atproto."+handle)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var dids []string
+	for _, record := range records {
+		if did, ok := strings.CutPrefix(strings.TrimSpace(record), "did="); ok {
+			dids = append(dids, did)
+		}
+	}
+	return dids, nil
+}
+
+// resolveWellKnown returns the DID served at the handle's well-known path,
+// or "" when the host answers without one
+func (v *HandleVerifier) resolveWellKnown(ctx context.Context, handle string) (string, error) {
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+handle+"/.well-known/atproto-did", nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2048))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rewriteTransport sends every request to target, keeping the path
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newWellKnownClient(t *testing.T, status int, body string) *http.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/.well-known/atproto-did", r.URL.Path)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: rewriteTransport{target: target}}
+}
+
+func txtRecords(records ...string) func(context.Context, string) ([]string, error) {
+	return func(ctx context.Context, name string) ([]string, error) {
+		return records, nil
+	}
+}
+
+func txtNotFound(ctx context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func txtTimeout(ctx context.Context, name string) ([]string, error) {
+	return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+}
+
+func TestVerifyHandle(t *testing.T) {
+	const did = "did:plc:alice"
+	ctx := context.Background()
+
+	t.Run("dns match", func(t *testing.T) {
+		var queried string
+		v := &HandleVerifier{
+			LookupTXT: func(ctx context.Context, name string) ([]string, error) {
+				queried = name
+				return []string{"v=spf1 -all", "did=" + did}, nil
+			},
+			Client: newWellKnownClient(t, http.StatusNotFound, ""),
+		}
+		ok, err := v.Verify(ctx, "Alice.Example.com", did)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "_atproto.alice.example.com", queried)
+	})
+
+	t.Run("well-known match", func(t *testing.T) {
+		v := &HandleVerifier{LookupTXT: txtNotFound, Client: newWellKnownClient(t, http.StatusOK, did+"\n")}
+		ok, err := v.Verify(ctx, "alice.example.com", did)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		v := &HandleVerifier{
+			LookupTXT: txtRecords("did=did:plc:mallory"),
+			Client:    newWellKnownClient(t, http.StatusOK, "did:plc:mallory"),
+		}
+		ok, err := v.Verify(ctx, "alice.example.com", did)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("no records", func(t *testing.T) {
+		v := &HandleVerifier{LookupTXT: txtNotFound, Client: newWellKnownClient(t, http.StatusNotFound, "")}
+		ok, err := v.Verify(ctx, "alice.example.com", did)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		client := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})}
+		v := &HandleVerifier{LookupTXT: txtTimeout, Client: client}
+		ok, err := v.Verify(ctx, "alice.example.com", did)
+		assert.False(t, ok)
+		assert.ErrorIs(t, err, ErrHandleUnreachable)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("one method confirms despite the other failing", func(t *testing.T) {
+		v := &HandleVerifier{LookupTXT: txtTimeout, Client: newWellKnownClient(t, http.StatusOK, did)}
+		ok, err := v.Verify(ctx, "alice.example.com", did)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("invalid handle", func(t *testing.T) {
+		_, err := (&HandleVerifier{}).Verify(ctx, "not a handle", did)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrHandleUnreachable)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PageLimits bounds the page size of list endpoints: Default is used when a
+// request names none and Max caps what it may ask for
+type PageLimits struct {
+	Default int `json:"default"`
+	Max     int `json:"max"`
+}
+
+// DefaultPageLimits applies when the page size settings are missing or invalid
+var DefaultPageLimits = PageLimits{Default: 10, Max: 100}
+
+// Page size setting keys in system_settings
+const (
+	DefaultPageSizeSetting = "default_page_size"
+	MaxPageSizeSetting     = "max_page_size"
+)
+
+// pageSettingsTTL is how long PageSettings trusts the values it last read
+const pageSettingsTTL = time.Minute
+
+// orDefault fills unset limits from DefaultPageLimits and keeps Default
+// within Max
+func (l PageLimits) orDefault() PageLimits {
+	if l.Max < 1 {
+		l.Max = DefaultPageLimits.Max
+	}
+	if l.Default < 1 {
+		l.Default = DefaultPageLimits.Default
+	}
+	if l.Default > l.Max {
+		l.Default = l.Max
+	}
+	return l
+}
+
+// PageSettings reads PageLimits from the default_page_size and max_page_size
+// system settings, so operators can tune list endpoints without a redeploy.
+// Values are cached for a minute. A nil PageSettings, or one whose settings
+// are missing or not positive integers, falls back to DefaultPageLimits. It
+// is safe for concurrent use.
+type PageSettings struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu       sync.Mutex
+	limits   PageLimits
+	loadedAt time.Time
+}
+
+// NewPageSettings creates a PageSettings reading from db
+func NewPageSettings(db *sql.DB) *PageSettings {
+	return &PageSettings{db: db, now: time.Now}
+}
+
+// Limits returns the current page limits
+func (p *PageSettings) Limits(ctx context.Context) PageLimits {
+	if p == nil || p.db == nil {
+		return DefaultPageLimits
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if !p.loadedAt.IsZero() && now.Sub(p.loadedAt) < pageSettingsTTL {
+		return p.limits
+	}
+
+	limits, err := p.load(ctx)
+	if err != nil {
+		log.Printf("Failed to load page size settings: %v", err)
+		if p.loadedAt.IsZero() {
+			return DefaultPageLimits
+		}
+		// Keep serving the last good values rather than retrying every request
+		p.loadedAt = now
+		return p.limits
+	}
+
+	p.limits = limits
+	p.loadedAt = now
+	return limits
+}
+
+// load reads the page size settings, skipping values that are not positive
+// integers
+func (p *PageSettings) load(ctx context.Context) (PageLimits, error) {
+	rows, err := p.db.QueryContext(ctx,
+		"SELECT key, value FROM system_settings WHERE key IN ($1, $2)",
+		DefaultPageSizeSetting, MaxPageSizeSetting)
+	if err != nil {
+		return PageLimits{}, err
+	}
+	defer rows.Close()
+
+	var limits PageLimits
+	for rows.Next() {
+		var key string
+		var value sql.NullString
+		if err := rows.Scan(&key, &value); err != nil {
+			return PageLimits{}, err
+		}
+
+		size, err := strconv.Atoi(value.String)
+		if err != nil || size < 1 {
+			log.Printf("Ignoring setting %s=%q: must be a positive integer", key, value.String)
+			continue
+		}
+		if key == DefaultPageSizeSetting {
+			limits.Default = size
+		} else {
+			limits.Max = size
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return PageLimits{}, err
+	}
+
+	return limits.orDefault(), nil
+}
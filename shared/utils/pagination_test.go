@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaginateWithLimits(t *testing.T) {
+	limits := PageLimits{Default: 25, Max: 50}
+
+	_, limit, _ := PaginateWithLimits(1, 0, 0, limits)
+	assert.Equal(t, 25, limit, "missing page size uses the default")
+	_, limit, _ = PaginateWithLimits(1, 80, 0, limits)
+	assert.Equal(t, 50, limit, "page size is capped at the max")
+	offset, limit, totalPages := PaginateWithLimits(3, 40, 100, limits)
+	assert.Equal(t, 80, offset)
+	assert.Equal(t, 40, limit)
+	assert.Equal(t, 3, totalPages)
+
+	// Unset limits behave like Paginate
+	_, limit, _ = PaginateWithLimits(1, 0, 0, PageLimits{})
+	assert.Equal(t, 10, limit)
+	_, limit, _ = PaginateWithLimits(1, 500, 0, PageLimits{})
+	assert.Equal(t, 100, limit)
+}
+
+func expectPageSettings(mock sqlmock.Sqlmock, defaultSize, maxSize string) {
+	mock.ExpectQuery(`SELECT key, value FROM system_settings WHERE key IN \(\$1, \$2\)`).
+		WithArgs(DefaultPageSizeSetting, MaxPageSizeSetting).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "value"}).
+			AddRow(DefaultPageSizeSetting, defaultSize).
+			AddRow(MaxPageSizeSetting, maxSize))
+}
+
+func TestPageSettingsAffectClamping(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := NewPageSettings(db)
+	settings.now = func() time.Time { return now }
+
+	expectPageSettings(mock, "20", "30")
+	limits := settings.Limits(context.Background())
+	assert.Equal(t, PageLimits{Default: 20, Max: 30}, limits)
+
+	mock.ExpectQuery(`SELECT id FROM proxies LIMIT \$1 OFFSET \$2`).
+		WithArgs(30, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM proxies$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	pagination, err := RunPaginatedQuery(context.Background(), db,
+		PaginatedQuery{Select: "SELECT id", From: "FROM proxies", PageSize: 75, Limits: limits},
+		func(rows *sql.Rows) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, 30, pagination.PageSize)
+
+	// Cached until the TTL passes, then changed settings take effect
+	assert.Equal(t, limits, settings.Limits(context.Background()))
+	now = now.Add(pageSettingsTTL)
+	expectPageSettings(mock, "5", "200")
+	assert.Equal(t, PageLimits{Default: 5, Max: 200}, settings.Limits(context.Background()))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPageSettingsRejectInvalidValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := NewPageSettings(db)
+	settings.now = func() time.Time { return now }
+
+	expectPageSettings(mock, "0", "lots")
+	assert.Equal(t, DefaultPageLimits, settings.Limits(context.Background()))
+
+	// A default above the max is lowered to it
+	now = now.Add(pageSettingsTTL)
+	expectPageSettings(mock, "80", "40")
+	assert.Equal(t, PageLimits{Default: 40, Max: 40}, settings.Limits(context.Background()))
+
+	// A failed reload keeps the last good values
+	now = now.Add(pageSettingsTTL)
+	mock.ExpectQuery(`FROM system_settings`).WillReturnError(errors.New("connection reset"))
+	assert.Equal(t, PageLimits{Default: 40, Max: 40}, settings.Limits(context.Background()))
+
+	var unset *PageSettings
+	assert.Equal(t, DefaultPageLimits, unset.Limits(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pprofProfiles are the runtime profiles served under /debug/pprof/<name>
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// PprofEnabled reports whether ENABLE_PPROF turns on the profiling routes.
+// They are off by default since profiles expose internals and cost CPU.
+func PprofEnabled() bool {
+	return GetEnvAsBool("ENABLE_PPROF", false)
+}
+
+// RegisterPprofRoutes mounts the net/http/pprof handlers under /debug/pprof,
+// behind guards if any are given
+func RegisterPprofRoutes(router *gin.Engine, guards ...gin.HandlerFunc) {
+	debug := router.Group("/debug/pprof", guards...)
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	for _, name := range pprofProfiles {
+		debug.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+}
@@ -0,0 +1,215 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect emitted by JSONSchema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// JSONSchemaDocument builds a schema document with one definition per named
+// model, for serving to client generators
+func JSONSchemaDocument(models map[string]interface{}) map[string]interface{} {
+	defs := make(map[string]interface{}, len(models))
+	for name, model := range models {
+		defs[name] = JSONSchema(model)
+	}
+	return map[string]interface{}{
+		"$schema": JSONSchemaDraft,
+		"$defs":   defs,
+	}
+}
+
+// JSONSchema derives a JSON Schema for v by reflection. Property names come
+// from json tags, and validator tags map onto schema constraints: required,
+// min/max, len, oneof, email and url. Rules after dive apply to the items of
+// a slice.
+func JSONSchema(v interface{}) map[string]interface{} {
+	return schemaForType(reflect.TypeOf(v), nil)
+}
+
+func schemaForType(t reflect.Type, rules []string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var schema map[string]interface{}
+	switch {
+	case t == timeType:
+		schema = map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == uuidType:
+		schema = map[string]interface{}{"type": "string", "format": "uuid"}
+	case t == rawJSONType:
+		schema = map[string]interface{}{}
+	default:
+		schema = schemaForKind(t)
+	}
+
+	applyValidationRules(schema, rules)
+	return schema
+}
+
+func schemaForKind(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaForType(t.Elem(), nil)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaForType(t.Elem(), nil)}
+	case reflect.Struct:
+		return schemaForStruct(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func schemaForStruct(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		rules := splitValidationRules(field.Tag.Get("validate"))
+		var fieldSchema map[string]interface{}
+		if idx := indexOf(rules, "dive"); idx >= 0 {
+			// Rules before dive constrain the slice, rules after it each item
+			sliceType := field.Type
+			for sliceType.Kind() == reflect.Ptr {
+				sliceType = sliceType.Elem()
+			}
+			fieldSchema = map[string]interface{}{
+				"type":  "array",
+				"items": schemaForType(sliceType.Elem(), rules[idx+1:]),
+			}
+			rules = rules[:idx]
+			applyValidationRules(fieldSchema, rules)
+		} else {
+			fieldSchema = schemaForType(field.Type, rules)
+		}
+		properties[name] = fieldSchema
+
+		if indexOf(rules, "required") >= 0 {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// applyValidationRules maps validator rules onto schema keywords. min and max
+// bound the value of numbers and the length of strings and arrays.
+func applyValidationRules(schema map[string]interface{}, rules []string) {
+	var minKey, maxKey string
+	switch schema["type"] {
+	case "integer", "number":
+		minKey, maxKey = "minimum", "maximum"
+	case "string":
+		minKey, maxKey = "minLength", "maxLength"
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "gte":
+			if n, ok := parseRuleNumber(param); ok && minKey != "" {
+				schema[minKey] = n
+			}
+		case "max", "lte":
+			if n, ok := parseRuleNumber(param); ok && maxKey != "" {
+				schema[maxKey] = n
+			}
+		case "len":
+			if n, ok := parseRuleNumber(param); ok && minKey != "" && minKey != "minimum" {
+				schema[minKey] = n
+				schema[maxKey] = n
+			}
+		case "oneof":
+			values := strings.Fields(param)
+			enum := make([]interface{}, 0, len(values))
+			for _, value := range values {
+				if schema["type"] == "integer" {
+					if n, err := strconv.Atoi(value); err == nil {
+						enum = append(enum, n)
+						continue
+					}
+				}
+				enum = append(enum, value)
+			}
+			schema["enum"] = enum
+		case "email":
+			schema["format"] = "email"
+		case "url", "uri":
+			schema["format"] = "uri"
+		}
+	}
+}
+
+// splitValidationRules splits a validate tag into its comma-separated rules
+func splitValidationRules(tag string) []string {
+	if tag == "" {
+		return nil
+	}
+	return strings.Split(tag, ",")
+}
+
+func parseRuleNumber(param string) (interface{}, bool) {
+	if n, err := strconv.Atoi(param); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(param, 64); err == nil {
+		return f, true
+	}
+	return nil, false
+}
+
+func indexOf(values []string, target string) int {
+	for i, value := range values {
+		if value == target {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+func TestJSONSchemaCreateProxyRequest(t *testing.T) {
+	schema := JSONSchema(models.CreateProxyRequest{})
+
+	assert.Equal(t, "object", schema["type"])
+	assert.ElementsMatch(t, []string{"name", "type", "host", "port"}, schema["required"])
+
+	properties := schema["properties"].(map[string]interface{})
+
+	port := properties["port"].(map[string]interface{})
+	assert.Equal(t, "integer", port["type"])
+	assert.Equal(t, 1, port["minimum"])
+	assert.Equal(t, 65535, port["maximum"])
+
+	proxyType := properties["type"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"http", "socks5"}, proxyType["enum"])
+
+	maxAccounts := properties["max_accounts"].(map[string]interface{})
+	assert.Equal(t, 1, maxAccounts["minimum"])
+
+	tags := properties["tags"].(map[string]interface{})
+	assert.Equal(t, "array", tags["type"])
+	assert.Equal(t, 100, tags["items"].(map[string]interface{})["maxLength"])
+}
+
+func TestJSONSchemaTimesAndTagsWithoutValidation(t *testing.T) {
+	schema := JSONSchema(models.Proxy{})
+	properties := schema["properties"].(map[string]interface{})
+
+	assert.NotContains(t, schema, "required")
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time"}, properties["created_at"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "format": "uuid"}, properties["uuid"])
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// settingsTTL is how long Settings trusts the values it last read
+const settingsTTL = time.Minute
+
+// Settings reads system_settings for services that let operators tune
+// behaviour without a redeploy. The table is read whole and cached for a
+// minute, so a lookup costs no query on most calls. Empty values count as
+// unset. A nil Settings, or one without a database, reports every setting as
+// unset. It is safe for concurrent use.
+type Settings struct {
+	db  *sql.DB
+	now func() time.Time
+
+	mu       sync.Mutex
+	values   map[string]string
+	loadedAt time.Time
+}
+
+// NewSettings creates a Settings reading from db
+func NewSettings(db *sql.DB) *Settings {
+	return &Settings{db: db, now: time.Now}
+}
+
+// Get returns the value of key and whether it is set
+func (s *Settings) Get(ctx context.Context, key string) (string, bool) {
+	value, ok := s.current(ctx)[key]
+	return value, ok
+}
+
+// PositiveInt returns key as an integer, or fallback when it is unset or not
+// a positive integer
+func (s *Settings) PositiveInt(ctx context.Context, key string, fallback int) int {
+	value, ok := s.Get(ctx, key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Printf("Ignoring setting %s=%q: must be a positive integer", key, value)
+		return fallback
+	}
+	return n
+}
+
+// current returns the cached settings, rereading them once the TTL passes.
+// The returned map must not be modified.
+func (s *Settings) current(ctx context.Context) map[string]string {
+	if s == nil || s.db == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !s.loadedAt.IsZero() && now.Sub(s.loadedAt) < settingsTTL {
+		return s.values
+	}
+
+	values, err := s.load(ctx)
+	if err != nil {
+		log.Printf("Failed to load system settings: %v", err)
+		// Keep serving the last good values rather than retrying every call
+		s.loadedAt = now
+		return s.values
+	}
+
+	s.values = values
+	s.loadedAt = now
+	return values
+}
+
+// load reads every set system setting
+func (s *Settings) load(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT key, value FROM system_settings WHERE COALESCE(value, '') <> ''")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectSettings(mock sqlmock.Sqlmock, pairs ...string) {
+	rows := sqlmock.NewRows([]string{"key", "value"})
+	for i := 0; i+1 < len(pairs); i += 2 {
+		rows.AddRow(pairs[i], pairs[i+1])
+	}
+	mock.ExpectQuery(`SELECT key, value FROM system_settings WHERE COALESCE\(value, ''\) <> ''`).
+		WillReturnRows(rows)
+}
+
+func TestSettingsCachesValues(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := NewSettings(db)
+	settings.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	expectSettings(mock, "strategy", "fastest")
+	value, ok := settings.Get(ctx, "strategy")
+	assert.True(t, ok)
+	assert.Equal(t, "fastest", value)
+	_, ok = settings.Get(ctx, "missing")
+	assert.False(t, ok)
+
+	// Cached until the TTL passes, then changed settings take effect
+	now = now.Add(settingsTTL)
+	expectSettings(mock, "strategy", "round_robin")
+	value, _ = settings.Get(ctx, "strategy")
+	assert.Equal(t, "round_robin", value)
+
+	// A failed reload keeps the last good values
+	now = now.Add(settingsTTL)
+	mock.ExpectQuery(`FROM system_settings`).WillReturnError(errors.New("connection reset"))
+	value, _ = settings.Get(ctx, "strategy")
+	assert.Equal(t, "round_robin", value)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSettingsPositiveInt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	settings := NewSettings(db)
+	ctx := context.Background()
+
+	expectSettings(mock, "vnodes", "150", "zero", "0", "words", "lots")
+	assert.Equal(t, 150, settings.PositiveInt(ctx, "vnodes", 100))
+	assert.Equal(t, 100, settings.PositiveInt(ctx, "zero", 100))
+	assert.Equal(t, 100, settings.PositiveInt(ctx, "words", 100))
+	assert.Equal(t, 100, settings.PositiveInt(ctx, "missing", 100))
+
+	var unset *Settings
+	assert.Equal(t, 100, unset.PositiveInt(ctx, "vnodes", 100))
+	_, ok := unset.Get(ctx, "vnodes")
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
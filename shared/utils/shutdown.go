@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ShutdownCoordinator runs registered shutdown steps in order, sharing one
+// deadline. Register steps that stop accepting work first (the HTTP server),
+// then steps that drain in-flight work, then steps that release what the
+// drained work could not finish (task claims through ReleaseTaskClaims).
+type ShutdownCoordinator struct {
+	mu    sync.Mutex
+	steps []shutdownStep
+}
+
+type shutdownStep struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewShutdownCoordinator creates an empty shutdown coordinator
+func NewShutdownCoordinator() *ShutdownCoordinator {
+	return &ShutdownCoordinator{}
+}
+
+// Register adds a named shutdown step
+func (c *ShutdownCoordinator) Register(name string, fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.steps = append(c.steps, shutdownStep{name: name, fn: fn})
+}
+
+// Shutdown runs every step in registration order. A failing or timed-out step
+// does not stop later ones, so claims are still released when draining runs
+// out of time; the errors are returned together.
+func (c *ShutdownCoordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	steps := append([]shutdownStep(nil), c.steps...)
+	c.mu.Unlock()
+
+	var errs []error
+	for _, step := range steps {
+		// Release steps still need a usable context after the deadline passes
+		stepCtx := ctx
+		if ctx.Err() != nil {
+			stepCtx = context.WithoutCancel(ctx)
+		}
+		if err := step.fn(stepCtx); err != nil {
+			log.Printf("Shutdown step %q failed: %v", step.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// InFlight tracks running units of work so shutdown can wait for them. Once
+// draining starts no new work is admitted.
+type InFlight struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+}
+
+// Start admits a unit of work, returning false once draining has begun. Each
+// successful Start must be paired with Done.
+func (f *InFlight) Start() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		return false
+	}
+	f.wg.Add(1)
+	return true
+}
+
+// Done marks a unit of work started with Start as finished
+func (f *InFlight) Done() {
+	f.wg.Done()
+}
+
+// Drain stops admitting work and waits for running work to finish or for ctx
+// to end, whichever is first
+func (f *InFlight) Drain(ctx context.Context) error {
+	f.mu.Lock()
+	f.draining = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("in-flight work did not finish: %w", ctx.Err())
+	}
+}
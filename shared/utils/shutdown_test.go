@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownReleasesClaimedTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// A worker claimed a task and is stuck in it past the shutdown deadline
+	var tasks InFlight
+	assert.True(t, tasks.Start())
+	defer tasks.Done()
+
+	mock.ExpectExec(`UPDATE tasks\s+SET status = CASE WHEN cancel_requested THEN 'cancelled' ELSE 'pending' END,\s+worker_id = NULL`).
+		WithArgs("worker-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var order []string
+	coordinator := NewShutdownCoordinator()
+	coordinator.Register("task worker", func(ctx context.Context) error {
+		order = append(order, "drain")
+		return tasks.Drain(ctx)
+	})
+	coordinator.Register("task claims", func(ctx context.Context) error {
+		order = append(order, "release")
+		_, err := ReleaseTaskClaims(ctx, db, "worker-1")
+		return err
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = coordinator.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"drain", "release"}, order)
+	assert.False(t, tasks.Start(), "no new work is admitted after draining")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInFlightDrainWaitsForWork(t *testing.T) {
+	var work InFlight
+	assert.True(t, work.Start())
+
+	finished := false
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		finished = true
+		work.Done()
+	}()
+
+	assert.NoError(t, work.Drain(context.Background()))
+	assert.True(t, finished)
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// StreamNDJSON writes the values passed to emit as newline-delimited JSON,
+// flushing every flushEvery rows so large exports reach the client as they
+// are produced instead of being buffered whole. ctx is checked after each
+// batch: once it ends, e.g. because the client disconnected, emit returns its
+// error so produce stops reading rows and releases its database cursor. It
+// returns the number of rows written. The NDJSON headers are only set once
+// the first row is written (or produce finishes without error), so while the
+// count is zero the response is untouched and callers can still report an
+// error status with their usual content type.
+func StreamNDJSON(ctx context.Context, w http.ResponseWriter, flushEvery int, produce func(emit func(v interface{}) error) error) (int, error) {
+	if flushEvery < 1 {
+		flushEvery = 1
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	rows := 0
+
+	err := produce(func(v interface{}) error {
+		if rows == 0 {
+			setNDJSONHeaders(w)
+		}
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		rows++
+		if rows%flushEvery == 0 {
+			if flusher != nil {
+				flusher.Flush()
+			}
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	if err == nil && rows == 0 {
+		// An empty export is still an NDJSON response
+		setNDJSONHeaders(w)
+	}
+	if flusher != nil && rows > 0 {
+		flusher.Flush()
+	}
+
+	return rows, err
+}
+
+func setNDJSONHeaders(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	// The size is unknown up front, so no Content-Length: the response is
+	// sent chunked
+	w.Header().Del("Content-Length")
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamNDJSONFlushesBeforeCompletion(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		StreamNDJSON(r.Context(), w, 2, func(emit func(v interface{}) error) error {
+			emit(map[string]int{"row": 1})
+			emit(map[string]int{"row": 2})
+			// The rest of the dataset is not generated until the client
+			// has seen the first flush
+			<-release
+			return emit(map[string]int{"row": 3})
+		})
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	assert.Equal(t, int64(-1), resp.ContentLength)
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, `{"row":1}`, line)
+	case <-time.After(2 * time.Second):
+		close(release)
+		t.Fatal("first row was not streamed before the dataset completed")
+	}
+
+	close(release)
+	assert.Equal(t, `{"row":2}`, <-lines)
+	assert.Equal(t, `{"row":3}`, <-lines)
+	<-done
+}
+
+func TestStreamNDJSONStopsAfterBatchWhenContextEnds(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+
+	produced := 0
+	rows, err := StreamNDJSON(ctx, w, 3, func(emit func(v interface{}) error) error {
+		for i := 1; i <= 10; i++ {
+			if i == 2 {
+				cancel()
+			}
+			produced++
+			if err := emit(map[string]int{"row": i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, rows, "the batch in progress is finished")
+	assert.Equal(t, 3, produced)
+
+	rows, err = StreamNDJSON(ctx, httptest.NewRecorder(), 3, func(emit func(v interface{}) error) error {
+		t.Error("nothing is produced once the context has ended")
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, rows)
+}
+
+func TestStreamNDJSONLeavesHeadersUntilFirstRow(t *testing.T) {
+	// A query that fails before any row leaves the response to the caller,
+	// which answers with a JSON error body
+	w := httptest.NewRecorder()
+	rows, err := StreamNDJSON(context.Background(), w, 10, func(emit func(v interface{}) error) error {
+		return errors.New("query failed")
+	})
+	assert.Error(t, err)
+	assert.Zero(t, rows)
+	assert.Empty(t, w.Header().Get("Content-Type"))
+
+	// An empty result is still an NDJSON response
+	w = httptest.NewRecorder()
+	rows, err = StreamNDJSON(context.Background(), w, 10, func(emit func(v interface{}) error) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Zero(t, rows)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+}
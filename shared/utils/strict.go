@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StrictJSONKey marks a request whose route opted into strict decoding
+const StrictJSONKey = "strict_json"
+
+// StrictJSON makes BindJSON reject request bodies with fields the target type
+// does not declare, so a typo such as "por" for "port" fails instead of being
+// silently dropped. Routes opt in individually to keep lenient clients working.
+func StrictJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(StrictJSONKey, true)
+		c.Next()
+	}
+}
+
+// BindJSON decodes the request body into obj, rejecting unknown fields on
+// routes behind StrictJSON and falling back to ShouldBindJSON elsewhere
+func BindJSON(c *gin.Context, obj interface{}) error {
+	if !c.GetBool(StrictJSONKey) {
+		return c.ShouldBindJSON(obj)
+	}
+	if c.Request.Body == nil {
+		return errors.New("invalid request")
+	}
+
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("request body must contain a single JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindJSONStrictRejectsUnknownFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		Port int `json:"port"`
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"por": 8080}`))
+	c.Set(StrictJSONKey, true)
+
+	var req request
+	err := BindJSON(c, &req)
+	assert.ErrorContains(t, err, `unknown field "por"`)
+
+	// Without StrictJSON the field is dropped
+	c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"por": 8080}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(StrictJSONKey, false)
+	assert.NoError(t, BindJSON(c, &req))
+	assert.Zero(t, req.Port)
+}
+
+func TestBindJSONStrictRejectsTrailingData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		Name string `json:"name"`
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("POST", "/", strings.NewReader(`{"name": "a"} {"name": "b"}`))
+	c.Set(StrictJSONKey, true)
+
+	var req request
+	assert.Error(t, BindJSON(c, &req))
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/bsky-automation/shared/models"
+)
+
+// ClaimNextTask marks the highest-priority due pending task as running under
+// workerID and returns it, or nil when there is nothing to claim. Tasks of
+// accounts in a rate-limit cooldown are scheduled past its end, so they are
+// not due yet. Nothing is claimed while automation is paused.
+func ClaimNextTask(ctx context.Context, db *sql.DB, workerID string) (*models.Task, error) {
+	paused, err := AutomationPaused(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		return nil, nil
+	}
+
+	query := `
+		UPDATE tasks
+		SET status = 'running', worker_id = $1, started_at = NOW(), updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM tasks
+			WHERE status = 'pending' AND scheduled_at <= NOW()
+			ORDER BY priority DESC, scheduled_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, uuid, COALESCE(account_id, 0), COALESCE(strategy_id, 0), COALESCE(account_strategy_id, 0),
+		          type, payload, status, priority, retry_count, max_retries, timeout_seconds,
+		          scheduled_at, started_at, worker_id, created_at, updated_at
+	`
+
+	task := &models.Task{}
+	err = db.QueryRowContext(ctx, query, workerID).Scan(
+		&task.ID, &task.UUID, &task.AccountID, &task.StrategyID, &task.AccountStrategyID,
+		&task.Type, &task.Payload, &task.Status, &task.Priority, &task.RetryCount, &task.MaxRetries, &task.TimeoutSeconds,
+		&task.ScheduledAt, &task.StartedAt, &task.WorkerID, &task.CreatedAt, &task.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim task: %w", err)
+	}
+	return task, nil
+}
+
+// ReleaseTaskClaims gives up the tasks still running under workerID, for a
+// worker's last shutdown step once draining is over. A task whose
+// cancellation was requested is finished as cancelled, as FinishCancelledTask
+// would; any other goes back to pending so another worker can pick it up. It
+// returns how many were released.
+func ReleaseTaskClaims(ctx context.Context, db *sql.DB, workerID string) (int64, error) {
+	query := `
+		UPDATE tasks
+		SET status = CASE WHEN cancel_requested THEN 'cancelled' ELSE 'pending' END,
+		    worker_id = NULL,
+		    started_at = CASE WHEN cancel_requested THEN started_at END,
+		    completed_at = CASE WHEN cancel_requested THEN NOW() END,
+		    updated_at = NOW()
+		WHERE worker_id = $1 AND status = 'running'
+	`
+	result, err := db.ExecContext(ctx, query, workerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to release task claims: %w", err)
+	}
+
+	released, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to release task claims: %w", err)
+	}
+	if released > 0 {
+		log.Printf("Released %d task claims held by worker %s", released, workerID)
+	}
+	return released, nil
+}
+
+// TaskCancelRequested reports whether cancellation was requested for a
+// running task. Workers call it between steps.
+func TaskCancelRequested(ctx context.Context, db *sql.DB, taskID int) (bool, error) {
+	var requested bool
+	err := db.QueryRowContext(ctx, "SELECT cancel_requested FROM tasks WHERE id = $1", taskID).Scan(&requested)
+	if err != nil {
+		return false, fmt.Errorf("failed to check task cancellation: %w", err)
+	}
+	return requested, nil
+}
+
+// WatchTaskCancellation returns a context that is cancelled once cancellation
+// is requested for the task, so in-flight requests stop promptly rather than
+// at the next step boundary. The flag is polled every interval until the
+// returned cancel func is called or ctx ends.
+func WatchTaskCancellation(ctx context.Context, db *sql.DB, taskID int, interval time.Duration) (context.Context, context.CancelFunc) {
+	taskCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-taskCtx.Done():
+				return
+			case <-ticker.C:
+				requested, err := TaskCancelRequested(taskCtx, db, taskID)
+				if err != nil {
+					if taskCtx.Err() == nil {
+						log.Printf("Task %d: %v", taskID, err)
+					}
+					continue
+				}
+				if requested {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return taskCtx, cancel
+}
+
+// FinishCancelledTask records that a worker stopped a running task because
+// cancellation was requested
+func FinishCancelledTask(ctx context.Context, db *sql.DB, taskID int) error {
+	query := `
+		UPDATE tasks
+		SET status = 'cancelled', worker_id = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`
+	if _, err := db.ExecContext(ctx, query, taskID); err != nil {
+		return fmt.Errorf("failed to record task cancellation: %w", err)
+	}
+	return nil
+}
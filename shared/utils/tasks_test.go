@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var claimedTaskColumns = []string{
+	"id", "uuid", "account_id", "strategy_id", "account_strategy_id",
+	"type", "payload", "status", "priority", "retry_count", "max_retries", "timeout_seconds",
+	"scheduled_at", "started_at", "worker_id", "created_at", "updated_at",
+}
+
+func TestClaimNextTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	now := time.Now()
+	expectAutomationPaused(mock, "false")
+	mock.ExpectQuery(`UPDATE tasks\s+SET status = 'running', worker_id = \$1.*WHERE status = 'pending' AND scheduled_at <= NOW\(\)`).
+		WithArgs("worker-1").
+		WillReturnRows(sqlmock.NewRows(claimedTaskColumns).AddRow(
+			10, GenerateUUID().String(), 1, 1, 1,
+			"like", []byte(`{}`), "running", 5, 0, 3, 300,
+			now, now, "worker-1", now, now))
+
+	task, err := ClaimNextTask(context.Background(), db, "worker-1")
+	assert.NoError(t, err)
+	if assert.NotNil(t, task) {
+		assert.Equal(t, 10, task.ID)
+		assert.Equal(t, "worker-1", *task.WorkerID)
+	}
+
+	// Nothing due
+	expectAutomationPaused(mock, "false")
+	mock.ExpectQuery(`UPDATE tasks`).WillReturnRows(sqlmock.NewRows(claimedTaskColumns))
+	task, err = ClaimNextTask(context.Background(), db, "worker-1")
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimNextTaskClaimsNothingWhilePaused(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// No UPDATE is expected, so any claim attempt fails the test
+	expectAutomationPaused(mock, "true")
+
+	task, err := ClaimNextTask(context.Background(), db, "worker-1")
+	assert.NoError(t, err)
+	assert.Nil(t, task)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWatchTaskCancellationCancelsRunningTask(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT cancel_requested FROM tasks WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}).AddRow(false))
+	mock.ExpectQuery(`SELECT cancel_requested FROM tasks WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"cancel_requested"}).AddRow(true))
+
+	taskCtx, cancel := WatchTaskCancellation(context.Background(), db, 7, 5*time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-taskCtx.Done():
+		assert.ErrorIs(t, taskCtx.Err(), context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("task context was not cancelled")
+	}
+
+	mock.ExpectExec(`UPDATE tasks\s+SET status = 'cancelled'`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, FinishCancelledTask(context.Background(), db, 7))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
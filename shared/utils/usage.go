@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ActionLimitWindow returns the length of the per-account action quota
+// window, configured through ACCOUNT_LIMIT_WINDOW in seconds
+func ActionLimitWindow() time.Duration {
+	return time.Duration(GetEnvAsInt("ACCOUNT_LIMIT_WINDOW", 86400)) * time.Second
+}
+
+// ActionUsageKey is the Redis counter of an account's actions of one type in
+// the current quota window
+func ActionUsageKey(accountID int, action string) string {
+	return fmt.Sprintf("account_usage:%d:%s", accountID, action)
+}
+
+// RecordActionUsage counts one action against an account's quota and returns
+// the count so far. Workers call it after an action succeeds. The window
+// starts with the first action and resets when the counter expires.
+func RecordActionUsage(ctx context.Context, rdb *redis.Client, accountID int, action string) (int64, error) {
+	key := ActionUsageKey(accountID, action)
+
+	pipe := rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ActionLimitWindow())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record action usage: %w", err)
+	}
+
+	return incr.Val(), nil
+}
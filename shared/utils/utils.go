@@ -3,8 +3,11 @@ package utils
 import (
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	mathrand "math/rand"
+	"net"
 	"net/url"
 	"os"
 	"regexp"
@@ -20,13 +23,19 @@ func GenerateUUID() uuid.UUID {
 	return uuid.New()
 }
 
-// RandomInt generates a random integer between min and max (inclusive)
-func RandomInt(min, max int) int {
+// RandomInt generates a random integer between min and max (inclusive).
+// An optional math/rand source makes the result reproducible, e.g. in tests;
+// otherwise crypto/rand is used.
+func RandomInt(min, max int, source ...mathrand.Source) int {
 	if min >= max {
 		return min
 	}
 	
 	diff := max - min + 1
+	if len(source) > 0 && source[0] != nil {
+		return min + mathrand.New(source[0]).Intn(diff)
+	}
+
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(diff)))
 	if err != nil {
 		// Fallback to time-based pseudo-random
@@ -72,20 +81,63 @@ func ValidateProxyURL(proxyURL string) error {
 	return nil
 }
 
+// ErrInvalidProxyHost is returned when a proxy host is not a bare hostname or IP
+var ErrInvalidProxyHost = errors.New("invalid proxy host")
+
+var hostnameRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// NormalizeProxyHost reduces a proxy host to a bare hostname or IP address.
+// A leading scheme and IPv6 brackets are stripped, hostnames are lowercased
+// and IPs are put in canonical form. Ports, paths, credentials and anything
+// else that is not part of the host are rejected.
+func NormalizeProxyHost(host string) (string, error) {
+	host = strings.TrimSpace(host)
+
+	if strings.Contains(host, "://") {
+		u, err := url.Parse(host)
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", ErrInvalidProxyHost, host)
+		}
+		if u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+			return "", fmt.Errorf("%w: %s must not include credentials or a path", ErrInvalidProxyHost, host)
+		}
+		if u.Port() != "" {
+			return "", fmt.Errorf("%w: %s must not include a port", ErrInvalidProxyHost, host)
+		}
+		host = u.Hostname()
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+
+	if host == "" {
+		return "", fmt.Errorf("%w: host is required", ErrInvalidProxyHost)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	hostname := strings.TrimSuffix(strings.ToLower(host), ".")
+	if len(hostname) > 253 || !hostnameRegex.MatchString(hostname) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidProxyHost, host)
+	}
+	return hostname, nil
+}
+
+// ProxyHostPort joins a normalized proxy host and port for use in a URL,
+// bracketing IPv6 addresses
+func ProxyHostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
 // ParseCronExpression validates a cron expression format
 func ParseCronExpression(cronExpr string) error {
 	if cronExpr == "" {
 		return nil // Empty is valid (no schedule)
 	}
-	
-	// Basic validation for cron expression (5 or 6 fields)
-	fields := strings.Fields(cronExpr)
-	if len(fields) != 5 && len(fields) != 6 {
-		return fmt.Errorf("cron expression must have 5 or 6 fields, got %d", len(fields))
-	}
-	
-	// More detailed validation could be added here
-	return nil
+
+	_, err := parseCron(cronExpr, time.UTC)
+	return err
 }
 
 // SanitizeString removes potentially harmful characters from strings